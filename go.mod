@@ -9,7 +9,9 @@ require (
 	gopkg.in/yaml.v3 v3.0.1
 )
 
-require github.com/rabbitmq/amqp091-go v1.10.0 // indirect
+require github.com/rabbitmq/amqp091-go v1.10.0
+
+require google.golang.org/protobuf v1.36.9
 
 require (
 	github.com/ebitengine/gomobile v0.0.0-20250923094054-ea854a63cce1 // indirect
@@ -0,0 +1,124 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock es la fuente de tiempo de la simulación (sensores, state manager y
+// ejecutor de escenarios). En tiempo real es el reloj del sistema; en el
+// modo run es un reloj virtual que avanza más rápido.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+	AfterFunc(d time.Duration, f func()) *Timer
+	NewTicker(d time.Duration) *Ticker
+}
+
+// Timer espera de AfterFunc (igual que time.Timer)
+type Timer struct {
+	stop  func() bool
+	reset func(d time.Duration) bool
+}
+
+// Stop cancela el timer; retorna false si ya se disparó o estaba detenido
+func (t *Timer) Stop() bool {
+	return t.stop()
+}
+
+// Reset vuelve a programar el timer para dentro de d; retorna true si
+// seguía pendiente
+func (t *Timer) Reset(d time.Duration) bool {
+	return t.reset(d)
+}
+
+// Ticker entrega el tiempo en C cada periodo (igual que time.Ticker)
+type Ticker struct {
+	C     <-chan time.Time
+	stop  func()
+	reset func(d time.Duration)
+}
+
+// Stop detiene el ticker
+func (t *Ticker) Stop() {
+	t.stop()
+}
+
+// Reset cambia el periodo del ticker; el próximo tick llega dentro de d
+func (t *Ticker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: periodo no positivo en Ticker.Reset")
+	}
+	t.reset(d)
+}
+
+var (
+	mu      sync.RWMutex
+	current Clock = Real{}
+)
+
+// Set cambia el reloj de la simulación (nil = tiempo real)
+func Set(c Clock) {
+	if c == nil {
+		c = Real{}
+	}
+
+	mu.Lock()
+	current = c
+	mu.Unlock()
+}
+
+// Get retorna el reloj de la simulación
+func Get() Clock {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Now retorna la hora actual de la simulación
+func Now() time.Time {
+	return Get().Now()
+}
+
+// Since retorna el tiempo de simulación transcurrido desde t
+func Since(t time.Time) time.Duration {
+	return Get().Now().Sub(t)
+}
+
+// Sleep bloquea durante d en tiempo de simulación
+func Sleep(d time.Duration) {
+	Get().Sleep(d)
+}
+
+// After entrega la hora en el canal después de d en tiempo de simulación
+func After(d time.Duration) <-chan time.Time {
+	return Get().After(d)
+}
+
+// AfterFunc ejecuta f en su propia goroutine después de d en tiempo de simulación
+func AfterFunc(d time.Duration, f func()) *Timer {
+	return Get().AfterFunc(d, f)
+}
+
+// NewTicker crea un ticker en tiempo de simulación
+func NewTicker(d time.Duration) *Ticker {
+	return Get().NewTicker(d)
+}
+
+// Real es el reloj del sistema
+type Real struct{}
+
+func (Real) Now() time.Time                         { return time.Now() }
+func (Real) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (Real) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (Real) AfterFunc(d time.Duration, f func()) *Timer {
+	timer := time.AfterFunc(d, f)
+	return &Timer{stop: timer.Stop, reset: timer.Reset}
+}
+
+func (Real) NewTicker(d time.Duration) *Ticker {
+	ticker := time.NewTicker(d)
+	return &Ticker{C: ticker.C, stop: ticker.Stop, reset: ticker.Reset}
+}
@@ -0,0 +1,178 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Virtual es un reloj que solo avanza con Advance: los Sleep, After y
+// tickers se disparan en orden cuando el tiempo virtual los alcanza
+type Virtual struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*virtualTimer // Ordenados por instante de disparo
+}
+
+// virtualTimer espera pendiente en el reloj virtual
+type virtualTimer struct {
+	at      time.Time
+	period  time.Duration // > 0 en tickers
+	channel chan time.Time
+	fn      func()
+}
+
+// NewVirtual crea un reloj virtual detenido en start
+func NewVirtual(start time.Time) *Virtual {
+	return &Virtual{now: start}
+}
+
+// Now retorna la hora virtual
+func (v *Virtual) Now() time.Time {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.now
+}
+
+// Sleep bloquea hasta que el tiempo virtual avance d
+func (v *Virtual) Sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	<-v.After(d)
+}
+
+// After entrega la hora virtual en el canal cuando avance d
+func (v *Virtual) After(d time.Duration) <-chan time.Time {
+	channel := make(chan time.Time, 1)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if d <= 0 {
+		channel <- v.now
+		return channel
+	}
+	v.schedule(&virtualTimer{at: v.now.Add(d), channel: channel})
+	return channel
+}
+
+// AfterFunc ejecuta f en su propia goroutine cuando el tiempo virtual avance d
+func (v *Virtual) AfterFunc(d time.Duration, f func()) *Timer {
+	timer := &virtualTimer{fn: f}
+
+	v.mu.Lock()
+	v.start(timer, d)
+	v.mu.Unlock()
+
+	return &Timer{
+		stop: func() bool {
+			v.mu.Lock()
+			defer v.mu.Unlock()
+			return v.unschedule(timer)
+		},
+		reset: func(d time.Duration) bool {
+			v.mu.Lock()
+			defer v.mu.Unlock()
+			pending := v.unschedule(timer)
+			v.start(timer, d)
+			return pending
+		},
+	}
+}
+
+// start programa un timer de AfterFunc; sin espera lo dispara enseguida
+// (llamar con mu tomado)
+func (v *Virtual) start(timer *virtualTimer, d time.Duration) {
+	if d <= 0 {
+		go timer.fn()
+		return
+	}
+	timer.at = v.now.Add(d)
+	v.schedule(timer)
+}
+
+// NewTicker crea un ticker que se dispara cada d de tiempo virtual
+func (v *Virtual) NewTicker(d time.Duration) *Ticker {
+	if d <= 0 {
+		panic("clock: periodo no positivo en NewTicker")
+	}
+
+	channel := make(chan time.Time, 1)
+	timer := &virtualTimer{period: d, channel: channel}
+
+	v.mu.Lock()
+	timer.at = v.now.Add(d)
+	v.schedule(timer)
+	v.mu.Unlock()
+
+	return &Ticker{
+		C: channel,
+		stop: func() {
+			v.mu.Lock()
+			v.unschedule(timer)
+			v.mu.Unlock()
+		},
+		reset: func(d time.Duration) {
+			v.mu.Lock()
+			v.unschedule(timer)
+			timer.period = d
+			timer.at = v.now.Add(d)
+			v.schedule(timer)
+			v.mu.Unlock()
+		},
+	}
+}
+
+// Advance avanza el tiempo virtual d, disparando en orden los timers vencidos
+func (v *Virtual) Advance(d time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	target := v.now.Add(d)
+	for len(v.timers) > 0 && !v.timers[0].at.After(target) {
+		timer := v.timers[0]
+		v.timers = v.timers[1:]
+
+		v.now = timer.at
+		switch {
+		case timer.fn != nil:
+			go timer.fn()
+		default:
+			// Igual que time.Ticker: si nadie leyó el tick anterior se descarta
+			select {
+			case timer.channel <- v.now:
+			default:
+			}
+		}
+
+		if timer.period > 0 {
+			timer.at = timer.at.Add(timer.period)
+			v.schedule(timer)
+		}
+	}
+	v.now = target
+}
+
+// schedule agrega un timer después de los que vencen antes o en el mismo
+// instante (llamar con mu tomado)
+func (v *Virtual) schedule(timer *virtualTimer) {
+	index := sort.Search(len(v.timers), func(i int) bool {
+		return v.timers[i].at.After(timer.at)
+	})
+	v.timers = append(v.timers, nil)
+	copy(v.timers[index+1:], v.timers[index:])
+	v.timers[index] = timer
+}
+
+// unschedule quita un timer pendiente; retorna false si ya no estaba
+// programado (llamar con mu tomado)
+func (v *Virtual) unschedule(timer *virtualTimer) bool {
+	for i, pending := range v.timers {
+		if pending == timer {
+			v.timers = append(v.timers[:i], v.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
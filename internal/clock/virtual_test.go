@@ -0,0 +1,272 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+// received retorna el valor pendiente en un canal sin bloquear
+func received(channel <-chan time.Time) (time.Time, bool) {
+	select {
+	case at := <-channel:
+		return at, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestVirtualAdvanceFiresInOrder(t *testing.T) {
+	start := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	v := NewVirtual(start)
+
+	third := v.After(3 * time.Second)
+	first := v.After(time.Second)
+	second := v.After(2 * time.Second)
+
+	v.Advance(999 * time.Millisecond)
+	if _, ok := received(first); ok {
+		t.Fatal("se disparó antes de su instante")
+	}
+
+	v.Advance(1500 * time.Millisecond)
+	tests := []struct {
+		name    string
+		channel <-chan time.Time
+		want    time.Duration
+		fired   bool
+	}{
+		{"1s", first, time.Second, true},
+		{"2s", second, 2 * time.Second, true},
+		{"3s", third, 0, false},
+	}
+	for _, tt := range tests {
+		at, ok := received(tt.channel)
+		if ok != tt.fired || (ok && !at.Equal(start.Add(tt.want))) {
+			t.Errorf("%s: disparado=%v a %v, want disparado=%v a +%v", tt.name, ok, at.Sub(start), tt.fired, tt.want)
+		}
+	}
+
+	if now := v.Now(); !now.Equal(start.Add(2499 * time.Millisecond)) {
+		t.Errorf("Now = +%v, want +2.499s", now.Sub(start))
+	}
+}
+
+func TestVirtualScheduleKeepsInsertionOrder(t *testing.T) {
+	v := NewVirtual(time.Unix(0, 0))
+
+	// Mismo instante: se disparan en el orden en que se programaron
+	channels := []<-chan time.Time{
+		v.After(2 * time.Second),
+		v.After(time.Second),
+		v.After(2 * time.Second),
+		v.After(time.Second),
+	}
+	want := []int{1, 3, 0, 2}
+
+	for i, timer := range v.timers {
+		if timer.channel != channels[want[i]] {
+			t.Errorf("timer %d no es el programado en la posición %d", i, want[i])
+		}
+	}
+}
+
+func TestVirtualAfterNonPositive(t *testing.T) {
+	start := time.Unix(100, 0)
+	v := NewVirtual(start)
+
+	for _, d := range []time.Duration{0, -time.Second} {
+		if at, ok := received(v.After(d)); !ok || !at.Equal(start) {
+			t.Errorf("After(%v) = %v (%v), want inmediato", d, at, ok)
+		}
+	}
+	if len(v.timers) != 0 {
+		t.Errorf("quedaron %d timers programados", len(v.timers))
+	}
+}
+
+func TestVirtualTicker(t *testing.T) {
+	start := time.Unix(0, 0)
+	v := NewVirtual(start)
+	ticker := v.NewTicker(time.Second)
+
+	v.Advance(time.Second)
+	if at, ok := received(ticker.C); !ok || !at.Equal(start.Add(time.Second)) {
+		t.Fatalf("primer tick = %v (%v), want +1s", at.Sub(start), ok)
+	}
+
+	// Nadie lee durante 3 ticks: igual que time.Ticker se descartan los que no entran
+	v.Advance(3 * time.Second)
+	if at, ok := received(ticker.C); !ok || !at.Equal(start.Add(2*time.Second)) {
+		t.Errorf("tick pendiente = +%v (%v), want el de +2s", at.Sub(start), ok)
+	}
+	if _, ok := received(ticker.C); ok {
+		t.Error("se acumuló más de un tick")
+	}
+
+	ticker.Stop()
+	v.Advance(5 * time.Second)
+	if _, ok := received(ticker.C); ok {
+		t.Error("tick después de Stop")
+	}
+	if len(v.timers) != 0 {
+		t.Errorf("el ticker detenido sigue programado (%d timers)", len(v.timers))
+	}
+}
+
+func TestVirtualNewTickerRejectsNonPositivePeriod(t *testing.T) {
+	for _, d := range []time.Duration{0, -time.Second} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewTicker(%v) no entró en pánico", d)
+				}
+			}()
+			NewVirtual(time.Unix(0, 0)).NewTicker(d)
+		}()
+	}
+}
+
+func TestVirtualTickerReset(t *testing.T) {
+	start := time.Unix(0, 0)
+	v := NewVirtual(start)
+	ticker := v.NewTicker(time.Second)
+
+	// El próximo tick llega un periodo nuevo después del Reset
+	v.Advance(500 * time.Millisecond)
+	ticker.Reset(2 * time.Second)
+	v.Advance(time.Second)
+	if _, ok := received(ticker.C); ok {
+		t.Fatal("tick con el periodo anterior")
+	}
+	v.Advance(time.Second)
+	if at, ok := received(ticker.C); !ok || !at.Equal(start.Add(2500*time.Millisecond)) {
+		t.Fatalf("tick = +%v (%v), want +2.5s", at.Sub(start), ok)
+	}
+	v.Advance(2 * time.Second)
+	if at, ok := received(ticker.C); !ok || !at.Equal(start.Add(4500*time.Millisecond)) {
+		t.Errorf("tick = +%v (%v), want +4.5s", at.Sub(start), ok)
+	}
+
+	// Un ticker detenido vuelve a andar con Reset
+	ticker.Stop()
+	ticker.Reset(time.Second)
+	v.Advance(time.Second)
+	if _, ok := received(ticker.C); !ok {
+		t.Error("sin tick tras Reset de un ticker detenido")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Reset(0) no entró en pánico")
+		}
+	}()
+	ticker.Reset(0)
+}
+
+func TestVirtualTimerStopAndReset(t *testing.T) {
+	v := NewVirtual(time.Unix(0, 0))
+	fired := make(chan string, 4)
+
+	stopped := v.AfterFunc(time.Second, func() { fired <- "detenido" })
+	reset := v.AfterFunc(time.Second, func() { fired <- "reprogramado" })
+
+	if !stopped.Stop() {
+		t.Error("Stop de un timer pendiente retornó false")
+	}
+	if stopped.Stop() {
+		t.Error("Stop de un timer ya detenido retornó true")
+	}
+	if !reset.Reset(3 * time.Second) {
+		t.Error("Reset de un timer pendiente retornó false")
+	}
+
+	v.Advance(2 * time.Second)
+	if len(v.timers) != 1 {
+		t.Fatalf("timers programados = %d, want 1", len(v.timers))
+	}
+
+	v.Advance(time.Second)
+	select {
+	case name := <-fired:
+		if name != "reprogramado" {
+			t.Errorf("se disparó %q, want reprogramado", name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("el timer reprogramado no se disparó")
+	}
+
+	// Ya disparado: Stop no lo cancela y Reset lo vuelve a programar
+	if reset.Stop() {
+		t.Error("Stop de un timer disparado retornó true")
+	}
+	if reset.Reset(time.Second) {
+		t.Error("Reset de un timer disparado retornó true")
+	}
+	v.Advance(time.Second)
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Error("Reset no volvió a programar el timer disparado")
+	}
+
+	select {
+	case name := <-fired:
+		t.Errorf("disparo inesperado de %q", name)
+	default:
+	}
+}
+
+func TestVirtualSleepAndAfterFunc(t *testing.T) {
+	v := NewVirtual(time.Unix(0, 0))
+
+	slept := make(chan struct{})
+	go func() {
+		v.Sleep(time.Second)
+		close(slept)
+	}()
+
+	called := make(chan struct{})
+	v.AfterFunc(time.Second, func() { close(called) })
+
+	// Esperar a que Sleep programe su timer
+	deadline := time.Now().Add(time.Second)
+	for {
+		v.mu.Lock()
+		pending := len(v.timers)
+		v.mu.Unlock()
+		if pending == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timers programados = %d, want 2", pending)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	v.Advance(time.Second)
+	for _, done := range []struct {
+		name    string
+		channel chan struct{}
+	}{{"Sleep", slept}, {"AfterFunc", called}} {
+		select {
+		case <-done.channel:
+		case <-time.After(time.Second):
+			t.Errorf("%s no terminó al avanzar el reloj", done.name)
+		}
+	}
+}
+
+func TestSetNilRestoresRealClock(t *testing.T) {
+	defer Set(nil)
+
+	v := NewVirtual(time.Unix(0, 0))
+	Set(v)
+	if Get() != v || !Now().Equal(time.Unix(0, 0)) {
+		t.Fatal("Set no cambió el reloj de la simulación")
+	}
+
+	Set(nil)
+	if _, ok := Get().(Real); !ok {
+		t.Errorf("Get() = %T, want Real", Get())
+	}
+}
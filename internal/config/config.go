@@ -11,15 +11,41 @@ import (
 // Config es la estructura principal de configuración
 type Config struct {
 	DeviceID   string           `yaml:"device_id"`
+	Vehicle    VehicleConfig    `yaml:"vehicle"`
 	Simulation SimulationConfig `yaml:"simulation"`
 	Sensors    SensorsConfig    `yaml:"sensors"`
 	Timeouts   TimeoutsConfig   `yaml:"timeouts"`
 	Thresholds ThresholdsConfig `yaml:"thresholds"`
+	States     StatesConfig     `yaml:"vehicle_states"`
+	Stops      StopsConfig      `yaml:"stops"`
+	GTFS       GTFSConfig       `yaml:"gtfs"`
+	Counting   CountingConfig   `yaml:"counting"`
+	Storage    StorageConfig    `yaml:"storage"`
+	Faults     FaultsConfig     `yaml:"faults"`
 	MQTT       MQTTConfig       `yaml:"mqtt"`
 	RabbitMQ   RabbitMQConfig   `yaml:"rabbitmq"`
+	GTFSRT     GTFSRTConfig     `yaml:"gtfs_realtime"`
+	Runner     RunnerConfig     `yaml:"runner"`
 	UI         UIConfig         `yaml:"ui"`
 }
 
+// VehicleConfig características físicas del vehículo
+type VehicleConfig struct {
+	Capacity        CapacityConfig `yaml:"capacity"`
+	OccupancyAlarms []float64      `yaml:"occupancy_alarms"` // Factores de carga que generan alarma al cruzarse
+}
+
+// CapacityConfig capacidad de pasajeros
+type CapacityConfig struct {
+	Seated   int `yaml:"seated"`   // Asientos
+	Standing int `yaml:"standing"` // Pasajeros de pie
+}
+
+// Total retorna la capacidad total (sentados + de pie)
+func (c CapacityConfig) Total() int {
+	return c.Seated + c.Standing
+}
+
 type SimulationConfig struct {
 	InitialScenario string  `yaml:"initial_scenario"`
 	Speed           float64 `yaml:"speed"`
@@ -31,6 +57,7 @@ type SensorsConfig struct {
 	MPU6050 MPU6050Config `yaml:"mpu6050"`
 	VL53L0X VL53L0XConfig `yaml:"vl53l0x"`
 	Camera  CameraConfig  `yaml:"camera"`
+	Door    DoorConfig    `yaml:"door"`
 }
 
 type GPSConfig struct {
@@ -54,9 +81,31 @@ type VL53L0XConfig struct {
 	Threshold int     `yaml:"threshold"`
 }
 
+// DoorConfig configuración del actuador de la puerta
+type DoorConfig struct {
+	OpeningTime      float64 `yaml:"opening_time"`       // Segundos para abrir completamente
+	ClosingTime      float64 `yaml:"closing_time"`       // Segundos para cerrar completamente
+	ClosedDistanceMM int     `yaml:"closed_distance_mm"` // Lectura del VL53L0X con puerta cerrada
+	OpenDistanceMM   int     `yaml:"open_distance_mm"`   // Lectura del VL53L0X con puerta abierta
+}
+
 type CameraConfig struct {
-	Frequency  float64 `yaml:"frequency"`
-	Confidence float64 `yaml:"confidence"`
+	Frequency  float64        `yaml:"frequency"`
+	Confidence float64        `yaml:"confidence"` // Umbral: detecciones con menor confianza se descartan
+	Detector   DetectorConfig `yaml:"detector"`
+}
+
+// DetectorConfig imperfecciones del detector YOLO + tracker (ceros = detector perfecto)
+type DetectorConfig struct {
+	MissProbability     float64 `yaml:"miss_probability"`           // Probabilidad de perder una detección por frame
+	OcclusionIoU        float64 `yaml:"occlusion_iou"`              // IoU a partir del cual la persona de atrás queda ocluida
+	OcclusionMissProb   float64 `yaml:"occlusion_miss_probability"` // Probabilidad de perder a una persona ocluida
+	IDSwitchRate        float64 `yaml:"id_switch_rate"`             // Probabilidad por track y frame de cambiar de ID
+	FalsePositiveRate   float64 `yaml:"false_positive_rate"`        // Detecciones fantasma por segundo
+	ConfidenceMean      float64 `yaml:"confidence_mean"`            // Media de la confianza (0 = usar la de cada persona)
+	ConfidenceStdDev    float64 `yaml:"confidence_stddev"`          // Desviación estándar de la confianza
+	GhostConfidenceMean float64 `yaml:"ghost_confidence_mean"`      // Media de la confianza de los fantasmas
+	GhostLifetimeFrames int     `yaml:"ghost_lifetime_frames"`      // Frames máximos que dura un fantasma
 }
 
 type TimeoutsConfig struct {
@@ -72,6 +121,167 @@ type ThresholdsConfig struct {
 	DistanceMM  int     `yaml:"distance_mm"`
 }
 
+// StatesConfig reglas del estado del vehículo (GPS + MPU)
+type StatesConfig struct {
+	HysteresisKmh     float64     `yaml:"hysteresis_kmh"`      // Bajo movement_kmh - hysteresis_kmh se deja de considerar movimiento GPS
+	Debounce          float64     `yaml:"debounce"`            // Segundos que un estado nuevo debe mantenerse antes de aplicarse
+	ApproachDistanceM float64     `yaml:"approach_distance_m"` // Distancia a la próxima parada para considerarse aproximándose
+	Rules             []StateRule `yaml:"rules"`               // Se evalúan en orden, gana la primera que cumple
+}
+
+// StateRule regla que asigna un estado cuando se cumplen sus condiciones
+type StateRule struct {
+	State    string         `yaml:"state"`
+	When     StateCondition `yaml:"when"`
+	MinDwell float64        `yaml:"min_dwell"` // Segundos mínimos en el estado antes de poder cambiar
+}
+
+// StateCondition condiciones de una regla (los campos omitidos no se evalúan)
+type StateCondition struct {
+	GPSFix          *bool    `yaml:"gps_fix,omitempty"`
+	GPSMoving       *bool    `yaml:"gps_moving,omitempty"`
+	MPUMoving       *bool    `yaml:"mpu_moving,omitempty"` // Acelerando o girando
+	Braking         *bool    `yaml:"braking,omitempty"`
+	ApproachingStop *bool    `yaml:"approaching_stop,omitempty"`
+	MinSpeedKmh     *float64 `yaml:"min_speed_kmh,omitempty"`
+	MaxSpeedKmh     *float64 `yaml:"max_speed_kmh,omitempty"`
+}
+
+// DefaultStateRules retorna las reglas de estado por defecto
+func DefaultStateRules() []StateRule {
+	yes, no := true, false
+	approachMaxKmh := 25.0
+
+	return []StateRule{
+		{State: "FRENANDO", When: StateCondition{GPSFix: &yes, GPSMoving: &yes, Braking: &yes}, MinDwell: 1.0},
+		{State: "APROXIMANDO_PARADA", When: StateCondition{GPSFix: &yes, GPSMoving: &yes, ApproachingStop: &yes, MaxSpeedKmh: &approachMaxKmh}, MinDwell: 2.0},
+		{State: "MOVIMIENTO_CONFIRMADO", When: StateCondition{GPSFix: &yes, GPSMoving: &yes, MPUMoving: &yes}, MinDwell: 1.0},
+		{State: "GPS_MOVIMIENTO", When: StateCondition{GPSFix: &yes, GPSMoving: &yes}, MinDwell: 1.0},
+		{State: "MPU_MOVIMIENTO", When: StateCondition{GPSFix: &yes, MPUMoving: &yes}, MinDwell: 1.0},
+		{State: "DETENIDO", When: StateCondition{GPSFix: &yes}, MinDwell: 2.0},
+		{State: "MPU_MOVIMIENTO_SIN_GPS", When: StateCondition{GPSFix: &no, MPUMoving: &yes}, MinDwell: 1.0},
+		{State: "DETENIDO_SIN_GPS", When: StateCondition{}, MinDwell: 2.0},
+	}
+}
+
+// StopsConfig detección de llegadas y salidas de paradas
+type StopsConfig struct {
+	GeofenceRadiusM float64 `yaml:"geofence_radius_m"` // Radio por defecto de la geocerca de cada parada
+}
+
+// GTFSConfig importación de rutas y horarios desde un feed GTFS estático
+type GTFSConfig struct {
+	Enabled         bool    `yaml:"enabled"`
+	Directory       string  `yaml:"directory"`         // Carpeta con stops.txt, routes.txt, trips.txt, stop_times.txt, shapes.txt
+	TripID          string  `yaml:"trip_id"`           // Viaje a simular ("" = primer viaje del feed)
+	DefaultDwellSec float64 `yaml:"default_dwell_sec"` // Tiempo en parada cuando el horario no lo define
+	MaxSpeedKmh     float64 `yaml:"max_speed_kmh"`     // Velocidad máxima al seguir el horario
+}
+
+// Modos de conteo de pasajeros
+const (
+	CountingModeDelta        = "delta"         // Diferencia de personas detectadas entre apertura y cierre
+	CountingModeLine         = "line"          // Cruce de cada track por una línea virtual
+	CountingModeFusion       = "fusion"        // Cruce de línea confirmado por un corte del haz del láser
+	CountingModeMaxOccupancy = "max_occupancy" // Pico de personas detectadas durante la parada
+)
+
+// CountingConfig configuración del conteo de pasajeros
+type CountingConfig struct {
+	Mode           string       `yaml:"mode"`            // "delta", "line", "fusion" o "max_occupancy"
+	Shadow         []string     `yaml:"shadow"`          // Estrategias que corren en paralelo solo para comparar (A/B)
+	LineY          float64      `yaml:"line_y"`          // Posición Y de la línea de conteo (px en la imagen)
+	LineMargin     float64      `yaml:"line_margin"`     // Banda muerta alrededor de la línea (px)
+	EntryDirection string       `yaml:"entry_direction"` // "up" o "down": sentido del cruce que cuenta como entrada
+	Fusion         FusionConfig `yaml:"fusion"`
+}
+
+// FusionConfig configuración de la fusión láser (VL53L0X) + cámara
+type FusionConfig struct {
+	Enabled           bool    `yaml:"enabled"`
+	CorrelationWindow float64 `yaml:"correlation_window"` // Segundos máximos entre corte del haz y cruce del track
+	DipMarginMM       int     `yaml:"dip_margin_mm"`      // Caída mínima bajo la distancia de puerta abierta para considerar un corte
+	ConfidenceBoost   float64 `yaml:"confidence_boost"`   // Se suma a la confianza si el láser confirma
+	ConfidencePenalty float64 `yaml:"confidence_penalty"` // Se resta a la confianza si el láser no confirma
+}
+
+// StorageConfig persistencia local de contadores de pasajeros
+type StorageConfig struct {
+	Enabled         bool   `yaml:"enabled"`
+	Directory       string `yaml:"directory"`         // Carpeta de los archivos por dispositivo
+	ServiceDayStart string `yaml:"service_day_start"` // Hora local (HH:MM) en que empieza el día de servicio
+	Timezone        string `yaml:"timezone"`          // Zona horaria IANA (vacío = local)
+}
+
+// FaultsConfig configuración de inyección de fallas en sensores
+type FaultsConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Faults  []FaultConfig `yaml:"faults"`
+}
+
+// FaultConfig describe una falla a inyectar en un sensor durante una ventana de tiempo
+type FaultConfig struct {
+	Sensor      string  `yaml:"sensor"`      // "gps", "mpu6050", "vl53l0x", "camera"
+	Type        string  `yaml:"type"`        // "drop", "freeze", "bias", "spike", "saturate", "delay", "duplicate", "out_of_range"
+	Start       float64 `yaml:"start"`       // Segundos desde la activación
+	Duration    float64 `yaml:"duration"`    // Segundos activa (0 = hasta limpiar)
+	Magnitude   float64 `yaml:"magnitude"`   // Sesgo, amplitud del pico o valor de saturación
+	Probability float64 `yaml:"probability"` // Probabilidad por muestra (0 = todas las muestras)
+	DelayMS     int     `yaml:"delay_ms"`    // Retardo en ms (solo "delay")
+}
+
+// Nombres de sensores que admiten fallas
+const (
+	SensorGPS     = "gps"
+	SensorMPU6050 = "mpu6050"
+	SensorVL53L0X = "vl53l0x"
+	SensorCamera  = "camera"
+)
+
+// Tipos de falla soportados
+const (
+	FaultDrop       = "drop"         // Descartar muestras
+	FaultFreeze     = "freeze"       // Repetir la última muestra válida
+	FaultBias       = "bias"         // Sumar un sesgo constante
+	FaultSpike      = "spike"        // Sumar picos aleatorios ±magnitud
+	FaultSaturate   = "saturate"     // Fijar la lectura en el máximo del rango
+	FaultDelay      = "delay"        // Publicar con retardo
+	FaultDuplicate  = "duplicate"    // Publicar cada evento dos veces
+	FaultOutOfRange = "out_of_range" // VL53L0X fuera de rango (8190mm)
+)
+
+// Validate verifica que la falla sea aplicable (sensor, tipo y parámetros)
+func (f FaultConfig) Validate() error {
+	switch f.Sensor {
+	case SensorGPS, SensorMPU6050, SensorVL53L0X, SensorCamera:
+	default:
+		return fmt.Errorf("sensor '%s' no válido", f.Sensor)
+	}
+
+	switch f.Type {
+	case FaultDrop, FaultFreeze, FaultBias, FaultSpike, FaultSaturate, FaultDuplicate:
+	case FaultDelay:
+		if f.DelayMS <= 0 {
+			return fmt.Errorf("la falla 'delay' requiere delay_ms > 0")
+		}
+	case FaultOutOfRange:
+		if f.Sensor != SensorVL53L0X {
+			return fmt.Errorf("la falla 'out_of_range' solo aplica a vl53l0x")
+		}
+	default:
+		return fmt.Errorf("tipo de falla '%s' no válido", f.Type)
+	}
+
+	if f.Start < 0 || f.Duration < 0 {
+		return fmt.Errorf("start y duration no pueden ser negativos")
+	}
+	if f.Probability < 0 || f.Probability > 1 {
+		return fmt.Errorf("probability debe estar entre 0 y 1")
+	}
+
+	return nil
+}
+
 // MQTTConfig configuración MQTT
 type MQTTConfig struct {
 	Enabled          bool             `yaml:"enabled"`
@@ -87,6 +297,10 @@ type MQTTConfig struct {
 	PublishHybrid    bool             `yaml:"publish_hybrid"`
 	PublishPassenger bool             `yaml:"publish_passenger"`
 	PublishDoor      bool             `yaml:"publish_door"`
+	PublishDwell     bool             `yaml:"publish_dwell"`    // Transiciones de puerta y resúmenes de parada
+	PublishStops     bool             `yaml:"publish_stops"`    // Llegadas, salidas y paradas omitidas
+	PublishScenario  bool             `yaml:"publish_scenario"` // Inicio, pasos y fin del escenario
+	PublishTrips     bool             `yaml:"publish_trips"`    // Inicio y fin de viajes
 }
 
 // MQTTTopicsConfig topics MQTT
@@ -95,6 +309,10 @@ type MQTTTopicsConfig struct {
 	Passenger string `yaml:"passenger"`
 	GPS       string `yaml:"gps"`
 	Door      string `yaml:"door"`
+	Dwell     string `yaml:"dwell"`
+	Stops     string `yaml:"stops"`
+	Scenario  string `yaml:"scenario"`
+	Trips     string `yaml:"trips"`
 	Status    string `yaml:"status"`
 }
 
@@ -112,6 +330,10 @@ type RabbitMQConfig struct {
 	PublishInterval   float64             `yaml:"publish_interval"`
 	PublishHybrid     bool                `yaml:"publish_hybrid"`
 	PublishPassenger  bool                `yaml:"publish_passenger"`
+	PublishDwell      bool                `yaml:"publish_dwell"`    // Transiciones de puerta y resúmenes de parada
+	PublishStops      bool                `yaml:"publish_stops"`    // Llegadas, salidas y paradas omitidas
+	PublishScenario   bool                `yaml:"publish_scenario"` // Inicio, pasos y fin del escenario
+	PublishTrips      bool                `yaml:"publish_trips"`    // Inicio y fin de viajes
 	Heartbeat         int                 `yaml:"heartbeat"`
 	ConnectionTimeout int                 `yaml:"connection_timeout"`
 	PrefetchCount     int                 `yaml:"prefetch_count"`
@@ -121,6 +343,29 @@ type RabbitMQConfig struct {
 type RabbitMQRoutingKeys struct {
 	Hybrid    string `yaml:"hybrid"`
 	Passenger string `yaml:"passenger"`
+	Dwell     string `yaml:"dwell"`
+	Stops     string `yaml:"stops"`
+	Scenario  string `yaml:"scenario"`
+	Trips     string `yaml:"trips"`
+}
+
+// GTFSRTConfig servidor HTTP de feeds GTFS-Realtime (protobuf)
+type GTFSRTConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Address string `yaml:"address"` // Dirección de escucha (p. ej. ":8090")
+}
+
+// RunnerConfig ejecución de escenarios sin UI ni brokers (subcomando run)
+type RunnerConfig struct {
+	Speed       float64 `yaml:"speed"`        // Segundos simulados por segundo real
+	TimeoutSec  int     `yaml:"timeout_sec"`  // Margen sobre la duración del escenario antes de cortarlo
+	JUnitReport string  `yaml:"junit_report"` // Reporte JUnit XML ("" = no generar)
+	JSONReport  string  `yaml:"json_report"`  // Reporte JSON ("" = no generar)
+	Seed        int64   `yaml:"seed"`         // Semilla de los sensores (0 = al azar)
+
+	// Detector de la cámara durante las pruebas (ceros = detector perfecto):
+	// con oclusiones y pérdidas el conteo esperado depende del azar
+	Detector DetectorConfig `yaml:"detector"`
 }
 
 type UIConfig struct {
@@ -148,12 +393,35 @@ func LoadConfig(filename string) (*Config, error) {
 		return nil, fmt.Errorf("error parseando YAML: %w", err)
 	}
 
+	// Topics que faltan en config.yaml (archivos anteriores a esos eventos)
+	fillDefaultTopics(&config)
+
 	// Reemplazar {{device_id}} y {device_id} en strings
 	config = replaceDeviceIDPlaceholders(config)
 
 	return &config, nil
 }
 
+// fillDefaultTopics completa los topics y routing keys vacíos con los de
+// Default(), usando {device_id} en lugar del ID por defecto
+func fillDefaultTopics(config *Config) {
+	defaults := Default()
+	fill := func(value *string, fallback string) {
+		if *value == "" {
+			*value = strings.ReplaceAll(fallback, defaults.DeviceID, "{device_id}")
+		}
+	}
+
+	fill(&config.MQTT.Topics.Dwell, defaults.MQTT.Topics.Dwell)
+	fill(&config.RabbitMQ.RoutingKeys.Dwell, defaults.RabbitMQ.RoutingKeys.Dwell)
+	fill(&config.MQTT.Topics.Stops, defaults.MQTT.Topics.Stops)
+	fill(&config.RabbitMQ.RoutingKeys.Stops, defaults.RabbitMQ.RoutingKeys.Stops)
+	fill(&config.MQTT.Topics.Scenario, defaults.MQTT.Topics.Scenario)
+	fill(&config.RabbitMQ.RoutingKeys.Scenario, defaults.RabbitMQ.RoutingKeys.Scenario)
+	fill(&config.MQTT.Topics.Trips, defaults.MQTT.Topics.Trips)
+	fill(&config.RabbitMQ.RoutingKeys.Trips, defaults.RabbitMQ.RoutingKeys.Trips)
+}
+
 // replaceDeviceIDPlaceholders reemplaza {{device_id}} y {device_id} en strings
 func replaceDeviceIDPlaceholders(config Config) Config {
 	deviceID := config.DeviceID
@@ -190,6 +458,30 @@ func replaceDeviceIDPlaceholders(config Config) Config {
 		deviceID,
 	)
 
+	config.MQTT.Topics.Dwell = strings.ReplaceAll(
+		config.MQTT.Topics.Dwell,
+		"{device_id}",
+		deviceID,
+	)
+
+	config.MQTT.Topics.Stops = strings.ReplaceAll(
+		config.MQTT.Topics.Stops,
+		"{device_id}",
+		deviceID,
+	)
+
+	config.MQTT.Topics.Scenario = strings.ReplaceAll(
+		config.MQTT.Topics.Scenario,
+		"{device_id}",
+		deviceID,
+	)
+
+	config.MQTT.Topics.Trips = strings.ReplaceAll(
+		config.MQTT.Topics.Trips,
+		"{device_id}",
+		deviceID,
+	)
+
 	config.MQTT.Topics.Status = strings.ReplaceAll(
 		config.MQTT.Topics.Status,
 		"{device_id}",
@@ -209,6 +501,30 @@ func replaceDeviceIDPlaceholders(config Config) Config {
 		deviceID,
 	)
 
+	config.RabbitMQ.RoutingKeys.Dwell = strings.ReplaceAll(
+		config.RabbitMQ.RoutingKeys.Dwell,
+		"{device_id}",
+		deviceID,
+	)
+
+	config.RabbitMQ.RoutingKeys.Stops = strings.ReplaceAll(
+		config.RabbitMQ.RoutingKeys.Stops,
+		"{device_id}",
+		deviceID,
+	)
+
+	config.RabbitMQ.RoutingKeys.Scenario = strings.ReplaceAll(
+		config.RabbitMQ.RoutingKeys.Scenario,
+		"{device_id}",
+		deviceID,
+	)
+
+	config.RabbitMQ.RoutingKeys.Trips = strings.ReplaceAll(
+		config.RabbitMQ.RoutingKeys.Trips,
+		"{device_id}",
+		deviceID,
+	)
+
 	return config
 }
 
@@ -221,6 +537,13 @@ func (m *MQTTConfig) GetTopic(topicTemplate string, deviceID string) string {
 func Default() *Config {
 	return &Config{
 		DeviceID: "COMBI-DEFAULT",
+		Vehicle: VehicleConfig{
+			Capacity: CapacityConfig{
+				Seated:   15,
+				Standing: 6,
+			},
+			OccupancyAlarms: []float64{0.8, 1.0},
+		},
 		Simulation: SimulationConfig{
 			InitialScenario: "parada_normal",
 			Speed:           1.0,
@@ -246,6 +569,23 @@ func Default() *Config {
 			Camera: CameraConfig{
 				Frequency:  5.0,
 				Confidence: 0.6,
+				Detector: DetectorConfig{
+					MissProbability:     0.05,
+					OcclusionIoU:        0.3,
+					OcclusionMissProb:   0.7,
+					IDSwitchRate:        0.005,
+					FalsePositiveRate:   0.05,
+					ConfidenceMean:      0.8,
+					ConfidenceStdDev:    0.08,
+					GhostConfidenceMean: 0.55,
+					GhostLifetimeFrames: 5,
+				},
+			},
+			Door: DoorConfig{
+				OpeningTime:      2.0,
+				ClosingTime:      3.0,
+				ClosedDistanceMM: 120,
+				OpenDistanceMM:   420,
 			},
 		},
 		Timeouts: TimeoutsConfig{
@@ -259,6 +599,44 @@ func Default() *Config {
 			MovementKmh: 3.0,
 			DistanceMM:  300,
 		},
+		States: StatesConfig{
+			HysteresisKmh:     1.0,
+			Debounce:          0.5,
+			ApproachDistanceM: 150,
+			Rules:             DefaultStateRules(),
+		},
+		Stops: StopsConfig{
+			GeofenceRadiusM: 50,
+		},
+		GTFS: GTFSConfig{
+			Enabled:         false,
+			Directory:       "gtfs/ruta5",
+			DefaultDwellSec: 20,
+			MaxSpeedKmh:     60,
+		},
+		Counting: CountingConfig{
+			Mode:           CountingModeDelta,
+			Shadow:         []string{},
+			LineY:          380,
+			LineMargin:     15,
+			EntryDirection: "up",
+			Fusion: FusionConfig{
+				Enabled:           true,
+				CorrelationWindow: 10.0,
+				DipMarginMM:       40,
+				ConfidenceBoost:   0.1,
+				ConfidencePenalty: 0.15,
+			},
+		},
+		Storage: StorageConfig{
+			Enabled:         true,
+			Directory:       "data",
+			ServiceDayStart: "03:00",
+			Timezone:        "America/Mexico_City",
+		},
+		Faults: FaultsConfig{
+			Enabled: false,
+		},
 		MQTT: MQTTConfig{
 			Enabled:          false,
 			Broker:           "tcp://localhost:1883",
@@ -270,11 +648,19 @@ func Default() *Config {
 			PublishHybrid:    true,
 			PublishPassenger: true,
 			PublishDoor:      true,
+			PublishDwell:     true,
+			PublishStops:     true,
+			PublishScenario:  true,
+			PublishTrips:     true,
 			Topics: MQTTTopicsConfig{
 				Hybrid:    "vehicle/COMBI-DEFAULT/hybrid",
 				Passenger: "vehicle/COMBI-DEFAULT/passenger",
 				GPS:       "vehicle/COMBI-DEFAULT/gps",
 				Door:      "vehicle/COMBI-DEFAULT/door",
+				Dwell:     "vehicle/COMBI-DEFAULT/dwell",
+				Stops:     "vehicle/COMBI-DEFAULT/stops",
+				Scenario:  "vehicle/COMBI-DEFAULT/scenario",
+				Trips:     "vehicle/COMBI-DEFAULT/trips",
 				Status:    "vehicle/COMBI-DEFAULT/status",
 			},
 		},
@@ -290,14 +676,33 @@ func Default() *Config {
 			PublishInterval:   1.0,
 			PublishHybrid:     true,
 			PublishPassenger:  true,
+			PublishDwell:      true,
+			PublishStops:      true,
+			PublishScenario:   true,
+			PublishTrips:      true,
 			Heartbeat:         60,
 			ConnectionTimeout: 30,
 			PrefetchCount:     1,
 			RoutingKeys: RabbitMQRoutingKeys{
 				Hybrid:    "vehicle.COMBI-DEFAULT.hybrid",
 				Passenger: "vehicle.COMBI-DEFAULT.passenger",
+				Dwell:     "vehicle.COMBI-DEFAULT.dwell",
+				Stops:     "vehicle.COMBI-DEFAULT.stops",
+				Scenario:  "vehicle.COMBI-DEFAULT.scenario",
+				Trips:     "vehicle.COMBI-DEFAULT.trips",
 			},
 		},
+		GTFSRT: GTFSRTConfig{
+			Enabled: false,
+			Address: ":8090",
+		},
+		Runner: RunnerConfig{
+			Speed:       50,
+			TimeoutSec:  120,
+			JUnitReport: "reports/junit.xml",
+			JSONReport:  "reports/results.json",
+			Seed:        1,
+		},
 		UI: UIConfig{
 			Window: WindowConfig{
 				Width:  1280,
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// loadTestConfig escribe source en un config.yaml temporal y lo carga
+func loadTestConfig(t *testing.T, source string) *Config {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	return cfg
+}
+
+func TestLoadConfigFillsMissingTopics(t *testing.T) {
+	cfg := loadTestConfig(t, `device_id: "COMBI-01"
+mqtt:
+  topics:
+    hybrid: "vehicle/{device_id}/hybrid"
+rabbitmq:
+  routing_keys:
+    hybrid: "flota.{device_id}.hybrid"
+`)
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"topic configurado", cfg.MQTT.Topics.Hybrid, "vehicle/COMBI-01/hybrid"},
+		{"routing key configurada", cfg.RabbitMQ.RoutingKeys.Hybrid, "flota.COMBI-01.hybrid"},
+		{"topic dwell", cfg.MQTT.Topics.Dwell, "vehicle/COMBI-01/dwell"},
+		{"routing key dwell", cfg.RabbitMQ.RoutingKeys.Dwell, "vehicle.COMBI-01.dwell"},
+		{"topic stops", cfg.MQTT.Topics.Stops, "vehicle/COMBI-01/stops"},
+		{"routing key stops", cfg.RabbitMQ.RoutingKeys.Stops, "vehicle.COMBI-01.stops"},
+		{"topic scenario", cfg.MQTT.Topics.Scenario, "vehicle/COMBI-01/scenario"},
+		{"routing key scenario", cfg.RabbitMQ.RoutingKeys.Scenario, "vehicle.COMBI-01.scenario"},
+		{"topic trips", cfg.MQTT.Topics.Trips, "vehicle/COMBI-01/trips"},
+		{"routing key trips", cfg.RabbitMQ.RoutingKeys.Trips, "vehicle.COMBI-01.trips"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
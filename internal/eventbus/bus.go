@@ -32,6 +32,22 @@ func (eb *EventBus) Subscribe(eventType EventType) <-chan Event {
 	return ch
 }
 
+// Unsubscribe cancela una suscripción y cierra su canal (termina los range
+// sobre él); no hace nada si el canal ya no está suscrito
+func (eb *EventBus) Unsubscribe(eventType EventType, channel <-chan Event) {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	subs := eb.subscribers[eventType]
+	for i, ch := range subs {
+		if ch == channel {
+			eb.subscribers[eventType] = append(subs[:i:i], subs[i+1:]...)
+			close(ch)
+			return
+		}
+	}
+}
+
 // Publish publica un evento a todos los suscriptores de ese tipo
 func (eb *EventBus) Publish(event Event) {
 	eb.mu.RLock()
@@ -15,6 +15,14 @@ const (
 	EventCamera    EventType = "camera"
 	EventVehicle   EventType = "vehicle_state"
 	EventPassenger EventType = "passenger"
+	EventFault     EventType = "fault"
+	EventDaily     EventType = "daily_summary"
+	EventDwell     EventType = "dwell_summary"
+	EventDoorState EventType = "door_state"
+	EventOccupancy EventType = "occupancy"
+	EventStop      EventType = "stop_event"
+	EventTrip      EventType = "trip"
+	EventScenario  EventType = "scenario"
 )
 
 // ========================================
@@ -40,6 +48,7 @@ type GPSData struct {
 	Satellites int     // Número de satélites
 	FixQuality int     // Calidad del fix (0=sin fix, 1=GPS, 2=DGPS)
 	Progress   float64 // ← NUEVO: Progreso en la ruta (0.0 a 1.0)
+	Direction  int     // Sentido sobre la ruta: +1 ida, -1 regreso
 
 }
 
@@ -96,6 +105,9 @@ type PersonTrack struct {
 	BoundingBox Box     // Coordenadas del bounding box
 	FirstSeen   int     // Frame en que se vio por primera vez
 	LastSeen    int     // Frame en que se vio por última vez
+	VelocityX   float64 // px/s en la imagen
+	VelocityY   float64 // px/s en la imagen (negativo = hacia la cabina)
+	Direction   string  // Dirección real simulada: "IN", "OUT" ("" si se desconoce)
 }
 
 type Box struct {
@@ -118,14 +130,22 @@ type VehicleStateData struct {
 	TurnRate     float64 // °/s (del MPU)
 
 	// Estados booleanos
-	IsMoving  bool
-	IsStopped bool
-	DoorOpen  bool
+	IsMoving        bool
+	IsStopped       bool
+	IsBraking       bool
+	ApproachingStop bool // A menos de approach_distance_m de la próxima parada
+	DoorOpen        bool
 
 	// GPS
 	HasGPSFix  bool
 	GPSQuality int
 
+	// Ocupación
+	PassengerCount int     // Pasajeros a bordo
+	Capacity       int     // Capacidad total (sentados + de pie)
+	LoadFactor     float64 // PassengerCount / Capacity
+	CrowdingLevel  string  // Nivel de ocupación (estilo GTFS-RT OccupancyStatus)
+
 	// Timestamp
 	Timestamp time.Time
 }
@@ -138,6 +158,8 @@ const (
 	VehicleGPSMovimiento        = "GPS_MOVIMIENTO"
 	VehicleMPUMovimiento        = "MPU_MOVIMIENTO"
 	VehicleMPUMovimientoSinGPS  = "MPU_MOVIMIENTO_SIN_GPS"
+	VehicleFrenando             = "FRENANDO"
+	VehicleAproximandoParada    = "APROXIMANDO_PARADA"
 )
 
 // ========================================
@@ -158,11 +180,224 @@ type PassengerEventData struct {
 	TotalEntries   int // Total de entradas del día
 	TotalExits     int // Total de salidas del día
 
+	// Parada (resuelta al abrir la puerta)
+	StopID         int     // ID de la parada (0 = desconocida)
+	StopName       string  // Nombre de la parada
+	Latitude       float64 // Posición del vehículo al abrir la puerta
+	Longitude      float64
+	DwellSessionID string // Sesión de puerta a la que pertenece el evento
+
 	// Metadata
 	DeviceID  string
 	Timestamp time.Time
 }
 
+// ========================================
+// RESUMEN DE PARADA (SESIÓN DE PUERTA)
+// ========================================
+
+type DwellSummaryData struct {
+	DwellSessionID string
+	StopID         int
+	StopName       string
+	Latitude       float64
+	Longitude      float64
+
+	OpenedAt    time.Time // Apertura de la puerta
+	ClosedAt    time.Time // Último cierre de la puerta
+	ConfirmedAt time.Time // Confirmación del cierre (cero si terminó por timeout)
+	DurationSec float64   // Duración de la sesión
+	TimedOut    bool      // La sesión terminó por el timeout de seguridad
+
+	Entries            int // Entradas en la parada
+	Exits              int // Salidas en la parada
+	CurrentCount       int // Pasajeros a bordo al salir de la parada
+	MaxDetectedPersons int // Máximo de personas vistas por la cámara
+
+	CountingStrategy string          // Estrategia que produjo Entries/Exits
+	ShadowCounts     []StrategyCount // Conteos de las estrategias en sombra (A/B)
+
+	DeviceID  string
+	Timestamp time.Time
+}
+
+// StrategyCount entradas y salidas de una estrategia de conteo en una parada
+type StrategyCount struct {
+	Strategy string
+	Entries  int
+	Exits    int
+}
+
+// ========================================
+// OCUPACIÓN Y ALARMAS DE CAPACIDAD
+// ========================================
+
+type OccupancyEventData struct {
+	Alarm         string  // "THRESHOLD_UP", "THRESHOLD_DOWN", "NEGATIVE_CLAMP"
+	Threshold     float64 // Factor de carga cruzado (0 para NEGATIVE_CLAMP)
+	LoadFactor    float64
+	CurrentCount  int
+	Capacity      int
+	CrowdingLevel string
+	DeviceID      string
+	Timestamp     time.Time
+}
+
+// Alarmas de ocupación
+const (
+	OccupancyThresholdUp   = "THRESHOLD_UP"
+	OccupancyThresholdDown = "THRESHOLD_DOWN"
+	OccupancyNegativeClamp = "NEGATIVE_CLAMP" // Salida con 0 a bordo (error de conteo)
+)
+
+// Niveles de ocupación (GTFS-RT OccupancyStatus)
+const (
+	CrowdingEmpty                   = "EMPTY"
+	CrowdingManySeatsAvailable      = "MANY_SEATS_AVAILABLE"
+	CrowdingFewSeatsAvailable       = "FEW_SEATS_AVAILABLE"
+	CrowdingStandingRoomOnly        = "STANDING_ROOM_ONLY"
+	CrowdingCrushedStandingRoomOnly = "CRUSHED_STANDING_ROOM_ONLY"
+	CrowdingFull                    = "FULL"
+)
+
+// ========================================
+// LLEGADAS Y SALIDAS DE PARADAS
+// ========================================
+
+type StopEventData struct {
+	EventType string // StopArrived, StopDeparted, StopSkipped, StopUnscheduled
+	StopID    int    // 0 en paradas no programadas
+	StopName  string
+	Latitude  float64
+	Longitude float64
+
+	ArrivedAt  time.Time // Llegada (cero en SKIPPED)
+	DepartedAt time.Time // Salida (solo DEPARTED)
+	DwellSec   float64   // Tiempo detenido en la parada (solo DEPARTED)
+	DoorOpened bool      // Se abrió la puerta durante la parada
+
+	// Horario (solo rutas programadas, p. ej. importadas de GTFS)
+	HasSchedule      bool
+	ScheduledArrival time.Time // Llegada programada según el inicio del viaje
+	DeviationSec     float64   // Llegada real - programada (positivo = tarde, negativo = adelantado)
+
+	DeviceID  string
+	Timestamp time.Time
+}
+
+// Tipos de eventos de parada
+const (
+	StopArrived     = "ARRIVED"
+	StopDeparted    = "DEPARTED"
+	StopSkipped     = "SKIPPED"
+	StopUnscheduled = "UNSCHEDULED_STOP"
+)
+
+// ========================================
+// CICLO DE VIDA DE VIAJES
+// ========================================
+
+type TripEventData struct {
+	EventType    string // TripStarted, TripCompleted, TripAborted
+	TripID       string
+	RouteName    string
+	Direction    string // scenario.DirectionOutbound o scenario.DirectionInbound
+	StartTime    time.Time
+	EndTime      time.Time // Cero en TRIP_STARTED
+	StopSequence []int     // IDs de paradas en el orden del viaje
+	DeviceID     string
+	Timestamp    time.Time
+}
+
+// Tipos de eventos de viaje
+const (
+	TripStarted   = "TRIP_STARTED"
+	TripCompleted = "TRIP_COMPLETED"
+	TripAborted   = "TRIP_ABORTED" // Terminado antes de llegar a la terminal (reset/apagado)
+)
+
+// ========================================
+// EJECUCIÓN DE ESCENARIOS
+// ========================================
+
+type ScenarioEventData struct {
+	EventType  string  // ScenarioStarted, ScenarioStep, ScenarioCompleted, ScenarioAborted, ScenarioStopped, ScenarioSeek
+	Name       string  // Escenario en curso
+	Step       int     // Índice del paso (ScenarioStep)
+	TotalSteps int     // Pasos del escenario
+	Action     string  // Acción del paso (ScenarioStep)
+	ElapsedSec float64 // Segundos desde el inicio de la vuelta
+	Loop       int     // Vuelta actual (1 = primera)
+	OnEnd      string  // Qué pasa al terminar: hold, stop, loop o next (ScenarioCompleted)
+	Next       string  // Escenario que sigue (on_end next)
+	Passed     int     // Verificaciones OK hasta el momento
+	Failed     int     // Verificaciones fallidas hasta el momento
+
+	// Lista de escenarios en curso (vacío si se corre un escenario suelto)
+	Playlist     string
+	Entry        int // Entrada actual (1 = primera)
+	TotalEntries int
+
+	Timestamp time.Time
+}
+
+// Tipos de eventos de escenario
+const (
+	ScenarioStarted   = "STARTED" // Inicio, nueva vuelta o escenario encadenado
+	ScenarioStep      = "STEP"
+	ScenarioCompleted = "COMPLETED"
+	ScenarioAborted   = "ABORTED" // Un assert falló
+	ScenarioStopped   = "STOPPED" // Detenido desde fuera (UI, reset, cambio de escenario)
+	ScenarioSeek      = "SEEK"    // Salto en la línea de tiempo (Seek)
+)
+
+// ========================================
+// TRANSICIONES DE LA MÁQUINA DE ESTADOS DE PUERTA
+// ========================================
+
+type DoorStateEventData struct {
+	From           string // Estado anterior (DoorState.String())
+	To             string // Estado nuevo
+	DwellSessionID string // Sesión de parada en curso
+	StopID         int
+	DeviceID       string
+	Timestamp      time.Time
+}
+
+// ========================================
+// RESUMEN DIARIO DE PASAJEROS
+// ========================================
+
+type DailySummaryData struct {
+	ServiceDate    string // Día de servicio (YYYY-MM-DD) que se cierra
+	TotalEntries   int    // Entradas del día
+	TotalExits     int    // Salidas del día
+	OnboardAtClose int    // Pasajeros a bordo al cerrar el día
+	DeviceID       string
+	Timestamp      time.Time
+}
+
+// ========================================
+// FALLAS DE SENSORES
+// ========================================
+
+type FaultEventData struct {
+	Sensor     string  // "gps", "mpu6050", "vl53l0x", "camera"
+	FaultType  string  // "drop", "freeze", "bias", ...
+	Status     string  // "STARTED", "INJECTED", "ENDED", "CLEARED"
+	Magnitude  float64 // Magnitud configurada
+	Injections int     // Muestras afectadas hasta el momento
+	Timestamp  time.Time
+}
+
+// Estados de una falla inyectada
+const (
+	FaultStarted  = "STARTED"
+	FaultInjected = "INJECTED" // Muestra alterada (como máximo uno por segundo y falla)
+	FaultEnded    = "ENDED"
+	FaultCleared  = "CLEARED"
+)
+
 // ========================================
 // ESTADOS DE LA MÁQUINA DE ESTADOS DE PUERTA
 // (PASSENGER_STATES)
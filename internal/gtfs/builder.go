@@ -0,0 +1,307 @@
+package gtfs
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/config"
+	"github.com/MarcosBrindi/transporte-simulator/internal/scenario"
+)
+
+// terminalMarginKm distancia antes de la terminal final a la que se detiene el
+// escenario (llegar a 1.0 invierte el sentido y cierra el viaje)
+const terminalMarginKm = 0.01
+
+// DefaultTripID retorna el primer viaje del feed por hora de salida
+func (f *Feed) DefaultTripID() (string, error) {
+	routeIDs := make([]string, 0, len(f.Routes))
+	for id := range f.Routes {
+		routeIDs = append(routeIDs, id)
+	}
+	sort.Strings(routeIDs)
+
+	for _, routeID := range routeIDs {
+		if tripIDs := f.TripsForRoute(routeID); len(tripIDs) > 0 {
+			return tripIDs[0], nil
+		}
+	}
+	return "", fmt.Errorf("el feed no tiene viajes con horario")
+}
+
+// BuildRoute construye la ruta de un viaje: sigue su trazado (o las paradas si
+// no tiene shape) y ubica cada parada con su horario relativo a la salida
+func (f *Feed) BuildRoute(tripID string) (*scenario.Route, error) {
+	trip, ok := f.Trips[tripID]
+	if !ok {
+		return nil, fmt.Errorf("viaje '%s' no existe en el feed", tripID)
+	}
+
+	stopTimes := f.StopTimes[tripID]
+	if len(stopTimes) < 2 {
+		return nil, fmt.Errorf("viaje '%s' tiene menos de 2 paradas", tripID)
+	}
+
+	// Trazado: shape del viaje o, si no hay, las coordenadas de las paradas
+	shape := f.Shapes[trip.ShapeID]
+	points := make([]scenario.ShapePoint, 0)
+	for _, point := range shape {
+		points = append(points, scenario.ShapePoint{Lat: point.Lat, Lon: point.Lon})
+	}
+	if len(points) < 2 {
+		shape = nil
+		points = points[:0]
+		for _, stopTime := range stopTimes {
+			stop, ok := f.Stops[stopTime.StopID]
+			if !ok {
+				return nil, fmt.Errorf("parada '%s' no existe en el feed", stopTime.StopID)
+			}
+			points = append(points, scenario.ShapePoint{Lat: stop.Lat, Lon: stop.Lon})
+		}
+	}
+
+	route := scenario.NewRouteFromShape(f.routeName(trip), points, nil)
+	if route.Length <= 0 {
+		return nil, fmt.Errorf("viaje '%s' tiene un trazado sin longitud", tripID)
+	}
+
+	firstDeparture := stopTimes[0].DepartureSec
+	lastDistance := 0.0
+
+	for i, stopTime := range stopTimes {
+		stop, ok := f.Stops[stopTime.StopID]
+		if !ok {
+			return nil, fmt.Errorf("parada '%s' no existe en el feed", stopTime.StopID)
+		}
+
+		// Con shape_dist_traveled en la parada y el trazado, la distancia sale
+		// del feed; si no, proyectar sobre el trazado sin retroceder (rutas que
+		// pasan dos veces por la misma zona)
+		distance, ok := distanceOnShape(shape, route.Shape, stopTime)
+		if !ok {
+			distance = projectOnShape(route.Shape, stop.Lat, stop.Lon, lastDistance)
+		}
+		lastDistance = distance
+
+		route.Stops = append(route.Stops, scenario.Stop{
+			ID:              i + 1,
+			Name:            stop.Name,
+			Position:        distance / route.Length,
+			ExternalID:      stop.ID,
+			ScheduledOffset: float64(stopTime.ArrivalSec - firstDeparture),
+		})
+	}
+
+	route.Scheduled = true
+	route.TripID = tripID
+	route.RouteID = trip.RouteID
+	route.Direction = trip.DirectionID
+
+	return route, nil
+}
+
+// BuildScenario genera un escenario que conduce el autobús siguiendo el horario
+// del viaje: en cada tramo ajusta la velocidad para llegar a la hora programada
+// (con tope de velocidad) y en cada parada se detiene y abre la puerta
+func (f *Feed) BuildScenario(tripID string, cfg config.GTFSConfig) (*scenario.Scenario, error) {
+	route, err := f.BuildRoute(tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	stopTimes := f.StopTimes[tripID]
+	firstDeparture := float64(stopTimes[0].DepartureSec)
+
+	maxSpeed := cfg.MaxSpeedKmh
+	if maxSpeed <= 0 {
+		maxSpeed = 60
+	}
+
+	steps := []scenario.ScenarioStep{
+		{Time: 0, Action: scenario.ActionLog, Value: fmt.Sprintf("🚌 Viaje %s: %s", tripID, route.Name)},
+	}
+
+	now := 0.0 // Tiempo del escenario (s desde la salida de la primera parada)
+	for i := 1; i < len(route.Stops); i++ {
+		from, to := route.Stops[i-1], route.Stops[i]
+
+		distanceKm := (to.Position - from.Position) * route.Length
+		if i == len(route.Stops)-1 {
+			distanceKm = math.Max(0, distanceKm-terminalMarginKm)
+		}
+
+		// Velocidad para llegar a la hora programada (si va tarde, al tope)
+		scheduledArrival := float64(stopTimes[i].ArrivalSec) - firstDeparture
+		speed := maxSpeed
+		if available := scheduledArrival - now; available > 0 {
+			speed = math.Min(maxSpeed, distanceKm/(available/3600.0))
+		}
+
+		if distanceKm > 0 && speed > 0 {
+			speed = math.Round(speed*10) / 10
+			steps = append(steps, scenario.ScenarioStep{Time: now, Action: scenario.ActionSetSpeed, Value: speed})
+			now += distanceKm / speed * 3600.0
+		}
+
+		// Llegada: detenerse y ciclo de puerta
+		steps = append(steps,
+			scenario.ScenarioStep{Time: now, Action: scenario.ActionSetSpeed, Value: 0.0},
+			scenario.ScenarioStep{Time: now, Action: scenario.ActionLog, Value: fmt.Sprintf("🚏 %s", to.Name)},
+		)
+
+		dwell := float64(stopTimes[i].DepartureSec - stopTimes[i].ArrivalSec)
+		if dwell <= 0 {
+			dwell = cfg.DefaultDwellSec
+		}
+
+		openAt := now + 1
+		closeAt := openAt + dwell
+		steps = append(steps,
+			scenario.ScenarioStep{Time: openAt, Action: scenario.ActionDoorOpen},
+			// wait_door_close ordena el cierre y retiene el arranque hasta que cierre
+			scenario.ScenarioStep{Time: closeAt, Action: scenario.ActionWaitDoorClose},
+		)
+
+		// Salir a la hora programada (o en cuanto cierre la puerta si va tarde)
+		now = math.Max(closeAt+1, float64(stopTimes[i].DepartureSec)-firstDeparture)
+	}
+
+	steps = append(steps, scenario.ScenarioStep{Time: now, Action: scenario.ActionLog, Value: "✅ Viaje completado"})
+
+	generated := &scenario.Scenario{
+		Name:        fmt.Sprintf("GTFS %s", tripID),
+		Description: fmt.Sprintf("Viaje programado %s (%d paradas, %.1f km)", tripID, len(route.Stops), route.Length),
+		Duration:    int(math.Ceil(now)) + 5,
+		Steps:       steps,
+	}
+
+	if err := generated.Validate(); err != nil {
+		return nil, fmt.Errorf("escenario generado inválido: %w", err)
+	}
+
+	return generated, nil
+}
+
+// routeName nombre legible de la ruta de un viaje
+func (f *Feed) routeName(trip Trip) string {
+	route, ok := f.Routes[trip.RouteID]
+	if !ok {
+		return trip.RouteID
+	}
+
+	name := route.ShortName
+	if route.LongName != "" {
+		if name != "" {
+			name += " - "
+		}
+		name += route.LongName
+	}
+	if name == "" {
+		name = route.ID
+	}
+	if trip.Headsign != "" {
+		name += " → " + trip.Headsign
+	}
+	return name
+}
+
+// distanceOnShape convierte el shape_dist_traveled de una parada (unidades
+// del feed) en km sobre el trazado, interpolando entre los puntos del shape
+// que lo tienen. route es el trazado construido desde shape (mismos índices).
+func distanceOnShape(shape []ShapePoint, route []scenario.ShapePoint, stopTime StopTime) (float64, bool) {
+	if !stopTime.HasDistTravel || len(shape) < 2 || len(shape) != len(route) {
+		return 0, false
+	}
+	for _, point := range shape {
+		if !point.HasDistTravel {
+			return 0, false
+		}
+	}
+
+	dist := stopTime.DistTraveled
+	if dist <= shape[0].DistTraveled {
+		return route[0].DistanceKm, true
+	}
+	for i := 1; i < len(shape); i++ {
+		from, to := shape[i-1].DistTraveled, shape[i].DistTraveled
+		if dist > to {
+			continue
+		}
+		ratio := 0.0
+		if to > from {
+			ratio = (dist - from) / (to - from)
+		}
+		return route[i-1].DistanceKm + ratio*(route[i].DistanceKm-route[i-1].DistanceKm), true
+	}
+	return route[len(route)-1].DistanceKm, true
+}
+
+// projectOnShape retorna la distancia a lo largo del trazado (km) del punto más
+// cercano a la coordenada, sin considerar tramos antes de minDistance
+func projectOnShape(shape []scenario.ShapePoint, lat, lon, minDistance float64) float64 {
+	best := minDistance
+	bestOffset := math.Inf(1)
+
+	for i := 1; i < len(shape); i++ {
+		from, to := shape[i-1], shape[i]
+		if to.DistanceKm < minDistance {
+			continue
+		}
+
+		// Plano local equirectangular centrado en el inicio del tramo (km)
+		kmPerDegLat := 111.32
+		kmPerDegLon := 111.32 * math.Cos(from.Lat*math.Pi/180.0)
+
+		sx := (to.Lon - from.Lon) * kmPerDegLon
+		sy := (to.Lat - from.Lat) * kmPerDegLat
+		px := (lon - from.Lon) * kmPerDegLon
+		py := (lat - from.Lat) * kmPerDegLat
+
+		fraction := 0.0
+		if lengthSq := sx*sx + sy*sy; lengthSq > 0 {
+			fraction = math.Max(0, math.Min(1, (px*sx+py*sy)/lengthSq))
+		}
+
+		offset := math.Hypot(px-sx*fraction, py-sy*fraction)
+		distance := from.DistanceKm + (to.DistanceKm-from.DistanceKm)*fraction
+		if distance < minDistance {
+			continue
+		}
+
+		if offset < bestOffset {
+			bestOffset = offset
+			best = distance
+		}
+	}
+
+	return best
+}
+
+// LoadTrip carga el feed configurado y construye la ruta y el escenario del
+// viaje indicado (o del primero del feed)
+func LoadTrip(cfg config.GTFSConfig) (*scenario.Route, *scenario.Scenario, error) {
+	feed, err := Load(cfg.Directory)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tripID := cfg.TripID
+	if tripID == "" {
+		if tripID, err = feed.DefaultTripID(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	route, err := feed.BuildRoute(tripID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	generated, err := feed.BuildScenario(tripID, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fmt.Printf("✅ [GTFS] Viaje %s: %s\n", tripID, route)
+	return route, generated, nil
+}
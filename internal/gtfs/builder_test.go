@@ -0,0 +1,57 @@
+package gtfs
+
+import (
+	"math"
+	"testing"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/scenario"
+)
+
+// testShape arma un trazado con distancias acumuladas
+func testShape(points ...[2]float64) []scenario.ShapePoint {
+	shape := make([]scenario.ShapePoint, 0, len(points))
+	distance := 0.0
+	for i, point := range points {
+		if i > 0 {
+			previous := points[i-1]
+			distance += scenario.HaversineKm(previous[0], previous[1], point[0], point[1])
+		}
+		shape = append(shape, scenario.ShapePoint{Lat: point[0], Lon: point[1], DistanceKm: distance})
+	}
+	return shape
+}
+
+func TestProjectOnShape(t *testing.T) {
+	// Recta hacia el norte sobre el ecuador en tramos de 0.01° (leg km)
+	leg := scenario.HaversineKm(0, 0, 0.01, 0)
+	cross := scenario.HaversineKm(0.01, 0, 0.01, 0.001)
+	straight := testShape([2]float64{0, 0}, [2]float64{0.01, 0}, [2]float64{0.02, 0})
+	// Ida y vuelta en U: sube por lon 0 y baja por lon 0.001
+	uTurn := testShape([2]float64{0, 0}, [2]float64{0.01, 0}, [2]float64{0.01, 0.001}, [2]float64{0, 0.001})
+
+	tests := []struct {
+		name        string
+		shape       []scenario.ShapePoint
+		lat, lon    float64
+		minDistance float64
+		want        float64 // km
+	}{
+		{"sobre el trazado", straight, 0.005, 0, 0, 0.5 * leg},
+		{"a un costado", straight, 0.015, 0.001, 0, 1.5 * leg},
+		{"antes del inicio", straight, -0.005, 0, 0, 0},
+		{"después del final", straight, 0.03, 0, 0, 2 * leg},
+		{"vértice", straight, 0.01, 0, 0, leg},
+		{"ida de la U", uTurn, 0.002, 0.0004, 0, 0.2 * leg},
+		{"vuelta de la U (parada posterior)", uTurn, 0.002, 0.0004, 1.5, leg + cross + 0.8*leg},
+		{"sin tramo después de minDistance", straight, 0.005, 0, 3, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := projectOnShape(tt.shape, tt.lat, tt.lon, tt.minDistance)
+			if math.Abs(got-tt.want) > 0.001 {
+				t.Errorf("got %.4f km, want %.4f km", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,406 @@
+package gtfs
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/scenario"
+)
+
+// Stop parada de stops.txt
+type Stop struct {
+	ID   string
+	Name string
+	Lat  float64
+	Lon  float64
+}
+
+// Route línea de routes.txt
+type Route struct {
+	ID        string
+	ShortName string
+	LongName  string
+}
+
+// Trip viaje programado de trips.txt
+type Trip struct {
+	ID          string
+	RouteID     string
+	ServiceID   string
+	DirectionID int // 0 = ida, 1 = regreso
+	ShapeID     string
+	Headsign    string
+}
+
+// StopTime horario de una parada en un viaje (stop_times.txt)
+type StopTime struct {
+	TripID        string
+	StopID        string
+	Sequence      int
+	ArrivalSec    int // Segundos desde la medianoche del día de servicio (puede pasar de 24h)
+	DepartureSec  int
+	DistTraveled  float64
+	HasDistTravel bool
+	Interpolated  bool // Parada sin horario en el feed (no timepoint): hora interpolada
+}
+
+// ShapePoint punto de shapes.txt
+type ShapePoint struct {
+	ShapeID       string
+	Lat           float64
+	Lon           float64
+	Sequence      int
+	DistTraveled  float64
+	HasDistTravel bool
+}
+
+// Feed feed GTFS estático cargado en memoria
+type Feed struct {
+	Stops     map[string]Stop
+	Routes    map[string]Route
+	Trips     map[string]Trip
+	StopTimes map[string][]StopTime   // Por trip_id, ordenados por stop_sequence
+	Shapes    map[string][]ShapePoint // Por shape_id, ordenados por shape_pt_sequence
+}
+
+// Load carga un feed GTFS desde un directorio con los archivos .txt
+// (shapes.txt es opcional: sin él la ruta sigue las paradas)
+func Load(dir string) (*Feed, error) {
+	feed := &Feed{
+		Stops:     make(map[string]Stop),
+		Routes:    make(map[string]Route),
+		Trips:     make(map[string]Trip),
+		StopTimes: make(map[string][]StopTime),
+		Shapes:    make(map[string][]ShapePoint),
+	}
+
+	loaders := []struct {
+		file     string
+		optional bool
+		load     func(record) error
+	}{
+		{"stops.txt", false, feed.loadStop},
+		{"routes.txt", false, feed.loadRoute},
+		{"trips.txt", false, feed.loadTrip},
+		{"stop_times.txt", false, feed.loadStopTime},
+		{"shapes.txt", true, feed.loadShapePoint},
+	}
+
+	for _, loader := range loaders {
+		path := filepath.Join(dir, loader.file)
+		if loader.optional {
+			if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+		}
+		if err := readCSV(path, loader.load); err != nil {
+			return nil, fmt.Errorf("error cargando %s: %w", loader.file, err)
+		}
+	}
+
+	for tripID := range feed.StopTimes {
+		stopTimes := feed.StopTimes[tripID]
+		sort.Slice(stopTimes, func(i, j int) bool { return stopTimes[i].Sequence < stopTimes[j].Sequence })
+		if err := feed.interpolateTimes(stopTimes); err != nil {
+			return nil, fmt.Errorf("viaje '%s': %w", tripID, err)
+		}
+	}
+	for shapeID := range feed.Shapes {
+		points := feed.Shapes[shapeID]
+		sort.Slice(points, func(i, j int) bool { return points[i].Sequence < points[j].Sequence })
+	}
+
+	fmt.Printf("✅ [GTFS] Feed cargado: %d paradas, %d rutas, %d viajes, %d trazados\n",
+		len(feed.Stops), len(feed.Routes), len(feed.Trips), len(feed.Shapes))
+
+	return feed, nil
+}
+
+// TripsForRoute retorna los IDs de los viajes de una ruta ordenados por hora de salida
+func (f *Feed) TripsForRoute(routeID string) []string {
+	tripIDs := make([]string, 0)
+	for id, trip := range f.Trips {
+		if trip.RouteID == routeID && len(f.StopTimes[id]) > 0 {
+			tripIDs = append(tripIDs, id)
+		}
+	}
+
+	sort.Slice(tripIDs, func(i, j int) bool {
+		return f.StopTimes[tripIDs[i]][0].DepartureSec < f.StopTimes[tripIDs[j]][0].DepartureSec
+	})
+	return tripIDs
+}
+
+func (f *Feed) loadStop(r record) error {
+	lat, err := r.float("stop_lat")
+	if err != nil {
+		return err
+	}
+	lon, err := r.float("stop_lon")
+	if err != nil {
+		return err
+	}
+
+	stop := Stop{ID: r.get("stop_id"), Name: r.get("stop_name"), Lat: lat, Lon: lon}
+	f.Stops[stop.ID] = stop
+	return nil
+}
+
+func (f *Feed) loadRoute(r record) error {
+	route := Route{ID: r.get("route_id"), ShortName: r.get("route_short_name"), LongName: r.get("route_long_name")}
+	f.Routes[route.ID] = route
+	return nil
+}
+
+func (f *Feed) loadTrip(r record) error {
+	direction := 0
+	if value := r.get("direction_id"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("direction_id inválido '%s'", value)
+		}
+		direction = parsed
+	}
+
+	trip := Trip{
+		ID:          r.get("trip_id"),
+		RouteID:     r.get("route_id"),
+		ServiceID:   r.get("service_id"),
+		DirectionID: direction,
+		ShapeID:     r.get("shape_id"),
+		Headsign:    r.get("trip_headsign"),
+	}
+	f.Trips[trip.ID] = trip
+	return nil
+}
+
+func (f *Feed) loadStopTime(r record) error {
+	sequence, err := strconv.Atoi(r.get("stop_sequence"))
+	if err != nil {
+		return fmt.Errorf("stop_sequence inválido '%s'", r.get("stop_sequence"))
+	}
+
+	stopTime := StopTime{
+		TripID:   r.get("trip_id"),
+		StopID:   r.get("stop_id"),
+		Sequence: sequence,
+	}
+
+	// Las paradas que no son timepoint pueden venir sin horario: se interpola
+	// después entre las paradas con horario (interpolateTimes)
+	arrivalValue := strings.TrimSpace(r.get("arrival_time"))
+	departureValue := strings.TrimSpace(r.get("departure_time"))
+	if arrivalValue == "" {
+		arrivalValue = departureValue
+	}
+	if departureValue == "" {
+		departureValue = arrivalValue
+	}
+	if arrivalValue == "" {
+		stopTime.Interpolated = true
+	} else {
+		arrival, err := ParseTime(arrivalValue)
+		if err != nil {
+			return err
+		}
+		departure, err := ParseTime(departureValue)
+		if err != nil {
+			return err
+		}
+		stopTime.ArrivalSec = arrival
+		stopTime.DepartureSec = departure
+	}
+
+	if value := r.get("shape_dist_traveled"); value != "" {
+		dist, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("shape_dist_traveled inválido '%s'", value)
+		}
+		stopTime.DistTraveled = dist
+		stopTime.HasDistTravel = true
+	}
+
+	f.StopTimes[stopTime.TripID] = append(f.StopTimes[stopTime.TripID], stopTime)
+	return nil
+}
+
+// interpolateTimes completa la hora de las paradas sin horario repartiendo
+// el tiempo entre las paradas con horario vecinas según la distancia:
+// shape_dist_traveled si todas la tienen o, si no, la distancia entre paradas
+func (f *Feed) interpolateTimes(stopTimes []StopTime) error {
+	if len(stopTimes) == 0 {
+		return nil
+	}
+	if stopTimes[0].Interpolated || stopTimes[len(stopTimes)-1].Interpolated {
+		return fmt.Errorf("la primera y la última parada deben tener horario")
+	}
+
+	distances := f.tripDistances(stopTimes)
+
+	from := 0
+	for i := 1; i < len(stopTimes); i++ {
+		if stopTimes[i].Interpolated {
+			continue
+		}
+
+		// Paradas sin horario entre from e i
+		start := float64(stopTimes[from].DepartureSec)
+		span := float64(stopTimes[i].ArrivalSec) - start
+		total := distances[i] - distances[from]
+		for j := from + 1; j < i; j++ {
+			ratio := float64(j-from) / float64(i-from)
+			if total > 0 {
+				ratio = (distances[j] - distances[from]) / total
+			}
+			seconds := int(math.Round(start + span*ratio))
+			stopTimes[j].ArrivalSec = seconds
+			stopTimes[j].DepartureSec = seconds
+		}
+		from = i
+	}
+	return nil
+}
+
+// tripDistances retorna la distancia acumulada de cada parada del viaje:
+// shape_dist_traveled (en las unidades del feed) si todas la tienen, o los km
+// en línea recta entre paradas (0 en los tramos con paradas desconocidas)
+func (f *Feed) tripDistances(stopTimes []StopTime) []float64 {
+	distances := make([]float64, len(stopTimes))
+
+	withDist := true
+	for _, stopTime := range stopTimes {
+		withDist = withDist && stopTime.HasDistTravel
+	}
+	if withDist {
+		for i, stopTime := range stopTimes {
+			distances[i] = stopTime.DistTraveled
+		}
+		return distances
+	}
+
+	for i := 1; i < len(stopTimes); i++ {
+		distances[i] = distances[i-1]
+		prev, okPrev := f.Stops[stopTimes[i-1].StopID]
+		stop, ok := f.Stops[stopTimes[i].StopID]
+		if okPrev && ok {
+			distances[i] += scenario.HaversineKm(prev.Lat, prev.Lon, stop.Lat, stop.Lon)
+		}
+	}
+	return distances
+}
+
+func (f *Feed) loadShapePoint(r record) error {
+	lat, err := r.float("shape_pt_lat")
+	if err != nil {
+		return err
+	}
+	lon, err := r.float("shape_pt_lon")
+	if err != nil {
+		return err
+	}
+	sequence, err := strconv.Atoi(r.get("shape_pt_sequence"))
+	if err != nil {
+		return fmt.Errorf("shape_pt_sequence inválido '%s'", r.get("shape_pt_sequence"))
+	}
+
+	point := ShapePoint{ShapeID: r.get("shape_id"), Lat: lat, Lon: lon, Sequence: sequence}
+	if value := r.get("shape_dist_traveled"); value != "" {
+		dist, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("shape_dist_traveled inválido '%s'", value)
+		}
+		point.DistTraveled = dist
+		point.HasDistTravel = true
+	}
+	f.Shapes[point.ShapeID] = append(f.Shapes[point.ShapeID], point)
+	return nil
+}
+
+// ParseTime convierte una hora GTFS (HH:MM:SS, puede pasar de 24h) a segundos
+// desde la medianoche del día de servicio
+func ParseTime(value string) (int, error) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("hora GTFS inválida '%s'", value)
+	}
+
+	total := 0
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 || (i > 0 && number > 59) {
+			return 0, fmt.Errorf("hora GTFS inválida '%s'", value)
+		}
+		total = total*60 + number
+	}
+	return total, nil
+}
+
+// record fila de un CSV GTFS indexada por el encabezado
+type record struct {
+	header map[string]int
+	values []string
+}
+
+// get retorna el valor de una columna ("" si no existe)
+func (r record) get(column string) string {
+	index, ok := r.header[column]
+	if !ok || index >= len(r.values) {
+		return ""
+	}
+	return strings.TrimSpace(r.values[index])
+}
+
+// float retorna el valor numérico de una columna obligatoria
+func (r record) float(column string) (float64, error) {
+	value, err := strconv.ParseFloat(r.get(column), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s inválido '%s'", column, r.get(column))
+	}
+	return value, nil
+}
+
+// readCSV recorre las filas de un archivo GTFS
+func readCSV(path string, handle func(record) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	headerRow, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("sin encabezado: %w", err)
+	}
+
+	header := make(map[string]int, len(headerRow))
+	for i, column := range headerRow {
+		// Quitar BOM UTF-8 de la primera columna
+		header[strings.TrimPrefix(strings.TrimSpace(column), "\ufeff")] = i
+	}
+
+	line := 1
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		line++
+		if err != nil {
+			return fmt.Errorf("línea %d: %w", line, err)
+		}
+		if err := handle(record{header: header, values: values}); err != nil {
+			return fmt.Errorf("línea %d: %w", line, err)
+		}
+	}
+}
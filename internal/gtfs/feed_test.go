@@ -0,0 +1,42 @@
+package gtfs
+
+import "testing"
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"00:00:00", 0, false},
+		{"08:15:30", 8*3600 + 15*60 + 30, false},
+		{"8:05:00", 8*3600 + 5*60, false},
+		{" 12:00:00 ", 12 * 3600, false},
+		{"25:10:00", 25*3600 + 10*60, false}, // Servicio que pasa de medianoche
+		{"", 0, true},
+		{"08:15", 0, true},
+		{"08:60:00", 0, true},
+		{"08:15:60", 0, true},
+		{"-1:00:00", 0, true},
+		{"08:xx:00", 0, true},
+		{"08:15:00:00", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseTime(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("se esperaba error, llegó %d", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error inesperado: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,352 @@
+package gtfsrt
+
+import (
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Mensajes de gtfs-realtime.proto (solo los campos que produce el simulador).
+// Se codifican a mano con protowire usando los números de campo oficiales.
+
+// Versión de la especificación que declara el encabezado
+const gtfsRealtimeVersion = "2.0"
+
+// Incrementality
+const incrementalityFullDataset = 0
+
+// VehicleStopStatus
+const (
+	StatusIncomingAt  = 0
+	StatusStoppedAt   = 1
+	StatusInTransitTo = 2
+)
+
+// TripDescriptor.ScheduleRelationship
+const (
+	TripScheduled   = 0
+	TripUnscheduled = 2
+)
+
+// StopTimeUpdate.ScheduleRelationship
+const (
+	StopScheduled = 0
+	StopSkipped   = 1
+)
+
+// OccupancyStatus (mismos nombres que eventbus.Crowding*)
+var occupancyStatus = map[string]int32{
+	"EMPTY":                      0,
+	"MANY_SEATS_AVAILABLE":       1,
+	"FEW_SEATS_AVAILABLE":        2,
+	"STANDING_ROOM_ONLY":         3,
+	"CRUSHED_STANDING_ROOM_ONLY": 4,
+	"FULL":                       5,
+	"NOT_ACCEPTING_PASSENGERS":   6,
+}
+
+// Alert.Cause
+const (
+	CauseOtherCause       = 2
+	CauseTechnicalProblem = 3
+)
+
+// Alert.Effect
+const (
+	EffectReducedService = 2
+	EffectOtherEffect    = 7
+	EffectUnknownEffect  = 8
+)
+
+// FeedMessage mensaje raíz del feed
+type FeedMessage struct {
+	Header   FeedHeader   `json:"header"`
+	Entities []FeedEntity `json:"entity"`
+}
+
+// FeedHeader encabezado del feed
+type FeedHeader struct {
+	Version        string `json:"gtfs_realtime_version"`
+	Incrementality int32  `json:"incrementality"`
+	Timestamp      uint64 `json:"timestamp"`
+}
+
+// FeedEntity entidad del feed (una de TripUpdate, Vehicle o Alert)
+type FeedEntity struct {
+	ID         string           `json:"id"`
+	TripUpdate *TripUpdate      `json:"trip_update,omitempty"`
+	Vehicle    *VehiclePosition `json:"vehicle,omitempty"`
+	Alert      *Alert           `json:"alert,omitempty"`
+}
+
+// TripDescriptor identifica el viaje
+type TripDescriptor struct {
+	TripID               string `json:"trip_id,omitempty"`
+	RouteID              string `json:"route_id,omitempty"`
+	DirectionID          uint32 `json:"direction_id"`
+	StartTime            string `json:"start_time,omitempty"` // HH:MM:SS
+	StartDate            string `json:"start_date,omitempty"` // YYYYMMDD
+	ScheduleRelationship int32  `json:"schedule_relationship"`
+}
+
+// VehicleDescriptor identifica el vehículo
+type VehicleDescriptor struct {
+	ID    string `json:"id"`
+	Label string `json:"label,omitempty"`
+}
+
+// Position posición del vehículo
+type Position struct {
+	Latitude  float32 `json:"latitude"`
+	Longitude float32 `json:"longitude"`
+	Bearing   float32 `json:"bearing"`
+	Speed     float32 `json:"speed"` // m/s
+}
+
+// VehiclePosition posición y estado de un vehículo
+type VehiclePosition struct {
+	Trip                *TripDescriptor    `json:"trip,omitempty"`
+	Vehicle             *VehicleDescriptor `json:"vehicle,omitempty"`
+	Position            *Position          `json:"position,omitempty"`
+	CurrentStopSequence uint32             `json:"current_stop_sequence,omitempty"`
+	StopID              string             `json:"stop_id,omitempty"`
+	CurrentStatus       int32              `json:"current_status"`
+	Timestamp           uint64             `json:"timestamp"`
+	OccupancyStatus     int32              `json:"occupancy_status"`
+	OccupancyPercentage uint32             `json:"occupancy_percentage"`
+}
+
+// StopTimeEvent llegada o salida real/estimada en una parada
+type StopTimeEvent struct {
+	Delay int32 `json:"delay"` // Segundos (positivo = tarde)
+	Time  int64 `json:"time"`  // Unix
+}
+
+// StopTimeUpdate actualización de una parada del viaje
+type StopTimeUpdate struct {
+	StopSequence         uint32         `json:"stop_sequence"`
+	StopID               string         `json:"stop_id,omitempty"`
+	Arrival              *StopTimeEvent `json:"arrival,omitempty"`
+	Departure            *StopTimeEvent `json:"departure,omitempty"`
+	ScheduleRelationship int32          `json:"schedule_relationship"`
+}
+
+// TripUpdate avance de un viaje respecto al horario
+type TripUpdate struct {
+	Trip            TripDescriptor     `json:"trip"`
+	Vehicle         *VehicleDescriptor `json:"vehicle,omitempty"`
+	StopTimeUpdates []StopTimeUpdate   `json:"stop_time_update"`
+	Timestamp       uint64             `json:"timestamp"`
+	Delay           int32              `json:"delay"`
+}
+
+// TimeRange periodo de vigencia de una alerta
+type TimeRange struct {
+	Start uint64 `json:"start,omitempty"`
+	End   uint64 `json:"end,omitempty"`
+}
+
+// EntitySelector entidad afectada por una alerta
+type EntitySelector struct {
+	RouteID string          `json:"route_id,omitempty"`
+	Trip    *TripDescriptor `json:"trip,omitempty"`
+	StopID  string          `json:"stop_id,omitempty"`
+}
+
+// Alert aviso para pasajeros
+type Alert struct {
+	ActivePeriods    []TimeRange      `json:"active_period"`
+	InformedEntities []EntitySelector `json:"informed_entity"`
+	Cause            int32            `json:"cause"`
+	Effect           int32            `json:"effect"`
+	HeaderText       string           `json:"header_text"`
+	DescriptionText  string           `json:"description_text,omitempty"`
+}
+
+// Marshal codifica el feed en formato protobuf
+func (m *FeedMessage) Marshal() []byte {
+	var header []byte
+	header = appendString(header, 1, m.Header.Version)
+	header = appendVarint(header, 2, uint64(m.Header.Incrementality))
+	header = appendVarint(header, 3, m.Header.Timestamp)
+
+	var b []byte
+	b = appendMessage(b, 1, header)
+	for i := range m.Entities {
+		b = appendMessage(b, 2, m.Entities[i].marshal())
+	}
+	return b
+}
+
+func (e *FeedEntity) marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, e.ID)
+	if e.TripUpdate != nil {
+		b = appendMessage(b, 3, e.TripUpdate.marshal())
+	}
+	if e.Vehicle != nil {
+		b = appendMessage(b, 4, e.Vehicle.marshal())
+	}
+	if e.Alert != nil {
+		b = appendMessage(b, 5, e.Alert.marshal())
+	}
+	return b
+}
+
+func (t *TripDescriptor) marshal() []byte {
+	var b []byte
+	b = appendOptionalString(b, 1, t.TripID)
+	b = appendOptionalString(b, 2, t.StartTime)
+	b = appendOptionalString(b, 3, t.StartDate)
+	b = appendVarint(b, 4, uint64(t.ScheduleRelationship))
+	b = appendOptionalString(b, 5, t.RouteID)
+	b = appendVarint(b, 6, uint64(t.DirectionID))
+	return b
+}
+
+func (v *VehicleDescriptor) marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, v.ID)
+	b = appendOptionalString(b, 2, v.Label)
+	return b
+}
+
+func (p *Position) marshal() []byte {
+	var b []byte
+	b = appendFloat(b, 1, p.Latitude)
+	b = appendFloat(b, 2, p.Longitude)
+	b = appendFloat(b, 3, p.Bearing)
+	b = appendFloat(b, 5, p.Speed)
+	return b
+}
+
+func (v *VehiclePosition) marshal() []byte {
+	var b []byte
+	if v.Trip != nil {
+		b = appendMessage(b, 1, v.Trip.marshal())
+	}
+	if v.Position != nil {
+		b = appendMessage(b, 2, v.Position.marshal())
+	}
+	if v.CurrentStopSequence > 0 {
+		b = appendVarint(b, 3, uint64(v.CurrentStopSequence))
+	}
+	b = appendVarint(b, 4, uint64(v.CurrentStatus))
+	b = appendVarint(b, 5, v.Timestamp)
+	b = appendOptionalString(b, 7, v.StopID)
+	if v.Vehicle != nil {
+		b = appendMessage(b, 8, v.Vehicle.marshal())
+	}
+	b = appendVarint(b, 9, uint64(v.OccupancyStatus))
+	b = appendVarint(b, 10, uint64(v.OccupancyPercentage))
+	return b
+}
+
+func (e *StopTimeEvent) marshal() []byte {
+	var b []byte
+	b = appendVarint(b, 1, uint64(int64(e.Delay))) // int32: negativos con extensión de signo
+	b = appendVarint(b, 2, uint64(e.Time))
+	return b
+}
+
+func (u *StopTimeUpdate) marshal() []byte {
+	var b []byte
+	b = appendVarint(b, 1, uint64(u.StopSequence))
+	if u.Arrival != nil {
+		b = appendMessage(b, 2, u.Arrival.marshal())
+	}
+	if u.Departure != nil {
+		b = appendMessage(b, 3, u.Departure.marshal())
+	}
+	b = appendOptionalString(b, 4, u.StopID)
+	b = appendVarint(b, 5, uint64(u.ScheduleRelationship))
+	return b
+}
+
+func (t *TripUpdate) marshal() []byte {
+	var b []byte
+	b = appendMessage(b, 1, t.Trip.marshal())
+	for i := range t.StopTimeUpdates {
+		b = appendMessage(b, 2, t.StopTimeUpdates[i].marshal())
+	}
+	if t.Vehicle != nil {
+		b = appendMessage(b, 3, t.Vehicle.marshal())
+	}
+	b = appendVarint(b, 4, t.Timestamp)
+	b = appendVarint(b, 5, uint64(int64(t.Delay)))
+	return b
+}
+
+func (r *TimeRange) marshal() []byte {
+	var b []byte
+	if r.Start > 0 {
+		b = appendVarint(b, 1, r.Start)
+	}
+	if r.End > 0 {
+		b = appendVarint(b, 2, r.End)
+	}
+	return b
+}
+
+func (s *EntitySelector) marshal() []byte {
+	var b []byte
+	b = appendOptionalString(b, 2, s.RouteID)
+	if s.Trip != nil {
+		b = appendMessage(b, 4, s.Trip.marshal())
+	}
+	b = appendOptionalString(b, 5, s.StopID)
+	return b
+}
+
+func (a *Alert) marshal() []byte {
+	var b []byte
+	for i := range a.ActivePeriods {
+		b = appendMessage(b, 1, a.ActivePeriods[i].marshal())
+	}
+	for i := range a.InformedEntities {
+		b = appendMessage(b, 5, a.InformedEntities[i].marshal())
+	}
+	b = appendVarint(b, 6, uint64(a.Cause))
+	b = appendVarint(b, 7, uint64(a.Effect))
+	b = appendMessage(b, 10, translatedString(a.HeaderText))
+	if a.DescriptionText != "" {
+		b = appendMessage(b, 11, translatedString(a.DescriptionText))
+	}
+	return b
+}
+
+// translatedString codifica un TranslatedString con una sola traducción en español
+func translatedString(text string) []byte {
+	var translation []byte
+	translation = appendString(translation, 1, text)
+	translation = appendString(translation, 2, "es")
+
+	return appendMessage(nil, 1, translation)
+}
+
+func appendVarint(b []byte, field protowire.Number, value uint64) []byte {
+	b = protowire.AppendTag(b, field, protowire.VarintType)
+	return protowire.AppendVarint(b, value)
+}
+
+func appendFloat(b []byte, field protowire.Number, value float32) []byte {
+	b = protowire.AppendTag(b, field, protowire.Fixed32Type)
+	return protowire.AppendFixed32(b, math.Float32bits(value))
+}
+
+func appendString(b []byte, field protowire.Number, value string) []byte {
+	b = protowire.AppendTag(b, field, protowire.BytesType)
+	return protowire.AppendString(b, value)
+}
+
+func appendOptionalString(b []byte, field protowire.Number, value string) []byte {
+	if value == "" {
+		return b
+	}
+	return appendString(b, field, value)
+}
+
+func appendMessage(b []byte, field protowire.Number, message []byte) []byte {
+	b = protowire.AppendTag(b, field, protowire.BytesType)
+	return protowire.AppendBytes(b, message)
+}
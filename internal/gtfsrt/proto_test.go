@@ -0,0 +1,216 @@
+package gtfsrt
+
+import (
+	"math"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// wireField campo protobuf decodificado (solo los tipos que usa el feed)
+type wireField struct {
+	typ     protowire.Type
+	varint  uint64
+	fixed32 uint32
+	bytes   []byte
+}
+
+// decodeMessage separa un mensaje en sus campos, fallando si no es protobuf válido
+func decodeMessage(t *testing.T, b []byte) map[protowire.Number][]wireField {
+	t.Helper()
+
+	fields := make(map[protowire.Number][]wireField)
+	for len(b) > 0 {
+		number, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("tag inválido: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		field := wireField{typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			field.varint, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed32Type:
+			field.fixed32, n = protowire.ConsumeFixed32(b)
+		case protowire.BytesType:
+			field.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			t.Fatalf("campo %d: tipo %v inesperado", number, typ)
+		}
+		if n < 0 {
+			t.Fatalf("campo %d: %v", number, protowire.ParseError(n))
+		}
+		b = b[n:]
+		fields[number] = append(fields[number], field)
+	}
+	return fields
+}
+
+// lookup sigue una ruta de números de campo por los mensajes anidados (la
+// primera aparición en cada nivel); ok = false si algún campo falta
+func lookup(t *testing.T, b []byte, path []protowire.Number) (wireField, bool) {
+	t.Helper()
+
+	var field wireField
+	for i, number := range path {
+		found := decodeMessage(t, b)[number]
+		if len(found) == 0 {
+			return wireField{}, false
+		}
+		field = found[0]
+		if i < len(path)-1 {
+			if field.typ != protowire.BytesType {
+				t.Fatalf("campo %d no es un mensaje", number)
+			}
+			b = field.bytes
+		}
+	}
+	return field, true
+}
+
+func testFeed() *FeedMessage {
+	return &FeedMessage{
+		Header: FeedHeader{Version: "2.0", Incrementality: incrementalityFullDataset, Timestamp: 1700000000},
+		Entities: []FeedEntity{
+			{
+				ID: "BUS-0001-trip",
+				TripUpdate: &TripUpdate{
+					Trip: TripDescriptor{TripID: "T1", RouteID: "R10", DirectionID: 1, ScheduleRelationship: TripScheduled},
+					StopTimeUpdates: []StopTimeUpdate{
+						{StopSequence: 3, StopID: "S3", Arrival: &StopTimeEvent{Delay: -30, Time: 1700000100}},
+					},
+					Timestamp: 1700000000,
+					Delay:     -30,
+				},
+			},
+			{
+				ID: "BUS-0001-vehicle",
+				Vehicle: &VehiclePosition{
+					Trip:          &TripDescriptor{RouteID: "R10", ScheduleRelationship: TripUnscheduled},
+					Vehicle:       &VehicleDescriptor{ID: "BUS-0001"},
+					Position:      &Position{Latitude: 16.75, Longitude: -93.125, Speed: 8.5},
+					CurrentStatus: StatusStoppedAt,
+					Timestamp:     1700000000,
+				},
+			},
+			{
+				ID: "BUS-0001-alert",
+				Alert: &Alert{
+					ActivePeriods: []TimeRange{{Start: 1700000000}},
+					Cause:         CauseTechnicalProblem,
+					Effect:        EffectUnknownEffect,
+					HeaderText:    "Unidad llena",
+				},
+			},
+		},
+	}
+}
+
+func TestFeedMessageMarshal(t *testing.T) {
+	encoded := testFeed().Marshal()
+
+	tests := []struct {
+		name string
+		path []protowire.Number
+		want interface{} // string, uint64, int64 (con signo), float32 o nil (ausente)
+	}{
+		{"versión", []protowire.Number{1, 1}, "2.0"},
+		{"incrementality", []protowire.Number{1, 2}, uint64(incrementalityFullDataset)},
+		{"timestamp del encabezado", []protowire.Number{1, 3}, uint64(1700000000)},
+		{"id de entidad", []protowire.Number{2, 1}, "BUS-0001-trip"},
+		{"trip_id", []protowire.Number{2, 3, 1, 1}, "T1"},
+		{"route_id", []protowire.Number{2, 3, 1, 5}, "R10"},
+		{"direction_id", []protowire.Number{2, 3, 1, 6}, uint64(1)},
+		{"stop_sequence", []protowire.Number{2, 3, 2, 1}, uint64(3)},
+		{"stop_id", []protowire.Number{2, 3, 2, 4}, "S3"},
+		{"delay negativo de llegada", []protowire.Number{2, 3, 2, 2, 1}, int64(-30)},
+		{"delay negativo del viaje", []protowire.Number{2, 3, 5}, int64(-30)},
+		{"sin departure", []protowire.Number{2, 3, 2, 3}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field, ok := lookup(t, encoded, tt.path)
+			if tt.want == nil {
+				if ok {
+					t.Fatalf("se esperaba el campo ausente, llegó %+v", field)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("falta el campo %v", tt.path)
+			}
+
+			var got interface{}
+			switch tt.want.(type) {
+			case string:
+				got = string(field.bytes)
+			case uint64:
+				got = field.varint
+			case int64:
+				got = int64(field.varint)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeedMessageMarshalEntities(t *testing.T) {
+	feed := testFeed()
+	entities := decodeMessage(t, feed.Marshal())[2]
+	if len(entities) != len(feed.Entities) {
+		t.Fatalf("got %d entidades, want %d", len(entities), len(feed.Entities))
+	}
+
+	tests := []struct {
+		name   string
+		entity int
+		path   []protowire.Number
+		want   interface{}
+	}{
+		{"posición: latitud", 1, []protowire.Number{4, 2, 1}, float32(16.75)},
+		{"posición: longitud", 1, []protowire.Number{4, 2, 2}, float32(-93.125)},
+		{"posición: velocidad", 1, []protowire.Number{4, 2, 5}, float32(8.5)},
+		{"vehículo sin trip_id", 1, []protowire.Number{4, 1, 1}, nil},
+		{"schedule_relationship", 1, []protowire.Number{4, 1, 4}, uint64(TripUnscheduled)},
+		{"current_status", 1, []protowire.Number{4, 4}, uint64(StatusStoppedAt)},
+		{"id del vehículo", 1, []protowire.Number{4, 8, 1}, "BUS-0001"},
+		{"inicio de la alerta", 2, []protowire.Number{5, 1, 1}, uint64(1700000000)},
+		{"alerta sin fin", 2, []protowire.Number{5, 1, 2}, nil},
+		{"causa", 2, []protowire.Number{5, 6}, uint64(CauseTechnicalProblem)},
+		{"texto del encabezado", 2, []protowire.Number{5, 10, 1, 1}, "Unidad llena"},
+		{"idioma del encabezado", 2, []protowire.Number{5, 10, 1, 2}, "es"},
+		{"sin descripción", 2, []protowire.Number{5, 11}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field, ok := lookup(t, entities[tt.entity].bytes, tt.path)
+			if tt.want == nil {
+				if ok {
+					t.Fatalf("se esperaba el campo ausente, llegó %+v", field)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("falta el campo %v", tt.path)
+			}
+
+			var got interface{}
+			switch tt.want.(type) {
+			case string:
+				got = string(field.bytes)
+			case uint64:
+				got = field.varint
+			case float32:
+				got = math.Float32frombits(field.fixed32)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package gtfsrt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/config"
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+	"github.com/MarcosBrindi/transporte-simulator/internal/scenario"
+)
+
+// Rutas HTTP de los feeds
+const (
+	PathVehiclePositions = "/gtfs-rt/vehicle-positions"
+	PathTripUpdates      = "/gtfs-rt/trip-updates"
+	PathAlerts           = "/gtfs-rt/alerts"
+)
+
+// Server produce los feeds GTFS-Realtime de uno o varios vehículos y los
+// sirve por HTTP (protobuf, o JSON con ?format=json)
+type Server struct {
+	config config.GTFSRTConfig
+
+	mu       sync.RWMutex
+	vehicles map[string]*vehicleTracker
+	server   *http.Server
+}
+
+// NewServer crea un servidor GTFS-Realtime
+func NewServer(cfg config.GTFSRTConfig) *Server {
+	return &Server{
+		config:   cfg,
+		vehicles: make(map[string]*vehicleTracker),
+	}
+}
+
+// Track agrega un vehículo al feed suscribiéndose a los eventos de su bus
+func (s *Server) Track(deviceID string, bus *eventbus.EventBus, route *scenario.Route) {
+	tracker := newVehicleTracker(deviceID, bus, route)
+
+	s.mu.Lock()
+	s.vehicles[deviceID] = tracker
+	s.mu.Unlock()
+}
+
+// Untrack quita un vehículo del feed
+func (s *Server) Untrack(deviceID string) {
+	s.mu.Lock()
+	delete(s.vehicles, deviceID)
+	s.mu.Unlock()
+}
+
+// Start inicia el servidor HTTP
+func (s *Server) Start() error {
+	if !s.config.Enabled {
+		fmt.Println("ℹ️  [GTFS-RT] Deshabilitado en configuración")
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", s.config.Address)
+	if err != nil {
+		return fmt.Errorf("error escuchando en %s: %w", s.config.Address, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(PathVehiclePositions, s.handleFeed(s.vehiclePositions))
+	mux.HandleFunc(PathTripUpdates, s.handleFeed(s.tripUpdates))
+	mux.HandleFunc(PathAlerts, s.handleFeed(s.alerts))
+
+	s.mu.Lock()
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	server := s.server
+	s.mu.Unlock()
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("❌ [GTFS-RT] Error del servidor: %v\n", err)
+		}
+	}()
+
+	fmt.Printf("✅ [GTFS-RT] Feeds en http://%s%s, %s, %s\n",
+		listener.Addr(), PathVehiclePositions, PathTripUpdates, PathAlerts)
+	return nil
+}
+
+// Stop detiene el servidor HTTP
+func (s *Server) Stop() {
+	s.mu.Lock()
+	server := s.server
+	s.server = nil
+	s.mu.Unlock()
+
+	if server == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	server.Shutdown(ctx)
+
+	fmt.Println("🛑 [GTFS-RT] Servidor detenido")
+}
+
+// handleFeed responde un feed en protobuf o JSON, opcionalmente filtrado por vehículo
+func (s *Server) handleFeed(build func(trackers []*vehicleTracker) []FeedEntity) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		feed := newFeed(build(s.trackers(r.URL.Query().Get("vehicle"))))
+
+		if r.URL.Query().Get("format") == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(feed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.Write(feed.Marshal())
+	}
+}
+
+// trackers retorna los vehículos del feed ordenados por ID ("" = todos)
+func (s *Server) trackers(deviceID string) []*vehicleTracker {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	trackers := make([]*vehicleTracker, 0, len(s.vehicles))
+	for id, tracker := range s.vehicles {
+		if deviceID == "" || id == deviceID {
+			trackers = append(trackers, tracker)
+		}
+	}
+
+	sort.Slice(trackers, func(i, j int) bool { return trackers[i].deviceID < trackers[j].deviceID })
+	return trackers
+}
+
+// newFeed arma un feed completo (FULL_DATASET) con las entidades actuales
+func newFeed(entities []FeedEntity) *FeedMessage {
+	return &FeedMessage{
+		Header: FeedHeader{
+			Version:        gtfsRealtimeVersion,
+			Incrementality: incrementalityFullDataset,
+			Timestamp:      uint64(time.Now().Unix()),
+		},
+		Entities: entities,
+	}
+}
+
+func (s *Server) vehiclePositions(trackers []*vehicleTracker) []FeedEntity {
+	entities := make([]FeedEntity, 0, len(trackers))
+	for _, tracker := range trackers {
+		if position := tracker.vehiclePosition(); position != nil {
+			entities = append(entities, FeedEntity{ID: "vehicle-" + tracker.deviceID, Vehicle: position})
+		}
+	}
+	return entities
+}
+
+func (s *Server) tripUpdates(trackers []*vehicleTracker) []FeedEntity {
+	entities := make([]FeedEntity, 0, len(trackers))
+	for _, tracker := range trackers {
+		if update := tracker.tripUpdate(); update != nil {
+			entities = append(entities, FeedEntity{ID: "trip-" + tracker.deviceID, TripUpdate: update})
+		}
+	}
+	return entities
+}
+
+func (s *Server) alerts(trackers []*vehicleTracker) []FeedEntity {
+	entities := make([]FeedEntity, 0)
+	for _, tracker := range trackers {
+		alerts := tracker.activeAlerts()
+
+		keys := make([]string, 0, len(alerts))
+		for key := range alerts {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			alert := alerts[key]
+			entities = append(entities, FeedEntity{ID: "alert-" + tracker.deviceID + "-" + key, Alert: &alert})
+		}
+	}
+	return entities
+}
@@ -0,0 +1,334 @@
+package gtfsrt
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+	"github.com/MarcosBrindi/transporte-simulator/internal/scenario"
+)
+
+// fullThreshold factor de carga a partir del cual se publica la alerta de unidad llena
+const fullThreshold = 1.0
+
+// vehicleTracker acumula el estado de un vehículo a partir de sus eventos
+type vehicleTracker struct {
+	deviceID string
+	route    *scenario.Route
+
+	mu         sync.RWMutex
+	gps        eventbus.GPSData
+	gpsAt      time.Time
+	hasGPS     bool
+	vehicle    eventbus.VehicleStateData
+	trip       eventbus.TripEventData
+	hasTrip    bool
+	stopSeq    map[int]uint32          // ID de parada → stop_sequence en el viaje actual
+	atStop     int                     // Parada donde está detenido (0 = en tránsito)
+	updates    map[int]*StopTimeUpdate // Paradas ya visitadas en el viaje actual
+	delay      int32                   // Última desviación observada (s)
+	alerts     map[string]*Alert       // Alertas activas por clave
+	alertStart map[string]time.Time
+}
+
+// newVehicleTracker crea el acumulador y se suscribe a los eventos del vehículo
+func newVehicleTracker(deviceID string, bus *eventbus.EventBus, route *scenario.Route) *vehicleTracker {
+	vt := &vehicleTracker{
+		deviceID:   deviceID,
+		route:      route,
+		stopSeq:    make(map[int]uint32),
+		updates:    make(map[int]*StopTimeUpdate),
+		alerts:     make(map[string]*Alert),
+		alertStart: make(map[string]time.Time),
+	}
+
+	vt.listen(bus, eventbus.EventGPS)
+	vt.listen(bus, eventbus.EventVehicle)
+	vt.listen(bus, eventbus.EventTrip)
+	vt.listen(bus, eventbus.EventStop)
+	vt.listen(bus, eventbus.EventOccupancy)
+	vt.listen(bus, eventbus.EventFault)
+
+	return vt
+}
+
+// listen procesa en una goroutine los eventos de un tipo
+func (vt *vehicleTracker) listen(bus *eventbus.EventBus, eventType eventbus.EventType) {
+	channel := bus.Subscribe(eventType)
+	go func() {
+		for event := range channel {
+			vt.handle(event)
+		}
+	}()
+}
+
+// handle actualiza el estado según el evento
+func (vt *vehicleTracker) handle(event eventbus.Event) {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+
+	switch data := event.Data.(type) {
+	case eventbus.GPSData:
+		vt.gps = data
+		vt.gpsAt = event.Timestamp
+		vt.hasGPS = true
+
+	case eventbus.VehicleStateData:
+		vt.vehicle = data
+
+	case eventbus.TripEventData:
+		vt.handleTrip(data)
+
+	case eventbus.StopEventData:
+		vt.handleStop(data)
+
+	case eventbus.OccupancyEventData:
+		vt.handleOccupancy(data)
+
+	case eventbus.FaultEventData:
+		vt.handleFault(data)
+	}
+}
+
+// handleTrip reinicia el avance del viaje al iniciar uno nuevo (llamar con mu tomado)
+func (vt *vehicleTracker) handleTrip(data eventbus.TripEventData) {
+	if data.EventType != eventbus.TripStarted {
+		if vt.hasTrip && vt.trip.TripID == data.TripID {
+			vt.hasTrip = false
+		}
+		return
+	}
+
+	vt.trip = data
+	vt.hasTrip = true
+	vt.atStop = 0
+	vt.delay = 0
+	vt.updates = make(map[int]*StopTimeUpdate)
+	vt.stopSeq = make(map[int]uint32, len(data.StopSequence))
+	for i, stopID := range data.StopSequence {
+		vt.stopSeq[stopID] = uint32(i + 1)
+	}
+}
+
+// handleStop registra llegadas, salidas y paradas omitidas (llamar con mu tomado)
+func (vt *vehicleTracker) handleStop(data eventbus.StopEventData) {
+	if data.StopID == 0 {
+		return // Parada no programada: no forma parte del viaje
+	}
+
+	update, ok := vt.updates[data.StopID]
+	if !ok {
+		update = &StopTimeUpdate{
+			StopSequence: vt.stopSeq[data.StopID],
+			StopID:       vt.stopID(data.StopID),
+		}
+		vt.updates[data.StopID] = update
+	}
+
+	switch data.EventType {
+	case eventbus.StopArrived:
+		vt.atStop = data.StopID
+		update.Arrival = &StopTimeEvent{Time: data.ArrivedAt.Unix()}
+		if data.HasSchedule && vt.scheduled() {
+			vt.delay = int32(math.Round(data.DeviationSec))
+			update.Arrival.Delay = vt.delay
+		}
+
+	case eventbus.StopDeparted:
+		vt.atStop = 0
+		update.Departure = &StopTimeEvent{Time: data.DepartedAt.Unix(), Delay: vt.delay}
+
+	case eventbus.StopSkipped:
+		update.ScheduleRelationship = StopSkipped
+	}
+}
+
+// handleOccupancy activa la alerta de unidad llena (llamar con mu tomado)
+func (vt *vehicleTracker) handleOccupancy(data eventbus.OccupancyEventData) {
+	if data.Threshold < fullThreshold {
+		return
+	}
+
+	switch data.Alarm {
+	case eventbus.OccupancyThresholdUp:
+		vt.setAlert("capacity", &Alert{
+			Cause:           CauseOtherCause,
+			Effect:          EffectReducedService,
+			HeaderText:      "Unidad llena",
+			DescriptionText: fmt.Sprintf("La unidad %s va a su capacidad máxima (%d pasajeros)", vt.deviceID, data.CurrentCount),
+		}, data.Timestamp)
+	case eventbus.OccupancyThresholdDown:
+		vt.clearAlert("capacity")
+	}
+}
+
+// handleFault publica una alerta técnica mientras dura la falla de un sensor (llamar con mu tomado)
+func (vt *vehicleTracker) handleFault(data eventbus.FaultEventData) {
+	key := "fault-" + data.Sensor + "-" + data.FaultType
+
+	switch data.Status {
+	case eventbus.FaultStarted, eventbus.FaultInjected:
+		vt.setAlert(key, &Alert{
+			Cause:           CauseTechnicalProblem,
+			Effect:          EffectUnknownEffect,
+			HeaderText:      "Información de la unidad no disponible",
+			DescriptionText: fmt.Sprintf("Falla del sensor %s (%s) en la unidad %s", data.Sensor, data.FaultType, vt.deviceID),
+		}, data.Timestamp)
+	case eventbus.FaultEnded:
+		vt.clearAlert(key)
+	case eventbus.FaultCleared:
+		for existing := range vt.alerts {
+			if strings.HasPrefix(existing, "fault-") {
+				vt.clearAlert(existing)
+			}
+		}
+	}
+}
+
+// setAlert activa una alerta (llamar con mu tomado)
+func (vt *vehicleTracker) setAlert(key string, alert *Alert, at time.Time) {
+	if _, active := vt.alerts[key]; !active {
+		vt.alertStart[key] = at
+	}
+	vt.alerts[key] = alert
+}
+
+// clearAlert desactiva una alerta (llamar con mu tomado)
+func (vt *vehicleTracker) clearAlert(key string) {
+	delete(vt.alerts, key)
+	delete(vt.alertStart, key)
+}
+
+// stopID retorna el stop_id publicado de una parada de la ruta
+func (vt *vehicleTracker) stopID(id int) string {
+	for _, stop := range vt.route.Stops {
+		if stop.ID == id && stop.ExternalID != "" {
+			return stop.ExternalID
+		}
+	}
+	return strconv.Itoa(id)
+}
+
+// routeID retorna el route_id publicado: el de GTFS si la ruta se importó de
+// un feed o, si no, el nombre de la ruta simulada
+func (vt *vehicleTracker) routeID() string {
+	if vt.route.RouteID != "" {
+		return vt.route.RouteID
+	}
+	return vt.route.Name
+}
+
+// scheduled retorna true si el viaje en curso es el del feed GTFS. El
+// regreso va en el sentido opuesto y no tiene viaje en el feed: se publica
+// como no programado, sin desviaciones de horario (llamar con mu tomado)
+func (vt *vehicleTracker) scheduled() bool {
+	return vt.route.Scheduled && vt.route.TripID != "" && vt.trip.Direction != scenario.DirectionInbound
+}
+
+// tripDescriptor describe el viaje en curso (llamar con mu tomado)
+func (vt *vehicleTracker) tripDescriptor() *TripDescriptor {
+	if !vt.hasTrip {
+		return nil
+	}
+
+	descriptor := &TripDescriptor{
+		TripID:               vt.trip.TripID,
+		RouteID:              vt.routeID(),
+		StartTime:            vt.trip.StartTime.Format("15:04:05"),
+		StartDate:            vt.trip.StartTime.Format("20060102"),
+		ScheduleRelationship: TripUnscheduled,
+		DirectionID:          uint32(vt.route.Direction),
+	}
+
+	if vt.trip.Direction == scenario.DirectionInbound {
+		descriptor.DirectionID = uint32(1 - vt.route.Direction)
+	}
+
+	// Ida de un viaje importado de GTFS estático: se identifica con el trip_id del feed
+	if vt.scheduled() {
+		descriptor.TripID = vt.route.TripID
+		descriptor.ScheduleRelationship = TripScheduled
+	}
+
+	return descriptor
+}
+
+// vehiclePosition construye la entidad VehiclePosition
+func (vt *vehicleTracker) vehiclePosition() *VehiclePosition {
+	vt.mu.RLock()
+	defer vt.mu.RUnlock()
+
+	if !vt.hasGPS {
+		return nil
+	}
+
+	position := &VehiclePosition{
+		Trip:                vt.tripDescriptor(),
+		Vehicle:             &VehicleDescriptor{ID: vt.deviceID, Label: vt.deviceID},
+		Position:            &Position{Latitude: float32(vt.gps.Latitude), Longitude: float32(vt.gps.Longitude), Bearing: float32(vt.gps.Course), Speed: float32(vt.gps.Speed / 3.6)},
+		CurrentStatus:       StatusInTransitTo,
+		Timestamp:           uint64(vt.gpsAt.Unix()),
+		OccupancyStatus:     occupancyStatus[vt.vehicle.CrowdingLevel],
+		OccupancyPercentage: uint32(math.Round(vt.vehicle.LoadFactor * 100)),
+	}
+
+	stopID := vt.atStop
+	if stopID != 0 {
+		position.CurrentStatus = StatusStoppedAt
+	} else if next := vt.route.GetNextStopInDirection(vt.gps.Progress, vt.gps.Direction); next != nil {
+		stopID = next.ID
+	}
+	if stopID != 0 {
+		position.StopID = vt.stopID(stopID)
+		position.CurrentStopSequence = vt.stopSeq[stopID]
+	}
+
+	return position
+}
+
+// tripUpdate construye la entidad TripUpdate del viaje en curso
+func (vt *vehicleTracker) tripUpdate() *TripUpdate {
+	vt.mu.RLock()
+	defer vt.mu.RUnlock()
+
+	if !vt.hasTrip {
+		return nil
+	}
+
+	updates := make([]StopTimeUpdate, 0, len(vt.updates))
+	for _, update := range vt.updates {
+		updates = append(updates, *update)
+	}
+	sort.Slice(updates, func(i, j int) bool { return updates[i].StopSequence < updates[j].StopSequence })
+
+	return &TripUpdate{
+		Trip:            *vt.tripDescriptor(),
+		Vehicle:         &VehicleDescriptor{ID: vt.deviceID, Label: vt.deviceID},
+		StopTimeUpdates: updates,
+		Timestamp:       uint64(vt.gpsAt.Unix()),
+		Delay:           vt.delay,
+	}
+}
+
+// activeAlerts retorna las alertas activas por clave
+func (vt *vehicleTracker) activeAlerts() map[string]Alert {
+	vt.mu.RLock()
+	defer vt.mu.RUnlock()
+
+	alerts := make(map[string]Alert, len(vt.alerts))
+	for key, alert := range vt.alerts {
+		copied := *alert
+		copied.ActivePeriods = []TimeRange{{Start: uint64(vt.alertStart[key].Unix())}}
+		copied.InformedEntities = []EntitySelector{{RouteID: vt.routeID()}}
+		if trip := vt.tripDescriptor(); trip != nil {
+			copied.InformedEntities = append(copied.InformedEntities, EntitySelector{Trip: trip})
+		}
+		alerts[key] = copied
+	}
+	return alerts
+}
@@ -11,6 +11,25 @@ import (
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 )
 
+// TripProvider entrega el viaje en curso del vehículo
+type TripProvider interface {
+	CurrentTripID() string
+}
+
+// stampTripID agrega el trip_id del viaje en curso a un payload
+func stampTripID(payload interface{}, trips TripProvider) {
+	fields, ok := payload.(map[string]interface{})
+	if !ok || trips == nil {
+		return
+	}
+	if _, exists := fields["trip_id"]; exists {
+		return
+	}
+	if tripID := trips.CurrentTripID(); tripID != "" {
+		fields["trip_id"] = tripID
+	}
+}
+
 // Publisher publica eventos a MQTT
 type Publisher struct {
 	config   config.MQTTConfig
@@ -37,6 +56,11 @@ type Publisher struct {
 	doorEvents      chan eventbus.Event
 	vehicleEvents   chan eventbus.Event
 	passengerEvents chan eventbus.Event
+	dwellEvents     chan eventbus.Event
+	stopEvents      chan eventbus.Event
+	scenarioEvents  chan eventbus.Event
+
+	trips TripProvider // Viaje en curso para etiquetar los payloads (opcional)
 }
 
 // NewPublisher crea un nuevo publicador MQTT
@@ -52,6 +76,9 @@ func NewPublisher(cfg config.MQTTConfig, deviceID string, bus *eventbus.EventBus
 		doorEvents:      make(chan eventbus.Event, 10),
 		vehicleEvents:   make(chan eventbus.Event, 10),
 		passengerEvents: make(chan eventbus.Event, 10),
+		dwellEvents:     make(chan eventbus.Event, 10),
+		stopEvents:      make(chan eventbus.Event, 10),
+		scenarioEvents:  make(chan eventbus.Event, 10),
 	}
 }
 
@@ -207,6 +234,49 @@ func (p *Publisher) subscribeToEvents() {
 			}
 		}
 	}()
+
+	// Puerta (transiciones) y resúmenes de parada
+	for _, eventType := range []eventbus.EventType{eventbus.EventDoorState, eventbus.EventDwell} {
+		dwellChannel := p.bus.Subscribe(eventType)
+		go func() {
+			for event := range dwellChannel {
+				if p.isRunning() {
+					select {
+					case p.dwellEvents <- event:
+					default:
+					}
+				}
+			}
+		}()
+	}
+
+	// Llegadas y salidas de paradas, inicio y fin de viajes
+	for _, eventType := range []eventbus.EventType{eventbus.EventStop, eventbus.EventTrip} {
+		stopChannel := p.bus.Subscribe(eventType)
+		go func() {
+			for event := range stopChannel {
+				if p.isRunning() {
+					select {
+					case p.stopEvents <- event:
+					default:
+					}
+				}
+			}
+		}()
+	}
+
+	// Inicio, pasos y fin del escenario
+	scenarioChannel := p.bus.Subscribe(eventbus.EventScenario)
+	go func() {
+		for event := range scenarioChannel {
+			if p.isRunning() {
+				select {
+				case p.scenarioEvents <- event:
+				default:
+				}
+			}
+		}
+	}()
 }
 
 // publishLoop publica periódicamente
@@ -231,6 +301,15 @@ func (p *Publisher) publishLoop() {
 		case passengerEvent := <-p.passengerEvents:
 			p.handlePassenger(passengerEvent)
 
+		case dwellEvent := <-p.dwellEvents:
+			p.handleDwell(dwellEvent)
+
+		case stopEvent := <-p.stopEvents:
+			p.handleStop(stopEvent)
+
+		case scenarioEvent := <-p.scenarioEvents:
+			p.handleScenario(scenarioEvent)
+
 		case <-ticker.C:
 			// Publicar estado híbrido cada intervalo
 			if p.config.PublishHybrid {
@@ -351,6 +430,205 @@ func (p *Publisher) publishPassenger(data eventbus.PassengerEventData) {
 		payload["sensor_distance_mm"] = *data.SensorDistanceMM
 	}
 
+	// Parada donde ocurrió el evento
+	if data.DwellSessionID != "" {
+		payload["dwell_session_id"] = data.DwellSessionID
+		payload["latitude"] = data.Latitude
+		payload["longitude"] = data.Longitude
+	}
+	if data.StopID != 0 {
+		payload["stop_id"] = data.StopID
+		payload["stop_name"] = data.StopName
+	}
+
+	p.publish(topic, payload)
+}
+
+// handleDwell procesa transiciones de puerta y resúmenes de parada
+func (p *Publisher) handleDwell(event eventbus.Event) {
+	if !p.config.PublishDwell {
+		return
+	}
+
+	switch data := event.Data.(type) {
+	case eventbus.DoorStateEventData:
+		p.publishDoorState(data)
+	case eventbus.DwellSummaryData:
+		p.publishDwellSummary(data)
+	}
+}
+
+// handleStop publica llegadas, salidas y paradas omitidas; los eventos de
+// viaje llegan por el mismo canal y van a su propio topic
+func (p *Publisher) handleStop(event eventbus.Event) {
+	if trip, ok := event.Data.(eventbus.TripEventData); ok {
+		if p.config.PublishTrips {
+			p.publishTrip(trip)
+		}
+		return
+	}
+
+	if !p.config.PublishStops {
+		return
+	}
+
+	data := event.Data.(eventbus.StopEventData)
+	topic := p.config.GetTopic(p.config.Topics.Stops, p.deviceID)
+
+	payload := map[string]interface{}{
+		"device_id":   p.deviceID,
+		"timestamp":   data.Timestamp.UTC().Format(time.RFC3339),
+		"event_type":  data.EventType,
+		"latitude":    data.Latitude,
+		"longitude":   data.Longitude,
+		"door_opened": data.DoorOpened,
+	}
+
+	if data.StopID != 0 {
+		payload["stop_id"] = data.StopID
+		payload["stop_name"] = data.StopName
+	}
+	if !data.ArrivedAt.IsZero() {
+		payload["arrived_at"] = data.ArrivedAt.UTC().Format(time.RFC3339)
+	}
+	if data.EventType == eventbus.StopDeparted {
+		payload["departed_at"] = data.DepartedAt.UTC().Format(time.RFC3339)
+		payload["dwell_sec"] = data.DwellSec
+	}
+	if data.HasSchedule {
+		payload["scheduled_arrival"] = data.ScheduledArrival.UTC().Format(time.RFC3339)
+		payload["schedule_deviation_sec"] = data.DeviationSec
+	}
+
+	p.publish(topic, payload)
+}
+
+// publishTrip publica el inicio o fin de un viaje
+func (p *Publisher) publishTrip(data eventbus.TripEventData) {
+	topic := p.config.GetTopic(p.config.Topics.Trips, p.deviceID)
+
+	payload := map[string]interface{}{
+		"device_id":     p.deviceID,
+		"timestamp":     data.Timestamp.UTC().Format(time.RFC3339),
+		"event_type":    data.EventType,
+		"trip_id":       data.TripID,
+		"route":         data.RouteName,
+		"direction":     data.Direction,
+		"start_time":    data.StartTime.UTC().Format(time.RFC3339),
+		"stop_sequence": data.StopSequence,
+	}
+
+	if !data.EndTime.IsZero() {
+		payload["end_time"] = data.EndTime.UTC().Format(time.RFC3339)
+	}
+
+	p.publish(topic, payload)
+}
+
+// handleScenario publica el avance del escenario en curso
+func (p *Publisher) handleScenario(event eventbus.Event) {
+	if !p.config.PublishScenario {
+		return
+	}
+
+	data := event.Data.(eventbus.ScenarioEventData)
+	topic := p.config.GetTopic(p.config.Topics.Scenario, p.deviceID)
+
+	payload := map[string]interface{}{
+		"device_id":   p.deviceID,
+		"timestamp":   data.Timestamp.UTC().Format(time.RFC3339),
+		"event_type":  data.EventType,
+		"scenario":    data.Name,
+		"total_steps": data.TotalSteps,
+		"elapsed_sec": data.ElapsedSec,
+		"loop":        data.Loop,
+		"passed":      data.Passed,
+		"failed":      data.Failed,
+	}
+
+	if data.Step >= 0 {
+		payload["step"] = data.Step
+	}
+	if data.Action != "" {
+		payload["action"] = data.Action
+	}
+	if data.OnEnd != "" {
+		payload["on_end"] = data.OnEnd
+	}
+	if data.Next != "" {
+		payload["next"] = data.Next
+	}
+	if data.Playlist != "" {
+		payload["playlist"] = data.Playlist
+		payload["entry"] = data.Entry
+		payload["total_entries"] = data.TotalEntries
+	}
+
+	p.publish(topic, payload)
+}
+
+// publishDoorState publica una transición de la máquina de estados de puerta
+func (p *Publisher) publishDoorState(data eventbus.DoorStateEventData) {
+	topic := p.config.GetTopic(p.config.Topics.Dwell, p.deviceID)
+
+	payload := map[string]interface{}{
+		"device_id":        p.deviceID,
+		"timestamp":        data.Timestamp.UTC().Format(time.RFC3339),
+		"event_type":       "DOOR_STATE",
+		"from_state":       data.From,
+		"to_state":         data.To,
+		"dwell_session_id": data.DwellSessionID,
+	}
+
+	if data.StopID != 0 {
+		payload["stop_id"] = data.StopID
+	}
+
+	p.publish(topic, payload)
+}
+
+// publishDwellSummary publica el resumen de una sesión de parada
+func (p *Publisher) publishDwellSummary(data eventbus.DwellSummaryData) {
+	topic := p.config.GetTopic(p.config.Topics.Dwell, p.deviceID)
+
+	payload := map[string]interface{}{
+		"device_id":            p.deviceID,
+		"timestamp":            data.Timestamp.UTC().Format(time.RFC3339),
+		"event_type":           "DWELL_SUMMARY",
+		"dwell_session_id":     data.DwellSessionID,
+		"latitude":             data.Latitude,
+		"longitude":            data.Longitude,
+		"opened_at":            data.OpenedAt.UTC().Format(time.RFC3339),
+		"closed_at":            data.ClosedAt.UTC().Format(time.RFC3339),
+		"duration_sec":         data.DurationSec,
+		"timed_out":            data.TimedOut,
+		"entries":              data.Entries,
+		"exits":                data.Exits,
+		"current_count":        data.CurrentCount,
+		"max_detected_persons": data.MaxDetectedPersons,
+		"counting_strategy":    data.CountingStrategy,
+	}
+
+	if len(data.ShadowCounts) > 0 {
+		shadows := make([]map[string]interface{}, 0, len(data.ShadowCounts))
+		for _, shadow := range data.ShadowCounts {
+			shadows = append(shadows, map[string]interface{}{
+				"strategy": shadow.Strategy,
+				"entries":  shadow.Entries,
+				"exits":    shadow.Exits,
+			})
+		}
+		payload["shadow_counts"] = shadows
+	}
+
+	if !data.ConfirmedAt.IsZero() {
+		payload["confirmed_at"] = data.ConfirmedAt.UTC().Format(time.RFC3339)
+	}
+	if data.StopID != 0 {
+		payload["stop_id"] = data.StopID
+		payload["stop_name"] = data.StopName
+	}
+
 	p.publish(topic, payload)
 }
 
@@ -388,11 +666,18 @@ func (p *Publisher) publishHybrid() {
 			"is_turning":      mpu.IsTurning,
 		},
 		"vehicle": map[string]interface{}{
-			"state":       vehicle.State,
-			"is_moving":   vehicle.IsMoving,
-			"is_stopped":  vehicle.IsStopped,
-			"door_open":   vehicle.DoorOpen,
-			"has_gps_fix": vehicle.HasGPSFix,
+			"state":            vehicle.State,
+			"is_moving":        vehicle.IsMoving,
+			"is_stopped":       vehicle.IsStopped,
+			"approaching_stop": vehicle.ApproachingStop,
+			"door_open":        vehicle.DoorOpen,
+			"has_gps_fix":      vehicle.HasGPSFix,
+		},
+		"occupancy": map[string]interface{}{
+			"passenger_count": vehicle.PassengerCount,
+			"capacity":        vehicle.Capacity,
+			"load_factor":     vehicle.LoadFactor,
+			"crowding_level":  vehicle.CrowdingLevel,
 		},
 		"door": map[string]interface{}{
 			"is_open":     door.IsOpen,
@@ -422,6 +707,11 @@ func (p *Publisher) publish(topic string, payload interface{}) {
 		return
 	}
 
+	p.mu.RLock()
+	trips := p.trips
+	p.mu.RUnlock()
+	stampTripID(payload, trips)
+
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
 		fmt.Printf("⚠️  [MQTT] Error serializando JSON: %v\n", err)
@@ -436,6 +726,13 @@ func (p *Publisher) publish(topic string, payload interface{}) {
 	}
 }
 
+// SetTripProvider asigna el origen del viaje en curso para etiquetar los payloads
+func (p *Publisher) SetTripProvider(trips TripProvider) {
+	p.mu.Lock()
+	p.trips = trips
+	p.mu.Unlock()
+}
+
 // isRunning verifica si está corriendo
 func (p *Publisher) isRunning() bool {
 	p.mu.RLock()
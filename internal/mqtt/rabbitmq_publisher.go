@@ -34,6 +34,11 @@ type RabbitMQPublisher struct {
 	mpuEvents       chan eventbus.Event
 	vehicleEvents   chan eventbus.Event
 	passengerEvents chan eventbus.Event
+	dwellEvents     chan eventbus.Event
+	stopEvents      chan eventbus.Event
+	scenarioEvents  chan eventbus.Event
+
+	trips TripProvider // Viaje en curso para etiquetar los payloads (opcional)
 }
 
 // NewRabbitMQPublisher crea un nuevo publicador RabbitMQ con canal compartido
@@ -49,6 +54,9 @@ func NewRabbitMQPublisher(ch *amqp.Channel, cfg config.RabbitMQConfig, deviceID
 		mpuEvents:       make(chan eventbus.Event, 10),
 		vehicleEvents:   make(chan eventbus.Event, 10),
 		passengerEvents: make(chan eventbus.Event, 10),
+		dwellEvents:     make(chan eventbus.Event, 10),
+		stopEvents:      make(chan eventbus.Event, 10),
+		scenarioEvents:  make(chan eventbus.Event, 10),
 	}
 }
 
@@ -142,6 +150,49 @@ func (p *RabbitMQPublisher) subscribeToEvents() {
 			}
 		}
 	}()
+
+	// Puerta (transiciones) y resúmenes de parada
+	for _, eventType := range []eventbus.EventType{eventbus.EventDoorState, eventbus.EventDwell} {
+		dwellChannel := p.bus.Subscribe(eventType)
+		go func() {
+			for event := range dwellChannel {
+				if p.isRunning() {
+					select {
+					case p.dwellEvents <- event:
+					default:
+					}
+				}
+			}
+		}()
+	}
+
+	// Llegadas y salidas de paradas, inicio y fin de viajes
+	for _, eventType := range []eventbus.EventType{eventbus.EventStop, eventbus.EventTrip} {
+		stopChannel := p.bus.Subscribe(eventType)
+		go func() {
+			for event := range stopChannel {
+				if p.isRunning() {
+					select {
+					case p.stopEvents <- event:
+					default:
+					}
+				}
+			}
+		}()
+	}
+
+	// Inicio, pasos y fin del escenario
+	scenarioChannel := p.bus.Subscribe(eventbus.EventScenario)
+	go func() {
+		for event := range scenarioChannel {
+			if p.isRunning() {
+				select {
+				case p.scenarioEvents <- event:
+				default:
+				}
+			}
+		}
+	}()
 }
 
 // publishLoop publica periódicamente
@@ -163,6 +214,15 @@ func (p *RabbitMQPublisher) publishLoop() {
 		case passengerEvent := <-p.passengerEvents:
 			p.handlePassenger(passengerEvent)
 
+		case dwellEvent := <-p.dwellEvents:
+			p.handleDwell(dwellEvent)
+
+		case stopEvent := <-p.stopEvents:
+			p.handleStop(stopEvent)
+
+		case scenarioEvent := <-p.scenarioEvents:
+			p.handleScenario(scenarioEvent)
+
 		case <-ticker.C:
 			// Publicar estado híbrido cada intervalo
 			if p.config.PublishHybrid {
@@ -232,6 +292,205 @@ func (p *RabbitMQPublisher) publishPassenger(data eventbus.PassengerEventData) {
 		payload["sensor_distance_mm"] = *data.SensorDistanceMM
 	}
 
+	// Parada donde ocurrió el evento
+	if data.DwellSessionID != "" {
+		payload["dwell_session_id"] = data.DwellSessionID
+		payload["latitude"] = data.Latitude
+		payload["longitude"] = data.Longitude
+	}
+	if data.StopID != 0 {
+		payload["stop_id"] = data.StopID
+		payload["stop_name"] = data.StopName
+	}
+
+	p.publish(routingKey, payload)
+}
+
+// handleDwell procesa transiciones de puerta y resúmenes de parada
+func (p *RabbitMQPublisher) handleDwell(event eventbus.Event) {
+	if !p.config.PublishDwell {
+		return
+	}
+
+	switch data := event.Data.(type) {
+	case eventbus.DoorStateEventData:
+		p.publishDoorState(data)
+	case eventbus.DwellSummaryData:
+		p.publishDwellSummary(data)
+	}
+}
+
+// handleStop publica llegadas, salidas y paradas omitidas; los eventos de
+// viaje llegan por el mismo canal y van a su propio topic
+func (p *RabbitMQPublisher) handleStop(event eventbus.Event) {
+	if trip, ok := event.Data.(eventbus.TripEventData); ok {
+		if p.config.PublishTrips {
+			p.publishTrip(trip)
+		}
+		return
+	}
+
+	if !p.config.PublishStops {
+		return
+	}
+
+	data := event.Data.(eventbus.StopEventData)
+	routingKey := p.config.RoutingKeys.Stops
+
+	payload := map[string]interface{}{
+		"device_id":   p.deviceID,
+		"timestamp":   data.Timestamp.Unix(),
+		"event_type":  data.EventType,
+		"latitude":    data.Latitude,
+		"longitude":   data.Longitude,
+		"door_opened": data.DoorOpened,
+	}
+
+	if data.StopID != 0 {
+		payload["stop_id"] = data.StopID
+		payload["stop_name"] = data.StopName
+	}
+	if !data.ArrivedAt.IsZero() {
+		payload["arrived_at"] = data.ArrivedAt.Unix()
+	}
+	if data.EventType == eventbus.StopDeparted {
+		payload["departed_at"] = data.DepartedAt.Unix()
+		payload["dwell_sec"] = data.DwellSec
+	}
+	if data.HasSchedule {
+		payload["scheduled_arrival"] = data.ScheduledArrival.Unix()
+		payload["schedule_deviation_sec"] = data.DeviationSec
+	}
+
+	p.publish(routingKey, payload)
+}
+
+// publishTrip publica el inicio o fin de un viaje
+func (p *RabbitMQPublisher) publishTrip(data eventbus.TripEventData) {
+	routingKey := p.config.RoutingKeys.Trips
+
+	payload := map[string]interface{}{
+		"device_id":     p.deviceID,
+		"timestamp":     data.Timestamp.Unix(),
+		"event_type":    data.EventType,
+		"trip_id":       data.TripID,
+		"route":         data.RouteName,
+		"direction":     data.Direction,
+		"start_time":    data.StartTime.Unix(),
+		"stop_sequence": data.StopSequence,
+	}
+
+	if !data.EndTime.IsZero() {
+		payload["end_time"] = data.EndTime.Unix()
+	}
+
+	p.publish(routingKey, payload)
+}
+
+// handleScenario publica el avance del escenario en curso
+func (p *RabbitMQPublisher) handleScenario(event eventbus.Event) {
+	if !p.config.PublishScenario {
+		return
+	}
+
+	data := event.Data.(eventbus.ScenarioEventData)
+	routingKey := p.config.RoutingKeys.Scenario
+
+	payload := map[string]interface{}{
+		"device_id":   p.deviceID,
+		"timestamp":   data.Timestamp.Unix(),
+		"event_type":  data.EventType,
+		"scenario":    data.Name,
+		"total_steps": data.TotalSteps,
+		"elapsed_sec": data.ElapsedSec,
+		"loop":        data.Loop,
+		"passed":      data.Passed,
+		"failed":      data.Failed,
+	}
+
+	if data.Step >= 0 {
+		payload["step"] = data.Step
+	}
+	if data.Action != "" {
+		payload["action"] = data.Action
+	}
+	if data.OnEnd != "" {
+		payload["on_end"] = data.OnEnd
+	}
+	if data.Next != "" {
+		payload["next"] = data.Next
+	}
+	if data.Playlist != "" {
+		payload["playlist"] = data.Playlist
+		payload["entry"] = data.Entry
+		payload["total_entries"] = data.TotalEntries
+	}
+
+	p.publish(routingKey, payload)
+}
+
+// publishDoorState publica una transición de la máquina de estados de puerta
+func (p *RabbitMQPublisher) publishDoorState(data eventbus.DoorStateEventData) {
+	routingKey := p.config.RoutingKeys.Dwell
+
+	payload := map[string]interface{}{
+		"device_id":        p.deviceID,
+		"timestamp":        data.Timestamp.Unix(),
+		"event_type":       "DOOR_STATE",
+		"from_state":       data.From,
+		"to_state":         data.To,
+		"dwell_session_id": data.DwellSessionID,
+	}
+
+	if data.StopID != 0 {
+		payload["stop_id"] = data.StopID
+	}
+
+	p.publish(routingKey, payload)
+}
+
+// publishDwellSummary publica el resumen de una sesión de parada
+func (p *RabbitMQPublisher) publishDwellSummary(data eventbus.DwellSummaryData) {
+	routingKey := p.config.RoutingKeys.Dwell
+
+	payload := map[string]interface{}{
+		"device_id":            p.deviceID,
+		"timestamp":            data.Timestamp.Unix(),
+		"event_type":           "DWELL_SUMMARY",
+		"dwell_session_id":     data.DwellSessionID,
+		"latitude":             data.Latitude,
+		"longitude":            data.Longitude,
+		"opened_at":            data.OpenedAt.Unix(),
+		"closed_at":            data.ClosedAt.Unix(),
+		"duration_sec":         data.DurationSec,
+		"timed_out":            data.TimedOut,
+		"entries":              data.Entries,
+		"exits":                data.Exits,
+		"current_count":        data.CurrentCount,
+		"max_detected_persons": data.MaxDetectedPersons,
+		"counting_strategy":    data.CountingStrategy,
+	}
+
+	if len(data.ShadowCounts) > 0 {
+		shadows := make([]map[string]interface{}, 0, len(data.ShadowCounts))
+		for _, shadow := range data.ShadowCounts {
+			shadows = append(shadows, map[string]interface{}{
+				"strategy": shadow.Strategy,
+				"entries":  shadow.Entries,
+				"exits":    shadow.Exits,
+			})
+		}
+		payload["shadow_counts"] = shadows
+	}
+
+	if !data.ConfirmedAt.IsZero() {
+		payload["confirmed_at"] = data.ConfirmedAt.Unix()
+	}
+	if data.StopID != 0 {
+		payload["stop_id"] = data.StopID
+		payload["stop_name"] = data.StopName
+	}
+
 	p.publish(routingKey, payload)
 }
 
@@ -262,6 +521,10 @@ func (p *RabbitMQPublisher) publishHybrid() {
 			"acceleration_ms2": mpu.AccelSmooth,
 			"turn_rate_dps":    mpu.GyroZ,
 			"vehicle_state":    vehicle.State,
+			"passenger_count":  vehicle.PassengerCount,
+			"capacity":         vehicle.Capacity,
+			"load_factor":      vehicle.LoadFactor,
+			"crowding_level":   vehicle.CrowdingLevel,
 		},
 	}
 
@@ -274,6 +537,11 @@ func (p *RabbitMQPublisher) publish(routingKey string, payload interface{}) {
 		return
 	}
 
+	p.mu.RLock()
+	trips := p.trips
+	p.mu.RUnlock()
+	stampTripID(payload, trips)
+
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
 		fmt.Printf("⚠️  [RabbitMQ] Error serializando JSON: %v\n", err)
@@ -312,6 +580,13 @@ func (p *RabbitMQPublisher) publish(routingKey string, payload interface{}) {
 	}
 }
 
+// SetTripProvider asigna el origen del viaje en curso para etiquetar los payloads
+func (p *RabbitMQPublisher) SetTripProvider(trips TripProvider) {
+	p.mu.Lock()
+	p.trips = trips
+	p.mu.Unlock()
+}
+
 // isRunning verifica si está corriendo
 func (p *RabbitMQPublisher) isRunning() bool {
 	p.mu.RLock()
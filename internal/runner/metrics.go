@@ -0,0 +1,104 @@
+package runner
+
+import (
+	"sync"
+	"time"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+	"github.com/MarcosBrindi/transporte-simulator/internal/statemanager"
+)
+
+// Metrics resumen de lo ocurrido durante un escenario
+type Metrics struct {
+	SimulatedSec   float64 `json:"simulated_sec"`
+	WallSec        float64 `json:"wall_sec"`
+	DistanceKm     float64 `json:"distance_km"`
+	MaxSpeedKmh    float64 `json:"max_speed_kmh"`
+	StopsArrived   int     `json:"stops_arrived"`
+	StopsSkipped   int     `json:"stops_skipped"`
+	DwellSessions  int     `json:"dwell_sessions"`
+	TripsCompleted int     `json:"trips_completed"`
+	FaultsInjected int     `json:"faults_injected"`
+	Entries        int     `json:"entries"`
+	Exits          int     `json:"exits"`
+	Onboard        int     `json:"onboard"`
+	FinalState     string  `json:"final_state"`
+}
+
+// metricsCollector acumula las métricas a partir de los eventos del bus
+type metricsCollector struct {
+	mu      sync.Mutex
+	metrics Metrics
+	lastGPS time.Time
+}
+
+// newMetricsCollector crea el acumulador y se suscribe a los eventos
+func newMetricsCollector(bus *eventbus.EventBus) *metricsCollector {
+	mc := &metricsCollector{}
+
+	for _, eventType := range []eventbus.EventType{
+		eventbus.EventGPS,
+		eventbus.EventStop,
+		eventbus.EventDwell,
+		eventbus.EventTrip,
+		eventbus.EventFault,
+	} {
+		channel := bus.Subscribe(eventType)
+		go func() {
+			for event := range channel {
+				mc.handle(event)
+			}
+		}()
+	}
+
+	return mc
+}
+
+// handle actualiza las métricas según el evento
+func (mc *metricsCollector) handle(event eventbus.Event) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	switch data := event.Data.(type) {
+	case eventbus.GPSData:
+		if data.Speed > mc.metrics.MaxSpeedKmh {
+			mc.metrics.MaxSpeedKmh = data.Speed
+		}
+		if !mc.lastGPS.IsZero() {
+			mc.metrics.DistanceKm += data.Speed * event.Timestamp.Sub(mc.lastGPS).Hours()
+		}
+		mc.lastGPS = event.Timestamp
+
+	case eventbus.StopEventData:
+		switch data.EventType {
+		case eventbus.StopArrived:
+			mc.metrics.StopsArrived++
+		case eventbus.StopSkipped:
+			mc.metrics.StopsSkipped++
+		}
+
+	case eventbus.DwellSummaryData:
+		mc.metrics.DwellSessions++
+
+	case eventbus.TripEventData:
+		if data.EventType == eventbus.TripCompleted {
+			mc.metrics.TripsCompleted++
+		}
+
+	case eventbus.FaultEventData:
+		if data.Status == eventbus.FaultStarted {
+			mc.metrics.FaultsInjected++
+		}
+	}
+}
+
+// snapshot retorna las métricas con los contadores finales del state manager
+func (mc *metricsCollector) snapshot(stateMgr *statemanager.StateManager) Metrics {
+	mc.mu.Lock()
+	metrics := mc.metrics
+	mc.mu.Unlock()
+
+	metrics.Onboard, metrics.Entries, metrics.Exits = stateMgr.GetPassengerStats()
+	metrics.FinalState = stateMgr.GetCurrentState().State
+	return metrics
+}
@@ -0,0 +1,158 @@
+package runner
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// jsonReport reporte JSON de una corrida
+type jsonReport struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Passed      int       `json:"passed"` // Escenarios exitosos
+	Failed      int       `json:"failed"`
+	Results     []Result  `json:"results"`
+}
+
+// junitTestSuites raíz del reporte JUnit XML
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     float64          `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite un escenario
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Time       float64         `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// junitTestCase una verificación (o la ejecución del escenario)
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJSON guarda los resultados en un reporte JSON
+func WriteJSON(path string, results []Result) error {
+	report := jsonReport{GeneratedAt: time.Now(), Results: results}
+	for _, result := range results {
+		if result.Success() {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(path, data)
+}
+
+// WriteJUnit guarda los resultados en un reporte JUnit XML: un testsuite por
+// escenario, un testcase por verificación y uno por la ejecución completa
+func WriteJUnit(path string, results []Result) error {
+	root := junitTestSuites{Name: "scenarios"}
+
+	for _, result := range results {
+		suite := junitTestSuite{
+			Name: result.Scenario,
+			Time: result.Metrics.SimulatedSec,
+			Properties: []junitProperty{
+				{Name: "file", Value: result.File},
+				{Name: "wall_sec", Value: fmt.Sprintf("%.2f", result.Metrics.WallSec)},
+				{Name: "distance_km", Value: fmt.Sprintf("%.3f", result.Metrics.DistanceKm)},
+				{Name: "stops_arrived", Value: fmt.Sprint(result.Metrics.StopsArrived)},
+				{Name: "entries", Value: fmt.Sprint(result.Metrics.Entries)},
+				{Name: "exits", Value: fmt.Sprint(result.Metrics.Exits)},
+				{Name: "onboard", Value: fmt.Sprint(result.Metrics.Onboard)},
+			},
+		}
+
+		// Ejecución: error al cargar, tiempo agotado o assert que abortó
+		execution := junitTestCase{Name: "ejecución", ClassName: result.Scenario, Time: result.Metrics.SimulatedSec}
+		switch {
+		case result.Error != "":
+			execution.Error = &junitFailure{Message: result.Error, Type: "error"}
+		case result.TimedOut:
+			execution.Error = &junitFailure{Message: "tiempo agotado antes de terminar el escenario", Type: "timeout"}
+		case result.Report.Aborted:
+			execution.Failure = &junitFailure{Message: "escenario abortado por un assert fallido", Type: "aborted"}
+		}
+		suite.Cases = append(suite.Cases, execution)
+
+		lastTime := 0.0
+		for _, assertion := range result.Report.Results {
+			testCase := junitTestCase{
+				Name:      fmt.Sprintf("paso %d: %s %s", assertion.Step, assertion.Action, assertion.Field),
+				ClassName: result.Scenario,
+				Time:      assertion.Time - lastTime,
+			}
+			lastTime = assertion.Time
+
+			if !assertion.Passed {
+				message := fmt.Sprintf("esperado %s, actual %s", assertion.Expected, assertion.Actual)
+				testCase.Failure = &junitFailure{Message: message, Type: assertion.Action, Text: assertion.Message}
+			}
+			suite.Cases = append(suite.Cases, testCase)
+		}
+
+		for _, testCase := range suite.Cases {
+			suite.Tests++
+			if testCase.Failure != nil {
+				suite.Failures++
+			}
+			if testCase.Error != nil {
+				suite.Errors++
+			}
+		}
+
+		root.Tests += suite.Tests
+		root.Failures += suite.Failures
+		root.Errors += suite.Errors
+		root.Time += suite.Time
+		root.Suites = append(root.Suites, suite)
+	}
+
+	data, err := xml.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(path, append([]byte(xml.Header), data...))
+}
+
+// writeFile escribe un reporte creando su directorio
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
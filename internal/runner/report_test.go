@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/scenario"
+)
+
+// testResults un escenario por cada forma de terminar
+func testResults() []Result {
+	passed := scenario.AssertionResult{Step: 1, Time: 10, Action: "expect", Field: "onboard", Expected: "= 2", Actual: "2", Passed: true}
+	failed := scenario.AssertionResult{Step: 2, Time: 25, Action: "expect", Field: "entries", Expected: "= 3", Actual: "1"}
+
+	return []Result{
+		{Scenario: "ok", Report: scenario.Report{Results: []scenario.AssertionResult{passed, passed}, Passed: 2}},
+		{Scenario: "falla", Report: scenario.Report{Results: []scenario.AssertionResult{passed, failed}, Passed: 1, Failed: 1}},
+		{Scenario: "abortado", Report: scenario.Report{Results: []scenario.AssertionResult{failed}, Failed: 1, Aborted: true}},
+		{Scenario: "timeout", TimedOut: true},
+		{Scenario: "error", Error: "archivo inválido"},
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reports", "junit.xml")
+	if err := WriteJUnit(path, testResults()); err != nil {
+		t.Fatalf("WriteJUnit: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var root junitTestSuites
+	if err := xml.Unmarshal(data, &root); err != nil {
+		t.Fatalf("XML inválido: %v", err)
+	}
+
+	// Cada escenario suma un testcase de ejecución más uno por verificación
+	if root.Tests != 10 || root.Failures != 3 || root.Errors != 2 || len(root.Suites) != 5 {
+		t.Errorf("tests=%d failures=%d errors=%d suites=%d, want 10/3/2/5",
+			root.Tests, root.Failures, root.Errors, len(root.Suites))
+	}
+
+	want := map[string][3]int{ // tests, failures, errors
+		"ok":       {3, 0, 0},
+		"falla":    {3, 1, 0},
+		"abortado": {2, 2, 0},
+		"timeout":  {1, 0, 1},
+		"error":    {1, 0, 1},
+	}
+	for _, suite := range root.Suites {
+		got := [3]int{suite.Tests, suite.Failures, suite.Errors}
+		if got != want[suite.Name] {
+			t.Errorf("%s: tests/failures/errors = %v, want %v", suite.Name, got, want[suite.Name])
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := WriteJSON(path, testResults()); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report jsonReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("JSON inválido: %v", err)
+	}
+	if report.Passed != 1 || report.Failed != 4 || len(report.Results) != 5 {
+		t.Errorf("passed=%d failed=%d results=%d, want 1/4/5", report.Passed, report.Failed, len(report.Results))
+	}
+}
@@ -0,0 +1,319 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/clock"
+	"github.com/MarcosBrindi/transporte-simulator/internal/config"
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+	"github.com/MarcosBrindi/transporte-simulator/internal/gtfs"
+	"github.com/MarcosBrindi/transporte-simulator/internal/scenario"
+	"github.com/MarcosBrindi/transporte-simulator/internal/sensors"
+	"github.com/MarcosBrindi/transporte-simulator/internal/statemanager"
+)
+
+// tick paso del reloj virtual entre cada pausa real (menor que el periodo
+// del sensor más rápido, 100 ms)
+const tick = 50 * time.Millisecond
+
+// Options opciones de una corrida
+type Options struct {
+	Speed   float64       // Segundos simulados por segundo real
+	Timeout time.Duration // Margen sobre la duración del escenario antes de cortarlo
+	Verbose bool          // Mostrar los logs de sensores, state manager y ejecutor
+	Seed    int64         // Semilla de los sensores (0 = una al azar por corrida)
+}
+
+// Result resultado de un escenario
+type Result struct {
+	File     string          `json:"file"`
+	Scenario string          `json:"scenario"`
+	Report   scenario.Report `json:"report"`
+	Metrics  Metrics         `json:"metrics"`
+	TimedOut bool            `json:"timed_out"`
+	Seed     int64           `json:"seed"` // Semilla de los sensores en esta corrida
+	Error    string          `json:"error,omitempty"`
+}
+
+// Success retorna true si el escenario terminó y pasaron todas sus verificaciones
+func (r Result) Success() bool {
+	return r.Error == "" && !r.TimedOut && r.Report.Success()
+}
+
+// OptionsFromConfig arma las opciones a partir de runner en config.yaml
+func OptionsFromConfig(cfg config.RunnerConfig) Options {
+	return Options{
+		Speed:   cfg.Speed,
+		Timeout: time.Duration(cfg.TimeoutSec) * time.Second,
+		Seed:    cfg.Seed,
+	}
+}
+
+// ExpandPaths convierte archivos y directorios en la lista de escenarios YAML
+func ExpandPaths(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !entry.IsDir() && (strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml")) {
+				files = append(files, filepath.Join(path, name))
+			}
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// Run ejecuta los escenarios uno por uno con reloj virtual, sin UI ni brokers
+func Run(files []string, cfg config.Config, opts Options) []Result {
+	// Sin brokers, feeds ni persistencia de contadores entre escenarios
+	cfg.MQTT.Enabled = false
+	cfg.RabbitMQ.Enabled = false
+	cfg.GTFSRT.Enabled = false
+	cfg.Storage.Enabled = false
+
+	// El detector de las pruebas: sin ruido los conteos esperados no dependen del azar
+	cfg.Sensors.Camera.Detector = cfg.Runner.Detector
+
+	if opts.Speed <= 0 {
+		opts.Speed = 1
+	}
+	if opts.Seed == 0 {
+		opts.Seed = time.Now().UnixNano()
+	}
+
+	// Las listas buscan sus escenarios por ID en scenarios/
+	repository := scenario.NewRepository(scenario.DefaultScenariosDir, scenario.DefaultPlaylistsDir)
+
+	results := make([]Result, 0, len(files))
+	for _, file := range files {
+		result := runFile(repository, file, cfg, opts)
+		results = append(results, result)
+
+		icon := "✅"
+		if !result.Success() {
+			icon = "❌"
+		}
+		fmt.Printf("%s [Runner] %s (%s): %d OK, %d fallidas, %.0fs simulados en %.1fs\n",
+			icon, result.Scenario, result.File, result.Report.Passed, result.Report.Failed,
+			result.Metrics.SimulatedSec, result.Metrics.WallSec)
+		if result.Error != "" {
+			fmt.Printf("   ⚠️  %s\n", result.Error)
+		}
+		if result.TimedOut {
+			fmt.Println("   ⏰ Tiempo agotado antes de terminar el escenario")
+		}
+		for _, failure := range result.Report.Failures() {
+			fmt.Printf("   ❌ Paso %d (%s) %s: esperado %s, actual %s\n",
+				failure.Step, failure.Action, failure.Field, failure.Expected, failure.Actual)
+		}
+	}
+
+	return results
+}
+
+// runFile carga y ejecuta un escenario o una lista de escenarios
+func runFile(repository *scenario.Repository, file string, cfg config.Config, opts Options) Result {
+	result := Result{File: file, Scenario: file, Seed: opts.Seed}
+
+	var scn *scenario.Scenario
+	var playlist *scenario.Playlist
+	var err error
+	if scenario.IsPlaylist(file) {
+		playlist, err = repository.LoadPlaylist(file)
+		if err == nil {
+			scn = playlist.Scenario(0)
+		}
+	} else {
+		scn, err = repository.Load(file)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Scenario = scn.Name
+	if playlist != nil {
+		result.Scenario = playlist.Name
+	}
+	result.Report.Scenario = result.Scenario
+
+	virtual := clock.NewVirtual(time.Now())
+	clock.Set(virtual)
+	defer clock.Set(nil)
+
+	return runScenario(scn, playlist, result, cfg, opts, virtual)
+}
+
+// runScenario arma el vehículo sobre un bus propio y avanza el reloj virtual
+// hasta que el escenario (o la lista, si no es nil) termina o se agota el tiempo
+func runScenario(scn *scenario.Scenario, playlist *scenario.Playlist, result Result, cfg config.Config, opts Options, virtual *clock.Virtual) Result {
+	bus := eventbus.NewEventBus()
+	defer bus.Close()
+
+	route := scenario.NewRouteFromCoordinates(
+		cfg.Sensors.GPS.InitialPosition.Latitude,
+		cfg.Sensors.GPS.InitialPosition.Longitude,
+	)
+	if cfg.GTFS.Enabled {
+		gtfsRoute, _, err := gtfs.LoadTrip(cfg.GTFS)
+		if err != nil {
+			result.Error = fmt.Sprintf("no se pudo importar el feed GTFS: %v", err)
+			return result
+		}
+		route = gtfsRoute
+	}
+
+	trips := scenario.NewTripController(bus, route, cfg.DeviceID)
+
+	gps := sensors.NewGPSSimulator(bus, cfg.Sensors.GPS, route)
+	mpu := sensors.NewMPU6050Simulator(bus, cfg.Sensors.MPU6050)
+	door := sensors.NewDoorActuator(cfg.Sensors.Door)
+	vl53l0x := sensors.NewVL53L0XSimulator(bus, cfg.Sensors.VL53L0X, door)
+	camera := sensors.NewCameraSimulator(bus, cfg.Sensors.Camera)
+
+	faults := sensors.NewFaultInjector(bus, cfg)
+
+	// Los logs de los componentes solo se ven con -verbose
+	var out io.Writer = io.Discard
+	if opts.Verbose {
+		out = os.Stdout
+	}
+	trips.SetOutput(out)
+	gps.SetOutput(out)
+	mpu.SetOutput(out)
+	door.SetOutput(out)
+	vl53l0x.SetOutput(out)
+	camera.SetOutput(out)
+	faults.SetOutput(out)
+
+	// Cada simulador con su propia secuencia de números aleatorios
+	mpu.SetSeed(opts.Seed)
+	vl53l0x.SetSeed(opts.Seed + 1)
+	camera.SetSeed(opts.Seed + 2)
+	faults.SetSeed(opts.Seed + 3)
+
+	gps.SetFaultInjector(faults)
+	mpu.SetFaultInjector(faults)
+	vl53l0x.SetFaultInjector(faults)
+	camera.SetFaultInjector(faults)
+	vl53l0x.SetDoorwayProbe(camera)
+	gps.SetTripController(trips)
+
+	stateMgr := statemanager.NewStateManager(bus, cfg)
+	stateMgr.SetOutput(out)
+	stateMgr.SetRoute(route)
+	stateMgr.SetTripSource(trips)
+
+	metrics := newMetricsCollector(bus)
+	wireSensors(bus, mpu, vl53l0x, camera)
+
+	trips.Start(clock.Now())
+	gps.Start()
+	mpu.Start()
+	vl53l0x.Start()
+	camera.Start()
+	stateMgr.Start()
+
+	executor := scenario.NewExecutor(scn, gps, bus)
+	executor.SetOutput(out)
+	executor.SetDoorController(door)
+	executor.SetFaultController(faults)
+	executor.SetPassengerController(camera)
+	executor.SetPositionController(gps)
+	executor.SetStateInspector(stateMgr)
+	if playlist != nil {
+		executor.SetPlaylist(playlist)
+	}
+
+	started := time.Now()
+	startedAt := clock.Now()
+	executor.Start()
+
+	// El escenario corre al menos su duración; se corta si se pasa del margen
+	duration := scn.GetDuration()
+	if playlist != nil {
+		duration = playlist.GetDuration()
+	}
+	deadline := duration + opts.Timeout
+	pause := time.Duration(float64(tick) / opts.Speed)
+
+	for {
+		virtual.Advance(tick)
+		time.Sleep(pause)
+
+		elapsed := clock.Since(startedAt)
+		report := executor.Report()
+		if !executor.IsRunning() && (elapsed >= duration || report.Aborted) {
+			break
+		}
+		if elapsed >= deadline {
+			result.TimedOut = true
+			executor.Stop()
+			break
+		}
+	}
+
+	result.Report = executor.Report()
+	result.Metrics = metrics.snapshot(stateMgr)
+	result.Metrics.SimulatedSec = clock.Since(startedAt).Seconds()
+	result.Metrics.WallSec = time.Since(started).Seconds()
+
+	trips.Abort(clock.Now())
+	gps.Stop()
+	mpu.Stop()
+	vl53l0x.Stop()
+	camera.Stop()
+	stateMgr.Stop()
+
+	return result
+}
+
+// wireSensors conecta los sensores que dependen del estado de otros
+func wireSensors(bus *eventbus.EventBus, mpu *sensors.MPU6050Simulator, vl53l0x *sensors.VL53L0XSimulator, camera *sensors.CameraSimulator) {
+	gpsChannel := bus.Subscribe(eventbus.EventGPS)
+	go func() {
+		for event := range gpsChannel {
+			data := event.Data.(eventbus.GPSData)
+			mpu.UpdateSpeed(data.Speed)
+		}
+	}()
+
+	vehicleChannel := bus.Subscribe(eventbus.EventVehicle)
+	go func() {
+		for event := range vehicleChannel {
+			data := event.Data.(eventbus.VehicleStateData)
+			vl53l0x.UpdateVehicleState(data.IsStopped)
+			camera.UpdateVehicleState(data.IsStopped)
+		}
+	}()
+
+	doorChannel := bus.Subscribe(eventbus.EventDoor)
+	go func() {
+		for event := range doorChannel {
+			data := event.Data.(eventbus.DoorData)
+			camera.UpdateDoorState(data.IsOpen)
+		}
+	}()
+}
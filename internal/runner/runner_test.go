@@ -0,0 +1,61 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandPaths(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.yaml", "a.yml", "notas.txt", "sub/c.yaml"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		paths   []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "directorio: solo YAML del primer nivel, ordenados",
+			paths: []string{dir},
+			want:  []string{filepath.Join(dir, "a.yml"), filepath.Join(dir, "b.yaml")},
+		},
+		{
+			name:  "archivos explícitos se aceptan con cualquier extensión",
+			paths: []string{filepath.Join(dir, "notas.txt"), filepath.Join(dir, "sub/c.yaml")},
+			want:  []string{filepath.Join(dir, "notas.txt"), filepath.Join(dir, "sub/c.yaml")},
+		},
+		{
+			name:  "directorio y archivo se ordenan juntos",
+			paths: []string{filepath.Join(dir, "sub"), dir},
+			want:  []string{filepath.Join(dir, "a.yml"), filepath.Join(dir, "b.yaml"), filepath.Join(dir, "sub/c.yaml")},
+		},
+		{
+			name:    "ruta inexistente",
+			paths:   []string{filepath.Join(dir, "no-existe.yaml")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandPaths(tt.paths)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
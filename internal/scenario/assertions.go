@@ -0,0 +1,392 @@
+package scenario
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+)
+
+// StateInspector es la interfaz para leer el estado consolidado del vehículo
+// (la implementa statemanager.StateManager)
+type StateInspector interface {
+	GetCurrentState() eventbus.VehicleStateData
+	GetPassengerStats() (current, entries, exits int)
+	GetDoorState() eventbus.DoorState
+}
+
+// ExpectParams parámetros de expect/assert: un campo y la condición que debe cumplir
+type ExpectParams struct {
+	Field     string      `yaml:"field"`     // Campo a verificar (ver stateFields y event.<tipo>.<campo>)
+	Equals    interface{} `yaml:"equals"`    // Valor esperado
+	Min       *float64    `yaml:"min"`       // Mínimo (campos numéricos)
+	Max       *float64    `yaml:"max"`       // Máximo (campos numéricos)
+	Tolerance float64     `yaml:"tolerance"` // Diferencia aceptada con equals (campos numéricos)
+	Within    float64     `yaml:"within"`    // Segundos que se reintenta la verificación (0 = una vez)
+	Message   string      `yaml:"message"`   // Descripción para el reporte
+}
+
+// AssertionResult resultado de un paso expect/assert
+type AssertionResult struct {
+	Step     int     `json:"step"`     // Índice del paso en el escenario
+	Time     float64 `json:"time_sec"` // Segundos desde el inicio al evaluar
+	Action   string  `json:"action"`   // expect o assert
+	Field    string  `json:"field"`
+	Expected string  `json:"expected"`
+	Actual   string  `json:"actual"`
+	Passed   bool    `json:"passed"`
+	Message  string  `json:"message,omitempty"`
+}
+
+// Report resultado de las verificaciones de un escenario
+type Report struct {
+	Scenario string            `json:"scenario"`
+	Results  []AssertionResult `json:"assertions"`
+	Passed   int               `json:"passed"`
+	Failed   int               `json:"failed"`
+	Aborted  bool              `json:"aborted"` // Un assert falló y detuvo el escenario
+}
+
+// Success retorna true si todas las verificaciones pasaron
+func (r Report) Success() bool {
+	return r.Failed == 0 && !r.Aborted
+}
+
+// Failures retorna las verificaciones que fallaron
+func (r Report) Failures() []AssertionResult {
+	failures := make([]AssertionResult, 0, r.Failed)
+	for _, result := range r.Results {
+		if !result.Passed {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// stateSnapshot estado leído del StateInspector en un instante
+type stateSnapshot struct {
+	vehicle eventbus.VehicleStateData
+	onboard int
+	entries int
+	exits   int
+	door    eventbus.DoorState
+}
+
+// stateFields campos del estado del vehículo que se pueden verificar
+var stateFields = map[string]func(s stateSnapshot) interface{}{
+	"state":       func(s stateSnapshot) interface{} { return s.vehicle.State },
+	"speed":       func(s stateSnapshot) interface{} { return s.vehicle.Speed },
+	"is_moving":   func(s stateSnapshot) interface{} { return s.vehicle.IsMoving },
+	"is_stopped":  func(s stateSnapshot) interface{} { return s.vehicle.IsStopped },
+	"door_open":   func(s stateSnapshot) interface{} { return s.vehicle.DoorOpen },
+	"gps_fix":     func(s stateSnapshot) interface{} { return s.vehicle.HasGPSFix },
+	"load_factor": func(s stateSnapshot) interface{} { return s.vehicle.LoadFactor },
+	"crowding":    func(s stateSnapshot) interface{} { return s.vehicle.CrowdingLevel },
+	"onboard":     func(s stateSnapshot) interface{} { return s.onboard },
+	"entries":     func(s stateSnapshot) interface{} { return s.entries },
+	"exits":       func(s stateSnapshot) interface{} { return s.exits },
+	"door_state":  func(s stateSnapshot) interface{} { return s.door.String() },
+}
+
+// progressField progreso en la ruta (0.0 a 1.0), leído del PositionController
+const progressField = "progress"
+
+// recordedEvents tipos de evento que se pueden verificar con event.<tipo>.<campo>
+// y events.<tipo> (cantidad publicada desde el inicio del escenario)
+var recordedEvents = []eventbus.EventType{
+	eventbus.EventGPS,
+	eventbus.EventDoor,
+	eventbus.EventVehicle,
+	eventbus.EventPassenger,
+	eventbus.EventFault,
+	eventbus.EventDwell,
+	eventbus.EventDoorState,
+	eventbus.EventOccupancy,
+	eventbus.EventStop,
+	eventbus.EventTrip,
+}
+
+// ParseExpect convierte el valor de un paso expect/assert
+func ParseExpect(value interface{}) (ExpectParams, error) {
+	var params ExpectParams
+
+	switch v := value.(type) {
+	case ExpectParams:
+		params = v
+	case map[string]interface{}:
+		if err := decodeParams(v, &params); err != nil {
+			return ExpectParams{}, fmt.Errorf("verificación inválida: %w", err)
+		}
+	default:
+		return ExpectParams{}, fmt.Errorf("se esperaba {field, equals|min|max}: %v", value)
+	}
+
+	if err := validateField(params.Field); err != nil {
+		return ExpectParams{}, err
+	}
+	if params.Equals == nil && params.Min == nil && params.Max == nil {
+		return ExpectParams{}, fmt.Errorf("la verificación de '%s' requiere equals, min o max", params.Field)
+	}
+	if params.Min != nil && params.Max != nil && *params.Min > *params.Max {
+		return ExpectParams{}, fmt.Errorf("min no puede ser mayor que max")
+	}
+	if params.Tolerance < 0 || params.Within < 0 {
+		return ExpectParams{}, fmt.Errorf("tolerance y within no pueden ser negativos")
+	}
+	return params, nil
+}
+
+// validateField verifica que el campo exista
+func validateField(field string) error {
+	if _, ok := stateFields[field]; ok || field == progressField {
+		return nil
+	}
+
+	prefix, rest, _ := strings.Cut(field, ".")
+	eventType, eventField, _ := strings.Cut(rest, ".")
+
+	switch {
+	case prefix == "events" && isRecordedEvent(eventType) && eventField == "":
+		return nil
+	case prefix == "event" && isRecordedEvent(eventType) && eventField != "":
+		return nil
+	}
+	return fmt.Errorf("campo '%s' no válido", field)
+}
+
+// isRecordedEvent verifica si el tipo de evento se registra para las verificaciones
+func isRecordedEvent(eventType string) bool {
+	for _, recorded := range recordedEvents {
+		if string(recorded) == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAssertions retorna true si el escenario tiene pasos expect/assert
+func (s *Scenario) HasAssertions() bool {
+	for _, step := range s.Steps {
+		if step.Action == ActionExpect || step.Action == ActionAssert {
+			return true
+		}
+	}
+	return false
+}
+
+// HasConditions retorna true si algún paso lee el estado (expect, assert,
+// loop_check o when)
+func (s *Scenario) HasConditions() bool {
+	for _, step := range s.Steps {
+		if step.Action == ActionLoopCheck || step.When != nil {
+			return true
+		}
+	}
+	return s.HasAssertions()
+}
+
+// historyLimit eventos recientes que se guardan por tipo (para when con event)
+const historyLimit = 64
+
+// eventRecorder guarda los últimos eventos y la cantidad publicada de cada tipo
+type eventRecorder struct {
+	bus      *eventbus.EventBus
+	channels map[eventbus.EventType]<-chan eventbus.Event
+
+	mu      sync.RWMutex
+	last    map[eventbus.EventType]eventbus.Event
+	counts  map[eventbus.EventType]int
+	history map[eventbus.EventType][]eventbus.Event
+}
+
+// newEventRecorder crea el registro y se suscribe a los eventos verificables
+func newEventRecorder(bus *eventbus.EventBus) *eventRecorder {
+	er := &eventRecorder{
+		bus:      bus,
+		channels: make(map[eventbus.EventType]<-chan eventbus.Event),
+		last:     make(map[eventbus.EventType]eventbus.Event),
+		counts:   make(map[eventbus.EventType]int),
+		history:  make(map[eventbus.EventType][]eventbus.Event),
+	}
+
+	for _, eventType := range recordedEvents {
+		channel := bus.Subscribe(eventType)
+		er.channels[eventType] = channel
+		go func() {
+			for event := range channel {
+				er.mu.Lock()
+				er.last[event.Type] = event
+				er.counts[event.Type]++
+				history := append(er.history[event.Type], event)
+				if len(history) > historyLimit {
+					history = history[len(history)-historyLimit:]
+				}
+				er.history[event.Type] = history
+				er.mu.Unlock()
+			}
+		}()
+	}
+
+	return er
+}
+
+// Close cancela las suscripciones y termina sus goroutines
+func (er *eventRecorder) Close() {
+	for eventType, channel := range er.channels {
+		er.bus.Unsubscribe(eventType, channel)
+	}
+	er.channels = nil
+}
+
+// Reset olvida los eventos registrados (al iniciar el escenario)
+func (er *eventRecorder) Reset() {
+	er.mu.Lock()
+	er.last = make(map[eventbus.EventType]eventbus.Event)
+	er.counts = make(map[eventbus.EventType]int)
+	er.history = make(map[eventbus.EventType][]eventbus.Event)
+	er.mu.Unlock()
+}
+
+// count retorna la cantidad de eventos publicados de un tipo
+func (er *eventRecorder) count(eventType eventbus.EventType) int {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+	return er.counts[eventType]
+}
+
+// since retorna los eventos de un tipo publicados después de los primeros
+// seen (hasta historyLimit) y la cantidad total publicada
+func (er *eventRecorder) since(eventType eventbus.EventType, seen int) ([]eventbus.Event, int) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	total := er.counts[eventType]
+	history := er.history[eventType]
+	fresh := total - seen
+	if fresh <= 0 {
+		return nil, total
+	}
+	if fresh > len(history) {
+		fresh = len(history)
+	}
+	return append([]eventbus.Event(nil), history[len(history)-fresh:]...), total
+}
+
+// value retorna el valor de events.<tipo> o event.<tipo>.<campo>
+func (er *eventRecorder) value(field string) (interface{}, error) {
+	prefix, rest, _ := strings.Cut(field, ".")
+	eventType, eventField, _ := strings.Cut(rest, ".")
+
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	if prefix == "events" {
+		return er.counts[eventbus.EventType(eventType)], nil
+	}
+
+	event, ok := er.last[eventbus.EventType(eventType)]
+	if !ok {
+		return nil, fmt.Errorf("sin eventos %s", eventType)
+	}
+	return structField(event.Data, eventField)
+}
+
+// structField lee un campo de los datos de un evento (event_type → EventType)
+func structField(data interface{}, name string) (interface{}, error) {
+	value := reflect.ValueOf(data)
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("el evento no tiene campos")
+	}
+
+	wanted := strings.ReplaceAll(strings.ToLower(name), "_", "")
+	for i := 0; i < value.NumField(); i++ {
+		if strings.ToLower(value.Type().Field(i).Name) != wanted {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+		if fieldValue.Kind() == reflect.Ptr {
+			if fieldValue.IsNil() {
+				return nil, nil
+			}
+			fieldValue = fieldValue.Elem()
+		}
+		return fieldValue.Interface(), nil
+	}
+	return nil, fmt.Errorf("el evento no tiene el campo '%s'", name)
+}
+
+// check compara el valor actual con la condición esperada
+func (p ExpectParams) check(actual interface{}) (bool, error) {
+	number, isNumber := toFloat(actual)
+
+	if p.Min != nil || p.Max != nil {
+		if !isNumber {
+			return false, fmt.Errorf("min/max requieren un campo numérico")
+		}
+		if p.Min != nil && number < *p.Min {
+			return false, nil
+		}
+		if p.Max != nil && number > *p.Max {
+			return false, nil
+		}
+	}
+
+	if p.Equals == nil {
+		return true, nil
+	}
+
+	if isNumber {
+		expected, err := ParseNumber(p.Equals)
+		if err != nil {
+			return false, fmt.Errorf("se esperaba un número en equals")
+		}
+		return math.Abs(number-expected) <= p.Tolerance, nil
+	}
+
+	if flag, ok := actual.(bool); ok {
+		expected, ok := p.Equals.(bool)
+		if !ok {
+			return false, fmt.Errorf("se esperaba true o false en equals")
+		}
+		return flag == expected, nil
+	}
+
+	return fmt.Sprint(actual) == fmt.Sprint(p.Equals), nil
+}
+
+// describe retorna la condición esperada en texto para el reporte
+func (p ExpectParams) describe() string {
+	var parts []string
+	if p.Equals != nil {
+		if p.Tolerance > 0 {
+			parts = append(parts, fmt.Sprintf("%v ± %v", p.Equals, p.Tolerance))
+		} else {
+			parts = append(parts, fmt.Sprint(p.Equals))
+		}
+	}
+	if p.Min != nil {
+		parts = append(parts, fmt.Sprintf(">= %v", *p.Min))
+	}
+	if p.Max != nil {
+		parts = append(parts, fmt.Sprintf("<= %v", *p.Max))
+	}
+	return strings.Join(parts, " y ")
+}
+
+// toFloat convierte valores numéricos a float64
+func toFloat(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,56 @@
+package scenario
+
+import "testing"
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
+
+func TestExpectParamsCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  ExpectParams
+		actual  interface{}
+		want    bool
+		wantErr bool
+	}{
+		{"equals entero", ExpectParams{Equals: 3}, 3, true, false},
+		{"equals entero distinto", ExpectParams{Equals: 3}, 4, false, false},
+		{"equals float exacto", ExpectParams{Equals: 12.5}, 12.5, true, false},
+		{"dentro de la tolerancia", ExpectParams{Equals: 10, Tolerance: 0.5}, 10.4, true, false},
+		{"en el borde de la tolerancia", ExpectParams{Equals: 10, Tolerance: 0.5}, 9.5, true, false},
+		{"fuera de la tolerancia", ExpectParams{Equals: 10, Tolerance: 0.5}, 10.6, false, false},
+		{"sin tolerancia", ExpectParams{Equals: 10}, 10.01, false, false},
+		{"entero sin signo", ExpectParams{Equals: 7}, uint32(7), true, false},
+		{"equals no numérico en campo numérico", ExpectParams{Equals: "diez"}, 10.0, false, true},
+		{"dentro de min y max", ExpectParams{Min: floatPtr(3), Max: floatPtr(8)}, 5, true, false},
+		{"en el mínimo", ExpectParams{Min: floatPtr(3)}, 3.0, true, false},
+		{"bajo el mínimo", ExpectParams{Min: floatPtr(3)}, 2.9, false, false},
+		{"sobre el máximo", ExpectParams{Max: floatPtr(8)}, 9, false, false},
+		{"min y equals", ExpectParams{Min: floatPtr(0), Equals: 5}, 5, true, false},
+		{"min en campo no numérico", ExpectParams{Min: floatPtr(0)}, "STOPPED", false, true},
+		{"bool igual", ExpectParams{Equals: true}, true, true, false},
+		{"bool distinto", ExpectParams{Equals: false}, true, false, false},
+		{"bool con equals de texto", ExpectParams{Equals: "true"}, true, false, true},
+		{"texto igual", ExpectParams{Equals: "STOPPED"}, "STOPPED", true, false},
+		{"texto distinto", ExpectParams{Equals: "STOPPED"}, "MOVING", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.params.check(tt.actual)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("se esperaba error, llegó %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error inesperado: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
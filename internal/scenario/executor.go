@@ -2,9 +2,13 @@ package scenario
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"sync"
 	"time"
 
+	"github.com/MarcosBrindi/transporte-simulator/internal/clock"
+	"github.com/MarcosBrindi/transporte-simulator/internal/config"
 	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
 )
 
@@ -13,18 +17,78 @@ type SpeedController interface {
 	SetSpeed(speed float64)
 }
 
+// DoorController es la interfaz para ordenar movimientos de la puerta
+type DoorController interface {
+	OpenDoor()
+	CloseDoor()
+	HoldDoor()
+	ObstructDoor()
+	Settle()
+	SetScenarioControl(enabled bool)
+}
+
+// FaultController es la interfaz para inyectar fallas en los sensores
+type FaultController interface {
+	InjectFault(fault config.FaultConfig) error
+	ClearFaults()
+}
+
+// PassengerController es la interfaz para ordenar que suban o bajen pasajeros
+type PassengerController interface {
+	QueuePassengers(boarding, alighting int)
+	SetScenarioControl(enabled bool)
+}
+
+// PositionController es la interfaz para mover el vehículo y manejar el fix del GPS
+type PositionController interface {
+	SetProgress(progress float64)
+	GetProgress() float64
+	SetFixLost(lost bool)
+	GetRoute() *Route
+	GetPose() VehiclePose
+	SetPose(pose VehiclePose)
+}
+
+// ScenarioLoader es la interfaz para cargar el escenario que sigue (on_end next)
+type ScenarioLoader interface {
+	Load(id string) (*Scenario, error)
+}
+
 // Executor ejecuta escenarios
 type Executor struct {
 	scenario        *Scenario
-	speedController SpeedController // ← Cambiado de *sensors.GPSSimulator a interfaz
+	speedController SpeedController     // ← Cambiado de *sensors.GPSSimulator a interfaz
+	doorController  DoorController      // Opcional: sin él la puerta sigue su ciclo automático
+	faultController FaultController     // Opcional: inyección de fallas desde el escenario
+	passengers      PassengerController // Opcional: sin él suben y bajan pasajeros al azar
+	position        PositionController  // Opcional: posición en la ruta y fix del GPS
+	inspector       StateInspector      // Opcional: estado del vehículo para expect/assert
+	loader          ScenarioLoader      // Opcional: sin él next usa un Repository sobre scenarios/
+	autoLoop        bool                // Repetir al terminar si el escenario no define on_end
+	playlist        *Playlist           // Opcional: corre sus escenarios en orden (ver playlist.go)
 	bus             *eventbus.EventBus
+	out             io.Writer      // Destino de los logs (os.Stdout por defecto)
+	recorder        *eventRecorder // Últimos eventos publicados (solo si hay expect/assert)
 
 	// Control
 	mu               sync.RWMutex
 	running          bool
 	paused           bool
-	startTime        time.Time
+	elapsedBase      float64     // Segundos de escenario acumulados hasta resumedAt
+	resumedAt        time.Time   // Desde aquí corre el tiempo (inicio, Resume, Seek o SetRate)
+	rate             float64     // Segundos de escenario por segundo de reloj
+	seekGen          uint64      // Cambia con cada Seek (corta las esperas en curso)
+	stepOnce         bool        // StepNext en pausa: ejecutar un solo paso
+	origin           VehiclePose // Vehículo al empezar la vuelta (Seek reproduce desde aquí)
 	currentStepIndex int
+	loop             int     // Vuelta actual (1 = primera)
+	jumpTo           int     // Próximo paso tras un loop_check cumplido (-1 = el siguiente)
+	timeShift        float64 // Segundos que se adelantan los pasos tras salir de un loop
+	entry            int     // Entrada actual de la lista (0 = primera)
+	entryRepeat      int     // Repetición actual de la entrada (1 = primera)
+	playlistLoop     int     // Vuelta actual de la lista (1 = primera)
+	results          []AssertionResult
+	aborted          bool
 }
 
 // NewExecutor crea un nuevo ejecutor de escenarios
@@ -33,54 +97,472 @@ func NewExecutor(scenario *Scenario, speedController SpeedController, bus *event
 		scenario:         scenario,
 		speedController:  speedController, // ← Acepta cualquier tipo que implemente la interfaz
 		bus:              bus,
+		out:              os.Stdout,
 		running:          false,
 		paused:           false,
+		rate:             1,
 		currentStepIndex: 0,
 	}
 }
 
-// Start inicia la ejecución del escenario
+// SetOutput redirige los logs del ejecutor (io.Discard los silencia); llamar antes de Start
+func (e *Executor) SetOutput(w io.Writer) {
+	e.out = w
+}
+
+// SetDoorController asigna el actuador de puerta que comanda el escenario
+func (e *Executor) SetDoorController(doorController DoorController) {
+	e.mu.Lock()
+	e.doorController = doorController
+	e.mu.Unlock()
+}
+
+// SetFaultController asigna el inyector de fallas que usa el escenario
+func (e *Executor) SetFaultController(faultController FaultController) {
+	e.mu.Lock()
+	e.faultController = faultController
+	e.mu.Unlock()
+}
+
+// SetPassengerController asigna el simulador de pasajeros que comanda el escenario
+func (e *Executor) SetPassengerController(passengers PassengerController) {
+	e.mu.Lock()
+	e.passengers = passengers
+	e.mu.Unlock()
+}
+
+// SetPositionController asigna el GPS que mueve el escenario
+func (e *Executor) SetPositionController(position PositionController) {
+	e.mu.Lock()
+	e.position = position
+	e.mu.Unlock()
+}
+
+// SetStateInspector asigna la fuente de estado que verifican expect/assert
+func (e *Executor) SetStateInspector(inspector StateInspector) {
+	e.mu.Lock()
+	e.inspector = inspector
+	e.mu.Unlock()
+}
+
+// SetScenarioLoader asigna de dónde se carga el escenario de next
+func (e *Executor) SetScenarioLoader(loader ScenarioLoader) {
+	e.mu.Lock()
+	e.loader = loader
+	e.mu.Unlock()
+}
+
+// SetAutoLoop repite los escenarios sin on_end al terminar (simulation.auto_loop)
+func (e *Executor) SetAutoLoop(enabled bool) {
+	e.mu.Lock()
+	e.autoLoop = enabled
+	e.mu.Unlock()
+}
+
+// SetPlaylist corre una lista de escenarios en lugar del escenario del
+// constructor (llamar antes de Start)
+func (e *Executor) SetPlaylist(playlist *Playlist) {
+	e.mu.Lock()
+	e.playlist = playlist
+	if playlist != nil && playlist.Scenario(0) != nil {
+		e.scenario = playlist.Scenario(0)
+	}
+	e.mu.Unlock()
+}
+
+// Start inicia la ejecución del escenario (o de la lista desde su primera entrada)
 func (e *Executor) Start() {
 	e.mu.Lock()
 	e.running = true
 	e.paused = false
-	e.startTime = time.Now()
+	e.results = nil
+	e.aborted = false
+	e.entry = 0
+	e.entryRepeat = 1
+	e.playlistLoop = 1
+	if e.playlist != nil {
+		e.scenario = e.playlist.Scenario(0)
+		fmt.Fprintf(e.out, "📜 [Executor] %s\n", e.playlist)
+	}
+	e.mu.Unlock()
+
+	e.begin(e.scenario, 1)
+	go e.execute()
+}
+
+// begin arranca una vuelta del escenario desde el primer paso
+func (e *Executor) begin(scenario *Scenario, loop int) {
+	e.mu.RLock()
+	again := loop > 1 || e.playlistLoop > 1
+	e.mu.RUnlock()
+
+	// Cada vuelta (loop, repetición o nueva ronda de la lista) sortea sus choose
+	if again {
+		redrawn, err := scenario.Redraw()
+		if err != nil {
+			fmt.Fprintf(e.out, "⚠️  [Executor] No se pudo sortear de nuevo '%s', se repite la vuelta anterior: %v\n", scenario.Name, err)
+		} else {
+			scenario = redrawn
+		}
+	}
+
+	e.mu.Lock()
+	e.scenario = scenario
+	e.loop = loop
+	e.elapsedBase = 0
+	e.resumedAt = clock.Now()
+	e.stepOnce = false
+	e.seekGen++
 	e.currentStepIndex = 0
+	e.jumpTo = -1
+	e.timeShift = 0
+	if scenario.HasConditions() {
+		if e.recorder == nil {
+			e.recorder = newEventRecorder(e.bus)
+		} else {
+			e.recorder.Reset()
+		}
+	}
+	position := e.position
 	e.mu.Unlock()
 
-	fmt.Printf("🎬 [Executor] Iniciando escenario: %s\n", e.scenario.Name)
-	fmt.Printf("📋 [Executor] %s\n", e.scenario.Description)
-	fmt.Printf("⏱️  [Executor] Duración: %.0fs\n", e.scenario.GetDuration().Seconds())
-	fmt.Println()
+	if position != nil {
+		pose := position.GetPose()
+		e.mu.Lock()
+		e.origin = pose
+		e.mu.Unlock()
+	}
+
+	// El escenario toma el control de la puerta solo si la maneja
+	e.takeControl(scenario, true)
 
-	go e.execute()
+	if loop > 1 {
+		fmt.Fprintf(e.out, "🔁 [Executor] Vuelta %d de '%s'\n", loop, scenario.Name)
+	} else {
+		fmt.Fprintf(e.out, "🎬 [Executor] Iniciando escenario: %s\n", scenario.Name)
+		fmt.Fprintf(e.out, "📋 [Executor] %s\n", scenario.Description)
+		fmt.Fprintf(e.out, "⏱️  [Executor] Duración: %.0fs\n", scenario.GetDuration().Seconds())
+	}
+	fmt.Fprintln(e.out)
+
+	e.publish(eventbus.ScenarioStarted, -1, "", "")
+}
+
+// takeControl toma o devuelve la puerta y los pasajeros según lo que maneje el escenario
+func (e *Executor) takeControl(scenario *Scenario, enabled bool) {
+	e.mu.RLock()
+	doorController := e.doorController
+	passengers := e.passengers
+	e.mu.RUnlock()
+
+	if doorController != nil && scenario.ControlsDoor() {
+		doorController.SetScenarioControl(enabled)
+	}
+	if passengers != nil && scenario.ControlsPassengers() {
+		passengers.SetScenarioControl(enabled)
+	}
 }
 
-// Stop detiene la ejecución
+// Stop detiene la ejecución desde fuera (UI, reset, cambio de escenario)
 func (e *Executor) Stop() {
+	if e.IsRunning() {
+		e.publish(eventbus.ScenarioStopped, -1, "", "")
+	}
+	e.halt()
+}
+
+// halt detiene la ejecución y devuelve la puerta y los pasajeros al ciclo automático
+func (e *Executor) halt() {
 	e.mu.Lock()
+	e.elapsedBase = e.elapsedLocked()
 	e.running = false
+	scenario := e.scenario
+	recorder := e.recorder
+	e.recorder = nil
+	e.mu.Unlock()
+
+	// El bus no se cierra al cambiar de escenario: soltar las suscripciones
+	if recorder != nil {
+		recorder.Close()
+	}
+
+	e.takeControl(scenario, false)
+
+	fmt.Fprintln(e.out, "🛑 [Executor] Escenario detenido")
+}
+
+// finish aplica on_end al terminar los pasos; retorna true si la ejecución
+// sigue (otra vuelta o el escenario de next)
+func (e *Executor) finish() bool {
+	if e.GetPlaylist() != nil {
+		return e.advancePlaylist()
+	}
+
+	e.mu.RLock()
+	scenario := e.scenario
+	loop := e.loop
+	onEnd := scenario.OnEnd
+	if onEnd == "" {
+		onEnd = EndHold
+		if e.autoLoop {
+			onEnd = EndLoop
+		}
+	}
+	e.mu.RUnlock()
+
+	if onEnd == EndLoop && scenario.MaxLoops > 0 && loop >= scenario.MaxLoops {
+		fmt.Fprintf(e.out, "🔁 [Executor] '%s' completó sus %d vueltas\n", scenario.Name, scenario.MaxLoops)
+		onEnd = EndHold
+	}
+
+	fmt.Fprintf(e.out, "✅ [Executor] Escenario '%s' completado\n", scenario.Name)
+	e.publish(eventbus.ScenarioCompleted, len(scenario.Steps)-1, "", onEnd)
+
+	switch onEnd {
+	case EndLoop:
+		e.begin(scenario, loop+1)
+		return true
+
+	case EndNext:
+		next, err := e.loadNext(scenario.Next)
+		if err == nil {
+			e.takeControl(scenario, false)
+			fmt.Fprintf(e.out, "⏭️  [Executor] Sigue el escenario: %s\n", next.Name)
+			e.begin(next, 1)
+			return true
+		}
+		fmt.Fprintf(e.out, "⚠️  [Executor] No se pudo cargar el escenario siguiente '%s': %v\n", scenario.Next, err)
+
+	case EndStop:
+		e.speedController.SetSpeed(0)
+		fmt.Fprintln(e.out, "   🚗 Vehículo detenido al terminar el escenario")
+	}
+
+	e.printReport()
+	e.halt()
+	return false
+}
+
+// advancePlaylist pasa a la siguiente repetición o entrada de la lista, con su
+// transición; al terminar la última entrada aplica el on_end de la lista.
+// Retorna true si la ejecución sigue.
+func (e *Executor) advancePlaylist() bool {
+	e.mu.RLock()
+	playlist := e.playlist
+	scenario := e.scenario
+	entry := e.entry
+	repeat := e.entryRepeat
+	round := e.playlistLoop
+	e.mu.RUnlock()
+
+	current := playlist.Entries[entry]
+	fmt.Fprintf(e.out, "✅ [Executor] Escenario '%s' completado (entrada %d/%d de '%s')\n",
+		scenario.Name, entry+1, len(playlist.Entries), playlist.Name)
+
+	// Otra repetición de la misma entrada
+	if repeat < current.repeats() {
+		e.publish(eventbus.ScenarioCompleted, len(scenario.Steps)-1, "", EndLoop)
+		e.mu.Lock()
+		e.entryRepeat = repeat + 1
+		e.mu.Unlock()
+		e.begin(scenario, repeat+1)
+		return true
+	}
+
+	nextEntry := entry + 1
+	if nextEntry >= len(playlist.Entries) {
+		onEnd := playlist.onEnd()
+		if onEnd == EndLoop && playlist.MaxLoops > 0 && round >= playlist.MaxLoops {
+			fmt.Fprintf(e.out, "🔁 [Executor] Lista '%s' completó sus %d vueltas\n", playlist.Name, playlist.MaxLoops)
+			onEnd = EndHold
+		}
+		if onEnd != EndLoop {
+			e.publish(eventbus.ScenarioCompleted, len(scenario.Steps)-1, "", onEnd)
+			if onEnd == EndStop {
+				e.speedController.SetSpeed(0)
+				fmt.Fprintln(e.out, "   🚗 Vehículo detenido al terminar la lista")
+			}
+			fmt.Fprintf(e.out, "🏁 [Executor] Lista '%s' completada\n", playlist.Name)
+			e.printReport()
+			e.halt()
+			return false
+		}
+		nextEntry = 0
+		round++
+	}
+
+	e.publish(eventbus.ScenarioCompleted, len(scenario.Steps)-1, "", EndNext)
+
+	// Transición: el vehículo sigue como quedó salvo la velocidad indicada
+	transition := current.Transition
+	if transition.Message != "" {
+		fmt.Fprintf(e.out, "   📢 %s\n", transition.Message)
+	}
+	if transition.Speed != nil {
+		e.speedController.SetSpeed(*transition.Speed)
+		fmt.Fprintf(e.out, "   🚗 Velocidad de transición: %.1f km/h\n", *transition.Speed)
+	}
+	if transition.Pause > 0 {
+		fmt.Fprintf(e.out, "   ⏸️  Transición de %.0fs\n", float64(transition.Pause))
+		if !e.waitUntil(e.Elapsed()+float64(transition.Pause), e.generation()) {
+			// Detenido, o un Seek volvió a los pasos del escenario que terminó
+			return e.IsRunning()
+		}
+	}
+
+	next := playlist.Scenario(nextEntry)
+	e.takeControl(scenario, false)
+	e.mu.Lock()
+	e.entry = nextEntry
+	e.entryRepeat = 1
+	e.playlistLoop = round
 	e.mu.Unlock()
 
-	fmt.Println("🛑 [Executor] Escenario detenido")
+	fmt.Fprintf(e.out, "⏭️  [Executor] Sigue el escenario: %s (entrada %d/%d)\n", next.Name, nextEntry+1, len(playlist.Entries))
+	e.begin(next, 1)
+	return true
+}
+
+// loadNext carga el escenario de next
+func (e *Executor) loadNext(id string) (*Scenario, error) {
+	e.mu.RLock()
+	loader := e.loader
+	e.mu.RUnlock()
+
+	if loader != nil {
+		return loader.Load(id)
+	}
+	return NewRepository(DefaultScenariosDir, DefaultPlaylistsDir).Load(id)
+}
+
+// publish publica el avance del escenario en el bus
+func (e *Executor) publish(eventType string, step int, action string, onEnd string) {
+	e.mu.RLock()
+	data := eventbus.ScenarioEventData{
+		EventType:  eventType,
+		Name:       e.scenario.Name,
+		Step:       step,
+		TotalSteps: len(e.scenario.Steps),
+		Action:     action,
+		ElapsedSec: e.elapsedLocked(),
+		Loop:       e.loop,
+		OnEnd:      onEnd,
+		Timestamp:  clock.Now(),
+	}
+	if onEnd == EndNext {
+		data.Next = e.scenario.Next
+	}
+	if e.playlist != nil {
+		data.Playlist = e.playlist.Name
+		data.Entry = e.entry + 1
+		data.TotalEntries = len(e.playlist.Entries)
+		if onEnd == EndNext {
+			next := (e.entry + 1) % len(e.playlist.Entries)
+			data.Next = e.playlist.Scenario(next).Name
+		}
+	}
+	for _, result := range e.results {
+		if result.Passed {
+			data.Passed++
+		} else {
+			data.Failed++
+		}
+	}
+	e.mu.RUnlock()
+
+	e.bus.Publish(eventbus.Event{
+		Type:      eventbus.EventScenario,
+		Timestamp: data.Timestamp,
+		Data:      data,
+	})
 }
 
 // Pause pausa la ejecución
 func (e *Executor) Pause() {
 	e.mu.Lock()
+	e.elapsedBase = e.elapsedLocked()
 	e.paused = true
 	e.mu.Unlock()
 
-	fmt.Println("⏸️  [Executor] Escenario pausado")
+	fmt.Fprintln(e.out, "⏸️  [Executor] Escenario pausado")
 }
 
 // Resume reanuda la ejecución
 func (e *Executor) Resume() {
 	e.mu.Lock()
+	if e.paused {
+		e.resumedAt = clock.Now()
+	}
 	e.paused = false
 	e.mu.Unlock()
 
-	fmt.Println("▶️  [Executor] Escenario reanudado")
+	fmt.Fprintln(e.out, "▶️  [Executor] Escenario reanudado")
+}
+
+// elapsedLocked retorna los segundos de escenario transcurridos; no avanza en
+// pausa y corre a la velocidad rate (llamar con mu tomado)
+func (e *Executor) elapsedLocked() float64 {
+	if !e.running || e.paused {
+		return e.elapsedBase
+	}
+	return e.elapsedBase + clock.Since(e.resumedAt).Seconds()*e.rate
+}
+
+// Elapsed retorna los segundos de escenario transcurridos en la vuelta actual
+func (e *Executor) Elapsed() float64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.elapsedLocked()
+}
+
+// isStepping retorna true si StepNext ejecuta un paso con el escenario en pausa
+func (e *Executor) isStepping() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.paused && e.stepOnce
+}
+
+// generation retorna el contador de Seek (una espera termina si cambia)
+func (e *Executor) generation() uint64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.seekGen
+}
+
+// waitUntil espera hasta el segundo target del escenario, en tramos cortos
+// para seguir pausas, Seek y cambios de rate. Retorna false si el escenario se
+// detuvo o hubo un Seek; con StepNext en pausa retorna true de inmediato.
+func (e *Executor) waitUntil(target float64, gen uint64) bool {
+	for {
+		e.mu.RLock()
+		running := e.running
+		paused := e.paused
+		stepping := e.paused && e.stepOnce
+		current := e.seekGen
+		elapsed := e.elapsedLocked()
+		rate := e.rate
+		e.mu.RUnlock()
+
+		if !running || current != gen {
+			return false
+		}
+		if stepping || elapsed >= target {
+			return true
+		}
+		if paused {
+			clock.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		wait := time.Duration((target - elapsed) / rate * float64(time.Second))
+		if wait > 100*time.Millisecond {
+			wait = 100 * time.Millisecond
+		}
+		if wait < time.Millisecond {
+			wait = time.Millisecond
+		}
+		clock.Sleep(wait)
+	}
 }
 
 // IsRunning retorna si está corriendo
@@ -95,51 +577,79 @@ func (e *Executor) execute() {
 	for e.IsRunning() {
 		e.mu.RLock()
 		paused := e.paused
+		stepping := e.stepOnce
 		currentStep := e.currentStepIndex
+		timeShift := e.timeShift
+		scenario := e.scenario
+		gen := e.seekGen
 		e.mu.RUnlock()
 
-		if paused {
-			time.Sleep(100 * time.Millisecond)
+		if paused && !stepping {
+			clock.Sleep(100 * time.Millisecond)
 			continue
 		}
 
-		// Verificar si hay más pasos
-		if currentStep >= len(e.scenario.Steps) {
-			// Escenario completado
-			fmt.Printf("✅ [Executor] Escenario '%s' completado\n", e.scenario.Name)
-			e.Stop()
-			break
+		// Verificar si hay más pasos (al terminar se aplica on_end)
+		if currentStep >= len(scenario.Steps) {
+			if !e.finish() {
+				break
+			}
+			continue
 		}
 
 		// Obtener siguiente paso
-		step := e.scenario.Steps[currentStep]
+		step := scenario.Steps[currentStep]
 
-		// Esperar hasta el tiempo del paso
-		elapsed := time.Since(e.startTime).Seconds()
-		if elapsed < step.Time {
-			sleepDuration := time.Duration((step.Time - elapsed) * float64(time.Second))
-			time.Sleep(sleepDuration)
+		// Esperar hasta el tiempo del paso (un Seek vuelve a empezar la vuelta del bucle)
+		if !e.waitUntil(step.Time-timeShift, gen) {
+			continue
 		}
 
-		// Ejecutar paso
-		e.executeStep(step)
+		// Ejecutar paso (los pasos con when esperan antes su condición, salvo con StepNext)
+		run := true
+		if step.When != nil && !stepping {
+			run = e.awaitTrigger(step, currentStep, step.Time-timeShift, gen)
+		}
+		if run && e.generation() == gen {
+			e.publish(eventbus.ScenarioStep, currentStep, step.Action, "")
+			e.executeStep(step)
+		}
 
-		// Avanzar al siguiente paso
+		// Avanzar al siguiente paso (salvo que un Seek ya haya elegido otro)
 		e.mu.Lock()
-		e.currentStepIndex++
+		if e.seekGen == gen {
+			if e.jumpTo >= 0 {
+				e.currentStepIndex = e.jumpTo
+				e.jumpTo = -1
+			} else {
+				e.currentStepIndex++
+			}
+		}
+		if stepping {
+			e.stepOnce = false
+		}
+		aborted := e.aborted
 		e.mu.Unlock()
+
+		if aborted {
+			fmt.Fprintf(e.out, "❌ [Executor] Escenario '%s' abortado en el paso %d\n", scenario.Name, currentStep)
+			e.publish(eventbus.ScenarioAborted, currentStep, step.Action, "")
+			e.printReport()
+			e.halt()
+			break
+		}
 	}
 }
 
 // executeStep ejecuta un paso individual
 func (e *Executor) executeStep(step ScenarioStep) {
-	elapsed := time.Since(e.startTime).Seconds()
+	elapsed := e.Elapsed()
 
-	fmt.Printf("🎬 [Executor] [%.1fs] Acción: %s", elapsed, step.Action)
+	fmt.Fprintf(e.out, "🎬 [Executor] [%.1fs] Acción: %s", elapsed, step.Action)
 	if step.Value != nil {
-		fmt.Printf(" (valor: %v)", step.Value)
+		fmt.Fprintf(e.out, " (valor: %v)", step.Value)
 	}
-	fmt.Println()
+	fmt.Fprintln(e.out)
 
 	switch step.Action {
 	case ActionSetSpeed:
@@ -163,45 +673,324 @@ func (e *Executor) executeStep(step ScenarioStep) {
 	case ActionResume:
 		e.Resume()
 
+	case ActionDoorOpen, ActionDoorClose, ActionDoorHold, ActionDoorObstruct:
+		e.handleDoorCommand(step)
+
+	case ActionInjectFault:
+		e.handleInjectFault(step)
+
+	case ActionClearFaults:
+		e.handleClearFaults(step)
+
+	case ActionBoard, ActionAlight:
+		e.handlePassengers(step)
+
+	case ActionGPSFixLost, ActionGPSFixRestore:
+		e.handleGPSFix(step)
+
+	case ActionJumpToStop:
+		e.handleJumpToStop(step)
+
+	case ActionSetPosition:
+		e.handleSetPosition(step)
+
+	case ActionExpect, ActionAssert:
+		e.handleExpect(step)
+
+	case ActionLoopCheck:
+		e.handleLoopCheck(step)
+
 	default:
-		fmt.Printf("⚠️  [Executor] Acción desconocida: %s\n", step.Action)
+		fmt.Fprintf(e.out, "⚠️  [Executor] Acción desconocida: %s\n", step.Action)
 	}
 }
 
 // handleSetSpeed cambia la velocidad del GPS
 func (e *Executor) handleSetSpeed(step ScenarioStep) {
-	var speed float64
-
-	// Convertir valor a float64
-	switch v := step.Value.(type) {
-	case float64:
-		speed = v
-	case int:
-		speed = float64(v)
-	default:
-		fmt.Printf("⚠️  [Executor] Valor inválido para set_speed: %v\n", step.Value)
+	speed, err := ParseNumber(step.Value)
+	if err != nil {
+		fmt.Fprintf(e.out, "⚠️  [Executor] Valor inválido para set_speed: %v\n", step.Value)
 		return
 	}
 
 	e.speedController.SetSpeed(speed) // ← Usa la interfaz
-	fmt.Printf("   🚗 Velocidad establecida: %.1f km/h\n", speed)
+	fmt.Fprintf(e.out, "   🚗 Velocidad establecida: %.1f km/h\n", speed)
 }
 
-// handleWaitDoorOpen espera a que se abra la puerta
-func (e *Executor) handleWaitDoorOpen(_ ScenarioStep) {
-	fmt.Println("   🚪 Esperando apertura de puerta...")
+// handleDoorCommand envía una orden al actuador de la puerta
+func (e *Executor) handleDoorCommand(step ScenarioStep) {
+	e.mu.RLock()
+	doorController := e.doorController
+	e.mu.RUnlock()
+
+	if doorController == nil {
+		fmt.Fprintf(e.out, "⚠️  [Executor] Sin actuador de puerta para %s\n", step.Action)
+		return
+	}
+
+	switch step.Action {
+	case ActionDoorOpen:
+		doorController.OpenDoor()
+		fmt.Fprintln(e.out, "   🚪 Abriendo puerta")
+	case ActionDoorClose:
+		doorController.CloseDoor()
+		fmt.Fprintln(e.out, "   🚪 Cerrando puerta")
+	case ActionDoorHold:
+		doorController.HoldDoor()
+		fmt.Fprintln(e.out, "   ✋ Puerta detenida")
+	case ActionDoorObstruct:
+		doorController.ObstructDoor()
+		fmt.Fprintln(e.out, "   🚧 Obstáculo en la puerta")
+	}
+}
+
+// handleInjectFault inyecta una falla en un sensor
+func (e *Executor) handleInjectFault(step ScenarioStep) {
+	e.mu.RLock()
+	faultController := e.faultController
+	e.mu.RUnlock()
+
+	if faultController == nil {
+		fmt.Fprintln(e.out, "⚠️  [Executor] Sin inyector de fallas para inject_fault")
+		return
+	}
+
+	fault, err := ParseFault(step.Value)
+	if err != nil {
+		fmt.Fprintf(e.out, "⚠️  [Executor] %v\n", err)
+		return
+	}
+
+	if err := faultController.InjectFault(fault); err != nil {
+		fmt.Fprintf(e.out, "⚠️  [Executor] Falla rechazada: %v\n", err)
+		return
+	}
+	fmt.Fprintf(e.out, "   💥 Falla inyectada: %s/%s\n", fault.Sensor, fault.Type)
+}
+
+// handleClearFaults elimina todas las fallas inyectadas
+func (e *Executor) handleClearFaults(_ ScenarioStep) {
+	e.mu.RLock()
+	faultController := e.faultController
+	e.mu.RUnlock()
+
+	if faultController == nil {
+		fmt.Fprintln(e.out, "⚠️  [Executor] Sin inyector de fallas para clear_faults")
+		return
+	}
+
+	faultController.ClearFaults()
+}
+
+// handlePassengers ordena que suban o bajen N pasajeros
+func (e *Executor) handlePassengers(step ScenarioStep) {
+	e.mu.RLock()
+	passengers := e.passengers
+	e.mu.RUnlock()
+
+	if passengers == nil {
+		fmt.Fprintf(e.out, "⚠️  [Executor] Sin simulador de pasajeros para %s\n", step.Action)
+		return
+	}
+
+	params, err := ParsePassengers(step.Value)
+	if err != nil {
+		fmt.Fprintf(e.out, "⚠️  [Executor] %v\n", err)
+		return
+	}
+
+	if step.Action == ActionBoard {
+		passengers.QueuePassengers(params.Count, 0)
+		fmt.Fprintf(e.out, "   🧍 Suben %d pasajeros\n", params.Count)
+	} else {
+		passengers.QueuePassengers(0, params.Count)
+		fmt.Fprintf(e.out, "   🚶 Bajan %d pasajeros\n", params.Count)
+	}
+}
+
+// handleGPSFix pierde o recupera el fix del GPS
+func (e *Executor) handleGPSFix(step ScenarioStep) {
+	e.mu.RLock()
+	position := e.position
+	e.mu.RUnlock()
+
+	if position == nil {
+		fmt.Fprintf(e.out, "⚠️  [Executor] Sin GPS para %s\n", step.Action)
+		return
+	}
+
+	lost := step.Action == ActionGPSFixLost
+	position.SetFixLost(lost)
+	if lost {
+		fmt.Fprintln(e.out, "   📡 Fix GPS perdido")
+	} else {
+		fmt.Fprintln(e.out, "   📡 Fix GPS recuperado")
+	}
+}
+
+// handleJumpToStop mueve el vehículo a una parada de la ruta
+func (e *Executor) handleJumpToStop(step ScenarioStep) {
+	e.mu.RLock()
+	position := e.position
+	e.mu.RUnlock()
+
+	if position == nil {
+		fmt.Fprintln(e.out, "⚠️  [Executor] Sin GPS para jump_to_stop")
+		return
+	}
+
+	ref, err := ParseStopRef(step.Value)
+	if err != nil {
+		fmt.Fprintf(e.out, "⚠️  [Executor] %v\n", err)
+		return
+	}
+
+	stop := position.GetRoute().FindStop(ref)
+	if stop == nil {
+		fmt.Fprintf(e.out, "⚠️  [Executor] Parada no encontrada en la ruta: %v\n", step.Value)
+		return
+	}
+
+	position.SetProgress(stop.Position)
+	fmt.Fprintf(e.out, "   📍 Vehículo en parada %s\n", stop.Name)
+}
+
+// handleSetPosition mueve el vehículo a un punto de la ruta
+func (e *Executor) handleSetPosition(step ScenarioStep) {
+	e.mu.RLock()
+	position := e.position
+	e.mu.RUnlock()
+
+	if position == nil {
+		fmt.Fprintln(e.out, "⚠️  [Executor] Sin GPS para set_position")
+		return
+	}
+
+	params, err := ParsePosition(step.Value)
+	if err != nil {
+		fmt.Fprintf(e.out, "⚠️  [Executor] %v\n", err)
+		return
+	}
+
+	position.SetProgress(params.Progress)
+	fmt.Fprintf(e.out, "   📍 Vehículo en progreso %.2f\n", params.Progress)
+}
+
+// awaitTrigger espera la condición when de un paso desde su tiempo planificado
+// y corre los pasos siguientes lo que duró la espera. Retorna false si el paso
+// no debe ejecutarse (timeout con skip/fail/abort o escenario detenido).
+func (e *Executor) awaitTrigger(step ScenarioStep, stepIndex int, planned float64, gen uint64) bool {
+	trigger := *step.When
+	eventType := trigger.eventType()
+
+	e.mu.RLock()
+	recorder := e.recorder
+	e.mu.RUnlock()
+
+	seen := 0
+	if eventType != "" && recorder != nil {
+		seen = recorder.count(eventType)
+	}
+
+	fmt.Fprintf(e.out, "⏳ [Executor] Paso %d (%s) espera: %s (máx %.0fs)\n",
+		stepIndex, step.Action, trigger.describe(), trigger.timeout().Seconds())
+
+	deadline := e.Elapsed() + trigger.timeout().Seconds()
+	fired := false
+	for e.IsRunning() && e.generation() == gen && !fired {
+		if eventType != "" {
+			if recorder != nil {
+				var events []eventbus.Event
+				events, seen = recorder.since(eventType, seen)
+				for _, event := range events {
+					if trigger.matches(event.Data) {
+						fired = true
+						break
+					}
+				}
+			}
+		} else if actual, err := e.fieldValue(trigger.Field); err == nil {
+			fired, _ = trigger.condition().check(actual)
+		}
+
+		if fired || e.Elapsed() >= deadline {
+			break
+		}
+		clock.Sleep(100 * time.Millisecond)
+	}
+
+	if !e.IsRunning() || e.generation() != gen {
+		return false
+	}
+
+	// Los pasos siguientes conservan su separación respecto de este
+	elapsed := e.Elapsed()
+	if delay := elapsed - planned; delay > 0 {
+		e.mu.Lock()
+		e.timeShift -= delay
+		e.mu.Unlock()
+	}
+
+	if fired {
+		fmt.Fprintf(e.out, "   ⚡ Condición cumplida a los %.1fs\n", elapsed)
+		return true
+	}
+
+	action := trigger.onTimeout()
+	fmt.Fprintf(e.out, "   ⏰ Timeout esperando %s (%s)\n", trigger.describe(), action)
+
+	switch action {
+	case OnTimeoutRun:
+		return true
+	case OnTimeoutFail, OnTimeoutAbort:
+		e.mu.Lock()
+		e.results = append(e.results, AssertionResult{
+			Step:     stepIndex,
+			Time:     elapsed,
+			Action:   "when",
+			Field:    trigger.describe(),
+			Expected: fmt.Sprintf("antes de %.0fs", trigger.timeout().Seconds()),
+			Actual:   "timeout",
+			Message:  trigger.Message,
+		})
+		if action == OnTimeoutAbort {
+			e.aborted = true
+		}
+		e.mu.Unlock()
+	}
+	return false
+}
+
+// handleWaitDoorOpen abre la puerta (si hay actuador) y espera a que se abra
+func (e *Executor) handleWaitDoorOpen(step ScenarioStep) {
+	params, err := ParseWaitDoor(step.Value)
+	if err != nil {
+		fmt.Fprintf(e.out, "⚠️  [Executor] %v\n", err)
+		return
+	}
+
+	e.mu.RLock()
+	doorController := e.doorController
+	e.mu.RUnlock()
+
+	if doorController != nil {
+		doorController.OpenDoor()
+	}
+
+	fmt.Fprintln(e.out, "   🚪 Esperando apertura de puerta...")
 
 	// Suscribirse a eventos de puerta
 	doorChannel := e.bus.Subscribe(eventbus.EventDoor)
+	defer e.bus.Unsubscribe(eventbus.EventDoor, doorChannel)
 
 	// Esperar hasta que la puerta se abra
-	timeout := time.After(30 * time.Second)
+	timeout := clock.After(params.timeout())
 	for {
 		select {
 		case event, ok := <-doorChannel:
 			if !ok {
 				// Channel cerrado, sistema detenido
-				fmt.Println("   ⚠️  Channel de puerta cerrado")
+				fmt.Fprintln(e.out, "   ⚠️  Channel de puerta cerrado")
 				return
 			}
 
@@ -213,34 +1002,49 @@ func (e *Executor) handleWaitDoorOpen(_ ScenarioStep) {
 			// Type assertion segura
 			data, ok := event.Data.(eventbus.DoorData)
 			if !ok {
-				fmt.Println("   ⚠️  Tipo de dato incorrecto en evento de puerta")
+				fmt.Fprintln(e.out, "   ⚠️  Tipo de dato incorrecto en evento de puerta")
 				continue
 			}
 
 			if data.IsOpen {
-				fmt.Println("   ✅ Puerta abierta")
+				fmt.Fprintln(e.out, "   ✅ Puerta abierta")
 				return
 			}
 		case <-timeout:
-			fmt.Println("   ⏰ Timeout esperando apertura de puerta")
+			fmt.Fprintln(e.out, "   ⏰ Timeout esperando apertura de puerta")
 			return
 		}
 	}
 }
 
-// handleWaitDoorClose espera a que se cierre la puerta
-func (e *Executor) handleWaitDoorClose(_ ScenarioStep) {
-	fmt.Println("   🚪 Esperando cierre de puerta...")
+// handleWaitDoorClose cierra la puerta (si hay actuador) y espera a que se cierre
+func (e *Executor) handleWaitDoorClose(step ScenarioStep) {
+	params, err := ParseWaitDoor(step.Value)
+	if err != nil {
+		fmt.Fprintf(e.out, "⚠️  [Executor] %v\n", err)
+		return
+	}
+
+	e.mu.RLock()
+	doorController := e.doorController
+	e.mu.RUnlock()
+
+	if doorController != nil {
+		doorController.CloseDoor()
+	}
+
+	fmt.Fprintln(e.out, "   🚪 Esperando cierre de puerta...")
 
 	doorChannel := e.bus.Subscribe(eventbus.EventDoor)
+	defer e.bus.Unsubscribe(eventbus.EventDoor, doorChannel)
 
-	timeout := time.After(30 * time.Second)
+	timeout := clock.After(params.timeout())
 	for {
 		select {
 		case event, ok := <-doorChannel:
 			if !ok {
 				// Channel cerrado, sistema detenido
-				fmt.Println("   ⚠️  Channel de puerta cerrado")
+				fmt.Fprintln(e.out, "   ⚠️  Channel de puerta cerrado")
 				return
 			}
 
@@ -252,48 +1056,211 @@ func (e *Executor) handleWaitDoorClose(_ ScenarioStep) {
 			// Type assertion segura
 			data, ok := event.Data.(eventbus.DoorData)
 			if !ok {
-				fmt.Println("   ⚠️  Tipo de dato incorrecto en evento de puerta")
+				fmt.Fprintln(e.out, "   ⚠️  Tipo de dato incorrecto en evento de puerta")
 				continue
 			}
 
 			if !data.IsOpen {
-				fmt.Println("   ✅ Puerta cerrada")
+				fmt.Fprintln(e.out, "   ✅ Puerta cerrada")
 				return
 			}
 		case <-timeout:
-			fmt.Println("   ⏰ Timeout esperando cierre de puerta")
+			fmt.Fprintln(e.out, "   ⏰ Timeout esperando cierre de puerta")
 			return
 		}
 	}
 }
 
+// handleExpect verifica un campo del estado o del último evento, reintentando
+// durante la ventana within; un assert fallido aborta el escenario
+func (e *Executor) handleExpect(step ScenarioStep) {
+	params, err := ParseExpect(step.Value)
+	if err != nil {
+		fmt.Fprintf(e.out, "⚠️  [Executor] %v\n", err)
+		return
+	}
+
+	e.mu.RLock()
+	stepIndex := e.currentStepIndex
+	gen := e.seekGen
+	e.mu.RUnlock()
+
+	// within cuenta en tiempo de escenario (se congela en pausa)
+	deadline := e.Elapsed() + params.Within
+	var actual interface{}
+	var passed bool
+	for {
+		actual, err = e.fieldValue(params.Field)
+		if err == nil {
+			passed, err = params.check(actual)
+		}
+		if passed || e.Elapsed() >= deadline || !e.IsRunning() || e.generation() != gen || e.isStepping() {
+			break
+		}
+		clock.Sleep(100 * time.Millisecond)
+	}
+
+	result := AssertionResult{
+		Step:     stepIndex,
+		Time:     e.Elapsed(),
+		Action:   step.Action,
+		Field:    params.Field,
+		Expected: params.describe(),
+		Actual:   fmt.Sprint(actual),
+		Passed:   passed,
+		Message:  params.Message,
+	}
+	if err != nil {
+		result.Actual = err.Error()
+	}
+
+	e.mu.Lock()
+	e.results = append(e.results, result)
+	if !passed && step.Action == ActionAssert {
+		e.aborted = true
+	}
+	e.mu.Unlock()
+
+	if passed {
+		fmt.Fprintf(e.out, "   ✅ %s = %s\n", result.Field, result.Actual)
+	} else {
+		fmt.Fprintf(e.out, "   ❌ %s: esperado %s, actual %s\n", result.Field, result.Expected, result.Actual)
+	}
+}
+
+// handleLoopCheck sale del loop (salta al paso siguiente al loop) si se cumple
+// su condición; los pasos posteriores se adelantan lo que duraban las vueltas omitidas
+func (e *Executor) handleLoopCheck(step ScenarioStep) {
+	check, err := ParseLoopCheck(step.Value)
+	if err != nil {
+		fmt.Fprintf(e.out, "⚠️  [Executor] %v\n", err)
+		return
+	}
+
+	actual, err := e.fieldValue(check.Until.Field)
+	if err != nil {
+		fmt.Fprintf(e.out, "   🔁 %s: %v, continúa el loop\n", check.Until.Field, err)
+		return
+	}
+	if done, _ := check.Until.check(actual); !done {
+		fmt.Fprintf(e.out, "   🔁 %s = %v, continúa el loop\n", check.Until.Field, actual)
+		return
+	}
+
+	e.mu.Lock()
+	e.jumpTo = check.Jump
+	if check.Jump < len(e.scenario.Steps) {
+		e.timeShift += e.scenario.Steps[check.Jump].Time - step.Time
+	}
+	e.mu.Unlock()
+
+	fmt.Fprintf(e.out, "   🔁 %s = %v, fin del loop\n", check.Until.Field, actual)
+}
+
+// fieldValue lee el valor actual de un campo verificable
+func (e *Executor) fieldValue(field string) (interface{}, error) {
+	if field == progressField {
+		e.mu.RLock()
+		position := e.position
+		e.mu.RUnlock()
+
+		if position == nil {
+			return nil, fmt.Errorf("sin GPS para leer el progreso")
+		}
+		return position.GetProgress(), nil
+	}
+
+	if read, ok := stateFields[field]; ok {
+		e.mu.RLock()
+		inspector := e.inspector
+		e.mu.RUnlock()
+
+		if inspector == nil {
+			return nil, fmt.Errorf("sin inspector de estado")
+		}
+
+		snapshot := stateSnapshot{
+			vehicle: inspector.GetCurrentState(),
+			door:    inspector.GetDoorState(),
+		}
+		snapshot.onboard, snapshot.entries, snapshot.exits = inspector.GetPassengerStats()
+		return read(snapshot), nil
+	}
+
+	if e.recorder == nil {
+		return nil, fmt.Errorf("sin registro de eventos")
+	}
+	return e.recorder.value(field)
+}
+
+// Report retorna el resultado de las verificaciones expect/assert
+func (e *Executor) Report() Report {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	name := e.scenario.Name
+	if e.playlist != nil {
+		name = e.playlist.Name
+	}
+	report := Report{
+		Scenario: name,
+		Results:  append([]AssertionResult(nil), e.results...),
+		Aborted:  e.aborted,
+	}
+	for _, result := range report.Results {
+		if result.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+	}
+	return report
+}
+
+// printReport imprime el resumen de las verificaciones (si el escenario las tiene)
+func (e *Executor) printReport() {
+	report := e.Report()
+	if !e.scenario.HasAssertions() && len(report.Results) == 0 {
+		return
+	}
+
+	icon := "✅"
+	if !report.Success() {
+		icon = "❌"
+	}
+	fmt.Fprintf(e.out, "%s [Executor] Verificaciones: %d OK, %d fallidas\n", icon, report.Passed, report.Failed)
+
+	for _, failure := range report.Failures() {
+		fmt.Fprintf(e.out, "   ❌ Paso %d (%s, %.1fs) %s: esperado %s, actual %s",
+			failure.Step, failure.Action, failure.Time, failure.Field, failure.Expected, failure.Actual)
+		if failure.Message != "" {
+			fmt.Fprintf(e.out, " — %s", failure.Message)
+		}
+		fmt.Fprintln(e.out)
+	}
+}
+
 // handleWait espera N segundos
 func (e *Executor) handleWait(step ScenarioStep) {
-	var seconds float64
-
-	switch v := step.Value.(type) {
-	case float64:
-		seconds = v
-	case int:
-		seconds = float64(v)
-	default:
-		fmt.Printf("⚠️  [Executor] Valor inválido para wait: %v\n", step.Value)
+	seconds, err := ParseNumber(step.Value)
+	if err != nil {
+		fmt.Fprintf(e.out, "⚠️  [Executor] Valor inválido para wait: %v\n", step.Value)
 		return
 	}
 
-	fmt.Printf("   ⏱️  Esperando %.1f segundos...\n", seconds)
-	time.Sleep(time.Duration(seconds * float64(time.Second)))
+	fmt.Fprintf(e.out, "   ⏱️  Esperando %.1f segundos...\n", seconds)
+	e.waitUntil(e.Elapsed()+seconds, e.generation())
 }
 
 // handleLog imprime un mensaje
 func (e *Executor) handleLog(step ScenarioStep) {
 	message, ok := step.Value.(string)
 	if !ok {
-		fmt.Printf("⚠️  [Executor] Valor inválido para log: %v\n", step.Value)
+		fmt.Fprintf(e.out, "⚠️  [Executor] Valor inválido para log: %v\n", step.Value)
 		return
 	}
 
-	fmt.Printf("   📢 %s\n", message)
+	fmt.Fprintf(e.out, "   📢 %s\n", message)
 }
 
 // GetProgress retorna el progreso del escenario (0.0 a 1.0)
@@ -305,7 +1272,7 @@ func (e *Executor) GetProgress() float64 {
 		return 0.0
 	}
 
-	elapsed := time.Since(e.startTime).Seconds()
+	elapsed := e.elapsedLocked()
 	total := e.scenario.GetDuration().Seconds()
 
 	progress := elapsed / total
@@ -315,6 +1282,34 @@ func (e *Executor) GetProgress() float64 {
 	return progress
 }
 
+// GetLoop retorna la vuelta actual del escenario (1 = primera)
+func (e *Executor) GetLoop() int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.loop
+}
+
+// GetPlaylist retorna la lista en curso (nil si se corre un escenario suelto)
+func (e *Executor) GetPlaylist() *Playlist {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.playlist
+}
+
+// GetPlaylistEntry retorna la entrada (0 = primera) y la repetición actuales de la lista
+func (e *Executor) GetPlaylistEntry() (entry, repeat int) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.entry, e.entryRepeat
+}
+
+// GetScenario retorna el escenario en curso (cambia con on_end next)
+func (e *Executor) GetScenario() *Scenario {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.scenario
+}
+
 // GetCurrentStep retorna el paso actual
 func (e *Executor) GetCurrentStep() int {
 	e.mu.RLock()
@@ -0,0 +1,189 @@
+package scenario
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+)
+
+// fakeSpeed guarda la última velocidad ordenada
+type fakeSpeed struct {
+	speed float64
+	calls int
+}
+
+func (f *fakeSpeed) SetSpeed(speed float64) {
+	f.speed = speed
+	f.calls++
+}
+
+// fakeLoader retorna escenarios de un mapa por ID
+type fakeLoader map[string]*Scenario
+
+func (f fakeLoader) Load(id string) (*Scenario, error) {
+	if scenario, ok := f[id]; ok {
+		return scenario, nil
+	}
+	return nil, errors.New("no existe")
+}
+
+// logScenario escenario de un solo paso con el on_end indicado
+func logScenario(name, onEnd string) *Scenario {
+	return &Scenario{
+		Name:  name,
+		Steps: []ScenarioStep{{Time: 0, Action: ActionLog, Value: name}},
+		OnEnd: onEnd,
+	}
+}
+
+// scenarioEvents retorna los eventos de escenario pendientes sin bloquear
+func scenarioEvents(channel <-chan eventbus.Event) []eventbus.ScenarioEventData {
+	events := make([]eventbus.ScenarioEventData, 0)
+	for {
+		select {
+		case event := <-channel:
+			events = append(events, event.Data.(eventbus.ScenarioEventData))
+		default:
+			return events
+		}
+	}
+}
+
+func TestScenarioValidateOnEnd(t *testing.T) {
+	tests := []struct {
+		name     string
+		onEnd    string
+		next     string
+		maxLoops int
+		wantErr  bool
+	}{
+		{"vacío", "", "", 0, false},
+		{"hold", EndHold, "", 0, false},
+		{"stop", EndStop, "", 0, false},
+		{"loop con límite", EndLoop, "", 3, false},
+		{"next con escenario", EndNext, "parada_normal", 0, false},
+		{"next sin escenario", EndNext, "", 0, true},
+		{"on_end desconocido", "restart", "", 0, true},
+		{"max_loops negativo", EndLoop, "", -1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scenario := logScenario("prueba", tt.onEnd)
+			scenario.Next = tt.next
+			scenario.MaxLoops = tt.maxLoops
+
+			err := scenario.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExecutorFinish(t *testing.T) {
+	next := logScenario("siguiente", "")
+
+	tests := []struct {
+		name         string
+		onEnd        string
+		next         string
+		maxLoops     int
+		loop         int
+		autoLoop     bool
+		wantContinue bool
+		wantOnEnd    string
+		wantScenario string
+		wantLoop     int
+		wantStopped  bool
+	}{
+		{"hold se detiene", EndHold, "", 0, 1, false, false, EndHold, "prueba", 1, false},
+		{"vacío sin auto_loop", "", "", 0, 1, false, false, EndHold, "prueba", 1, false},
+		{"vacío con auto_loop", "", "", 0, 1, true, true, EndLoop, "prueba", 2, false},
+		{"hold ignora auto_loop", EndHold, "", 0, 1, true, false, EndHold, "prueba", 1, false},
+		{"stop detiene el vehículo", EndStop, "", 0, 1, false, false, EndStop, "prueba", 1, true},
+		{"loop empieza otra vuelta", EndLoop, "", 3, 2, false, true, EndLoop, "prueba", 3, false},
+		{"loop en la última vuelta", EndLoop, "", 3, 3, false, false, EndHold, "prueba", 3, false},
+		{"next carga el siguiente", EndNext, "siguiente", 0, 1, false, true, EndNext, "siguiente", 1, false},
+		{"next inexistente se detiene", EndNext, "otro", 0, 1, false, false, EndNext, "prueba", 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scenario := logScenario("prueba", tt.onEnd)
+			scenario.Next = tt.next
+			scenario.MaxLoops = tt.maxLoops
+
+			bus := eventbus.NewEventBus()
+			channel := bus.Subscribe(eventbus.EventScenario)
+			speed := &fakeSpeed{speed: 30}
+
+			executor := NewExecutor(scenario, speed, bus)
+			executor.SetScenarioLoader(fakeLoader{"siguiente": next})
+			executor.SetAutoLoop(tt.autoLoop)
+			executor.running = true
+			executor.loop = tt.loop
+			executor.currentStepIndex = len(scenario.Steps)
+
+			if got := executor.finish(); got != tt.wantContinue {
+				t.Errorf("finish = %v, want %v", got, tt.wantContinue)
+			}
+			if executor.IsRunning() != tt.wantContinue {
+				t.Errorf("running = %v, want %v", executor.IsRunning(), tt.wantContinue)
+			}
+			if name := executor.GetScenario().Name; name != tt.wantScenario {
+				t.Errorf("escenario = %s, want %s", name, tt.wantScenario)
+			}
+			if loop := executor.GetLoop(); loop != tt.wantLoop {
+				t.Errorf("vuelta = %d, want %d", loop, tt.wantLoop)
+			}
+			if stopped := speed.calls > 0 && speed.speed == 0; stopped != tt.wantStopped {
+				t.Errorf("vehículo detenido = %v, want %v", stopped, tt.wantStopped)
+			}
+
+			events := scenarioEvents(channel)
+			if len(events) == 0 || events[0].EventType != eventbus.ScenarioCompleted {
+				t.Fatalf("eventos = %+v, want COMPLETED primero", events)
+			}
+			if events[0].OnEnd != tt.wantOnEnd {
+				t.Errorf("on_end publicado = %s, want %s", events[0].OnEnd, tt.wantOnEnd)
+			}
+			if tt.wantOnEnd == EndNext && events[0].Next != tt.next {
+				t.Errorf("next publicado = %s, want %s", events[0].Next, tt.next)
+			}
+
+			// Si sigue, la nueva vuelta o el escenario siguiente publica STARTED
+			if tt.wantContinue {
+				if len(events) != 2 || events[1].EventType != eventbus.ScenarioStarted {
+					t.Fatalf("eventos = %+v, want COMPLETED y STARTED", events)
+				}
+				if events[1].Name != tt.wantScenario || events[1].Loop != tt.wantLoop {
+					t.Errorf("inicio = %s vuelta %d, want %s vuelta %d",
+						events[1].Name, events[1].Loop, tt.wantScenario, tt.wantLoop)
+				}
+			} else if len(events) != 1 {
+				t.Errorf("eventos = %d, want solo COMPLETED", len(events))
+			}
+		})
+	}
+}
+
+func TestExecutorStopPublishesOnce(t *testing.T) {
+	bus := eventbus.NewEventBus()
+	channel := bus.Subscribe(eventbus.EventScenario)
+
+	executor := NewExecutor(logScenario("prueba", ""), &fakeSpeed{}, bus)
+	executor.running = true
+
+	executor.Stop()
+	executor.Stop()
+
+	events := scenarioEvents(channel)
+	if len(events) != 1 || events[0].EventType != eventbus.ScenarioStopped {
+		t.Errorf("eventos = %+v, want un solo STOPPED", events)
+	}
+	if executor.IsRunning() {
+		t.Error("el ejecutor sigue corriendo tras Stop")
+	}
+}
@@ -0,0 +1,340 @@
+package scenario
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Límites de la expansión (evitan escenarios gigantes por error)
+const (
+	maxIterations    = 1000   // repeat y loop.max
+	maxExpandedSteps = 100000 // Pasos planos después de expandir
+)
+
+// Seconds duración en segundos; en YAML acepta un número o una duración ("5s", "1m30s")
+type Seconds float64
+
+// UnmarshalYAML implementa yaml.Unmarshaler
+func (s *Seconds) UnmarshalYAML(node *yaml.Node) error {
+	var number float64
+	if err := node.Decode(&number); err == nil {
+		*s = Seconds(number)
+		return nil
+	}
+
+	var text string
+	if err := node.Decode(&text); err != nil {
+		return err
+	}
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		return fmt.Errorf("duración inválida '%s': %w", text, err)
+	}
+	*s = Seconds(duration.Seconds())
+	return nil
+}
+
+// After retorna un desplazamiento relativo al paso anterior (para escenarios en Go)
+func After(seconds float64) *Seconds {
+	value := Seconds(seconds)
+	return &value
+}
+
+// LoopSpec repetición hasta que se cumple una condición (se evalúa antes de cada vuelta)
+type LoopSpec struct {
+	Until ExpectParams `yaml:"until"` // Misma sintaxis que expect (sin within)
+	Max   int          `yaml:"max"`   // Máximo de vueltas
+}
+
+// ChoiceSpec alternativa de un choose
+type ChoiceSpec struct {
+	Weight float64        `yaml:"weight"` // Peso relativo (0 = 1)
+	Block  string         `yaml:"block"`
+	Steps  []ScenarioStep `yaml:"steps"`
+}
+
+// LoopCheckParams parámetros de loop_check (paso generado por loop)
+type LoopCheckParams struct {
+	Until ExpectParams `yaml:"until"`
+	Jump  int          `yaml:"jump"` // Índice del primer paso después del loop
+}
+
+// IsControl retorna true si el paso es una construcción de control de flujo
+func (step ScenarioStep) IsControl() bool {
+	return step.Block != "" || len(step.Steps) > 0 || step.Repeat > 0 || step.Loop != nil || len(step.Choose) > 0
+}
+
+// Expand convierte bloques, repeat, loop, choose y tiempos relativos (after)
+// en la lista plana de pasos con tiempo absoluto que ejecuta el Executor.
+// Un escenario ya expandido no cambia. Si hay choose guarda los pasos
+// originales para que Redraw sortee de nuevo en cada vuelta.
+func (s *Scenario) Expand() error {
+	seed := s.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	x := &expander{
+		blocks: s.Blocks,
+		rng:    rand.New(rand.NewSource(seed)),
+		active: make(map[string]bool),
+	}
+	if _, err := x.expandSteps(s.Steps, 0, "paso"); err != nil {
+		return err
+	}
+
+	if x.drew && s.draws == nil {
+		s.draws = &redraw{source: s.Steps, rng: x.rng}
+	}
+	s.Steps = x.out
+	return nil
+}
+
+// redraw pasos sin expandir de un escenario con choose y el generador que
+// sigue sorteando (con seed, la secuencia de vueltas se repite)
+type redraw struct {
+	mu     sync.Mutex
+	source []ScenarioStep
+	rng    *rand.Rand
+}
+
+// Redraw retorna una copia del escenario expandida con un nuevo sorteo de sus
+// choose (el Executor la pide en cada vuelta de on_end loop y en cada
+// repetición de una lista). Sin choose retorna el mismo escenario.
+func (s *Scenario) Redraw() (*Scenario, error) {
+	if s.draws == nil {
+		return s, nil
+	}
+
+	s.draws.mu.Lock()
+	defer s.draws.mu.Unlock()
+
+	x := &expander{
+		blocks: s.Blocks,
+		rng:    s.draws.rng,
+		active: make(map[string]bool),
+	}
+	if _, err := x.expandSteps(s.draws.source, 0, "paso"); err != nil {
+		return nil, err
+	}
+
+	redrawn := *s
+	redrawn.Steps = x.out
+	if err := redrawn.Validate(); err != nil {
+		return nil, err
+	}
+	return &redrawn, nil
+}
+
+// expander arma la lista plana de pasos
+type expander struct {
+	blocks map[string][]ScenarioStep
+	rng    *rand.Rand
+	active map[string]bool // Bloques en expansión (detecta recursión)
+	out    []ScenarioStep
+	drew   bool // Hubo al menos un choose
+}
+
+// expandSteps agrega una secuencia de pasos; time es relativo a base y after
+// al paso anterior. Retorna el tiempo del último paso.
+func (x *expander) expandSteps(steps []ScenarioStep, base float64, path string) (float64, error) {
+	last := base
+
+	for i, step := range steps {
+		where := fmt.Sprintf("%s %d", path, i)
+
+		start := base + step.Time
+		if step.After != nil {
+			if step.Time != 0 {
+				return 0, fmt.Errorf("%s: usar time o after, no ambos", where)
+			}
+			if *step.After < 0 {
+				return 0, fmt.Errorf("%s: after no puede ser negativo", where)
+			}
+			start = last + float64(*step.After)
+		}
+
+		if !step.IsControl() {
+			if len(x.out) >= maxExpandedSteps {
+				return 0, fmt.Errorf("%s: el escenario supera %d pasos al expandirse", where, maxExpandedSteps)
+			}
+			flat := step
+			flat.Time = start
+			flat.After = nil
+			x.out = append(x.out, flat)
+			last = start
+			continue
+		}
+
+		end, err := x.expandControl(step, start, where)
+		if err != nil {
+			return 0, err
+		}
+		last = end
+	}
+
+	return last, nil
+}
+
+// expandControl expande repeat, loop y choose sobre un bloque o pasos anidados
+func (x *expander) expandControl(step ScenarioStep, start float64, where string) (float64, error) {
+	if step.Action != "" {
+		return 0, fmt.Errorf("%s: un paso de control no lleva action", where)
+	}
+	if step.When != nil {
+		return 0, fmt.Errorf("%s: when va en el primer paso del bloque, no en el paso de control", where)
+	}
+	if step.Repeat < 0 || step.Repeat > maxIterations {
+		return 0, fmt.Errorf("%s: repeat debe estar entre 0 y %d (0 = una vez)", where, maxIterations)
+	}
+	if step.Repeat > 0 && step.Loop != nil {
+		return 0, fmt.Errorf("%s: usar repeat o loop, no ambos", where)
+	}
+
+	// Una vuelta: elegir una alternativa (choose) o el bloque/pasos del paso
+	iteration := func(at float64) (float64, error) {
+		if len(step.Choose) > 0 {
+			if step.Block != "" || len(step.Steps) > 0 {
+				return 0, fmt.Errorf("%s: choose no se combina con block ni steps", where)
+			}
+			choice, err := x.choose(step.Choose, where)
+			if err != nil {
+				return 0, err
+			}
+			return x.expandBody(choice.Block, choice.Steps, at, where+" choose")
+		}
+		return x.expandBody(step.Block, step.Steps, at, where)
+	}
+
+	if step.Loop != nil {
+		return x.expandLoop(step, iteration, start, where)
+	}
+
+	times := step.Repeat
+	if times == 0 {
+		times = 1
+	}
+
+	last := start
+	for i := 0; i < times; i++ {
+		end, err := iteration(last)
+		if err != nil {
+			return 0, err
+		}
+		last = end
+	}
+	return last, nil
+}
+
+// expandLoop antepone a cada vuelta un loop_check que salta al final del loop
+// cuando se cumple la condición
+func (x *expander) expandLoop(step ScenarioStep, iteration func(at float64) (float64, error), start float64, where string) (float64, error) {
+	loop := step.Loop
+	if loop.Max <= 0 || loop.Max > maxIterations {
+		return 0, fmt.Errorf("%s: loop.max debe estar entre 1 y %d", where, maxIterations)
+	}
+	until, err := ParseExpect(loop.Until)
+	if err != nil {
+		return 0, fmt.Errorf("%s: loop.until: %w", where, err)
+	}
+
+	checks := make([]int, 0, loop.Max)
+	last := start
+	for i := 0; i < loop.Max; i++ {
+		checks = append(checks, len(x.out))
+		x.out = append(x.out, ScenarioStep{Time: last, Action: ActionLoopCheck, Line: step.Line})
+
+		end, err := iteration(last)
+		if err != nil {
+			return 0, err
+		}
+		last = end
+	}
+
+	// El salto apunta al primer paso después del loop
+	for _, index := range checks {
+		x.out[index].Value = LoopCheckParams{Until: until, Jump: len(x.out)}
+	}
+	return last, nil
+}
+
+// expandBody expande un bloque con nombre o una lista de pasos anidados
+func (x *expander) expandBody(block string, steps []ScenarioStep, start float64, where string) (float64, error) {
+	if block == "" {
+		if len(steps) == 0 {
+			return 0, fmt.Errorf("%s: se esperaba block o steps", where)
+		}
+		return x.expandSteps(steps, start, where+" >")
+	}
+	if len(steps) > 0 {
+		return 0, fmt.Errorf("%s: usar block o steps, no ambos", where)
+	}
+
+	body, ok := x.blocks[block]
+	if !ok {
+		return 0, fmt.Errorf("%s: bloque '%s' no definido", where, block)
+	}
+	if x.active[block] {
+		return 0, fmt.Errorf("%s: el bloque '%s' se incluye a sí mismo", where, block)
+	}
+
+	x.active[block] = true
+	defer delete(x.active, block)
+	return x.expandSteps(body, start, fmt.Sprintf("%s > bloque %s", where, block))
+}
+
+// choose elige una alternativa al azar según su peso
+func (x *expander) choose(choices []ChoiceSpec, where string) (ChoiceSpec, error) {
+	total := 0.0
+	for _, choice := range choices {
+		if choice.Weight < 0 {
+			return ChoiceSpec{}, fmt.Errorf("%s: los pesos de choose no pueden ser negativos", where)
+		}
+		total += choiceWeight(choice)
+	}
+
+	x.drew = true
+	target := x.rng.Float64() * total
+	for _, choice := range choices {
+		target -= choiceWeight(choice)
+		if target < 0 {
+			return choice, nil
+		}
+	}
+	return choices[len(choices)-1], nil
+}
+
+// choiceWeight retorna el peso de una alternativa (0 = 1)
+func choiceWeight(choice ChoiceSpec) float64 {
+	if choice.Weight == 0 {
+		return 1
+	}
+	return choice.Weight
+}
+
+// ParseLoopCheck convierte el valor de un paso loop_check
+func ParseLoopCheck(value interface{}) (LoopCheckParams, error) {
+	var params LoopCheckParams
+
+	switch v := value.(type) {
+	case LoopCheckParams:
+		params = v
+	case map[string]interface{}:
+		if err := decodeParams(v, &params); err != nil {
+			return LoopCheckParams{}, fmt.Errorf("loop_check inválido: %w", err)
+		}
+	default:
+		return LoopCheckParams{}, fmt.Errorf("se esperaba {until, jump}: %v", value)
+	}
+
+	until, err := ParseExpect(params.Until)
+	if err != nil {
+		return LoopCheckParams{}, err
+	}
+	params.Until = until
+	return params, nil
+}
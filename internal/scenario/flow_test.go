@@ -0,0 +1,262 @@
+package scenario
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// expandYAML parsea un escenario y lo expande
+func expandYAML(t *testing.T, source string) (*Scenario, error) {
+	t.Helper()
+
+	var scenario Scenario
+	if err := yaml.Unmarshal([]byte(source), &scenario); err != nil {
+		t.Fatalf("YAML inválido: %v", err)
+	}
+	return &scenario, scenario.Expand()
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		wantTimes   []float64
+		wantActions []string
+		wantJumps   map[int]int // Índice de cada loop_check → paso al que salta
+		wantErr     string
+	}{
+		{
+			name: "after relativo al paso anterior",
+			source: `
+steps:
+  - { time: 1, action: log, value: a }
+  - { after: 2, action: log, value: b }
+  - { after: 1m, action: log, value: c }
+`,
+			wantTimes:   []float64{1, 3, 63},
+			wantActions: []string{ActionLog, ActionLog, ActionLog},
+		},
+		{
+			name: "bloque repetido con tiempos relativos a cada vuelta",
+			source: `
+blocks:
+  tramo:
+    - { time: 0, action: set_speed, value: 20 }
+    - { time: 4, action: set_speed, value: 0 }
+steps:
+  - { time: 5, block: tramo, repeat: 2 }
+  - { after: 1, action: log, value: fin }
+`,
+			wantTimes:   []float64{5, 9, 9, 13, 14},
+			wantActions: []string{ActionSetSpeed, ActionSetSpeed, ActionSetSpeed, ActionSetSpeed, ActionLog},
+		},
+		{
+			name: "repeat 0 corre una vez",
+			source: `
+steps:
+  - repeat: 0
+    steps: [{ time: 2, action: log, value: a }]
+`,
+			wantTimes:   []float64{2},
+			wantActions: []string{ActionLog},
+		},
+		{
+			name: "loop antepone loop_check que salta después del loop",
+			source: `
+steps:
+  - loop: { until: { field: speed, equals: 0 }, max: 2 }
+    steps:
+      - { time: 0, action: set_speed, value: 10 }
+      - { time: 3, action: log, value: vuelta }
+  - { after: 1, action: log, value: fin }
+`,
+			wantTimes:   []float64{0, 0, 3, 3, 3, 6, 7},
+			wantActions: []string{ActionLoopCheck, ActionSetSpeed, ActionLog, ActionLoopCheck, ActionSetSpeed, ActionLog, ActionLog},
+			wantJumps:   map[int]int{0: 6, 3: 6},
+		},
+		{
+			name: "loops anidados saltan al final de su propio loop",
+			source: `
+steps:
+  - loop: { until: { field: onboard, min: 5 }, max: 2 }
+    steps:
+      - loop: { until: { field: speed, equals: 0 }, max: 1 }
+        steps: [{ time: 1, action: log, value: adentro }]
+`,
+			wantTimes:   []float64{0, 0, 1, 1, 1, 2},
+			wantActions: []string{ActionLoopCheck, ActionLoopCheck, ActionLog, ActionLoopCheck, ActionLoopCheck, ActionLog},
+			wantJumps:   map[int]int{0: 6, 1: 3, 3: 6, 4: 6},
+		},
+		{
+			name: "choose con una sola alternativa",
+			source: `
+steps:
+  - choose:
+      - weight: 2
+        steps: [{ time: 1, action: log, value: unica }]
+`,
+			wantTimes:   []float64{1},
+			wantActions: []string{ActionLog},
+		},
+		{
+			name: "bloque que se incluye a sí mismo",
+			source: `
+blocks:
+  a: [{ block: a }]
+steps:
+  - { block: a }
+`,
+			wantErr: "se incluye a sí mismo",
+		},
+		{
+			name: "recursión indirecta",
+			source: `
+blocks:
+  a: [{ block: b }]
+  b: [{ block: a }]
+steps:
+  - { block: a }
+`,
+			wantErr: "se incluye a sí mismo",
+		},
+		{
+			name: "el mismo bloque dos veces seguidas no es recursión",
+			source: `
+blocks:
+  a: [{ time: 0, action: log, value: a }]
+  b: [{ block: a }, { time: 1, block: a }]
+steps:
+  - { block: b }
+`,
+			wantTimes:   []float64{0, 1},
+			wantActions: []string{ActionLog, ActionLog},
+		},
+		{
+			name:    "bloque no definido",
+			source:  "steps: [{ block: nada }]",
+			wantErr: "no definido",
+		},
+		{
+			name:    "time y after a la vez",
+			source:  "steps: [{ time: 1, after: 1, action: log }]",
+			wantErr: "usar time o after",
+		},
+		{
+			name:    "repeat negativo",
+			source:  "steps: [{ repeat: -1, steps: [{ action: log }] }]",
+			wantErr: "repeat debe estar entre 0 y",
+		},
+		{
+			name:    "loop sin max",
+			source:  "steps: [{ loop: { until: { field: speed, equals: 0 } }, steps: [{ action: log }] }]",
+			wantErr: "loop.max",
+		},
+		{
+			name:    "paso de control con action",
+			source:  "steps: [{ action: log, repeat: 2, steps: [{ action: log }] }]",
+			wantErr: "no lleva action",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scenario, err := expandYAML(t, tt.source)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("se esperaba un error con '%s', llegó %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error inesperado: %v", err)
+			}
+
+			times := make([]float64, 0, len(scenario.Steps))
+			actions := make([]string, 0, len(scenario.Steps))
+			for _, step := range scenario.Steps {
+				times = append(times, step.Time)
+				actions = append(actions, step.Action)
+			}
+			if !reflect.DeepEqual(times, tt.wantTimes) {
+				t.Errorf("tiempos: got %v, want %v", times, tt.wantTimes)
+			}
+			if !reflect.DeepEqual(actions, tt.wantActions) {
+				t.Errorf("acciones: got %v, want %v", actions, tt.wantActions)
+			}
+
+			for index, jump := range tt.wantJumps {
+				params, err := ParseLoopCheck(scenario.Steps[index].Value)
+				if err != nil {
+					t.Fatalf("paso %d: %v", index, err)
+				}
+				if params.Jump != jump {
+					t.Errorf("paso %d: salta a %d, want %d", index, params.Jump, jump)
+				}
+			}
+		})
+	}
+}
+
+func TestExpandKeepsLines(t *testing.T) {
+	scenario, err := expandYAML(t, `
+blocks:
+  tramo:
+    - { time: 0, action: set_speed, value: 20 }
+steps:
+  - { time: 0, action: log, value: a }
+  - loop: { until: { field: speed, equals: 0 }, max: 1 }
+    block: tramo
+`)
+	if err != nil {
+		t.Fatalf("error inesperado: %v", err)
+	}
+
+	lines := make([]int, 0, len(scenario.Steps))
+	for _, step := range scenario.Steps {
+		lines = append(lines, step.Line)
+	}
+	// log (línea 6), loop_check en la línea del loop (7), paso del bloque (4)
+	if want := []int{6, 7, 4}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("líneas: got %v, want %v", lines, want)
+	}
+}
+
+func TestRedrawWithSeed(t *testing.T) {
+	source := `
+name: Sorteo
+seed: 42
+steps:
+  - choose:
+      - steps: [{ time: 0, action: log, value: a }]
+      - steps: [{ time: 0, action: log, value: b }]
+`
+	draws := func() []interface{} {
+		scenario, err := expandYAML(t, source)
+		if err != nil {
+			t.Fatalf("error inesperado: %v", err)
+		}
+		values := []interface{}{scenario.Steps[0].Value}
+		for i := 0; i < 10; i++ {
+			redrawn, err := scenario.Redraw()
+			if err != nil {
+				t.Fatalf("Redraw: %v", err)
+			}
+			values = append(values, redrawn.Steps[0].Value)
+		}
+		return values
+	}
+
+	first, second := draws(), draws()
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("con la misma seed los sorteos difieren: %v vs %v", first, second)
+	}
+	for _, value := range first[1:] {
+		if value != first[0] {
+			return
+		}
+	}
+	t.Errorf("Redraw no cambió la alternativa en 10 vueltas: %v", first)
+}
@@ -1,5 +1,7 @@
 package scenario
 
+import "fmt"
+
 // GetParadaNormal retorna el escenario "Parada Normal"
 func GetParadaNormal() *Scenario {
 	return &Scenario{
@@ -93,44 +95,50 @@ func GetParadaConSalidas() *Scenario {
 
 // GetCircuitoCompleto retorna un circuito con múltiples paradas
 func GetCircuitoCompleto() *Scenario {
-	return &Scenario{
+	scenario := &Scenario{
 		Name:        "Circuito Completo",
 		Description: "Recorrido completo con 3 paradas",
 		Duration:    180,
+		Blocks: map[string][]ScenarioStep{
+			// Frenar, abrir y cerrar la puerta y arrancar (22s)
+			"parada": {
+				{Time: 0, Action: ActionSetSpeed, Value: 10.0},
+				{After: After(3), Action: ActionSetSpeed, Value: 0.0},
+				{After: After(5), Action: ActionWaitDoorOpen},
+				{After: After(10), Action: ActionWaitDoorClose},
+				{After: After(4), Action: ActionSetSpeed, Value: 30.0},
+			},
+		},
 		Steps: []ScenarioStep{
 			// PARADA 1
 			{Time: 0, Action: ActionLog, Value: "🚌 === PARADA 1: Terminal Sur ==="},
-			{Time: 0, Action: ActionSetSpeed, Value: 0.0},
-			{Time: 5, Action: ActionWaitDoorOpen},
-			{Time: 15, Action: ActionWaitDoorClose},
-			{Time: 18, Action: ActionSetSpeed, Value: 30.0},
+			{Time: 0, Block: "parada"},
 
 			// Tránsito 1→2
-			{Time: 30, Action: ActionSetSpeed, Value: 50.0},
+			{After: After(12), Action: ActionSetSpeed, Value: 50.0},
 
 			// PARADA 2
-			{Time: 50, Action: ActionLog, Value: "🚌 === PARADA 2: Centro Comercial ==="},
-			{Time: 50, Action: ActionSetSpeed, Value: 10.0},
-			{Time: 53, Action: ActionSetSpeed, Value: 0.0},
-			{Time: 58, Action: ActionWaitDoorOpen},
-			{Time: 68, Action: ActionWaitDoorClose},
-			{Time: 72, Action: ActionSetSpeed, Value: 30.0},
+			{After: After(20), Action: ActionLog, Value: "🚌 === PARADA 2: Centro Comercial ==="},
+			{After: After(0), Block: "parada"},
 
 			// Tránsito 2→3
-			{Time: 85, Action: ActionSetSpeed, Value: 45.0},
+			{After: After(13), Action: ActionSetSpeed, Value: 45.0},
 
 			// PARADA 3
-			{Time: 110, Action: ActionLog, Value: "🚌 === PARADA 3: Terminal Norte ==="},
-			{Time: 110, Action: ActionSetSpeed, Value: 10.0},
-			{Time: 113, Action: ActionSetSpeed, Value: 0.0},
-			{Time: 118, Action: ActionWaitDoorOpen},
-			{Time: 128, Action: ActionWaitDoorClose},
+			{After: After(25), Action: ActionLog, Value: "🚌 === PARADA 3: Terminal Norte ==="},
+			{After: After(0), Block: "parada"},
 
 			// Fin
-			{Time: 135, Action: ActionLog, Value: "✅ Circuito completado"},
-			{Time: 135, Action: ActionSetSpeed, Value: 0.0},
+			{After: After(3), Action: ActionLog, Value: "✅ Circuito completado"},
+			{After: After(0), Action: ActionSetSpeed, Value: 0.0},
 		},
 	}
+
+	// Los bloques de un escenario predefinido son fijos: un error es de programación
+	if err := scenario.Expand(); err != nil {
+		panic(fmt.Sprintf("escenario %s: %v", scenario.Name, err))
+	}
+	return scenario
 }
 
 // GetAllScenarios retorna todos los escenarios disponibles
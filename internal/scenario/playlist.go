@@ -0,0 +1,158 @@
+package scenario
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPlaylistsDir directorio de las listas YAML que ofrece la UI
+const DefaultPlaylistsDir = "playlists"
+
+// Playlist lista de escenarios que el Executor corre uno tras otro sin
+// reiniciar el vehículo: posición, velocidad, viaje y pasajeros pasan de un
+// escenario al siguiente (p. ej. un turno: pico de la mañana, mediodía, pico
+// de la tarde). El on_end de cada escenario se ignora dentro de la lista.
+type Playlist struct {
+	Name        string          `yaml:"name"`
+	Description string          `yaml:"description"`
+	Entries     []PlaylistEntry `yaml:"entries"`
+	OnEnd       string          `yaml:"on_end"`    // hold, stop o loop (volver a la primera entrada)
+	MaxLoops    int             `yaml:"max_loops"` // Vueltas con on_end loop (0 = sin límite)
+
+	scenarios []*Scenario // Escenario cargado de cada entrada
+}
+
+// PlaylistEntry escenario de la lista
+type PlaylistEntry struct {
+	Scenario   string     `yaml:"scenario"`   // ID (predefinido o yaml_<archivo>) o ruta YAML (ver Repository.Load)
+	Repeat     int        `yaml:"repeat"`     // Veces seguidas (0 = 1)
+	Transition Transition `yaml:"transition"` // Qué pasa antes de la entrada siguiente
+}
+
+// Transition paso entre una entrada y la siguiente
+type Transition struct {
+	Pause   Seconds  `yaml:"pause"`   // Espera antes de la entrada siguiente
+	Speed   *float64 `yaml:"speed"`   // Velocidad durante la pausa (nil = la que quedó)
+	Message string   `yaml:"message"` // Log al empezar la transición
+}
+
+// repeats retorna cuántas veces seguidas corre la entrada
+func (entry PlaylistEntry) repeats() int {
+	if entry.Repeat == 0 {
+		return 1
+	}
+	return entry.Repeat
+}
+
+// parsePlaylist convierte el contenido de una lista YAML en una lista
+// validada, cargando cada entrada con load (ver Repository.LoadPlaylist)
+func parsePlaylist(data []byte, filename string, load func(id string) (*Scenario, error)) (*Playlist, error) {
+	var playlist Playlist
+	if err := yaml.Unmarshal(data, &playlist); err != nil {
+		return nil, &LoadError{File: filename, Stage: StageYAML, Line: yamlErrorLine(err), Step: -1, Err: err}
+	}
+
+	if err := playlist.Validate(); err != nil {
+		return nil, &LoadError{File: filename, Stage: StagePlaylist, Step: -1, Err: err}
+	}
+
+	lines := sequenceLines(data, "entries")
+	for i, entry := range playlist.Entries {
+		scenario, err := load(entry.Scenario)
+		if err != nil {
+			loadErr := &LoadError{File: filename, Stage: StagePlaylist, Step: -1,
+				Err: fmt.Errorf("entrada %d: %w", i, err)}
+			if i < len(lines) {
+				loadErr.Line = lines[i]
+			}
+			return nil, loadErr
+		}
+		playlist.scenarios = append(playlist.scenarios, scenario)
+	}
+
+	return &playlist, nil
+}
+
+// IsPlaylist retorna true si el archivo YAML es una lista (tiene entries)
+func IsPlaylist(filename string) bool {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return false
+	}
+
+	var probe struct {
+		Entries []interface{} `yaml:"entries"`
+	}
+	return yaml.Unmarshal(data, &probe) == nil && len(probe.Entries) > 0
+}
+
+// Validate valida la lista (sin cargar sus escenarios)
+func (p *Playlist) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("la lista debe tener un nombre")
+	}
+	if len(p.Entries) == 0 {
+		return fmt.Errorf("la lista debe tener al menos una entrada")
+	}
+
+	switch p.OnEnd {
+	case "", EndHold, EndStop, EndLoop:
+	default:
+		return fmt.Errorf("on_end '%s' no válido (hold, stop o loop)", p.OnEnd)
+	}
+	if p.MaxLoops < 0 {
+		return fmt.Errorf("max_loops no puede ser negativo")
+	}
+
+	for i, entry := range p.Entries {
+		if entry.Scenario == "" {
+			return fmt.Errorf("entrada %d: falta scenario", i)
+		}
+		if entry.Repeat < 0 || entry.Repeat > maxIterations {
+			return fmt.Errorf("entrada %d: repeat debe estar entre 0 y %d (0 = una vez)", i, maxIterations)
+		}
+		if entry.Transition.Pause < 0 {
+			return fmt.Errorf("entrada %d: la pausa no puede ser negativa", i)
+		}
+		if speed := entry.Transition.Speed; speed != nil && *speed < 0 {
+			return fmt.Errorf("entrada %d: la velocidad no puede ser negativa", i)
+		}
+	}
+	return nil
+}
+
+// Scenario retorna el escenario cargado de una entrada
+func (p *Playlist) Scenario(entry int) *Scenario {
+	if entry < 0 || entry >= len(p.scenarios) {
+		return nil
+	}
+	return p.scenarios[entry]
+}
+
+// onEnd retorna qué hacer al terminar la última entrada
+func (p *Playlist) onEnd() string {
+	if p.OnEnd == "" {
+		return EndHold
+	}
+	return p.OnEnd
+}
+
+// GetDuration retorna la duración de una vuelta de la lista (repeticiones y pausas incluidas)
+func (p *Playlist) GetDuration() time.Duration {
+	var total time.Duration
+	for i, entry := range p.Entries {
+		if scenario := p.Scenario(i); scenario != nil {
+			total += scenario.GetDuration() * time.Duration(entry.repeats())
+		}
+		total += time.Duration(float64(entry.Transition.Pause) * float64(time.Second))
+	}
+	return total
+}
+
+// String implementa fmt.Stringer
+func (p *Playlist) String() string {
+	return fmt.Sprintf("Lista: %s (%d escenarios, %.0fs)", p.Name, len(p.Entries), p.GetDuration().Seconds())
+}
@@ -0,0 +1,132 @@
+package scenario
+
+import (
+	"testing"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+)
+
+// testPlaylist lista de dos entradas ya cargadas: "a" dos veces y "b"
+func testPlaylist(onEnd string, maxLoops int) *Playlist {
+	speed := 25.0
+	return &Playlist{
+		Name: "Turno",
+		Entries: []PlaylistEntry{
+			{Scenario: "a", Repeat: 2, Transition: Transition{Speed: &speed}},
+			{Scenario: "b"},
+		},
+		OnEnd:     onEnd,
+		MaxLoops:  maxLoops,
+		scenarios: []*Scenario{logScenario("a", EndNext), logScenario("b", EndLoop)},
+	}
+}
+
+func TestPlaylistValidate(t *testing.T) {
+	negative := -1.0
+
+	tests := []struct {
+		name    string
+		modify  func(p *Playlist)
+		wantErr bool
+	}{
+		{"válida", func(p *Playlist) {}, false},
+		{"sin nombre", func(p *Playlist) { p.Name = "" }, true},
+		{"sin entradas", func(p *Playlist) { p.Entries = nil }, true},
+		{"on_end next", func(p *Playlist) { p.OnEnd = EndNext }, true},
+		{"max_loops negativo", func(p *Playlist) { p.MaxLoops = -1 }, true},
+		{"entrada sin escenario", func(p *Playlist) { p.Entries[1].Scenario = "" }, true},
+		{"repeat negativo", func(p *Playlist) { p.Entries[0].Repeat = -1 }, true},
+		{"repeat excesivo", func(p *Playlist) { p.Entries[0].Repeat = maxIterations + 1 }, true},
+		{"pausa negativa", func(p *Playlist) { p.Entries[0].Transition.Pause = -1 }, true},
+		{"velocidad negativa", func(p *Playlist) { p.Entries[0].Transition.Speed = &negative }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			playlist := testPlaylist(EndHold, 0)
+			tt.modify(playlist)
+
+			err := playlist.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExecutorAdvancePlaylist(t *testing.T) {
+	tests := []struct {
+		name         string
+		onEnd        string
+		maxLoops     int
+		entry        int
+		repeat       int
+		round        int
+		wantContinue bool
+		wantOnEnd    string
+		wantScenario string
+		wantEntry    int
+		wantRepeat   int
+		wantSpeed    float64
+	}{
+		{"repite la entrada", EndHold, 0, 0, 1, 1, true, EndLoop, "a", 0, 2, 30},
+		{"pasa a la siguiente con su velocidad", EndHold, 0, 0, 2, 1, true, EndNext, "b", 1, 1, 25},
+		{"hold al terminar", EndHold, 0, 1, 1, 1, false, EndHold, "b", 1, 1, 30},
+		{"stop al terminar", EndStop, 0, 1, 1, 1, false, EndStop, "b", 1, 1, 0},
+		{"loop vuelve a la primera", EndLoop, 2, 1, 1, 1, true, EndNext, "a", 0, 1, 30},
+		{"loop en la última vuelta", EndLoop, 2, 1, 1, 2, false, EndHold, "b", 1, 1, 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			playlist := testPlaylist(tt.onEnd, tt.maxLoops)
+			bus := eventbus.NewEventBus()
+			channel := bus.Subscribe(eventbus.EventScenario)
+			speed := &fakeSpeed{speed: 30}
+
+			executor := NewExecutor(nil, speed, bus)
+			executor.SetPlaylist(playlist)
+			executor.running = true
+			executor.scenario = playlist.Scenario(tt.entry)
+			executor.entry = tt.entry
+			executor.entryRepeat = tt.repeat
+			executor.playlistLoop = tt.round
+
+			if got := executor.finish(); got != tt.wantContinue {
+				t.Errorf("finish = %v, want %v", got, tt.wantContinue)
+			}
+			entry, repeat := executor.GetPlaylistEntry()
+			if name := executor.GetScenario().Name; name != tt.wantScenario || entry != tt.wantEntry || repeat != tt.wantRepeat {
+				t.Errorf("escenario %s entrada %d repetición %d, want %s %d %d",
+					name, entry, repeat, tt.wantScenario, tt.wantEntry, tt.wantRepeat)
+			}
+			if speed.speed != tt.wantSpeed {
+				t.Errorf("velocidad = %v, want %v", speed.speed, tt.wantSpeed)
+			}
+
+			// El on_end de los escenarios se ignora: manda la lista
+			events := scenarioEvents(channel)
+			if len(events) == 0 || events[0].EventType != eventbus.ScenarioCompleted || events[0].OnEnd != tt.wantOnEnd {
+				t.Fatalf("eventos = %+v, want COMPLETED con on_end %s", events, tt.wantOnEnd)
+			}
+			if events[0].Playlist != "Turno" || events[0].TotalEntries != 2 {
+				t.Errorf("lista publicada = %s (%d entradas), want Turno (2)", events[0].Playlist, events[0].TotalEntries)
+			}
+			if tt.wantOnEnd == EndNext && events[0].Next != tt.wantScenario {
+				t.Errorf("next publicado = %s, want %s", events[0].Next, tt.wantScenario)
+			}
+		})
+	}
+}
+
+func TestExecutorPlaylistReport(t *testing.T) {
+	executor := NewExecutor(nil, &fakeSpeed{}, eventbus.NewEventBus())
+	executor.SetPlaylist(testPlaylist(EndHold, 0))
+
+	if name := executor.GetScenario().Name; name != "a" {
+		t.Errorf("escenario inicial = %s, want a", name)
+	}
+	if report := executor.Report(); report.Scenario != "Turno" {
+		t.Errorf("reporte de %s, want Turno", report.Scenario)
+	}
+}
@@ -0,0 +1,326 @@
+package scenario
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultScenariosDir directorio de los escenarios YAML
+const DefaultScenariosDir = "scenarios"
+
+// Etapas de carga que informa LoadError
+const (
+	StageRead     = "lectura"
+	StageYAML     = "YAML inválido"
+	StageExpand   = "control de flujo"
+	StageValidate = "escenario inválido"
+	StagePlaylist = "lista inválida"
+	StageNotFound = "no encontrado"
+)
+
+// LoadError error al cargar un escenario o una lista, con dónde falló
+type LoadError struct {
+	File  string // Archivo (o ID si no se encontró)
+	Stage string // Etapa: lectura, YAML, control de flujo, validación...
+	Line  int    // Línea del YAML (0 = desconocida)
+	Step  int    // Paso del escenario (-1 = no aplica)
+	Err   error
+}
+
+// Error implementa error: "archivo:línea: etapa: detalle"
+func (e *LoadError) Error() string {
+	location := e.File
+	if e.Line > 0 {
+		location = fmt.Sprintf("%s:%d", e.File, e.Line)
+	}
+	return fmt.Sprintf("%s: %s: %v", location, e.Stage, e.Err)
+}
+
+// Unwrap retorna el error original
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// yamlLineRe número de línea en los mensajes de yaml.v3 ("line 12: ...")
+var yamlLineRe = regexp.MustCompile(`line (\d+)`)
+
+// yamlErrorLine extrae la primera línea que menciona un error de yaml.v3
+func yamlErrorLine(err error) int {
+	match := yamlLineRe.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0
+	}
+	line, _ := strconv.Atoi(match[1])
+	return line
+}
+
+// sequenceLines retorna la línea de cada elemento de la lista key del
+// documento (p. ej. "steps" o "entries")
+func sequenceLines(data []byte, key string) []int {
+	var doc yaml.Node
+	if yaml.Unmarshal(data, &doc) != nil || len(doc.Content) == 0 {
+		return nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != key || root.Content[i+1].Kind != yaml.SequenceNode {
+			continue
+		}
+		lines := make([]int, 0, len(root.Content[i+1].Content))
+		for _, item := range root.Content[i+1].Content {
+			lines = append(lines, item.Line)
+		}
+		return lines
+	}
+	return nil
+}
+
+// isYAMLFile verifica la extensión .yaml/.yml
+func isYAMLFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// ScenarioInfo contiene información de un escenario disponible
+type ScenarioInfo struct {
+	ID       string // "parada_normal", "yaml_mi_escenario", "playlist_turno"
+	Name     string // "Parada Normal", "Mi Escenario (YAML)"
+	Source   string // "builtin", "yaml" o "playlist"
+	FilePath string // Ruta al archivo YAML (si no es builtin)
+	Err      error  // Diagnóstico si el archivo no carga (nil = válido)
+}
+
+// cachedFile contenido de un YAML ya leído y su diagnóstico
+type cachedFile struct {
+	modTime time.Time
+	size    int64
+	data    []byte
+	err     error
+}
+
+// Repository descubre, parsea, valida y cachea los escenarios y listas de la
+// simulación. Es la única puerta de entrada a los YAML: UI, runner y main
+// cargan por acá, así todos reciben los mismos diagnósticos.
+// El cache guarda el contenido de cada archivo hasta que cambia su fecha o
+// tamaño; cada Load vuelve a parsear para que choose sin seed sortee de nuevo.
+type Repository struct {
+	scenariosDir string
+	playlistsDir string
+
+	mu    sync.Mutex
+	cache map[string]*cachedFile
+}
+
+// NewRepository crea un repositorio sobre los directorios de escenarios y listas
+func NewRepository(scenariosDir, playlistsDir string) *Repository {
+	return &Repository{
+		scenariosDir: scenariosDir,
+		playlistsDir: playlistsDir,
+		cache:        make(map[string]*cachedFile),
+	}
+}
+
+// List retorna los escenarios predefinidos, los YAML del directorio de
+// escenarios y las listas, cada uno con su diagnóstico
+func (r *Repository) List() []ScenarioInfo {
+	infos := []ScenarioInfo{
+		{ID: "parada_normal", Name: "Parada Normal", Source: "builtin"},
+		{ID: "parada_con_salidas", Name: "Parada con Salidas", Source: "builtin"},
+		{ID: "circuito_completo", Name: "Circuito Completo", Source: "builtin"},
+	}
+
+	for _, path := range r.yamlFiles(r.scenariosDir) {
+		base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		_, err := r.loadFile(path)
+		infos = append(infos, ScenarioInfo{
+			ID:       "yaml_" + base,
+			Name:     displayName(base) + " (YAML)",
+			Source:   "yaml",
+			FilePath: path,
+			Err:      err,
+		})
+		if err != nil {
+			fmt.Printf("⚠️  Escenario YAML inválido: %v\n", err)
+		} else {
+			fmt.Printf("📄 Escenario YAML detectado: %s (%s)\n", displayName(base), path)
+		}
+	}
+
+	for _, path := range r.yamlFiles(r.playlistsDir) {
+		if !IsPlaylist(path) {
+			continue
+		}
+		base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		_, err := r.loadPlaylistFile(path)
+		infos = append(infos, ScenarioInfo{
+			ID:       "playlist_" + base,
+			Name:     displayName(base) + " (Lista)",
+			Source:   "playlist",
+			FilePath: path,
+			Err:      err,
+		})
+		if err != nil {
+			fmt.Printf("⚠️  Lista de escenarios inválida: %v\n", err)
+		} else {
+			fmt.Printf("📄 Lista de escenarios detectada: %s (%s)\n", displayName(base), path)
+		}
+	}
+
+	return infos
+}
+
+// Load carga un escenario por ID: predefinido ("parada_normal"), YAML del
+// directorio ("yaml_<archivo>", .yaml o .yml) o ruta a un archivo YAML
+// (relativa al directorio de escenarios si no existe tal cual).
+// Implementa ScenarioLoader.
+func (r *Repository) Load(id string) (*Scenario, error) {
+	if scenario := GetScenarioByName(id); scenario != nil {
+		return scenario, nil
+	}
+
+	path, err := r.resolve(id, "yaml_", r.scenariosDir)
+	if err != nil {
+		return nil, err
+	}
+	return r.loadFile(path)
+}
+
+// LoadPlaylist carga una lista por ID ("playlist_<archivo>") o ruta YAML,
+// con todos sus escenarios validados
+func (r *Repository) LoadPlaylist(id string) (*Playlist, error) {
+	path, err := r.resolve(id, "playlist_", r.playlistsDir)
+	if err != nil {
+		return nil, err
+	}
+	return r.loadPlaylistFile(path)
+}
+
+// resolve convierte un ID con prefijo o una ruta en el archivo a cargar
+func (r *Repository) resolve(id, prefix, dir string) (string, error) {
+	if strings.HasPrefix(id, prefix) {
+		base := filepath.Join(dir, strings.TrimPrefix(id, prefix))
+		for _, ext := range []string{".yaml", ".yml"} {
+			if _, err := os.Stat(base + ext); err == nil {
+				return base + ext, nil
+			}
+		}
+		return "", &LoadError{File: id, Stage: StageNotFound, Step: -1,
+			Err: fmt.Errorf("no existe %s.yaml ni %s.yml", base, base)}
+	}
+
+	if !isYAMLFile(id) {
+		return "", &LoadError{File: id, Stage: StageNotFound, Step: -1,
+			Err: fmt.Errorf("no es un escenario predefinido ni un archivo .yaml/.yml")}
+	}
+	if _, err := os.Stat(id); errors.Is(err, fs.ErrNotExist) && dir != "" && !filepath.IsAbs(id) {
+		if _, err := os.Stat(filepath.Join(dir, id)); err == nil {
+			return filepath.Join(dir, id), nil
+		}
+	}
+	return id, nil
+}
+
+// read retorna el contenido de un archivo, del cache si no cambió
+func (r *Repository) read(path string) (*cachedFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, &LoadError{File: path, Stage: StageRead, Step: -1, Err: err}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cached, ok := r.cache[path]; ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &LoadError{File: path, Stage: StageRead, Step: -1, Err: err}
+	}
+	cached := &cachedFile{modTime: info.ModTime(), size: info.Size(), data: data}
+	r.cache[path] = cached
+	return cached, nil
+}
+
+// loadFile parsea y valida un escenario YAML (un archivo inválido que no
+// cambió retorna el diagnóstico guardado sin volver a parsearlo)
+func (r *Repository) loadFile(path string) (*Scenario, error) {
+	cached, err := r.read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	cachedErr := cached.err
+	r.mu.Unlock()
+	if cachedErr != nil {
+		return nil, cachedErr
+	}
+
+	scenario, err := ParseScenario(cached.data, path)
+	if err != nil {
+		r.mu.Lock()
+		cached.err = err
+		r.mu.Unlock()
+	}
+	return scenario, err
+}
+
+// loadPlaylistFile parsea y valida una lista y carga sus escenarios
+func (r *Repository) loadPlaylistFile(path string) (*Playlist, error) {
+	cached, err := r.read(path)
+	if err != nil {
+		return nil, err
+	}
+	return parsePlaylist(cached.data, path, r.Load)
+}
+
+// yamlFiles retorna los .yaml/.yml de un directorio en orden alfabético
+func (r *Repository) yamlFiles(dir string) []string {
+	if dir == "" {
+		return nil
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			fmt.Printf("⚠️  Error leyendo directorio %s: %v\n", dir, err)
+		}
+		return nil
+	}
+
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		if !file.IsDir() && isYAMLFile(file.Name()) {
+			paths = append(paths, filepath.Join(dir, file.Name()))
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// displayName convierte un nombre de archivo en un título ("mi_escenario" → "Mi Escenario")
+func displayName(base string) string {
+	words := strings.Fields(strings.ReplaceAll(base, "_", " "))
+	for i, word := range words {
+		runes := []rune(word)
+		words[i] = strings.ToUpper(string(runes[0])) + string(runes[1:])
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,253 @@
+package scenario
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const shortScenarioYAML = "name: Corto\nduration: 20\nsteps:\n  - time: 0\n    action: log\n    value: hola\n"
+
+// newTestRepository crea un repositorio con los archivos indicados
+// (clave "scenarios/x.yaml" o "playlists/x.yaml")
+func newTestRepository(t *testing.T, files map[string]string) (*Repository, string) {
+	t.Helper()
+
+	root := t.TempDir()
+	for _, dir := range []string{"scenarios", "playlists"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, source := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewRepository(filepath.Join(root, "scenarios"), filepath.Join(root, "playlists")), root
+}
+
+func TestRepositoryLoad(t *testing.T) {
+	repo, root := newTestRepository(t, map[string]string{
+		"scenarios/corto.yaml": shortScenarioYAML,
+		"scenarios/otro.yml":   shortScenarioYAML,
+	})
+
+	tests := []struct {
+		name      string
+		id        string
+		wantName  string
+		wantStage string // "" = sin error
+	}{
+		{"predefinido", "parada_normal", GetScenarioByName("parada_normal").Name, ""},
+		{"yaml del directorio", "yaml_corto", "Corto", ""},
+		{"yaml con extensión .yml", "yaml_otro", "Corto", ""},
+		{"archivo relativo al directorio", "corto.yaml", "Corto", ""},
+		{"ruta al archivo", filepath.Join(root, "scenarios", "corto.yaml"), "Corto", ""},
+		{"id desconocido", "inexistente", "", StageNotFound},
+		{"yaml inexistente", "yaml_falta", "", StageNotFound},
+		{"ruta inexistente", filepath.Join(root, "falta.yaml"), "", StageRead},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scenario, err := repo.Load(tt.id)
+			if tt.wantStage == "" {
+				if err != nil {
+					t.Fatalf("Load: %v", err)
+				}
+				if scenario.Name != tt.wantName {
+					t.Errorf("nombre = %s, want %s", scenario.Name, tt.wantName)
+				}
+				return
+			}
+
+			var loadErr *LoadError
+			if !errors.As(err, &loadErr) || loadErr.Stage != tt.wantStage {
+				t.Errorf("err = %v, want etapa %s", err, tt.wantStage)
+			}
+		})
+	}
+}
+
+func TestRepositoryDiagnostics(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		wantStage string
+		wantLine  int
+		wantStep  int
+	}{
+		{
+			name:      "tipo inválido",
+			source:    "name: Roto\nsteps:\n  - time: 0\n    action: log\n  - time: [1]\n    action: log\n",
+			wantStage: StageYAML,
+			wantLine:  5,
+			wantStep:  -1,
+		},
+		{
+			name:      "acción inválida",
+			source:    "name: Roto\nsteps:\n  - time: 0\n    action: log\n    value: hola\n  - time: 1\n    action: volar\n",
+			wantStage: StageValidate,
+			wantLine:  6,
+			wantStep:  1,
+		},
+		{
+			name:      "falla inválida",
+			source:    "name: Roto\nsteps:\n  - time: 0\n    action: inject_fault\n    value:\n      sensor: gps\n      type: delay\n",
+			wantStage: StageValidate,
+			wantLine:  3,
+			wantStep:  0,
+		},
+		{
+			name:      "acción inválida dentro de un repeat",
+			source:    "name: Roto\nsteps:\n  - repeat: 2\n    steps:\n      - time: 0\n        action: volar\n",
+			wantStage: StageValidate,
+			wantLine:  5,
+			wantStep:  0,
+		},
+		{
+			name:      "control de flujo inválido",
+			source:    "name: Roto\nsteps:\n  - repeat: -1\n    steps:\n      - time: 0\n        action: log\n",
+			wantStage: StageExpand,
+			wantStep:  -1,
+		},
+		{
+			name:      "sin nombre",
+			source:    "steps:\n  - time: 0\n    action: log\n",
+			wantStage: StageValidate,
+			wantStep:  -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _ := newTestRepository(t, map[string]string{"scenarios/roto.yaml": tt.source})
+
+			_, err := repo.Load("yaml_roto")
+			var loadErr *LoadError
+			if !errors.As(err, &loadErr) {
+				t.Fatalf("err = %v, want *LoadError", err)
+			}
+			if loadErr.Stage != tt.wantStage || loadErr.Line != tt.wantLine || loadErr.Step != tt.wantStep {
+				t.Errorf("diagnóstico = %s línea %d paso %d, want %s línea %d paso %d",
+					loadErr.Stage, loadErr.Line, loadErr.Step, tt.wantStage, tt.wantLine, tt.wantStep)
+			}
+		})
+	}
+}
+
+func TestRepositoryCache(t *testing.T) {
+	repo, root := newTestRepository(t, map[string]string{"scenarios/corto.yaml": "name: Roto\nsteps: []\n"})
+	path := filepath.Join(root, "scenarios", "corto.yaml")
+
+	_, first := repo.Load("yaml_corto")
+	_, second := repo.Load("yaml_corto")
+	if first == nil || first != second {
+		t.Errorf("un archivo inválido sin cambios debería retornar el diagnóstico guardado: %v / %v", first, second)
+	}
+
+	// Al cambiar el archivo se vuelve a leer
+	if err := os.WriteFile(path, []byte(shortScenarioYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if scenario, err := repo.Load("yaml_corto"); err != nil || scenario.Name != "Corto" {
+		t.Errorf("tras editar: %v, %v; want Corto", scenario, err)
+	}
+
+	// Cada Load parsea de nuevo: los escenarios no se comparten
+	a, _ := repo.Load("yaml_corto")
+	b, _ := repo.Load("yaml_corto")
+	if a == b {
+		t.Error("dos Load retornaron el mismo *Scenario")
+	}
+}
+
+func TestRepositoryLoadPlaylist(t *testing.T) {
+	playlistSource := `name: Turno
+on_end: loop
+max_loops: 2
+entries:
+  - scenario: yaml_corto
+    repeat: 3
+    transition:
+      pause: 10s
+  - scenario: parada_normal
+`
+	brokenSource := "name: Rota\nentries:\n  - scenario: yaml_corto\n  - scenario: yaml_falta\n"
+
+	repo, _ := newTestRepository(t, map[string]string{
+		"scenarios/corto.yaml":  shortScenarioYAML,
+		"playlists/turno.yaml":  playlistSource,
+		"playlists/rota.yaml":   brokenSource,
+		"playlists/suelto.yaml": shortScenarioYAML,
+		"playlists/notas.txt":   playlistSource,
+	})
+
+	playlist, err := repo.LoadPlaylist("playlist_turno")
+	if err != nil {
+		t.Fatalf("LoadPlaylist: %v", err)
+	}
+	if playlist.Scenario(0).Name != "Corto" || playlist.Scenario(1) == nil || playlist.Scenario(2) != nil {
+		t.Errorf("escenarios cargados = %v, %v, %v", playlist.Scenario(0), playlist.Scenario(1), playlist.Scenario(2))
+	}
+
+	// 3 × 20s + 10s de pausa + la duración de parada_normal
+	want := 70*time.Second + GetScenarioByName("parada_normal").GetDuration()
+	if got := playlist.GetDuration(); got != want {
+		t.Errorf("duración = %v, want %v", got, want)
+	}
+
+	// La entrada que no carga se informa con su línea
+	_, err = repo.LoadPlaylist("playlist_rota")
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) || loadErr.Stage != StagePlaylist || loadErr.Line != 4 {
+		t.Errorf("err = %v, want lista inválida en la línea 4", err)
+	}
+}
+
+func TestRepositoryList(t *testing.T) {
+	repo, _ := newTestRepository(t, map[string]string{
+		"scenarios/corto.yaml":  shortScenarioYAML,
+		"scenarios/roto.yaml":   "name: Roto\nsteps: []\n",
+		"playlists/turno.yaml":  "name: Turno\nentries:\n  - scenario: yaml_corto\n",
+		"playlists/suelto.yaml": shortScenarioYAML,
+		"playlists/notas.txt":   "name: Notas\nentries:\n  - scenario: yaml_corto\n",
+	})
+
+	infos := make(map[string]ScenarioInfo)
+	for _, info := range repo.List() {
+		infos[info.ID] = info
+	}
+
+	if info, ok := infos["parada_normal"]; !ok || info.Source != "builtin" {
+		t.Errorf("parada_normal = %+v, want predefinido", info)
+	}
+	if info := infos["yaml_corto"]; info.Source != "yaml" || info.Name != "Corto (YAML)" || info.Err != nil {
+		t.Errorf("yaml_corto = %+v, want YAML válido", info)
+	}
+	if info := infos["yaml_roto"]; info.Err == nil {
+		t.Error("yaml_roto debería listarse con su diagnóstico")
+	}
+	if info := infos["playlist_turno"]; info.Source != "playlist" || info.Name != "Turno (Lista)" || info.Err != nil {
+		t.Errorf("playlist_turno = %+v, want lista válida", info)
+	}
+	if _, ok := infos["playlist_suelto"]; ok {
+		t.Error("un escenario en playlists/ no es una lista")
+	}
+	if _, ok := infos["playlist_notas"]; ok {
+		t.Error("solo se listan archivos .yaml/.yml")
+	}
+}
+
+func TestDisplayName(t *testing.T) {
+	if got := displayName("pico_de_la_mañana"); got != "Pico De La Mañana" {
+		t.Errorf("displayName = %q", got)
+	}
+}
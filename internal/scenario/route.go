@@ -1,12 +1,29 @@
 package scenario
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+)
+
+// earthRadiusKm radio medio de la Tierra
+const earthRadiusKm = 6371.0
 
 // Stop representa una parada en la ruta
 type Stop struct {
 	ID       int     // ID de la parada
 	Name     string  // Nombre de la parada
 	Position float64 // Posición en la ruta (0.0 a 1.0)
+	Radius   float64 // Radio de la geocerca en metros (0 = stops.geofence_radius_m)
+
+	ExternalID      string  // ID de la parada en el feed de origen (GTFS stop_id)
+	ScheduledOffset float64 // Segundos desde la salida programada del viaje (si Route.Scheduled)
+}
+
+// ShapePoint es un punto del trazado de la ruta
+type ShapePoint struct {
+	Lat        float64
+	Lon        float64
+	DistanceKm float64 // Distancia acumulada desde el inicio del trazado
 }
 
 // Route representa una ruta lineal con paradas
@@ -18,6 +35,41 @@ type Route struct {
 	StartLon float64 // Longitud inicial
 	EndLat   float64 // Latitud final
 	EndLon   float64 // Longitud final
+
+	Shape     []ShapePoint // Trazado (vacío = línea recta de Start a End)
+	Scheduled bool         // Las paradas tienen horario (ScheduledOffset)
+	TripID    string       // Viaje programado de origen (GTFS trip_id)
+	RouteID   string       // Línea de origen (GTFS route_id; vacío = ruta simulada)
+	Direction int          // direction_id del viaje de origen (el regreso es el opuesto)
+}
+
+// NewRouteFromShape crea una ruta que sigue un trazado de coordenadas
+// (calcula la distancia acumulada y la longitud total)
+func NewRouteFromShape(name string, points []ShapePoint, stops []Stop) *Route {
+	shape := make([]ShapePoint, len(points))
+	copy(shape, points)
+
+	for i := range shape {
+		if i == 0 {
+			shape[i].DistanceKm = 0
+			continue
+		}
+		shape[i].DistanceKm = shape[i-1].DistanceKm + HaversineKm(shape[i-1].Lat, shape[i-1].Lon, shape[i].Lat, shape[i].Lon)
+	}
+
+	route := &Route{
+		Name:  name,
+		Stops: stops,
+		Shape: shape,
+	}
+
+	if len(shape) > 0 {
+		route.StartLat, route.StartLon = shape[0].Lat, shape[0].Lon
+		route.EndLat, route.EndLon = shape[len(shape)-1].Lat, shape[len(shape)-1].Lon
+		route.Length = shape[len(shape)-1].DistanceKm
+	}
+
+	return route
 }
 
 // NewDefaultRoute crea una ruta de ejemplo con coordenadas parametrizadas
@@ -54,6 +106,14 @@ func (r *Route) GetPositionAtProgress(progress float64) (lat, lon float64) {
 		progress = 1.0
 	}
 
+	// Seguir el trazado si existe
+	if len(r.Shape) >= 2 {
+		from, to, fraction := r.shapeSegment(progress)
+		lat = from.Lat + (to.Lat-from.Lat)*fraction
+		lon = from.Lon + (to.Lon-from.Lon)*fraction
+		return lat, lon
+	}
+
 	// Interpolación lineal
 	lat = r.StartLat + (r.EndLat-r.StartLat)*progress
 	lon = r.StartLon + (r.EndLon-r.StartLon)*progress
@@ -61,6 +121,42 @@ func (r *Route) GetPositionAtProgress(progress float64) (lat, lon float64) {
 	return lat, lon
 }
 
+// GetCourseAtProgress retorna el rumbo (0-360) en sentido de ida en un punto de la ruta
+func (r *Route) GetCourseAtProgress(progress float64) float64 {
+	fromLat, fromLon := r.StartLat, r.StartLon
+	toLat, toLon := r.EndLat, r.EndLon
+
+	if len(r.Shape) >= 2 {
+		from, to, _ := r.shapeSegment(math.Max(0.0, math.Min(1.0, progress)))
+		fromLat, fromLon = from.Lat, from.Lon
+		toLat, toLon = to.Lat, to.Lon
+	}
+
+	angleDeg := math.Atan2(toLon-fromLon, toLat-fromLat) * 180.0 / math.Pi
+	return math.Mod(angleDeg+360.0, 360.0)
+}
+
+// shapeSegment retorna el segmento del trazado que contiene el progreso y la
+// fracción recorrida dentro de él
+func (r *Route) shapeSegment(progress float64) (from, to ShapePoint, fraction float64) {
+	target := progress * r.Shape[len(r.Shape)-1].DistanceKm
+
+	for i := 1; i < len(r.Shape); i++ {
+		if r.Shape[i].DistanceKm < target && i < len(r.Shape)-1 {
+			continue
+		}
+
+		from, to = r.Shape[i-1], r.Shape[i]
+		segment := to.DistanceKm - from.DistanceKm
+		if segment > 0 {
+			fraction = math.Max(0.0, math.Min(1.0, (target-from.DistanceKm)/segment))
+		}
+		return from, to, fraction
+	}
+
+	return r.Shape[0], r.Shape[0], 0
+}
+
 // GetNearestStop retorna la parada más cercana al progreso actual
 func (r *Route) GetNearestStop(progress float64) *Stop {
 	if len(r.Stops) == 0 {
@@ -92,18 +188,38 @@ func (r *Route) GetNextStop(progress float64) *Stop {
 	return nil // Ya pasamos todas las paradas
 }
 
+// GetNextStopInDirection retorna la próxima parada según el sentido (+1 ida, -1 regreso)
+func (r *Route) GetNextStopInDirection(progress float64, direction int) *Stop {
+	if direction >= 0 {
+		return r.GetNextStop(progress)
+	}
+
+	for i := len(r.Stops) - 1; i >= 0; i-- {
+		if r.Stops[i].Position < progress {
+			return &r.Stops[i]
+		}
+	}
+	return nil // Ya pasamos todas las paradas
+}
+
+// FindStop busca una parada por ID o por nombre
+func (r *Route) FindStop(ref StopRef) *Stop {
+	for i := range r.Stops {
+		stop := &r.Stops[i]
+		if (ref.ID > 0 && stop.ID == ref.ID) || (ref.Name != "" && stop.Name == ref.Name) {
+			return stop
+		}
+	}
+	return nil
+}
+
 // GetDistanceToStop calcula distancia en km a una parada
 func (r *Route) GetDistanceToStop(progress float64, stop *Stop) float64 {
 	if stop == nil {
 		return 0.0
 	}
 
-	progressDiff := stop.Position - progress
-	if progressDiff < 0 {
-		progressDiff = 0
-	}
-
-	return progressDiff * r.Length
+	return abs(stop.Position-progress) * r.Length
 }
 
 // String implementa fmt.Stringer
@@ -111,6 +227,18 @@ func (r *Route) String() string {
 	return fmt.Sprintf("Ruta: %s (%.1f km, %d paradas)", r.Name, r.Length, len(r.Stops))
 }
 
+// HaversineKm retorna la distancia en km entre dos coordenadas
+func HaversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := math.Pi / 180.0
+	dLat := (lat2 - lat1) * toRad
+	dLon := (lon2 - lon1) * toRad
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
 // Helper function
 func abs(x float64) float64 {
 	if x < 0 {
@@ -1,26 +1,69 @@
 package scenario
 
 import (
+	"errors"
 	"fmt"
-	"os"
 	"time"
 
+	"github.com/MarcosBrindi/transporte-simulator/internal/config"
 	"gopkg.in/yaml.v3"
 )
 
 // Scenario representa un escenario completo
 type Scenario struct {
-	Name        string         `yaml:"name"`
-	Description string         `yaml:"description"`
-	Duration    int            `yaml:"duration"` // Duración total en segundos
-	Steps       []ScenarioStep `yaml:"steps"`
+	Name        string                    `yaml:"name"`
+	Description string                    `yaml:"description"`
+	Duration    int                       `yaml:"duration"` // Duración total en segundos
+	Seed        int64                     `yaml:"seed"`     // Semilla de choose (0 = aleatoria)
+	Blocks      map[string][]ScenarioStep `yaml:"blocks"`   // Bloques reutilizables (ver flow.go)
+	Steps       []ScenarioStep            `yaml:"steps"`
+
+	// Al terminar los pasos (vacío = loop si simulation.auto_loop, hold si no)
+	OnEnd    string `yaml:"on_end"`    // hold, stop, loop o next
+	Next     string `yaml:"next"`      // Escenario que sigue con on_end next (ID o archivo YAML)
+	MaxLoops int    `yaml:"max_loops"` // Vueltas con on_end loop (0 = sin límite)
+
+	draws *redraw // Pasos sin expandir y sorteo de choose (nil = sin choose, ver Redraw)
 }
 
+// Comportamientos al terminar el escenario
+const (
+	EndHold = "hold" // Dejar el vehículo como quedó
+	EndStop = "stop" // Detener el vehículo (velocidad 0)
+	EndLoop = "loop" // Volver al primer paso
+	EndNext = "next" // Seguir con el escenario de next
+)
+
 // ScenarioStep es un paso del escenario
 type ScenarioStep struct {
-	Time   float64     `yaml:"time"`   // Tiempo en segundos desde el inicio
+	Time   float64     `yaml:"time"`   // Tiempo en segundos desde el inicio (o desde el inicio del bloque)
 	Action string      `yaml:"action"` // Tipo de acción
 	Value  interface{} `yaml:"value"`  // Valor de la acción (puede ser float, string, etc.)
+
+	// Disparo por condición: desde time, esperar a que se cumpla (ver trigger.go)
+	When *Trigger `yaml:"when,omitempty"`
+
+	// Control de flujo: Expand los convierte en pasos con Time absoluto
+	After  *Seconds       `yaml:"after,omitempty"`  // Segundos desde el paso anterior (en lugar de time)
+	Block  string         `yaml:"block,omitempty"`  // Insertar un bloque con nombre
+	Steps  []ScenarioStep `yaml:"steps,omitempty"`  // Pasos anidados (para repeat/loop)
+	Repeat int            `yaml:"repeat,omitempty"` // Repetir block/steps N veces
+	Loop   *LoopSpec      `yaml:"loop,omitempty"`   // Repetir block/steps hasta una condición
+	Choose []ChoiceSpec   `yaml:"choose,omitempty"` // Elegir una alternativa al azar por peso
+
+	Line int `yaml:"-"` // Línea del YAML (0 = escenario en Go); Expand la conserva
+}
+
+// UnmarshalYAML implementa yaml.Unmarshaler guardando la línea del paso
+func (step *ScenarioStep) UnmarshalYAML(node *yaml.Node) error {
+	type rawStep ScenarioStep // Sin métodos: evita la recursión
+	var raw rawStep
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	*step = ScenarioStep(raw)
+	step.Line = node.Line
+	return nil
 }
 
 // ActionType define los tipos de acciones posibles
@@ -32,24 +75,99 @@ const (
 	ActionLog           = "log"             // Imprimir mensaje
 	ActionPause         = "pause"           // Pausar simulación
 	ActionResume        = "resume"          // Reanudar simulación
+	ActionDoorOpen      = "door_open"       // Ordenar apertura de puerta
+	ActionDoorClose     = "door_close"      // Ordenar cierre de puerta
+	ActionDoorHold      = "door_hold"       // Detener la puerta donde esté
+	ActionDoorObstruct  = "door_obstruct"   // Simular obstáculo en la puerta
+	ActionInjectFault   = "inject_fault"    // Inyectar falla en un sensor
+	ActionClearFaults   = "clear_faults"    // Eliminar todas las fallas
+	ActionBoard         = "board"           // Subir N pasajeros por la puerta
+	ActionAlight        = "alight"          // Bajar N pasajeros por la puerta
+	ActionGPSFixLost    = "gps_fix_lost"    // Perder el fix del GPS
+	ActionGPSFixRestore = "gps_fix_restore" // Recuperar el fix del GPS
+	ActionJumpToStop    = "jump_to_stop"    // Mover el vehículo a una parada (ID o nombre)
+	ActionSetPosition   = "set_position"    // Mover el vehículo a un punto de la ruta (progreso 0.0-1.0)
+	ActionExpect        = "expect"          // Verificar el estado (si falla, se reporta y continúa)
+	ActionAssert        = "assert"          // Verificar el estado (si falla, se reporta y se detiene)
+	ActionLoopCheck     = "loop_check"      // Salir de un loop si se cumple su condición (lo genera loop)
 )
 
-// LoadScenario carga un escenario desde un archivo YAML
-func LoadScenario(filename string) (*Scenario, error) {
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		return nil, fmt.Errorf("error leyendo escenario: %w", err)
-	}
+// PassengerParams parámetros de board/alight
+type PassengerParams struct {
+	Count int `yaml:"count"` // Pasajeros que suben o bajan
+}
+
+// StopRef identifica una parada por ID o por nombre (jump_to_stop)
+type StopRef struct {
+	ID   int    `yaml:"id"`
+	Name string `yaml:"name"`
+}
+
+// WaitDoorParams parámetros de wait_door_open/wait_door_close
+type WaitDoorParams struct {
+	Timeout Seconds `yaml:"timeout"` // Espera máxima (0 = defaultDoorTimeout)
+}
+
+// defaultDoorTimeout espera máxima de wait_door_open/wait_door_close
+const defaultDoorTimeout = 30 * time.Second
+
+// PositionParams parámetros de set_position
+type PositionParams struct {
+	Progress float64 `yaml:"progress"` // Posición en la ruta (0.0 a 1.0)
+}
+
+// actionValidators valida el valor de cada acción (nil = sin parámetros)
+var actionValidators = map[string]func(value interface{}) error{
+	ActionSetSpeed:      validateSpeed,
+	ActionWaitDoorOpen:  func(value interface{}) error { _, err := ParseWaitDoor(value); return err },
+	ActionWaitDoorClose: func(value interface{}) error { _, err := ParseWaitDoor(value); return err },
+	ActionWait:          validateWait,
+	ActionLog:           nil,
+	ActionPause:         nil,
+	ActionResume:        nil,
+	ActionDoorOpen:      nil,
+	ActionDoorClose:     nil,
+	ActionDoorHold:      nil,
+	ActionDoorObstruct:  nil,
+	ActionInjectFault:   func(value interface{}) error { _, err := ParseFault(value); return err },
+	ActionClearFaults:   nil,
+	ActionBoard:         func(value interface{}) error { _, err := ParsePassengers(value); return err },
+	ActionAlight:        func(value interface{}) error { _, err := ParsePassengers(value); return err },
+	ActionGPSFixLost:    nil,
+	ActionGPSFixRestore: nil,
+	ActionJumpToStop:    func(value interface{}) error { _, err := ParseStopRef(value); return err },
+	ActionSetPosition:   func(value interface{}) error { _, err := ParsePosition(value); return err },
+	ActionExpect:        func(value interface{}) error { _, err := ParseExpect(value); return err },
+	ActionAssert:        func(value interface{}) error { _, err := ParseExpect(value); return err },
+	ActionLoopCheck:     func(value interface{}) error { _, err := ParseLoopCheck(value); return err },
+}
 
+// ParseScenario convierte el contenido de un YAML en un escenario expandido y
+// validado (filename solo se usa en los errores). Los errores son *LoadError
+// con el archivo, la etapa y, si se conoce, la línea que falló. Los archivos
+// se cargan con Repository.
+func ParseScenario(data []byte, filename string) (*Scenario, error) {
 	var scenario Scenario
-	err = yaml.Unmarshal(data, &scenario)
-	if err != nil {
-		return nil, fmt.Errorf("error parseando YAML: %w", err)
+	if err := yaml.Unmarshal(data, &scenario); err != nil {
+		return nil, &LoadError{File: filename, Stage: StageYAML, Line: yamlErrorLine(err), Step: -1, Err: err}
+	}
+
+	// Expandir bloques, repeticiones y tiempos relativos
+	if err := scenario.Expand(); err != nil {
+		return nil, &LoadError{File: filename, Stage: StageExpand, Step: -1, Err: err}
 	}
 
 	// Validar escenario
 	if err := scenario.Validate(); err != nil {
-		return nil, fmt.Errorf("escenario inválido: %w", err)
+		loadErr := &LoadError{File: filename, Stage: StageValidate, Step: -1, Err: err}
+		var stepErr *StepError
+		if errors.As(err, &stepErr) {
+			loadErr.Step = stepErr.Index
+			if stepErr.Index >= 0 && stepErr.Index < len(scenario.Steps) {
+				loadErr.Line = scenario.Steps[stepErr.Index].Line
+			}
+		}
+		return nil, loadErr
 	}
 
 	return &scenario, nil
@@ -65,46 +183,291 @@ func (s *Scenario) Validate() error {
 		return fmt.Errorf("el escenario debe tener al menos un paso")
 	}
 
+	switch s.OnEnd {
+	case "", EndHold, EndStop, EndLoop:
+	case EndNext:
+		if s.Next == "" {
+			return fmt.Errorf("on_end next requiere el escenario en next")
+		}
+	default:
+		return fmt.Errorf("on_end '%s' no válido (hold, stop, loop o next)", s.OnEnd)
+	}
+	if s.MaxLoops < 0 {
+		return fmt.Errorf("max_loops no puede ser negativo")
+	}
+
 	// Verificar que los tiempos estén ordenados
 	lastTime := -1.0
 	for i, step := range s.Steps {
 		if step.Time < 0 {
-			return fmt.Errorf("paso %d: el tiempo no puede ser negativo", i)
+			return &StepError{Index: i, Err: fmt.Errorf("el tiempo no puede ser negativo")}
 		}
 		if step.Time < lastTime {
-			return fmt.Errorf("paso %d: los pasos deben estar ordenados por tiempo", i)
+			return &StepError{Index: i, Err: fmt.Errorf("los pasos deben estar ordenados por tiempo")}
 		}
 		lastTime = step.Time
 
-		// Validar acción
+		// Validar acción y sus parámetros
+		if step.IsControl() || step.After != nil {
+			return &StepError{Index: i, Err: fmt.Errorf("control de flujo sin expandir (usar Expand)")}
+		}
 		if !isValidAction(step.Action) {
-			return fmt.Errorf("paso %d: acción '%s' no válida", i, step.Action)
+			return &StepError{Index: i, Err: fmt.Errorf("acción '%s' no válida", step.Action)}
+		}
+
+		if validate := actionValidators[step.Action]; validate != nil {
+			if err := validate(step.Value); err != nil {
+				return &StepError{Index: i, Action: step.Action, Err: err}
+			}
+		}
+		if step.When != nil {
+			if err := step.When.Validate(); err != nil {
+				return &StepError{Index: i, Action: step.Action, Err: fmt.Errorf("when: %w", err)}
+			}
+		}
+
+		// El salto de un loop_check debe ir hacia adelante
+		if step.Action == ActionLoopCheck {
+			check, _ := ParseLoopCheck(step.Value)
+			if check.Jump <= i || check.Jump > len(s.Steps) {
+				return &StepError{Index: i, Action: step.Action, Err: fmt.Errorf("salto inválido a %d", check.Jump)}
+			}
 		}
 	}
 
 	return nil
 }
 
+// StepError error de validación de un paso (Index es su posición en Steps)
+type StepError struct {
+	Index  int
+	Action string // Vacío si la acción misma es inválida
+	Err    error
+}
+
+// Error implementa error
+func (e *StepError) Error() string {
+	if e.Action == "" {
+		return fmt.Sprintf("paso %d: %v", e.Index, e.Err)
+	}
+	return fmt.Sprintf("paso %d (%s): %v", e.Index, e.Action, e.Err)
+}
+
+// Unwrap retorna el error original
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
 // isValidAction verifica si una acción es válida
 func isValidAction(action string) bool {
-	validActions := []string{
-		ActionSetSpeed,
-		ActionWaitDoorOpen,
-		ActionWaitDoorClose,
-		ActionWait,
-		ActionLog,
-		ActionPause,
-		ActionResume,
-	}
-
-	for _, valid := range validActions {
-		if action == valid {
+	_, ok := actionValidators[action]
+	return ok
+}
+
+// isDoorAction verifica si una acción controla o espera la puerta
+func isDoorAction(action string) bool {
+	switch action {
+	case ActionWaitDoorOpen, ActionWaitDoorClose,
+		ActionDoorOpen, ActionDoorClose, ActionDoorHold, ActionDoorObstruct:
+		return true
+	}
+	return false
+}
+
+// ControlsPassengers retorna true si el escenario decide quién sube y baja
+func (s *Scenario) ControlsPassengers() bool {
+	for _, step := range s.Steps {
+		if step.Action == ActionBoard || step.Action == ActionAlight {
 			return true
 		}
 	}
 	return false
 }
 
+// ControlsDoor retorna true si algún paso del escenario maneja la puerta
+func (s *Scenario) ControlsDoor() bool {
+	for _, step := range s.Steps {
+		if isDoorAction(step.Action) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFault convierte el valor de un paso inject_fault en una falla
+func ParseFault(value interface{}) (config.FaultConfig, error) {
+	switch v := value.(type) {
+	case config.FaultConfig:
+		if err := v.Validate(); err != nil {
+			return config.FaultConfig{}, err
+		}
+		return v, nil
+	case map[string]interface{}:
+		// Reutilizar los tags YAML de config.FaultConfig
+		var fault config.FaultConfig
+		if err := decodeParams(v, &fault); err != nil {
+			return config.FaultConfig{}, fmt.Errorf("falla inválida: %w", err)
+		}
+		if fault.Sensor == "" || fault.Type == "" {
+			return config.FaultConfig{}, fmt.Errorf("la falla requiere sensor y type")
+		}
+		if err := fault.Validate(); err != nil {
+			return config.FaultConfig{}, err
+		}
+		return fault, nil
+	}
+	return config.FaultConfig{}, fmt.Errorf("valor inválido para inject_fault: %v", value)
+}
+
+// ParseNumber convierte el valor numérico de un paso (set_speed, wait)
+func ParseNumber(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	}
+	return 0, fmt.Errorf("se esperaba un número: %v", value)
+}
+
+// ParsePassengers convierte el valor de board/alight (N o {count: N})
+func ParsePassengers(value interface{}) (PassengerParams, error) {
+	var params PassengerParams
+
+	switch v := value.(type) {
+	case PassengerParams:
+		params = v
+	case int:
+		params.Count = v
+	case map[string]interface{}:
+		if err := decodeParams(v, &params); err != nil {
+			return PassengerParams{}, fmt.Errorf("pasajeros inválidos: %w", err)
+		}
+	default:
+		return PassengerParams{}, fmt.Errorf("se esperaba la cantidad de pasajeros: %v", value)
+	}
+
+	if params.Count <= 0 {
+		return PassengerParams{}, fmt.Errorf("la cantidad de pasajeros debe ser mayor a 0")
+	}
+	return params, nil
+}
+
+// ParseStopRef convierte el valor de jump_to_stop (ID, nombre o {id|name})
+func ParseStopRef(value interface{}) (StopRef, error) {
+	var ref StopRef
+
+	switch v := value.(type) {
+	case StopRef:
+		ref = v
+	case int:
+		ref.ID = v
+	case string:
+		ref.Name = v
+	case map[string]interface{}:
+		if err := decodeParams(v, &ref); err != nil {
+			return StopRef{}, fmt.Errorf("parada inválida: %w", err)
+		}
+	default:
+		return StopRef{}, fmt.Errorf("se esperaba el ID o nombre de la parada: %v", value)
+	}
+
+	if ref.ID <= 0 && ref.Name == "" {
+		return StopRef{}, fmt.Errorf("la parada requiere id o name")
+	}
+	return ref, nil
+}
+
+// ParseWaitDoor convierte el valor de wait_door_open/wait_door_close
+// (sin valor, segundos o {timeout})
+func ParseWaitDoor(value interface{}) (WaitDoorParams, error) {
+	var params WaitDoorParams
+
+	switch v := value.(type) {
+	case nil:
+	case WaitDoorParams:
+		params = v
+	case float64, int:
+		seconds, _ := ParseNumber(v)
+		params.Timeout = Seconds(seconds)
+	case map[string]interface{}:
+		if err := decodeParams(v, &params); err != nil {
+			return WaitDoorParams{}, fmt.Errorf("espera inválida: %w", err)
+		}
+	default:
+		return WaitDoorParams{}, fmt.Errorf("se esperaba el timeout en segundos: %v", value)
+	}
+
+	if params.Timeout < 0 {
+		return WaitDoorParams{}, fmt.Errorf("el timeout no puede ser negativo")
+	}
+	return params, nil
+}
+
+// timeout retorna la espera máxima de la puerta
+func (p WaitDoorParams) timeout() time.Duration {
+	if p.Timeout == 0 {
+		return defaultDoorTimeout
+	}
+	return time.Duration(float64(p.Timeout) * float64(time.Second))
+}
+
+// ParsePosition convierte el valor de set_position (progreso o {progress})
+func ParsePosition(value interface{}) (PositionParams, error) {
+	var params PositionParams
+
+	switch v := value.(type) {
+	case PositionParams:
+		params = v
+	case float64, int:
+		params.Progress, _ = ParseNumber(v)
+	case map[string]interface{}:
+		if err := decodeParams(v, &params); err != nil {
+			return PositionParams{}, fmt.Errorf("posición inválida: %w", err)
+		}
+	default:
+		return PositionParams{}, fmt.Errorf("se esperaba el progreso en la ruta: %v", value)
+	}
+
+	if params.Progress < 0 || params.Progress > 1 {
+		return PositionParams{}, fmt.Errorf("el progreso debe estar entre 0.0 y 1.0")
+	}
+	return params, nil
+}
+
+// validateSpeed valida el valor de set_speed
+func validateSpeed(value interface{}) error {
+	speed, err := ParseNumber(value)
+	if err != nil {
+		return err
+	}
+	if speed < 0 {
+		return fmt.Errorf("la velocidad no puede ser negativa")
+	}
+	return nil
+}
+
+// validateWait valida el valor de wait
+func validateWait(value interface{}) error {
+	seconds, err := ParseNumber(value)
+	if err != nil {
+		return err
+	}
+	if seconds < 0 {
+		return fmt.Errorf("la espera no puede ser negativa")
+	}
+	return nil
+}
+
+// decodeParams convierte un mapa YAML en una estructura de parámetros (usa sus tags yaml)
+func decodeParams(value map[string]interface{}, out interface{}) error {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, out)
+}
+
 // GetDuration retorna la duración total del escenario
 func (s *Scenario) GetDuration() time.Duration {
 	if s.Duration > 0 {
@@ -124,18 +487,3 @@ func (s *Scenario) GetDuration() time.Duration {
 func (s *Scenario) String() string {
 	return fmt.Sprintf("Escenario: %s (%d pasos, %.0fs)", s.Name, len(s.Steps), s.GetDuration().Seconds())
 }
-
-// LoadFromYAML carga un escenario desde un archivo YAML
-func LoadFromYAML(filePath string) (*Scenario, error) {
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("error leyendo archivo: %w", err)
-	}
-
-	var scenario Scenario
-	if err := yaml.Unmarshal(data, &scenario); err != nil {
-		return nil, fmt.Errorf("error parseando YAML: %w", err)
-	}
-
-	return &scenario, nil
-}
@@ -0,0 +1,231 @@
+package scenario
+
+import (
+	"fmt"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/clock"
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+)
+
+// VehiclePose estado del vehículo en la ruta que reconstruye un Seek
+type VehiclePose struct {
+	Progress  float64 // Posición en la ruta (0.0 a 1.0)
+	Direction int     // +1 ida, -1 regreso (0 = conservar el actual)
+	Speed     float64 // km/h
+	FixLost   bool
+}
+
+// advancePose mueve el vehículo seconds segundos a su velocidad actual,
+// invirtiendo el sentido en las terminales como el GPS
+func advancePose(pose VehiclePose, seconds float64, route *Route) VehiclePose {
+	if pose.Speed <= 0 || seconds <= 0 || route == nil || route.Length <= 0 {
+		return pose
+	}
+	if pose.Direction == 0 {
+		pose.Direction = 1
+	}
+
+	pose.Progress += pose.Speed / 3600.0 * seconds / route.Length * float64(pose.Direction)
+	for pose.Progress > 1.0 || pose.Progress < 0.0 {
+		if pose.Progress > 1.0 {
+			pose.Progress = 2.0 - pose.Progress
+			pose.Direction = -1
+		} else {
+			pose.Progress = -pose.Progress
+			pose.Direction = 1
+		}
+	}
+	return pose
+}
+
+// replay reconstruye el vehículo en el instante until aplicando, desde origin,
+// los pasos anteriores que lo mueven (velocidad, posición, fix y puerta).
+// Retorna la última orden de puerta (nil si ningún paso la mueve).
+// Los pasos se toman en su tiempo planificado: no se reproducen las esperas de
+// when ni las salidas tempranas de loop.
+func replay(steps []ScenarioStep, origin VehiclePose, route *Route, until float64) (VehiclePose, *bool) {
+	pose := origin
+	var doorOpen *bool
+	last := 0.0
+
+	for _, step := range steps {
+		if step.Time >= until {
+			break
+		}
+		pose = advancePose(pose, step.Time-last, route)
+		last = step.Time
+
+		switch step.Action {
+		case ActionSetSpeed:
+			if speed, err := ParseNumber(step.Value); err == nil {
+				pose.Speed = speed
+			}
+		case ActionJumpToStop:
+			if ref, err := ParseStopRef(step.Value); err == nil && route != nil {
+				if stop := route.FindStop(ref); stop != nil {
+					pose.Progress = stop.Position
+				}
+			}
+		case ActionSetPosition:
+			if params, err := ParsePosition(step.Value); err == nil {
+				pose.Progress = params.Progress
+			}
+		case ActionGPSFixLost, ActionGPSFixRestore:
+			pose.FixLost = step.Action == ActionGPSFixLost
+		case ActionDoorOpen, ActionWaitDoorOpen, ActionDoorClose, ActionWaitDoorClose:
+			open := step.Action == ActionDoorOpen || step.Action == ActionWaitDoorOpen
+			doorOpen = &open
+		}
+	}
+
+	return advancePose(pose, until-last, route), doorOpen
+}
+
+// Seek salta al segundo t del escenario: reconstruye velocidad, posición, fix
+// y puerta como si los pasos anteriores se hubieran ejecutado, y sigue desde
+// el primer paso en t o después. Fallas y pasajeros no se reproducen.
+func (e *Executor) Seek(t float64) error {
+	e.mu.Lock()
+	if !e.running {
+		e.mu.Unlock()
+		return fmt.Errorf("no hay un escenario en ejecución")
+	}
+
+	scenario := e.scenario
+	t = clampSeek(scenario, t)
+	position := e.position
+	doorController := e.doorController
+	origin := e.origin
+
+	e.elapsedBase = t
+	e.resumedAt = clock.Now()
+	e.currentStepIndex = firstStepAt(scenario.Steps, t)
+	e.jumpTo = -1
+	e.timeShift = 0
+	e.seekGen++
+	e.mu.Unlock()
+
+	var route *Route
+	if position != nil {
+		route = position.GetRoute()
+	}
+	pose, doorOpen := replay(scenario.Steps, origin, route, t)
+
+	if position != nil {
+		position.SetPose(pose)
+	} else {
+		e.speedController.SetSpeed(pose.Speed)
+	}
+	if doorController != nil && doorOpen != nil && scenario.ControlsDoor() {
+		if *doorOpen {
+			doorController.OpenDoor()
+		} else {
+			doorController.CloseDoor()
+		}
+		doorController.Settle()
+	}
+
+	fmt.Fprintf(e.out, "⏩ [Executor] Seek a %.1fs (paso %d, %.1f km/h, progreso %.2f)\n",
+		t, e.GetCurrentStep(), pose.Speed, pose.Progress)
+	e.publish(eventbus.ScenarioSeek, e.GetCurrentStep(), "", "")
+	return nil
+}
+
+// SeekBy salta seconds segundos hacia adelante (o atrás si es negativo)
+func (e *Executor) SeekBy(seconds float64) error {
+	return e.Seek(e.Elapsed() + seconds)
+}
+
+// StepNext adelanta el tiempo hasta el próximo paso y lo ejecuta sin esperar
+// su condición when; con el escenario pausado ejecuta solo ese paso
+func (e *Executor) StepNext() error {
+	e.mu.Lock()
+	if !e.running {
+		e.mu.Unlock()
+		return fmt.Errorf("no hay un escenario en ejecución")
+	}
+	if e.currentStepIndex >= len(e.scenario.Steps) {
+		e.mu.Unlock()
+		return fmt.Errorf("no quedan pasos en '%s'", e.scenario.Name)
+	}
+
+	step := e.scenario.Steps[e.currentStepIndex]
+	elapsed := e.elapsedLocked()
+	skipped := step.Time - e.timeShift - elapsed
+	if skipped > 0 {
+		e.elapsedBase = elapsed + skipped
+		e.resumedAt = clock.Now()
+	}
+	if e.paused {
+		e.stepOnce = true
+	}
+	index := e.currentStepIndex
+	position := e.position
+	doorController := e.doorController
+	scenario := e.scenario
+	e.mu.Unlock()
+
+	// El vehículo recorre lo que habría recorrido en los segundos salteados
+	if position != nil && skipped > 0 {
+		position.SetPose(advancePose(position.GetPose(), skipped, position.GetRoute()))
+	}
+	if doorController != nil && scenario.ControlsDoor() {
+		doorController.Settle()
+	}
+
+	fmt.Fprintf(e.out, "⏭️  [Executor] Paso %d (%s)\n", index, step.Action)
+	return nil
+}
+
+// SetRate cambia cuántos segundos de escenario corren por segundo de reloj
+// (sigue al multiplicador de velocidad de la UI)
+func (e *Executor) SetRate(rate float64) {
+	if rate <= 0 {
+		fmt.Fprintf(e.out, "⚠️  [Executor] Velocidad de reproducción inválida: %.2f\n", rate)
+		return
+	}
+
+	e.mu.Lock()
+	e.elapsedBase = e.elapsedLocked()
+	e.resumedAt = clock.Now()
+	e.rate = rate
+	e.mu.Unlock()
+}
+
+// GetRate retorna la velocidad de reproducción del escenario
+func (e *Executor) GetRate() float64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.rate
+}
+
+// TimelineEnd retorna el último segundo al que se puede saltar: la duración
+// del escenario o el tiempo de su último paso si es posterior
+func (s *Scenario) TimelineEnd() float64 {
+	end := s.GetDuration().Seconds()
+	if n := len(s.Steps); n > 0 && s.Steps[n-1].Time > end {
+		end = s.Steps[n-1].Time
+	}
+	return end
+}
+
+// clampSeek limita t a la línea de tiempo del escenario
+func clampSeek(scenario *Scenario, t float64) float64 {
+	if t < 0 {
+		return 0
+	}
+	if end := scenario.TimelineEnd(); t > end {
+		return end
+	}
+	return t
+}
+
+// firstStepAt retorna el índice del primer paso con tiempo t o posterior
+func firstStepAt(steps []ScenarioStep, t float64) int {
+	for i, step := range steps {
+		if step.Time >= t {
+			return i
+		}
+	}
+	return len(steps)
+}
@@ -0,0 +1,269 @@
+package scenario
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/clock"
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+)
+
+// fakeDoor registra las órdenes de puerta
+type fakeDoor struct {
+	commands []string
+}
+
+func (f *fakeDoor) OpenDoor()                       { f.commands = append(f.commands, "open") }
+func (f *fakeDoor) CloseDoor()                      { f.commands = append(f.commands, "close") }
+func (f *fakeDoor) HoldDoor()                       { f.commands = append(f.commands, "hold") }
+func (f *fakeDoor) ObstructDoor()                   { f.commands = append(f.commands, "obstruct") }
+func (f *fakeDoor) Settle()                         { f.commands = append(f.commands, "settle") }
+func (f *fakeDoor) SetScenarioControl(enabled bool) {}
+
+// seekRoute ruta de 1 km con una parada a mitad de camino
+func seekRoute() *Route {
+	return &Route{Length: 1, Stops: []Stop{{ID: 2, Name: "Mercado", Position: 0.5}}}
+}
+
+// seekScenario acelera a 36 km/h (10 m/s), abre la puerta en la parada y arranca
+func seekScenario() *Scenario {
+	return &Scenario{
+		Name:     "seek",
+		Duration: 60,
+		Steps: []ScenarioStep{
+			{Time: 0, Action: ActionSetSpeed, Value: 36.0},
+			{Time: 10, Action: ActionJumpToStop, Value: 2},
+			{Time: 10, Action: ActionSetSpeed, Value: 0.0},
+			{Time: 12, Action: ActionDoorOpen},
+			{Time: 20, Action: ActionDoorClose},
+			{Time: 25, Action: ActionSetSpeed, Value: 36.0},
+		},
+	}
+}
+
+// withVirtualClock usa un reloj virtual durante el test
+func withVirtualClock(t *testing.T) *clock.Virtual {
+	t.Helper()
+	virtual := clock.NewVirtual(time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	clock.Set(virtual)
+	t.Cleanup(func() { clock.Set(nil) })
+	return virtual
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestAdvancePose(t *testing.T) {
+	route := seekRoute()
+
+	tests := []struct {
+		name    string
+		pose    VehiclePose
+		seconds float64
+		want    VehiclePose
+	}{
+		{"detenido no se mueve", VehiclePose{Progress: 0.3, Direction: 1}, 10, VehiclePose{Progress: 0.3, Direction: 1}},
+		{"ida", VehiclePose{Progress: 0.1, Direction: 1, Speed: 36}, 20, VehiclePose{Progress: 0.3, Direction: 1, Speed: 36}},
+		{"sin sentido va de ida", VehiclePose{Progress: 0.1, Speed: 36}, 10, VehiclePose{Progress: 0.2, Direction: 1, Speed: 36}},
+		{"regreso", VehiclePose{Progress: 0.5, Direction: -1, Speed: 36}, 20, VehiclePose{Progress: 0.3, Direction: -1, Speed: 36}},
+		{"invierte en la terminal", VehiclePose{Progress: 0.9, Direction: 1, Speed: 36}, 20, VehiclePose{Progress: 0.9, Direction: -1, Speed: 36}},
+		{"invierte en el origen", VehiclePose{Progress: 0.1, Direction: -1, Speed: 36}, 20, VehiclePose{Progress: 0.1, Direction: 1, Speed: 36}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := advancePose(tt.pose, tt.seconds, route)
+			if !almostEqual(got.Progress, tt.want.Progress) || got.Direction != tt.want.Direction || got.Speed != tt.want.Speed {
+				t.Errorf("pose = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if got := advancePose(VehiclePose{Progress: 0.1, Speed: 36}, 10, nil); got.Progress != 0.1 {
+		t.Errorf("sin ruta: progreso = %v, want 0.1", got.Progress)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	scenario := seekScenario()
+	origin := VehiclePose{Progress: 0, Direction: 1}
+
+	tests := []struct {
+		name     string
+		until    float64
+		progress float64
+		speed    float64
+		door     string // "" = sin orden, "open" o "close"
+	}{
+		{"al inicio", 0, 0, 0, ""},
+		{"acelerando", 5, 0.05, 36, ""},
+		{"en la parada", 11, 0.5, 0, ""},
+		{"puerta abierta", 15, 0.5, 0, "open"},
+		{"puerta cerrada", 22, 0.5, 0, "close"},
+		{"saliendo", 30, 0.55, 36, "close"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pose, doorOpen := replay(scenario.Steps, origin, seekRoute(), tt.until)
+			if !almostEqual(pose.Progress, tt.progress) || pose.Speed != tt.speed {
+				t.Errorf("pose = %+v, want progreso %v a %v km/h", pose, tt.progress, tt.speed)
+			}
+
+			door := ""
+			if doorOpen != nil {
+				door = "close"
+				if *doorOpen {
+					door = "open"
+				}
+			}
+			if door != tt.door {
+				t.Errorf("puerta = %q, want %q", door, tt.door)
+			}
+		})
+	}
+}
+
+func TestClampSeekAndFirstStepAt(t *testing.T) {
+	scenario := seekScenario()
+
+	if got := clampSeek(scenario, -5); got != 0 {
+		t.Errorf("clampSeek(-5) = %v, want 0", got)
+	}
+	if got := clampSeek(scenario, 100); got != 60 {
+		t.Errorf("clampSeek(100) = %v, want 60", got)
+	}
+
+	tests := []struct {
+		t    float64
+		want int
+	}{
+		{0, 0},
+		{5, 1},
+		{10, 1},
+		{12, 3},
+		{26, 6},
+	}
+	for _, tt := range tests {
+		if got := firstStepAt(scenario.Steps, tt.t); got != tt.want {
+			t.Errorf("firstStepAt(%v) = %d, want %d", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestExecutorSeek(t *testing.T) {
+	withVirtualClock(t)
+
+	bus := eventbus.NewEventBus()
+	channel := bus.Subscribe(eventbus.EventScenario)
+	position := &fakePosition{route: seekRoute(), pose: VehiclePose{Direction: 1}}
+	door := &fakeDoor{}
+
+	executor := NewExecutor(seekScenario(), &fakeSpeed{}, bus)
+	executor.SetPositionController(position)
+	executor.SetDoorController(door)
+
+	if err := executor.Seek(10); err == nil {
+		t.Error("Seek sin escenario en ejecución debería fallar")
+	}
+
+	executor.running = true
+	executor.begin(executor.scenario, 1)
+	scenarioEvents(channel)
+
+	if err := executor.Seek(15); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	if step := executor.GetCurrentStep(); step != 4 {
+		t.Errorf("paso = %d, want 4 (door_close)", step)
+	}
+	if elapsed := executor.Elapsed(); elapsed != 15 {
+		t.Errorf("transcurrido = %v, want 15", elapsed)
+	}
+	if !almostEqual(position.pose.Progress, 0.5) || position.pose.Speed != 0 {
+		t.Errorf("pose = %+v, want detenido en la parada", position.pose)
+	}
+	if len(door.commands) != 2 || door.commands[0] != "open" || door.commands[1] != "settle" {
+		t.Errorf("puerta = %v, want [open settle]", door.commands)
+	}
+
+	events := scenarioEvents(channel)
+	if len(events) != 1 || events[0].EventType != eventbus.ScenarioSeek || events[0].Step != 4 {
+		t.Errorf("eventos = %+v, want un SEEK al paso 4", events)
+	}
+
+	// Hacia atrás vuelve a reproducir desde el origen de la vuelta
+	if err := executor.SeekBy(-10); err != nil {
+		t.Fatalf("SeekBy: %v", err)
+	}
+	if step := executor.GetCurrentStep(); step != 1 || !almostEqual(position.pose.Progress, 0.05) || position.pose.Speed != 36 {
+		t.Errorf("paso %d pose %+v, want paso 1 en 0.05 a 36 km/h", step, position.pose)
+	}
+}
+
+func TestExecutorSetRate(t *testing.T) {
+	virtual := withVirtualClock(t)
+
+	executor := NewExecutor(seekScenario(), &fakeSpeed{}, eventbus.NewEventBus())
+	executor.running = true
+	executor.begin(executor.scenario, 1)
+
+	virtual.Advance(2 * time.Second)
+	executor.SetRate(3)
+	virtual.Advance(2 * time.Second)
+	if elapsed := executor.Elapsed(); elapsed != 8 {
+		t.Errorf("transcurrido = %v, want 8 (2s a x1 y 2s a x3)", elapsed)
+	}
+
+	executor.SetRate(0)
+	if rate := executor.GetRate(); rate != 3 {
+		t.Errorf("rate = %v, want 3 (se ignora una velocidad inválida)", rate)
+	}
+
+	// En pausa el tiempo del escenario no corre
+	executor.Pause()
+	virtual.Advance(5 * time.Second)
+	if elapsed := executor.Elapsed(); elapsed != 8 {
+		t.Errorf("en pausa: transcurrido = %v, want 8", elapsed)
+	}
+	executor.Resume()
+	virtual.Advance(time.Second)
+	if elapsed := executor.Elapsed(); elapsed != 11 {
+		t.Errorf("tras reanudar: transcurrido = %v, want 11", elapsed)
+	}
+}
+
+func TestExecutorStepNext(t *testing.T) {
+	virtual := withVirtualClock(t)
+
+	position := &fakePosition{route: seekRoute(), pose: VehiclePose{Direction: 1, Speed: 36}}
+	executor := NewExecutor(seekScenario(), &fakeSpeed{}, eventbus.NewEventBus())
+	executor.SetPositionController(position)
+	executor.running = true
+	executor.begin(executor.scenario, 1)
+	executor.currentStepIndex = 1
+
+	virtual.Advance(4 * time.Second)
+	executor.Pause()
+	if err := executor.StepNext(); err != nil {
+		t.Fatalf("StepNext: %v", err)
+	}
+
+	// Salta los 6s que faltaban para el paso 1 y el vehículo los recorre
+	if elapsed := executor.Elapsed(); elapsed != 10 {
+		t.Errorf("transcurrido = %v, want 10", elapsed)
+	}
+	if !almostEqual(position.pose.Progress, 0.06) {
+		t.Errorf("progreso = %v, want 0.06", position.pose.Progress)
+	}
+	if !executor.isStepping() {
+		t.Error("en pausa StepNext debería ejecutar un solo paso")
+	}
+
+	executor.currentStepIndex = len(executor.scenario.Steps)
+	if err := executor.StepNext(); err == nil {
+		t.Error("StepNext sin pasos restantes debería fallar")
+	}
+}
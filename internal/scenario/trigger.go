@@ -0,0 +1,169 @@
+package scenario
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+)
+
+// defaultTriggerTimeout espera máxima de un when sin timeout
+const defaultTriggerTimeout = 60 * time.Second
+
+// Qué hacer con el paso si su condición no se cumple a tiempo
+const (
+	OnTimeoutSkip  = "skip"  // Omitir el paso y seguir (por defecto)
+	OnTimeoutRun   = "run"   // Ejecutar el paso igual
+	OnTimeoutFail  = "fail"  // Reportar una verificación fallida y omitir el paso
+	OnTimeoutAbort = "abort" // Reportar una verificación fallida y detener el escenario
+)
+
+// Trigger condición que dispara un paso: desde su time, el paso espera a que
+// se cumpla (un campo del estado, un evento nuevo o la llegada a una parada).
+// Los pasos siguientes se corren lo que duró la espera.
+type Trigger struct {
+	// Condición sobre un campo (misma sintaxis que expect)
+	Field     string      `yaml:"field"`
+	Equals    interface{} `yaml:"equals"`
+	Min       *float64    `yaml:"min"`
+	Max       *float64    `yaml:"max"`
+	Tolerance float64     `yaml:"tolerance"`
+
+	// Evento publicado después de empezar la espera (tipo de recordedEvents)
+	Event string                 `yaml:"event"`
+	Match map[string]interface{} `yaml:"match"` // Campos del evento que deben coincidir
+
+	// Llegada a una parada (ID o nombre); atajo de event stop_event ARRIVED
+	Stop interface{} `yaml:"stop"`
+
+	Timeout   Seconds `yaml:"timeout"`    // Espera máxima (0 = defaultTriggerTimeout)
+	OnTimeout string  `yaml:"on_timeout"` // skip, run, fail o abort
+	Message   string  `yaml:"message"`    // Descripción para el reporte (fail/abort)
+}
+
+// Validate verifica que el trigger tenga exactamente una condición válida
+func (t Trigger) Validate() error {
+	kinds := 0
+	if t.Field != "" {
+		kinds++
+		if _, err := ParseExpect(t.condition()); err != nil {
+			return err
+		}
+	}
+	if t.Event != "" {
+		kinds++
+		if !isRecordedEvent(t.Event) {
+			return fmt.Errorf("evento '%s' no válido", t.Event)
+		}
+	}
+	if t.Stop != nil {
+		kinds++
+		if _, err := ParseStopRef(t.Stop); err != nil {
+			return err
+		}
+	}
+
+	if kinds != 1 {
+		return fmt.Errorf("when requiere uno de field, event o stop")
+	}
+	if t.Match != nil && t.Event == "" {
+		return fmt.Errorf("match solo se usa con event")
+	}
+	if t.Timeout < 0 {
+		return fmt.Errorf("timeout no puede ser negativo")
+	}
+
+	switch t.OnTimeout {
+	case "", OnTimeoutSkip, OnTimeoutRun, OnTimeoutFail, OnTimeoutAbort:
+		return nil
+	}
+	return fmt.Errorf("on_timeout '%s' no válido (skip, run, fail o abort)", t.OnTimeout)
+}
+
+// condition retorna la condición sobre un campo como parámetros de expect
+func (t Trigger) condition() ExpectParams {
+	return ExpectParams{
+		Field:     t.Field,
+		Equals:    t.Equals,
+		Min:       t.Min,
+		Max:       t.Max,
+		Tolerance: t.Tolerance,
+	}
+}
+
+// timeout retorna la espera máxima del trigger
+func (t Trigger) timeout() time.Duration {
+	if t.Timeout == 0 {
+		return defaultTriggerTimeout
+	}
+	return time.Duration(float64(t.Timeout) * float64(time.Second))
+}
+
+// onTimeout retorna qué hacer si la condición no se cumple a tiempo
+func (t Trigger) onTimeout() string {
+	if t.OnTimeout == "" {
+		return OnTimeoutSkip
+	}
+	return t.OnTimeout
+}
+
+// eventType retorna el tipo de evento que espera el trigger ("" = campo)
+func (t Trigger) eventType() eventbus.EventType {
+	if t.Stop != nil {
+		return eventbus.EventStop
+	}
+	return eventbus.EventType(t.Event)
+}
+
+// matches verifica si un evento nuevo dispara el trigger
+func (t Trigger) matches(data interface{}) bool {
+	if t.Stop != nil {
+		ref, _ := ParseStopRef(t.Stop)
+		stop, ok := data.(eventbus.StopEventData)
+		if !ok || stop.EventType != eventbus.StopArrived {
+			return false
+		}
+		return (ref.ID > 0 && stop.StopID == ref.ID) || (ref.Name != "" && stop.StopName == ref.Name)
+	}
+
+	for name, expected := range t.Match {
+		actual, err := structField(data, name)
+		if err != nil {
+			return false
+		}
+		if ok, _ := (ExpectParams{Equals: expected}).check(actual); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// describe retorna la condición en texto para logs y reportes
+func (t Trigger) describe() string {
+	switch {
+	case t.Stop != nil:
+		ref, _ := ParseStopRef(t.Stop)
+		if ref.Name != "" {
+			return fmt.Sprintf("llegada a %s", ref.Name)
+		}
+		return fmt.Sprintf("llegada a la parada %d", ref.ID)
+
+	case t.Event != "":
+		if len(t.Match) == 0 {
+			return "evento " + t.Event
+		}
+		names := make([]string, 0, len(t.Match))
+		for name := range t.Match {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts := make([]string, 0, len(names))
+		for _, name := range names {
+			parts = append(parts, fmt.Sprintf("%s=%v", name, t.Match[name]))
+		}
+		return fmt.Sprintf("evento %s (%s)", t.Event, strings.Join(parts, ", "))
+	}
+	return fmt.Sprintf("%s %s", t.Field, t.condition().describe())
+}
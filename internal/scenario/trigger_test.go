@@ -0,0 +1,171 @@
+package scenario
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/clock"
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+)
+
+func TestTriggerValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		trigger Trigger
+		wantErr bool
+	}{
+		{"campo", Trigger{Field: "speed", Equals: 0}, false},
+		{"evento con match", Trigger{Event: "stop_event", Match: map[string]interface{}{"event_type": "ARRIVED"}}, false},
+		{"parada por nombre", Trigger{Stop: "Centro", OnTimeout: OnTimeoutAbort}, false},
+		{"sin condición", Trigger{}, true},
+		{"dos condiciones", Trigger{Field: "speed", Equals: 0, Stop: 2}, true},
+		{"campo desconocido", Trigger{Field: "altitud", Equals: 0}, true},
+		{"evento no registrado", Trigger{Event: "gps_raw"}, true},
+		{"parada sin id ni nombre", Trigger{Stop: map[string]interface{}{}}, true},
+		{"match sin evento", Trigger{Field: "speed", Equals: 0, Match: map[string]interface{}{"a": 1}}, true},
+		{"timeout negativo", Trigger{Field: "speed", Equals: 0, Timeout: -1}, true},
+		{"on_timeout inválido", Trigger{Field: "speed", Equals: 0, OnTimeout: "retry"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.trigger.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTriggerMatches(t *testing.T) {
+	arrived := eventbus.StopEventData{EventType: eventbus.StopArrived, StopID: 2, StopName: "Mercado"}
+	departed := eventbus.StopEventData{EventType: eventbus.StopDeparted, StopID: 2, StopName: "Mercado"}
+	entry := eventbus.PassengerEventData{EventType: "ENTRY", CurrentCount: 3}
+
+	tests := []struct {
+		name    string
+		trigger Trigger
+		data    interface{}
+		want    bool
+	}{
+		{"llegada por ID", Trigger{Stop: 2}, arrived, true},
+		{"llegada por nombre", Trigger{Stop: "Mercado"}, arrived, true},
+		{"llegada a otra parada", Trigger{Stop: 3}, arrived, false},
+		{"salida de la parada", Trigger{Stop: 2}, departed, false},
+		{"evento sin match", Trigger{Event: "passenger_event"}, entry, true},
+		{"match que coincide", Trigger{Event: "passenger_event", Match: map[string]interface{}{"event_type": "ENTRY", "current_count": 3}}, entry, true},
+		{"match que no coincide", Trigger{Event: "passenger_event", Match: map[string]interface{}{"event_type": "EXIT"}}, entry, false},
+		{"match con campo inexistente", Trigger{Event: "passenger_event", Match: map[string]interface{}{"color": "rojo"}}, entry, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.trigger.matches(tt.data); got != tt.want {
+				t.Errorf("matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTriggerDefaults(t *testing.T) {
+	if got := (Trigger{}).timeout(); got != defaultTriggerTimeout {
+		t.Errorf("timeout por defecto = %v, want %v", got, defaultTriggerTimeout)
+	}
+	if got := (Trigger{Timeout: 2.5}).timeout(); got != 2500*time.Millisecond {
+		t.Errorf("timeout = %v, want 2.5s", got)
+	}
+	if got := (Trigger{}).onTimeout(); got != OnTimeoutSkip {
+		t.Errorf("on_timeout por defecto = %s, want skip", got)
+	}
+	if got := (Trigger{Stop: 2}).eventType(); got != eventbus.EventStop {
+		t.Errorf("evento de stop = %s, want %s", got, eventbus.EventStop)
+	}
+}
+
+// fakePosition vehículo fijo en la ruta
+type fakePosition struct {
+	progress float64
+	pose     VehiclePose
+	route    *Route
+}
+
+func (f *fakePosition) SetProgress(progress float64) { f.progress = progress }
+func (f *fakePosition) GetProgress() float64         { return f.progress }
+func (f *fakePosition) SetFixLost(lost bool)         {}
+func (f *fakePosition) GetRoute() *Route             { return f.route }
+func (f *fakePosition) GetPose() VehiclePose         { return f.pose }
+func (f *fakePosition) SetPose(pose VehiclePose)     { f.pose = pose }
+
+// awaitWithVirtualClock corre awaitTrigger avanzando un reloj virtual hasta que termina
+func awaitWithVirtualClock(t *testing.T, executor *Executor, step ScenarioStep) (bool, time.Duration) {
+	t.Helper()
+
+	virtual := clock.NewVirtual(time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	clock.Set(virtual)
+	defer clock.Set(nil)
+
+	start := virtual.Now()
+	executor.running = true
+	executor.resumedAt = start
+
+	done := make(chan bool, 1)
+	go func() { done <- executor.awaitTrigger(step, 0, 0, executor.seekGen) }()
+
+	for i := 0; i < 10000; i++ {
+		select {
+		case run := <-done:
+			return run, virtual.Now().Sub(start)
+		default:
+			virtual.Advance(100 * time.Millisecond)
+			time.Sleep(100 * time.Microsecond)
+		}
+	}
+	t.Fatal("awaitTrigger no terminó")
+	return false, 0
+}
+
+func TestAwaitTrigger(t *testing.T) {
+	tests := []struct {
+		name        string
+		progress    float64
+		onTimeout   string
+		wantRun     bool
+		wantResults int
+		wantAborted bool
+	}{
+		{"condición cumplida", 0.5, "", true, 0, false},
+		{"timeout con skip", 0.1, OnTimeoutSkip, false, 0, false},
+		{"timeout con run", 0.1, OnTimeoutRun, true, 0, false},
+		{"timeout con fail", 0.1, OnTimeoutFail, false, 1, false},
+		{"timeout con abort", 0.1, OnTimeoutAbort, false, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := NewExecutor(&Scenario{}, nil, eventbus.NewEventBus())
+			executor.SetPositionController(&fakePosition{progress: tt.progress})
+
+			step := ScenarioStep{Action: ActionLog, When: &Trigger{
+				Field:     progressField,
+				Min:       floatPtr(0.4),
+				Timeout:   2,
+				OnTimeout: tt.onTimeout,
+			}}
+
+			run, waited := awaitWithVirtualClock(t, executor, step)
+			report := executor.Report()
+			if run != tt.wantRun || len(report.Results) != tt.wantResults || report.Aborted != tt.wantAborted {
+				t.Errorf("corre=%v verificaciones=%d abortado=%v, want %v/%d/%v",
+					run, len(report.Results), report.Aborted, tt.wantRun, tt.wantResults, tt.wantAborted)
+			}
+
+			// Con timeout se espera completo; la espera corre los pasos siguientes
+			if tt.onTimeout != "" && waited < 2*time.Second {
+				t.Errorf("esperó %v, want al menos el timeout de 2s", waited)
+			}
+			if shift := -executor.timeShift; shift < waited.Seconds()-0.2 || shift > waited.Seconds()+0.2 {
+				t.Errorf("corrimiento = %.1fs, want ~%.1fs", shift, waited.Seconds())
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package scenario
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+)
+
+// Sentidos de un viaje sobre la ruta
+const (
+	DirectionOutbound = "OUTBOUND" // De la terminal inicial (progreso 0.0) a la final (1.0)
+	DirectionInbound  = "INBOUND"  // De la terminal final de regreso a la inicial
+)
+
+// Trip representa un viaje de terminal a terminal
+type Trip struct {
+	ID           string
+	RouteName    string
+	Direction    string
+	StartTime    time.Time
+	EndTime      time.Time // Cero mientras el viaje está en curso
+	StopSequence []Stop    // Paradas en el orden del viaje
+}
+
+// IsActive retorna si el viaje sigue en curso
+func (t Trip) IsActive() bool {
+	return t.EndTime.IsZero()
+}
+
+// TripController gestiona el ciclo de vida de los viajes de un vehículo:
+// inicia un viaje en la terminal y, al llegar a la otra, lo cierra e
+// inicia el de regreso en sentido contrario
+type TripController struct {
+	bus      *eventbus.EventBus
+	out      io.Writer // Destino de los logs (os.Stdout por defecto)
+	route    *Route
+	deviceID string
+
+	mu        sync.RWMutex
+	current   *Trip
+	tripCount int
+}
+
+// NewTripController crea un controlador de viajes para una ruta
+func NewTripController(bus *eventbus.EventBus, route *Route, deviceID string) *TripController {
+	return &TripController{
+		bus:      bus,
+		out:      os.Stdout,
+		route:    route,
+		deviceID: deviceID,
+	}
+}
+
+// SetOutput redirige los logs de los viajes (io.Discard los silencia)
+func (tc *TripController) SetOutput(w io.Writer) {
+	tc.out = w
+}
+
+// Start inicia un viaje de ida desde la terminal inicial (aborta el viaje en curso)
+func (tc *TripController) Start(now time.Time) {
+	tc.Abort(now)
+	tc.startTrip(DirectionOutbound, now)
+}
+
+// ReachTerminal cierra el viaje actual al llegar a la terminal e inicia el
+// viaje en sentido contrario. Retorna el nuevo sentido
+func (tc *TripController) ReachTerminal(now time.Time) string {
+	tc.mu.Lock()
+	current := tc.current
+	tc.current = nil
+	tc.mu.Unlock()
+
+	direction := DirectionOutbound
+	if current != nil {
+		current.EndTime = now
+		tc.publish(eventbus.TripCompleted, *current, now)
+		fmt.Fprintf(tc.out, "🏁 [Trips] Viaje %s completado (%s, %.0fs)\n",
+			current.ID, current.Direction, now.Sub(current.StartTime).Seconds())
+
+		if current.Direction == DirectionOutbound {
+			direction = DirectionInbound
+		}
+	}
+
+	tc.startTrip(direction, now)
+	return direction
+}
+
+// Abort termina el viaje en curso sin haber llegado a la terminal
+func (tc *TripController) Abort(now time.Time) {
+	tc.mu.Lock()
+	current := tc.current
+	tc.current = nil
+	tc.mu.Unlock()
+
+	if current == nil {
+		return
+	}
+
+	current.EndTime = now
+	tc.publish(eventbus.TripAborted, *current, now)
+	fmt.Fprintf(tc.out, "⏹️  [Trips] Viaje %s abortado\n", current.ID)
+}
+
+// CurrentTrip retorna el viaje en curso
+func (tc *TripController) CurrentTrip() (Trip, bool) {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+
+	if tc.current == nil {
+		return Trip{}, false
+	}
+	return *tc.current, true
+}
+
+// CurrentTripID retorna el ID del viaje en curso ("" si no hay)
+func (tc *TripController) CurrentTripID() string {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+
+	if tc.current == nil {
+		return ""
+	}
+	return tc.current.ID
+}
+
+// Direction retorna el sentido del viaje en curso
+func (tc *TripController) Direction() string {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+
+	if tc.current == nil {
+		return DirectionOutbound
+	}
+	return tc.current.Direction
+}
+
+// startTrip crea y publica un viaje nuevo
+func (tc *TripController) startTrip(direction string, now time.Time) {
+	tc.mu.Lock()
+	tc.tripCount++
+	trip := &Trip{
+		ID:           fmt.Sprintf("%s-%s-T%03d", tc.deviceID, now.Format("20060102T150405"), tc.tripCount),
+		RouteName:    tc.route.Name,
+		Direction:    direction,
+		StartTime:    now,
+		StopSequence: tc.stopSequence(direction),
+	}
+	tc.current = trip
+	tc.mu.Unlock()
+
+	tc.publish(eventbus.TripStarted, *trip, now)
+	fmt.Fprintf(tc.out, "🚩 [Trips] Viaje %s iniciado (%s, %d paradas)\n", trip.ID, direction, len(trip.StopSequence))
+}
+
+// stopSequence retorna las paradas en el orden en que se recorren
+func (tc *TripController) stopSequence(direction string) []Stop {
+	stops := make([]Stop, len(tc.route.Stops))
+	copy(stops, tc.route.Stops)
+
+	if direction == DirectionInbound {
+		for i, j := 0, len(stops)-1; i < j; i, j = i+1, j-1 {
+			stops[i], stops[j] = stops[j], stops[i]
+		}
+	}
+	return stops
+}
+
+// publish publica un evento del ciclo de vida del viaje
+func (tc *TripController) publish(eventType string, trip Trip, now time.Time) {
+	stopIDs := make([]int, 0, len(trip.StopSequence))
+	for _, stop := range trip.StopSequence {
+		stopIDs = append(stopIDs, stop.ID)
+	}
+
+	tc.bus.Publish(eventbus.Event{
+		Type:      eventbus.EventTrip,
+		Timestamp: now,
+		Data: eventbus.TripEventData{
+			EventType:    eventType,
+			TripID:       trip.ID,
+			RouteName:    trip.RouteName,
+			Direction:    trip.Direction,
+			StartTime:    trip.StartTime,
+			EndTime:      trip.EndTime,
+			StopSequence: stopIDs,
+			DeviceID:     tc.deviceID,
+			Timestamp:    now,
+		},
+	})
+}
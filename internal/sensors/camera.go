@@ -2,18 +2,60 @@ package sensors
 
 import (
 	"fmt"
-	"math/rand"
+	"io"
+	"math"
+	"os"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/MarcosBrindi/transporte-simulator/internal/clock"
 	"github.com/MarcosBrindi/transporte-simulator/internal/config"
 	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
 )
 
+// Geometría de la imagen (coordenadas de imagen, origen arriba-izquierda)
+// La cámara mira la puerta desde la cabina: abajo está la calle, arriba el pasillo
+const (
+	CameraImageWidth  = 640.0
+	CameraImageHeight = 480.0
+	CameraDoorLineY   = 380.0 // Umbral de la puerta (debajo = afuera, arriba = adentro)
+
+	cabinZoneMinY  = 150.0 // Zona donde se quedan los pasajeros que suben
+	cabinZoneMaxY  = 260.0
+	doorZoneMinX   = 220.0 // Ancho de la puerta en la imagen
+	doorZoneMaxX   = 420.0
+	maxAgents      = 6    // Personas caminando a la vez por la puerta
+	boardingRate   = 0.25 // Personas que suben por segundo (puerta abierta)
+	alightingRate  = 0.12 // Personas que bajan por segundo (puerta abierta)
+	minWalkSpeed   = 60.0 // px/s
+	maxWalkSpeed   = 110.0
+	lateralJitter  = 10.0 // px/s
+	agentMinWidth  = 80.0
+	agentMaxWidth  = 110.0
+	agentMinHeight = 180.0
+	agentMaxHeight = 220.0
+)
+
+// scriptedSpawnInterval segundos entre pasajeros ordenados por el escenario
+const scriptedSpawnInterval = 1.2
+
+// doorwayHalfDepth px alrededor de la línea de la puerta donde una persona corta el haz del láser
+const doorwayHalfDepth = 40.0
+
+// Dirección real (ground truth) de una persona simulada
+const (
+	DirectionIn  = "IN"  // Sube al vehículo
+	DirectionOut = "OUT" // Baja del vehículo
+)
+
 // CameraSimulator simula una cámara con detector YOLO
 type CameraSimulator struct {
 	bus    *eventbus.EventBus
+	out    io.Writer // Destino de los logs (os.Stdout por defecto)
 	config config.CameraConfig
+	faults *FaultInjector // Inyector de fallas (opcional)
+	rng    *Random        // Llegadas, tamaños y errores del detector
 
 	// Campos protegidos por mutex
 	mu             sync.RWMutex
@@ -22,38 +64,92 @@ type CameraSimulator struct {
 	frameNumber    int
 	doorOpen       bool
 	vehicleStopped bool
-	activeTracks   map[int]*PersonTrackState // Tracks activos
+	activeTracks   map[int]*PersonAgent // Personas en la imagen
+	ghosts         []*ghostDetection    // Falsos positivos activos
 	nextTrackID    int
-	personCount    int // Número de personas simuladas en la puerta
+
+	// Pasajeros ordenados por el escenario (reemplazan las llegadas al azar)
+	scenarioControl   bool
+	pendingBoarding   int
+	pendingAlighting  int
+	lastScriptedSpawn int // Frame del último pasajero ordenado
 
 	// Campos de estado actual
 	frameCount int
 }
 
-// PersonTrackState mantiene el estado de un track
-type PersonTrackState struct {
+// PersonAgent es una persona que camina por la zona de la puerta
+// X, Y son el centro del bounding box en coordenadas de imagen
+type PersonAgent struct {
 	TrackID    int
+	X          float64
+	Y          float64
+	VX         float64 // px/s
+	VY         float64 // px/s (negativo = hacia la cabina)
+	Width      float64
+	Height     float64
+	TargetY    float64 // Donde se detiene (solo agentes que suben)
+	Direction  string  // DirectionIn o DirectionOut
+	Walking    bool
 	FirstSeen  int
 	LastSeen   int
 	Confidence float64
 }
 
+// Box retorna el bounding box del agente
+func (pa *PersonAgent) Box() eventbus.Box {
+	return eventbus.Box{
+		X1: pa.X - pa.Width/2,
+		Y1: pa.Y - pa.Height/2,
+		X2: pa.X + pa.Width/2,
+		Y2: pa.Y + pa.Height/2,
+	}
+}
+
+// IsVisible retorna si alguna parte del agente está dentro de la imagen
+func (pa *PersonAgent) IsVisible() bool {
+	box := pa.Box()
+	return box.X2 > 0 && box.X1 < CameraImageWidth &&
+		box.Y2 > 0 && box.Y1 < CameraImageHeight
+}
+
+// ghostDetection es un falso positivo del detector (sombra, reflejo, etc.)
+type ghostDetection struct {
+	TrackID    int
+	Box        eventbus.Box
+	Confidence float64
+	FirstSeen  int
+	FramesLeft int
+}
+
 // NewCameraSimulator crea un nuevo simulador de cámara
 func NewCameraSimulator(bus *eventbus.EventBus, cfg config.CameraConfig) *CameraSimulator {
 	return &CameraSimulator{
 		bus:            bus,
+		out:            os.Stdout,
 		config:         cfg,
 		running:        false,
 		paused:         false,
 		frameNumber:    0,
 		doorOpen:       false,
 		vehicleStopped: false,
-		activeTracks:   make(map[int]*PersonTrackState),
+		activeTracks:   make(map[int]*PersonAgent),
+		ghosts:         make([]*ghostDetection, 0),
 		nextTrackID:    1,
-		personCount:    0,
+		rng:            NewRandom(),
 	}
 }
 
+// SetOutput redirige los logs de la cámara (io.Discard los silencia); llamar antes de Start
+func (cam *CameraSimulator) SetOutput(w io.Writer) {
+	cam.out = w
+}
+
+// SetSeed fija la semilla del simulador
+func (cam *CameraSimulator) SetSeed(seed int64) {
+	cam.rng.Seed(seed)
+}
+
 // Start inicia el simulador en su propia goroutine
 func (cam *CameraSimulator) Start() {
 	cam.mu.Lock()
@@ -62,8 +158,8 @@ func (cam *CameraSimulator) Start() {
 
 	go cam.loop()
 
-	fmt.Println("✅ [Camera] Simulador iniciado")
-	fmt.Printf("📷 [Camera] Frecuencia: %.1f Hz (%.0fms/frame)\n",
+	fmt.Fprintln(cam.out, "✅ [Camera] Simulador iniciado")
+	fmt.Fprintf(cam.out, "📷 [Camera] Frecuencia: %.1f Hz (%.0fms/frame)\n",
 		cam.config.Frequency, 1000.0/cam.config.Frequency)
 }
 
@@ -73,7 +169,7 @@ func (cam *CameraSimulator) Stop() {
 	cam.running = false
 	cam.mu.Unlock()
 
-	fmt.Println("[Camera] Simulador detenido")
+	fmt.Fprintln(cam.out, "[Camera] Simulador detenido")
 }
 
 // Pause pausa el simulador
@@ -104,9 +200,16 @@ func (cam *CameraSimulator) UpdateVehicleState(isStopped bool) {
 	cam.mu.Unlock()
 }
 
+// SetFaultInjector asigna el inyector de fallas del sensor
+func (cam *CameraSimulator) SetFaultInjector(faults *FaultInjector) {
+	cam.mu.Lock()
+	cam.faults = faults
+	cam.mu.Unlock()
+}
+
 // loop es el bucle principal del simulador
 func (cam *CameraSimulator) loop() {
-	ticker := time.NewTicker(time.Duration(1000.0/cam.config.Frequency) * time.Millisecond)
+	ticker := clock.NewTicker(time.Duration(1000.0/cam.config.Frequency) * time.Millisecond)
 	defer ticker.Stop()
 
 	for {
@@ -128,10 +231,14 @@ func (cam *CameraSimulator) loop() {
 		// Generar frame
 		data := cam.generateFrame()
 
-		// Publicar evento
-		cam.bus.Publish(eventbus.Event{
+		// Publicar evento (pasando por el inyector de fallas)
+		cam.mu.RLock()
+		faults := cam.faults
+		cam.mu.RUnlock()
+
+		publishWithFaults(cam.bus, faults, SensorCamera, eventbus.Event{
 			Type:      eventbus.EventCamera,
-			Timestamp: time.Now(),
+			Timestamp: clock.Now(),
 			Data:      data,
 		})
 
@@ -151,8 +258,8 @@ func (cam *CameraSimulator) generateFrame() eventbus.CameraData {
 	// 2. Puerta está abierta
 	if !cam.vehicleStopped || !cam.doorOpen {
 		// Sin detecciones
-		cam.activeTracks = make(map[int]*PersonTrackState)
-		cam.personCount = 0
+		cam.activeTracks = make(map[int]*PersonAgent)
+		cam.ghosts = make([]*ghostDetection, 0)
 
 		return eventbus.CameraData{
 			DetectedPersons: 0,
@@ -162,102 +269,396 @@ func (cam *CameraSimulator) generateFrame() eventbus.CameraData {
 		}
 	}
 
-	// Simular detección de personas cuando la puerta está abierta
+	// Mover y generar personas
 	cam.simulatePersonDetections()
 
-	// Convertir tracks activos a slice
-	tracks := make([]eventbus.PersonTrack, 0, len(cam.activeTracks))
-	totalConfidence := 0.0
-
-	for _, track := range cam.activeTracks {
-		tracks = append(tracks, eventbus.PersonTrack{
-			TrackID:    track.TrackID,
-			Confidence: track.Confidence,
-			BoundingBox: eventbus.Box{
-				X1: 100 + float64(rand.Intn(200)),
-				Y1: 100 + float64(rand.Intn(200)),
-				X2: 300 + float64(rand.Intn(200)),
-				Y2: 400 + float64(rand.Intn(100)),
-			},
-			FirstSeen: track.FirstSeen,
-			LastSeen:  cam.frameNumber,
-		})
+	// Pasar las personas por el detector imperfecto
+	tracks := cam.detectAgents()
+	tracks = append(tracks, cam.detectGhosts()...)
 
+	// Filtrar por umbral de confianza (config.Confidence)
+	filtered := make([]eventbus.PersonTrack, 0, len(tracks))
+	totalConfidence := 0.0
+	for _, track := range tracks {
+		if track.Confidence < cam.config.Confidence {
+			continue
+		}
+		filtered = append(filtered, track)
 		totalConfidence += track.Confidence
-		track.LastSeen = cam.frameNumber
 	}
 
 	avgConfidence := 0.0
-	if len(tracks) > 0 {
-		avgConfidence = totalConfidence / float64(len(tracks))
+	if len(filtered) > 0 {
+		avgConfidence = totalConfidence / float64(len(filtered))
 	}
 
 	return eventbus.CameraData{
-		DetectedPersons: len(tracks),
-		Tracks:          tracks,
+		DetectedPersons: len(filtered),
+		Tracks:          filtered,
 		FrameNumber:     cam.frameNumber,
 		Confidence:      avgConfidence,
 	}
 }
 
-// simulatePersonDetections simula detecciones de personas
-func (cam *CameraSimulator) simulatePersonDetections() {
-	// Generar cambios en el número de personas cada ~3 segundos
-	// (asumiendo 5 FPS, cada 15 frames)
-	if cam.frameNumber%15 == 0 {
-		// Decidir si agregar/quitar personas
-		change := rand.Intn(5) - 1 // -1, 0, 1, 2, 3 (bias hacia agregar)
-
-		cam.personCount += change
-		if cam.personCount < 0 {
-			cam.personCount = 0
+// detectAgents genera las detecciones de personas reales con pérdidas,
+// oclusiones y cambios de ID
+func (cam *CameraSimulator) detectAgents() []eventbus.PersonTrack {
+	detector := cam.config.Detector
+	tracks := make([]eventbus.PersonTrack, 0, len(cam.activeTracks))
+
+	// Orden por ID: el map no tiene orden y cada agente debe tomar su sorteo en el mismo turno
+	visible := make([]*PersonAgent, 0, len(cam.activeTracks))
+	for _, agent := range cam.activeTracks {
+		if agent.IsVisible() {
+			visible = append(visible, agent)
+		}
+	}
+	sort.Slice(visible, func(i, j int) bool { return visible[i].TrackID < visible[j].TrackID })
+
+	// Cambios de ID (fragmentación o intercambio con otra persona) antes de
+	// emitir: un intercambio con alguien ya emitido duplicaría su ID en el frame
+	if detector.IDSwitchRate > 0 {
+		for _, agent := range visible {
+			if cam.rng.Float64() < detector.IDSwitchRate {
+				cam.switchTrackID(agent, visible)
+			}
 		}
-		if cam.personCount > 5 {
-			cam.personCount = 5
+	}
+
+	for _, agent := range visible {
+		// Detección perdida
+		if cam.rng.Float64() < detector.MissProbability {
+			continue
 		}
 
-		// Ajustar tracks según nuevo conteo
-		cam.adjustTracks()
+		// Oclusión: otra persona más cerca de la cámara tapa a esta
+		if cam.isOccluded(agent, visible) && cam.rng.Float64() < detector.OcclusionMissProb {
+			continue
+		}
+
+		tracks = append(tracks, eventbus.PersonTrack{
+			TrackID:     agent.TrackID,
+			Confidence:  cam.sampleConfidence(agent.Confidence),
+			BoundingBox: agent.Box(),
+			FirstSeen:   agent.FirstSeen,
+			LastSeen:    cam.frameNumber,
+			VelocityX:   agent.VX,
+			VelocityY:   agent.VY,
+			Direction:   agent.Direction,
+		})
+
+		agent.LastSeen = cam.frameNumber
 	}
 
-	// Mantener tracks existentes (actualizar LastSeen se hace en generateFrame)
+	return tracks
 }
 
-// adjustTracks ajusta los tracks según el conteo deseado
-func (cam *CameraSimulator) adjustTracks() {
-	currentCount := len(cam.activeTracks)
-	targetCount := cam.personCount
+// isOccluded verifica si un agente está tapado por otro más cercano
+// (más abajo en la imagen = más cerca de la cámara)
+func (cam *CameraSimulator) isOccluded(agent *PersonAgent, visible []*PersonAgent) bool {
+	threshold := cam.config.Detector.OcclusionIoU
+	if threshold <= 0 {
+		return false
+	}
 
-	if targetCount > currentCount {
-		// Agregar nuevos tracks
-		toAdd := targetCount - currentCount
-		for i := 0; i < toAdd; i++ {
-			trackID := cam.nextTrackID
-			cam.nextTrackID++
+	box := agent.Box()
+	for _, other := range visible {
+		if other == agent {
+			continue
+		}
+		otherBox := other.Box()
+		if otherBox.Y2 > box.Y2 && boxIoU(box, otherBox) >= threshold {
+			return true
+		}
+	}
+	return false
+}
 
-			cam.activeTracks[trackID] = &PersonTrackState{
-				TrackID:    trackID,
-				FirstSeen:  cam.frameNumber,
-				LastSeen:   cam.frameNumber,
-				Confidence: 0.7 + rand.Float64()*0.25, // 0.7-0.95
-			}
+// switchTrackID simula un error del tracker: intercambio de IDs con una
+// persona cercana o, si no hay ninguna, un ID nuevo (fragmentación)
+func (cam *CameraSimulator) switchTrackID(agent *PersonAgent, visible []*PersonAgent) {
+	box := agent.Box()
+	for _, other := range visible {
+		if other == agent {
+			continue
+		}
+		if boxIoU(box, other.Box()) > 0 {
+			fmt.Fprintf(cam.out, "🔀 [Camera] Intercambio de ID: %d ↔ %d (frame %d)\n", agent.TrackID, other.TrackID, cam.frameNumber)
+			agent.TrackID, other.TrackID = other.TrackID, agent.TrackID
+			agent.FirstSeen, other.FirstSeen = other.FirstSeen, agent.FirstSeen
+			cam.reindexTracks()
+			return
+		}
+	}
+
+	newID := cam.nextTrackID
+	cam.nextTrackID++
+	fmt.Fprintf(cam.out, "🔀 [Camera] Track fragmentado: ID=%d → %d (frame %d)\n", agent.TrackID, newID, cam.frameNumber)
+	agent.TrackID = newID
+	agent.FirstSeen = cam.frameNumber
+	cam.reindexTracks()
+}
+
+// reindexTracks reconstruye el map de agentes tras un cambio de ID
+func (cam *CameraSimulator) reindexTracks() {
+	reindexed := make(map[int]*PersonAgent, len(cam.activeTracks))
+	for _, agent := range cam.activeTracks {
+		reindexed[agent.TrackID] = agent
+	}
+	cam.activeTracks = reindexed
+}
+
+// detectGhosts genera y envejece falsos positivos
+func (cam *CameraSimulator) detectGhosts() []eventbus.PersonTrack {
+	detector := cam.config.Detector
+	frameDelta := 1.0 / cam.config.Frequency
 
-			fmt.Printf("👤 [Camera] Nuevo track detectado: ID=%d (frame %d)\n", trackID, cam.frameNumber)
+	// Nuevos fantasmas
+	if detector.FalsePositiveRate > 0 && cam.rng.Float64() < detector.FalsePositiveRate*frameDelta {
+		lifetime := detector.GhostLifetimeFrames
+		if lifetime < 1 {
+			lifetime = 1
 		}
-	} else if targetCount < currentCount {
-		// Remover tracks (simular que personas salieron del campo de visión)
-		toRemove := currentCount - targetCount
-		removed := 0
-
-		for trackID := range cam.activeTracks {
-			if removed >= toRemove {
-				break
-			}
-			fmt.Printf("👋 [Camera] Track perdido: ID=%d (frame %d)\n", trackID, cam.frameNumber)
+
+		x := cam.rng.Float64() * (CameraImageWidth - agentMaxWidth)
+		y := cam.rng.Float64() * (CameraImageHeight - agentMaxHeight)
+		ghost := &ghostDetection{
+			TrackID: cam.nextTrackID,
+			Box: eventbus.Box{
+				X1: x,
+				Y1: y,
+				X2: x + agentMinWidth + cam.rng.Float64()*(agentMaxWidth-agentMinWidth),
+				Y2: y + agentMinHeight + cam.rng.Float64()*(agentMaxHeight-agentMinHeight),
+			},
+			Confidence: detector.GhostConfidenceMean,
+			FirstSeen:  cam.frameNumber,
+			FramesLeft: 1 + cam.rng.Intn(lifetime),
+		}
+		cam.nextTrackID++
+		cam.ghosts = append(cam.ghosts, ghost)
+
+		fmt.Fprintf(cam.out, "👻 [Camera] Detección fantasma: ID=%d (frame %d)\n", ghost.TrackID, cam.frameNumber)
+	}
+
+	tracks := make([]eventbus.PersonTrack, 0, len(cam.ghosts))
+	remaining := cam.ghosts[:0]
+
+	for _, ghost := range cam.ghosts {
+		tracks = append(tracks, eventbus.PersonTrack{
+			TrackID:     ghost.TrackID,
+			Confidence:  clampConfidence(ghost.Confidence + cam.rng.NormFloat64()*detector.ConfidenceStdDev),
+			BoundingBox: ghost.Box,
+			FirstSeen:   ghost.FirstSeen,
+			LastSeen:    cam.frameNumber,
+		})
+
+		ghost.FramesLeft--
+		if ghost.FramesLeft > 0 {
+			remaining = append(remaining, ghost)
+		}
+	}
+	cam.ghosts = remaining
+
+	return tracks
+}
+
+// sampleConfidence genera la confianza de una detección en este frame
+func (cam *CameraSimulator) sampleConfidence(base float64) float64 {
+	detector := cam.config.Detector
+	if detector.ConfidenceMean <= 0 {
+		return base
+	}
+	return clampConfidence(detector.ConfidenceMean + cam.rng.NormFloat64()*detector.ConfidenceStdDev)
+}
+
+// clampConfidence limita la confianza al rango [0, 1]
+func clampConfidence(confidence float64) float64 {
+	if confidence < 0 {
+		return 0
+	}
+	if confidence > 1 {
+		return 1
+	}
+	return confidence
+}
+
+// boxIoU calcula la intersección sobre unión de dos bounding boxes
+func boxIoU(a, b eventbus.Box) float64 {
+	interX1 := math.Max(a.X1, b.X1)
+	interY1 := math.Max(a.Y1, b.Y1)
+	interX2 := math.Min(a.X2, b.X2)
+	interY2 := math.Min(a.Y2, b.Y2)
+
+	if interX2 <= interX1 || interY2 <= interY1 {
+		return 0
+	}
+
+	intersection := (interX2 - interX1) * (interY2 - interY1)
+	areaA := (a.X2 - a.X1) * (a.Y2 - a.Y1)
+	areaB := (b.X2 - b.X1) * (b.Y2 - b.Y1)
+
+	return intersection / (areaA + areaB - intersection)
+}
+
+// simulatePersonDetections mueve a las personas y genera nuevas llegadas
+func (cam *CameraSimulator) simulatePersonDetections() {
+	frameDelta := 1.0 / cam.config.Frequency
+
+	// Mover agentes existentes
+	for trackID, agent := range cam.activeTracks {
+		cam.moveAgent(agent, frameDelta)
+
+		// Persona que bajó y salió de la imagen
+		if agent.Direction == DirectionOut && !agent.IsVisible() {
+			fmt.Fprintf(cam.out, "👋 [Camera] Track salió de la imagen: ID=%d (frame %d)\n", trackID, cam.frameNumber)
 			delete(cam.activeTracks, trackID)
-			removed++
 		}
 	}
+
+	// Pasajeros ordenados por el escenario: uno a la vez, sin llegadas al azar
+	if cam.scenarioControl {
+		cam.spawnScriptedPassenger(frameDelta)
+		return
+	}
+
+	// Nuevas llegadas (proceso de Poisson por frame)
+	if cam.walkingAgents() < maxAgents && cam.rng.Float64() < boardingRate*frameDelta {
+		cam.spawnAgent(DirectionIn)
+	}
+	if cam.walkingAgents() < maxAgents && cam.rng.Float64() < alightingRate*frameDelta {
+		cam.spawnAgent(DirectionOut)
+	}
+}
+
+// spawnScriptedPassenger hace pasar al siguiente pasajero ordenado (primero bajan, luego suben)
+func (cam *CameraSimulator) spawnScriptedPassenger(frameDelta float64) {
+	if cam.pendingBoarding == 0 && cam.pendingAlighting == 0 {
+		return
+	}
+	if cam.walkingAgents() >= maxAgents {
+		return
+	}
+	if float64(cam.frameNumber-cam.lastScriptedSpawn)*frameDelta < scriptedSpawnInterval {
+		return
+	}
+
+	cam.lastScriptedSpawn = cam.frameNumber
+	if cam.pendingAlighting > 0 {
+		cam.pendingAlighting--
+		cam.spawnAgent(DirectionOut)
+		return
+	}
+	cam.pendingBoarding--
+	cam.spawnAgent(DirectionIn)
+}
+
+// walkingAgents cuenta las personas que van pasando por la puerta; las que ya
+// subieron siguen en la imagen (el tracker confirma la entrada con ellas) pero
+// no ocupan el paso ni cuentan para maxAgents (llamar con mu tomado)
+func (cam *CameraSimulator) walkingAgents() int {
+	count := 0
+	for _, agent := range cam.activeTracks {
+		if agent.Walking {
+			count++
+		}
+	}
+	return count
+}
+
+// QueuePassengers ordena que suban y bajen pasajeros en la próxima apertura de puerta
+func (cam *CameraSimulator) QueuePassengers(boarding, alighting int) {
+	cam.mu.Lock()
+	defer cam.mu.Unlock()
+
+	cam.pendingBoarding += boarding
+	cam.pendingAlighting += alighting
+}
+
+// SetScenarioControl activa (true) los pasajeros ordenados por el escenario
+// o devuelve (false) las llegadas al azar
+func (cam *CameraSimulator) SetScenarioControl(enabled bool) {
+	cam.mu.Lock()
+	defer cam.mu.Unlock()
+
+	cam.scenarioControl = enabled
+	if !enabled {
+		cam.pendingBoarding = 0
+		cam.pendingAlighting = 0
+	}
+}
+
+// moveAgent avanza la posición de un agente un frame
+func (cam *CameraSimulator) moveAgent(agent *PersonAgent, frameDelta float64) {
+	if !agent.Walking {
+		return
+	}
+
+	agent.X += agent.VX * frameDelta
+	agent.Y += agent.VY * frameDelta
+
+	// Mantenerse dentro del ancho de la puerta/pasillo
+	if agent.X < doorZoneMinX || agent.X > doorZoneMaxX {
+		agent.VX = -agent.VX
+	}
+
+	// Persona que sube: se queda de pie en la cabina
+	if agent.Direction == DirectionIn && agent.Y <= agent.TargetY {
+		agent.Y = agent.TargetY
+		agent.VX = 0
+		agent.VY = 0
+		agent.Walking = false
+	}
+}
+
+// spawnAgent crea una persona que sube (desde la calle) o baja (desde la cabina)
+func (cam *CameraSimulator) spawnAgent(direction string) {
+	trackID := cam.nextTrackID
+	cam.nextTrackID++
+
+	height := agentMinHeight + cam.rng.Float64()*(agentMaxHeight-agentMinHeight)
+	speed := minWalkSpeed + cam.rng.Float64()*(maxWalkSpeed-minWalkSpeed)
+
+	agent := &PersonAgent{
+		TrackID:    trackID,
+		X:          doorZoneMinX + cam.rng.Float64()*(doorZoneMaxX-doorZoneMinX),
+		VX:         (cam.rng.Float64()*2 - 1) * lateralJitter,
+		Width:      agentMinWidth + cam.rng.Float64()*(agentMaxWidth-agentMinWidth),
+		Height:     height,
+		Direction:  direction,
+		Walking:    true,
+		FirstSeen:  cam.frameNumber,
+		LastSeen:   cam.frameNumber,
+		Confidence: 0.7 + cam.rng.Float64()*0.25, // 0.7-0.95
+	}
+
+	if direction == DirectionIn {
+		// Entra desde abajo (calle) y camina hacia la cabina
+		agent.Y = CameraImageHeight + height/2
+		agent.VY = -speed
+		agent.TargetY = cabinZoneMinY + cam.rng.Float64()*(cabinZoneMaxY-cabinZoneMinY)
+	} else {
+		// Sale desde la cabina y camina hacia la calle
+		agent.Y = cabinZoneMinY + cam.rng.Float64()*(cabinZoneMaxY-cabinZoneMinY)
+		agent.VY = speed
+	}
+
+	cam.activeTracks[trackID] = agent
+
+	fmt.Fprintf(cam.out, "👤 [Camera] Nuevo track detectado: ID=%d %s (frame %d)\n", trackID, direction, cam.frameNumber)
+}
+
+// IsDoorwayOccupied retorna si alguna persona está pasando por el umbral
+// de la puerta (implementa DoorwayProbe para el VL53L0X)
+func (cam *CameraSimulator) IsDoorwayOccupied() bool {
+	cam.mu.RLock()
+	defer cam.mu.RUnlock()
+
+	for _, agent := range cam.activeTracks {
+		if agent.Walking && math.Abs(agent.Y-CameraDoorLineY) <= doorwayHalfDepth {
+			return true
+		}
+	}
+	return false
 }
 
 // GetActiveTracksCount retorna el número de tracks activos
@@ -272,11 +673,14 @@ func (cam *CameraSimulator) Reset() {
 	cam.mu.Lock()
 	defer cam.mu.Unlock()
 
-	cam.activeTracks = make(map[int]*PersonTrackState)
+	cam.activeTracks = make(map[int]*PersonAgent)
+	cam.ghosts = make([]*ghostDetection, 0)
 	cam.nextTrackID = 1
 	cam.frameCount = 0
+	cam.pendingBoarding = 0
+	cam.pendingAlighting = 0
 
-	fmt.Println("🔄 [Camera] Reset completado")
+	fmt.Fprintln(cam.out, "🔄 [Camera] Reset completado")
 }
 
 // SetFrequency cambia la frecuencia de actualización
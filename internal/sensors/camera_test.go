@@ -0,0 +1,366 @@
+package sensors
+
+import (
+	"math"
+	"testing"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/config"
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+)
+
+// newTestCamera crea una cámara a 10 Hz sin arrancar su bucle
+func newTestCamera() *CameraSimulator {
+	return NewCameraSimulator(eventbus.NewEventBus(), config.CameraConfig{Frequency: 10})
+}
+
+func TestPersonAgentVisibility(t *testing.T) {
+	tests := []struct {
+		name    string
+		x, y    float64
+		visible bool
+	}{
+		{"en el centro", 320, 240, true},
+		{"asomando por abajo", 320, CameraImageHeight + 90, true},
+		{"debajo de la imagen", 320, CameraImageHeight + 120, false},
+		{"arriba de la imagen", 320, -120, false},
+		{"fuera a la derecha", CameraImageWidth + 60, 240, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := &PersonAgent{X: tt.x, Y: tt.y, Width: 100, Height: 200}
+			if got := agent.IsVisible(); got != tt.visible {
+				t.Errorf("IsVisible() = %v, want %v (caja %+v)", got, tt.visible, agent.Box())
+			}
+		})
+	}
+}
+
+func TestMoveAgent(t *testing.T) {
+	tests := []struct {
+		name        string
+		agent       PersonAgent
+		wantY       float64
+		wantVX      float64
+		wantWalking bool
+	}{
+		{
+			name:        "sube y sigue caminando",
+			agent:       PersonAgent{X: 300, Y: 400, VY: -100, TargetY: 200, Direction: DirectionIn, Walking: true},
+			wantY:       390,
+			wantWalking: true,
+		},
+		{
+			name:        "sube y se queda de pie en la cabina",
+			agent:       PersonAgent{X: 300, Y: 205, VX: 5, VY: -100, TargetY: 200, Direction: DirectionIn, Walking: true},
+			wantY:       200,
+			wantVX:      0,
+			wantWalking: false,
+		},
+		{
+			name:        "baja hacia la calle",
+			agent:       PersonAgent{X: 300, Y: 200, VY: 80, Direction: DirectionOut, Walking: true},
+			wantY:       208,
+			wantWalking: true,
+		},
+		{
+			name:        "rebota en el borde de la puerta",
+			agent:       PersonAgent{X: doorZoneMaxX, Y: 300, VX: 10, VY: 80, Direction: DirectionOut, Walking: true},
+			wantY:       308,
+			wantVX:      -10,
+			wantWalking: true,
+		},
+		{
+			name:        "de pie no se mueve",
+			agent:       PersonAgent{X: 300, Y: 200, Direction: DirectionIn},
+			wantY:       200,
+			wantWalking: false,
+		},
+	}
+
+	camera := newTestCamera()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := tt.agent
+			camera.moveAgent(&agent, 0.1)
+
+			if agent.Y != tt.wantY || agent.VX != tt.wantVX || agent.Walking != tt.wantWalking {
+				t.Errorf("Y=%v VX=%v caminando=%v, want Y=%v VX=%v caminando=%v",
+					agent.Y, agent.VX, agent.Walking, tt.wantY, tt.wantVX, tt.wantWalking)
+			}
+		})
+	}
+}
+
+func TestSpawnAgent(t *testing.T) {
+	camera := newTestCamera()
+
+	for i := 0; i < 20; i++ {
+		camera.spawnAgent(DirectionIn)
+		camera.spawnAgent(DirectionOut)
+	}
+	if len(camera.activeTracks) != 40 || camera.nextTrackID != 41 {
+		t.Fatalf("tracks=%d siguiente ID=%d, want 40 y 41", len(camera.activeTracks), camera.nextTrackID)
+	}
+
+	for id, agent := range camera.activeTracks {
+		if agent.TrackID != id {
+			t.Errorf("track %d guardado con ID %d", agent.TrackID, id)
+		}
+		if agent.X < doorZoneMinX || agent.X > doorZoneMaxX {
+			t.Errorf("track %d fuera de la puerta: X=%v", id, agent.X)
+		}
+
+		switch agent.Direction {
+		case DirectionIn:
+			// Llega desde el borde inferior (la calle) y se detiene en la cabina
+			if math.Abs(agent.Box().Y1-CameraImageHeight) > 1e-6 || agent.VY >= 0 {
+				t.Errorf("track %d sube desde Y=%v con VY=%v", id, agent.Y, agent.VY)
+			}
+			if agent.TargetY < cabinZoneMinY || agent.TargetY > cabinZoneMaxY {
+				t.Errorf("track %d se detiene fuera de la cabina: %v", id, agent.TargetY)
+			}
+		case DirectionOut:
+			if agent.Y < cabinZoneMinY || agent.Y > cabinZoneMaxY || agent.VY <= 0 {
+				t.Errorf("track %d baja desde Y=%v con VY=%v", id, agent.Y, agent.VY)
+			}
+		}
+	}
+}
+
+func TestGenerateFrameRequiresStoppedAndOpen(t *testing.T) {
+	tests := []struct {
+		name    string
+		stopped bool
+		open    bool
+		want    bool // Se ven detecciones
+	}{
+		{"en marcha con puerta cerrada", false, false, false},
+		{"detenido con puerta cerrada", true, false, false},
+		{"en marcha con puerta abierta", false, true, false},
+		{"detenido con puerta abierta", true, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			camera := newTestCamera()
+			camera.UpdateVehicleState(tt.stopped)
+			camera.UpdateDoorState(tt.open)
+			camera.activeTracks[1] = &PersonAgent{TrackID: 1, X: 300, Y: 200, Width: 100, Height: 200, Direction: DirectionIn}
+			camera.nextTrackID = 2
+
+			frame := camera.generateFrame()
+			if got := frame.DetectedPersons > 0; got != tt.want {
+				t.Errorf("detecciones = %d, want visibles=%v", frame.DetectedPersons, tt.want)
+			}
+			if !tt.want && camera.GetActiveTracksCount() != 0 {
+				t.Errorf("quedaron %d tracks con la puerta cerrada o en marcha", camera.GetActiveTracksCount())
+			}
+		})
+	}
+}
+
+func TestAlightingAgentLeavesImage(t *testing.T) {
+	camera := newTestCamera()
+	camera.UpdateVehicleState(true)
+	camera.UpdateDoorState(true)
+	camera.spawnAgent(DirectionOut)
+
+	// A 60 px/s como mínimo, 10 s de frames alcanzan para salir por abajo
+	for frame := 0; frame < 100; frame++ {
+		camera.generateFrame()
+		if _, ok := camera.activeTracks[1]; !ok {
+			return
+		}
+	}
+	t.Errorf("el pasajero que baja sigue en la imagen: %+v", camera.activeTracks[1])
+}
+
+// newTestDetector crea una cámara con el detector indicado y dos agentes
+// superpuestos: el 1 atrás y el 2 más cerca de la cámara (más abajo)
+func newTestDetector(detector config.DetectorConfig) *CameraSimulator {
+	camera := NewCameraSimulator(eventbus.NewEventBus(), config.CameraConfig{Frequency: 10, Confidence: 0.6, Detector: detector})
+	camera.activeTracks[1] = &PersonAgent{TrackID: 1, X: 300, Y: 200, Width: 100, Height: 200, Confidence: 0.9}
+	camera.activeTracks[2] = &PersonAgent{TrackID: 2, X: 310, Y: 220, Width: 100, Height: 200, Confidence: 0.9}
+	camera.nextTrackID = 3
+	return camera
+}
+
+// trackIDs retorna el conjunto de IDs detectados
+func trackIDs(tracks []eventbus.PersonTrack) map[int]bool {
+	ids := make(map[int]bool, len(tracks))
+	for _, track := range tracks {
+		ids[track.TrackID] = true
+	}
+	return ids
+}
+
+func TestDetectAgents(t *testing.T) {
+	tests := []struct {
+		name     string
+		detector config.DetectorConfig
+		want     []int
+	}{
+		{"detector perfecto", config.DetectorConfig{}, []int{1, 2}},
+		{"pierde todas las detecciones", config.DetectorConfig{MissProbability: 1}, nil},
+		{"oclusión del que está atrás", config.DetectorConfig{OcclusionIoU: 0.3, OcclusionMissProb: 1}, []int{2}},
+		{"oclusión bajo el umbral de IoU", config.DetectorConfig{OcclusionIoU: 0.95, OcclusionMissProb: 1}, []int{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			camera := newTestDetector(tt.detector)
+
+			ids := trackIDs(camera.detectAgents())
+			if len(ids) != len(tt.want) {
+				t.Fatalf("detectados %v, want %v", ids, tt.want)
+			}
+			for _, id := range tt.want {
+				if !ids[id] {
+					t.Errorf("detectados %v, falta el track %d", ids, id)
+				}
+			}
+		})
+	}
+}
+
+func TestSwitchTrackID(t *testing.T) {
+	t.Run("intercambio con la persona superpuesta", func(t *testing.T) {
+		camera := newTestDetector(config.DetectorConfig{})
+		first, second := camera.activeTracks[1], camera.activeTracks[2]
+		first.FirstSeen, second.FirstSeen = 10, 20
+
+		camera.switchTrackID(first, []*PersonAgent{first, second})
+
+		if first.TrackID != 2 || second.TrackID != 1 || first.FirstSeen != 20 || second.FirstSeen != 10 {
+			t.Errorf("tras el intercambio: %+v y %+v", first, second)
+		}
+		if camera.activeTracks[2] != first || camera.activeTracks[1] != second {
+			t.Error("el map de tracks no quedó reindexado")
+		}
+	})
+
+	t.Run("fragmentación sin vecinos", func(t *testing.T) {
+		camera := newTestDetector(config.DetectorConfig{})
+		delete(camera.activeTracks, 2)
+		agent := camera.activeTracks[1]
+		camera.frameNumber = 7
+
+		camera.switchTrackID(agent, []*PersonAgent{agent})
+
+		if agent.TrackID != 3 || agent.FirstSeen != 7 || camera.nextTrackID != 4 {
+			t.Errorf("track fragmentado = %+v, siguiente ID %d", agent, camera.nextTrackID)
+		}
+		if _, ok := camera.activeTracks[1]; ok || camera.activeTracks[3] != agent {
+			t.Error("el map de tracks no quedó reindexado")
+		}
+	})
+}
+
+func TestDetectGhosts(t *testing.T) {
+	// Tasa igual a la frecuencia: un fantasma nuevo en cada frame
+	camera := newTestDetector(config.DetectorConfig{FalsePositiveRate: 10, GhostConfidenceMean: 0.7, GhostLifetimeFrames: 1})
+
+	first := camera.detectGhosts()
+	if len(first) != 1 || first[0].TrackID != 3 || first[0].Confidence != 0.7 {
+		t.Fatalf("primer frame = %+v, want un fantasma con ID 3 y confianza 0.7", first)
+	}
+	box := first[0].BoundingBox
+	if box.X1 < 0 || box.Y1 < 0 || box.X2 > CameraImageWidth || box.Y2 > CameraImageHeight {
+		t.Errorf("fantasma fuera de la imagen: %+v", box)
+	}
+
+	// Con vida de un frame el anterior desaparece y aparece otro
+	second := camera.detectGhosts()
+	if len(second) != 1 || second[0].TrackID != 4 || len(camera.ghosts) != 0 {
+		t.Errorf("segundo frame = %+v con %d pendientes, want solo el fantasma 4", second, len(camera.ghosts))
+	}
+}
+
+func TestGenerateFrameConfidenceThreshold(t *testing.T) {
+	camera := newTestDetector(config.DetectorConfig{})
+	camera.activeTracks[1].Confidence = 0.5
+	camera.UpdateVehicleState(true)
+	camera.UpdateDoorState(true)
+
+	frame := camera.generateFrame()
+	if ids := trackIDs(frame.Tracks); ids[1] || !ids[2] {
+		t.Errorf("tracks = %v, want solo el 2 (el 1 está bajo el umbral)", ids)
+	}
+	if frame.Confidence < 0.6 {
+		t.Errorf("confianza promedio = %v, incluye detecciones descartadas", frame.Confidence)
+	}
+}
+
+func TestBoxIoU(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b eventbus.Box
+		want float64
+	}{
+		{"idénticas", eventbus.Box{X1: 0, Y1: 0, X2: 10, Y2: 10}, eventbus.Box{X1: 0, Y1: 0, X2: 10, Y2: 10}, 1},
+		{"disjuntas", eventbus.Box{X1: 0, Y1: 0, X2: 10, Y2: 10}, eventbus.Box{X1: 20, Y1: 20, X2: 30, Y2: 30}, 0},
+		{"tocándose por el borde", eventbus.Box{X1: 0, Y1: 0, X2: 10, Y2: 10}, eventbus.Box{X1: 10, Y1: 0, X2: 20, Y2: 10}, 0},
+		{"mitad superpuesta", eventbus.Box{X1: 0, Y1: 0, X2: 10, Y2: 10}, eventbus.Box{X1: 5, Y1: 0, X2: 15, Y2: 10}, 1.0 / 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := boxIoU(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("boxIoU = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDoorwayOccupied(t *testing.T) {
+	tests := []struct {
+		name     string
+		agent    PersonAgent
+		occupied bool
+	}{
+		{"cruzando el umbral", PersonAgent{Y: CameraDoorLineY + 10, Walking: true}, true},
+		{"en la cabina", PersonAgent{Y: cabinZoneMinY, Walking: true}, false},
+		{"de pie sobre el umbral", PersonAgent{Y: CameraDoorLineY}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			camera := newTestCamera()
+			agent := tt.agent
+			camera.activeTracks[1] = &agent
+
+			if got := camera.IsDoorwayOccupied(); got != tt.occupied {
+				t.Errorf("IsDoorwayOccupied() = %v, want %v", got, tt.occupied)
+			}
+		})
+	}
+}
+
+func TestScriptedPassengerIgnoresStandingAgents(t *testing.T) {
+	tests := []struct {
+		name      string
+		walking   bool
+		wantSpawn bool
+	}{
+		{"cabina llena de gente de pie", false, true},
+		{"puerta llena de gente caminando", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			camera := newTestCamera()
+			for id := 1; id <= maxAgents; id++ {
+				camera.activeTracks[id] = &PersonAgent{TrackID: id, X: 300, Y: 200, Width: 100, Height: 200, Direction: DirectionIn, Walking: tt.walking}
+			}
+			camera.nextTrackID = maxAgents + 1
+			camera.frameNumber = 100
+			camera.pendingBoarding = 1
+
+			camera.spawnScriptedPassenger(0.1)
+			if spawned := len(camera.activeTracks) > maxAgents; spawned != tt.wantSpawn {
+				t.Errorf("subió = %v, want %v (caminando: %v)", spawned, tt.wantSpawn, tt.walking)
+			}
+		})
+	}
+}
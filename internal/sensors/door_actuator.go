@@ -0,0 +1,251 @@
+package sensors
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/clock"
+	"github.com/MarcosBrindi/transporte-simulator/internal/config"
+)
+
+// DoorCommand es una orden enviada al actuador de la puerta
+type DoorCommand string
+
+const (
+	DoorCommandOpen     DoorCommand = "open"     // Abrir completamente
+	DoorCommandClose    DoorCommand = "close"    // Cerrar completamente
+	DoorCommandHold     DoorCommand = "hold"     // Detener la hoja donde esté
+	DoorCommandObstruct DoorCommand = "obstruct" // Obstáculo en la puerta (reabre si cierra)
+)
+
+// Valores por defecto del actuador
+const (
+	defaultDoorOpeningTime = 2.0 // segundos
+	defaultDoorClosingTime = 3.0 // segundos
+	defaultDoorClosedMM    = 120 // mm con la puerta cerrada
+	defaultDoorOpenMM      = 420 // mm con la puerta abierta
+	maxDoorStep            = 0.5 // segundos máximos por paso (evita saltos tras pausas)
+)
+
+// DoorActuator simula el motor de la puerta con tiempo de recorrido
+// La posición va de 0.0 (cerrada) a 1.0 (abierta)
+type DoorActuator struct {
+	out    io.Writer // Destino de los logs (os.Stdout por defecto)
+	config config.DoorConfig
+
+	// Campos protegidos por mutex
+	mu               sync.RWMutex
+	position         float64     // Posición actual (0.0 a 1.0)
+	target           float64     // Posición objetivo
+	command          DoorCommand // Última orden recibida
+	obstructed       bool        // Hay un obstáculo en la puerta
+	scenarioControl  bool        // true si el escenario controla la puerta
+	lastUpdate       time.Time
+	openingTime      float64
+	closingTime      float64
+	closedDistanceMM int
+	openDistanceMM   int
+}
+
+// NewDoorActuator crea un nuevo actuador de puerta (inicialmente cerrada)
+func NewDoorActuator(cfg config.DoorConfig) *DoorActuator {
+	da := &DoorActuator{
+		out:              os.Stdout,
+		config:           cfg,
+		position:         0.0,
+		target:           0.0,
+		command:          DoorCommandClose,
+		obstructed:       false,
+		scenarioControl:  false,
+		lastUpdate:       clock.Now(),
+		openingTime:      cfg.OpeningTime,
+		closingTime:      cfg.ClosingTime,
+		closedDistanceMM: cfg.ClosedDistanceMM,
+		openDistanceMM:   cfg.OpenDistanceMM,
+	}
+
+	// Valores por defecto si no están configurados
+	if da.openingTime <= 0 {
+		da.openingTime = defaultDoorOpeningTime
+	}
+	if da.closingTime <= 0 {
+		da.closingTime = defaultDoorClosingTime
+	}
+	if da.closedDistanceMM <= 0 {
+		da.closedDistanceMM = defaultDoorClosedMM
+	}
+	if da.openDistanceMM <= da.closedDistanceMM {
+		da.openDistanceMM = defaultDoorOpenMM
+	}
+
+	return da
+}
+
+// SetOutput redirige los logs del actuador de puerta (io.Discard los silencia)
+func (da *DoorActuator) SetOutput(w io.Writer) {
+	da.out = w
+}
+
+// Command aplica una orden al actuador
+func (da *DoorActuator) Command(cmd DoorCommand) {
+	da.mu.Lock()
+	defer da.mu.Unlock()
+
+	da.applyCommand(cmd)
+}
+
+// applyCommand aplica una orden (requiere lock)
+func (da *DoorActuator) applyCommand(cmd DoorCommand) {
+	switch cmd {
+	case DoorCommandOpen:
+		da.target = 1.0
+		da.obstructed = false
+	case DoorCommandClose:
+		da.target = 0.0
+		da.obstructed = false
+	case DoorCommandHold:
+		da.target = da.position
+	case DoorCommandObstruct:
+		da.obstructed = true
+		// Sensor de seguridad: si la puerta estaba cerrando, se reabre
+		if da.target < da.position {
+			da.target = 1.0
+		}
+	default:
+		fmt.Fprintf(da.out, "⚠️  [Door] Orden desconocida: %s\n", cmd)
+		return
+	}
+
+	da.command = cmd
+}
+
+// OpenDoor abre la puerta (implementa scenario.DoorController)
+func (da *DoorActuator) OpenDoor() {
+	da.Command(DoorCommandOpen)
+}
+
+// CloseDoor cierra la puerta (implementa scenario.DoorController)
+func (da *DoorActuator) CloseDoor() {
+	da.Command(DoorCommandClose)
+}
+
+// HoldDoor detiene la puerta en su posición actual (implementa scenario.DoorController)
+func (da *DoorActuator) HoldDoor() {
+	da.Command(DoorCommandHold)
+}
+
+// ObstructDoor simula un obstáculo en la puerta (implementa scenario.DoorController)
+func (da *DoorActuator) ObstructDoor() {
+	da.Command(DoorCommandObstruct)
+}
+
+// SetScenarioControl indica si el escenario controla la puerta
+// Sin control de escenario el VL53L0X usa el ciclo automático
+func (da *DoorActuator) SetScenarioControl(enabled bool) {
+	da.mu.Lock()
+	da.scenarioControl = enabled
+	da.mu.Unlock()
+
+	if enabled {
+		fmt.Fprintln(da.out, "🚪 [Door] Puerta controlada por el escenario")
+	} else {
+		fmt.Fprintln(da.out, "🚪 [Door] Puerta en ciclo automático")
+	}
+}
+
+// IsScenarioControlled retorna si el escenario controla la puerta
+func (da *DoorActuator) IsScenarioControlled() bool {
+	da.mu.RLock()
+	defer da.mu.RUnlock()
+	return da.scenarioControl
+}
+
+// Update avanza la posición de la puerta según el tiempo transcurrido
+func (da *DoorActuator) Update() float64 {
+	da.mu.Lock()
+	defer da.mu.Unlock()
+
+	now := clock.Now()
+	deltaTime := now.Sub(da.lastUpdate).Seconds()
+	da.lastUpdate = now
+
+	if deltaTime > maxDoorStep {
+		deltaTime = maxDoorStep
+	}
+
+	// Obstáculo: la puerta no puede cerrar
+	if da.obstructed && da.target < da.position {
+		da.target = da.position
+	}
+
+	if da.position < da.target {
+		da.position += deltaTime / da.openingTime
+		if da.position > da.target {
+			da.position = da.target
+		}
+	} else if da.position > da.target {
+		da.position -= deltaTime / da.closingTime
+		if da.position < da.target {
+			da.position = da.target
+		}
+	}
+
+	return da.position
+}
+
+// Settle lleva la puerta a su posición objetivo sin animación (Seek del escenario)
+func (da *DoorActuator) Settle() {
+	da.mu.Lock()
+	defer da.mu.Unlock()
+
+	da.position = da.target
+	da.lastUpdate = clock.Now()
+}
+
+// GetPosition retorna la posición actual (0.0 cerrada, 1.0 abierta)
+func (da *DoorActuator) GetPosition() float64 {
+	da.mu.RLock()
+	defer da.mu.RUnlock()
+	return da.position
+}
+
+// GetCommand retorna la última orden aplicada
+func (da *DoorActuator) GetCommand() DoorCommand {
+	da.mu.RLock()
+	defer da.mu.RUnlock()
+	return da.command
+}
+
+// IsObstructed retorna si hay un obstáculo en la puerta
+func (da *DoorActuator) IsObstructed() bool {
+	da.mu.RLock()
+	defer da.mu.RUnlock()
+	return da.obstructed
+}
+
+// DistanceAt retorna la distancia que mediría el VL53L0X en una posición dada
+func (da *DoorActuator) DistanceAt(position float64) int {
+	da.mu.RLock()
+	defer da.mu.RUnlock()
+
+	span := float64(da.openDistanceMM - da.closedDistanceMM)
+	return da.closedDistanceMM + int(span*position)
+}
+
+// Reset cierra la puerta y devuelve el control al ciclo automático
+func (da *DoorActuator) Reset() {
+	da.mu.Lock()
+	defer da.mu.Unlock()
+
+	da.position = 0.0
+	da.target = 0.0
+	da.command = DoorCommandClose
+	da.obstructed = false
+	da.scenarioControl = false
+	da.lastUpdate = clock.Now()
+
+	fmt.Fprintln(da.out, "🔄 [Door] Reset completado")
+}
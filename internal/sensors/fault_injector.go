@@ -0,0 +1,395 @@
+package sensors
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/clock"
+	"github.com/MarcosBrindi/transporte-simulator/internal/config"
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+)
+
+// Nombres de sensores usados por el inyector de fallas (ver config.FaultConfig)
+const (
+	SensorGPS     = config.SensorGPS
+	SensorMPU6050 = config.SensorMPU6050
+	SensorVL53L0X = config.SensorVL53L0X
+	SensorCamera  = config.SensorCamera
+)
+
+// Tipos de falla soportados
+const (
+	FaultDrop       = config.FaultDrop
+	FaultFreeze     = config.FaultFreeze
+	FaultBias       = config.FaultBias
+	FaultSpike      = config.FaultSpike
+	FaultSaturate   = config.FaultSaturate
+	FaultDelay      = config.FaultDelay
+	FaultDuplicate  = config.FaultDuplicate
+	FaultOutOfRange = config.FaultOutOfRange
+)
+
+// Lecturas de saturación por defecto (si magnitude = 0)
+const (
+	gpsSaturationKmh     = 255.0 // km/h
+	mpuSaturationMS2     = 19.6  // m/s² (±2g)
+	vl53l0xSaturationMM  = 2000  // mm (alcance máximo)
+	vl53l0xOutOfRangeMM  = 8190  // mm (código de fuera de rango del VL53L0X)
+	cameraSaturationPers = 20    // personas
+)
+
+// faultReportInterval separa dos eventos INJECTED de una misma falla
+const faultReportInterval = time.Second
+
+// activeFault es una falla programada o activa
+type activeFault struct {
+	config     config.FaultConfig
+	startTime  time.Time
+	endTime    time.Time // Cero = sin fin
+	started    bool
+	ended      bool           // Terminada o eliminada (los timers que ya corrieron la ignoran)
+	timers     []*clock.Timer // Inicio y fin programados
+	injections int
+	lastReport time.Time // Último evento INJECTED publicado
+}
+
+// FaultInjector altera las muestras de los sensores antes de publicarlas
+type FaultInjector struct {
+	bus       *eventbus.EventBus
+	out       io.Writer // Destino de los logs (os.Stdout por defecto)
+	cfg       config.FaultsConfig
+	threshold int     // Umbral del VL53L0X para recalcular IsOpen
+	rng       *Random // Probabilidad por muestra y picos
+
+	mu       sync.Mutex
+	faults   []*activeFault
+	lastGood map[string]eventbus.Event // Última muestra sin falla por sensor (para freeze)
+}
+
+// NewFaultInjector crea un inyector y programa las fallas del config
+func NewFaultInjector(bus *eventbus.EventBus, cfg config.Config) *FaultInjector {
+	fi := &FaultInjector{
+		bus:       bus,
+		out:       os.Stdout,
+		cfg:       cfg.Faults,
+		threshold: cfg.Sensors.VL53L0X.Threshold,
+		faults:    make([]*activeFault, 0),
+		lastGood:  make(map[string]eventbus.Event),
+		rng:       NewRandom(),
+	}
+
+	fi.scheduleConfigFaults()
+
+	return fi
+}
+
+// SetOutput redirige los logs del inyector de fallas (io.Discard los silencia)
+func (fi *FaultInjector) SetOutput(w io.Writer) {
+	fi.out = w
+}
+
+// SetSeed fija la semilla de las fallas
+func (fi *FaultInjector) SetSeed(seed int64) {
+	fi.rng.Seed(seed)
+}
+
+// scheduleConfigFaults programa las fallas definidas en config.yaml
+func (fi *FaultInjector) scheduleConfigFaults() {
+	if !fi.cfg.Enabled {
+		return
+	}
+
+	for _, fault := range fi.cfg.Faults {
+		if err := fi.InjectFault(fault); err != nil {
+			fmt.Fprintf(fi.out, "⚠️  [Faults] Falla ignorada: %v\n", err)
+		}
+	}
+}
+
+// InjectFault programa una falla; Start es relativo al momento de la llamada
+func (fi *FaultInjector) InjectFault(fault config.FaultConfig) error {
+	if err := fault.Validate(); err != nil {
+		return err
+	}
+
+	now := clock.Now()
+	startTime := now.Add(time.Duration(fault.Start * float64(time.Second)))
+	var endTime time.Time
+	if fault.Duration > 0 {
+		endTime = startTime.Add(time.Duration(fault.Duration * float64(time.Second)))
+	}
+
+	scheduled := &activeFault{
+		config:    fault,
+		startTime: startTime,
+		endTime:   endTime,
+	}
+
+	// Inicio y fin por timer: no dependen de que el sensor publique muestras
+	// (un drop sin muestras que alterar también anuncia su fin a tiempo)
+	fi.mu.Lock()
+	fi.faults = append(fi.faults, scheduled)
+	scheduled.timers = append(scheduled.timers, clock.AfterFunc(startTime.Sub(now), func() { fi.startFault(scheduled) }))
+	if !endTime.IsZero() {
+		scheduled.timers = append(scheduled.timers, clock.AfterFunc(endTime.Sub(now), func() { fi.endFault(scheduled) }))
+	}
+	fi.mu.Unlock()
+
+	fmt.Fprintf(fi.out, "💥 [Faults] Falla programada: %s/%s en %.1fs (duración: %.1fs)\n",
+		fault.Sensor, fault.Type, fault.Start, fault.Duration)
+
+	return nil
+}
+
+// ClearFaults elimina todas las fallas (activas y programadas)
+func (fi *FaultInjector) ClearFaults() {
+	fi.mu.Lock()
+	cleared := make([]*activeFault, 0)
+	for _, fault := range fi.faults {
+		if fault.started && !fault.ended {
+			cleared = append(cleared, fault)
+		}
+		fault.ended = true
+		for _, timer := range fault.timers {
+			timer.Stop()
+		}
+	}
+	fi.faults = make([]*activeFault, 0)
+	fi.mu.Unlock()
+
+	for _, fault := range cleared {
+		fi.publishFaultEvent(fault, eventbus.FaultCleared)
+	}
+
+	fmt.Fprintln(fi.out, "🧹 [Faults] Fallas eliminadas")
+}
+
+// Reset elimina las fallas y vuelve a programar las del config
+func (fi *FaultInjector) Reset() {
+	fi.ClearFaults()
+
+	fi.mu.Lock()
+	fi.lastGood = make(map[string]eventbus.Event)
+	fi.mu.Unlock()
+
+	fi.scheduleConfigFaults()
+}
+
+// Publish publica un evento de sensor aplicando las fallas activas
+func (fi *FaultInjector) Publish(sensor string, event eventbus.Event) {
+	faults := fi.activeFaultsFor(sensor, event.Timestamp)
+
+	if len(faults) == 0 {
+		fi.mu.Lock()
+		fi.lastGood[sensor] = event
+		fi.mu.Unlock()
+
+		fi.bus.Publish(event)
+		return
+	}
+
+	copies := 1
+	var delay time.Duration
+
+	for _, fault := range faults {
+		// Probabilidad por muestra
+		if fault.config.Probability > 0 && fi.rng.Float64() >= fault.config.Probability {
+			continue
+		}
+
+		fi.mu.Lock()
+		fault.injections++
+		frozen, hasFrozen := fi.lastGood[sensor]
+		report := fault.lastReport.IsZero() || event.Timestamp.Sub(fault.lastReport) >= faultReportInterval
+		if report {
+			fault.lastReport = event.Timestamp
+		}
+		fi.mu.Unlock()
+
+		if report {
+			fi.publishFaultEvent(fault, eventbus.FaultInjected)
+		}
+
+		switch fault.config.Type {
+		case FaultDrop:
+			return
+		case FaultFreeze:
+			if hasFrozen {
+				event.Data = frozen.Data
+			}
+		case FaultDelay:
+			delay = time.Duration(fault.config.DelayMS) * time.Millisecond
+		case FaultDuplicate:
+			copies = 2
+		default:
+			event.Data = fi.distort(sensor, event.Data, fault.config)
+		}
+	}
+
+	for i := 0; i < copies; i++ {
+		if delay > 0 {
+			delayed := event
+			clock.AfterFunc(delay, func() { fi.bus.Publish(delayed) })
+		} else {
+			fi.bus.Publish(event)
+		}
+	}
+}
+
+// activeFaultsFor retorna las fallas de un sensor vigentes en now (el inicio
+// y el fin los anuncian los timers de InjectFault)
+func (fi *FaultInjector) activeFaultsFor(sensor string, now time.Time) []*activeFault {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+
+	active := make([]*activeFault, 0)
+	for _, fault := range fi.faults {
+		if fault.config.Sensor != sensor || fault.ended || now.Before(fault.startTime) {
+			continue
+		}
+		if !fault.endTime.IsZero() && !now.Before(fault.endTime) {
+			continue
+		}
+		active = append(active, fault)
+	}
+
+	return active
+}
+
+// startFault anuncia el inicio de una falla programada
+func (fi *FaultInjector) startFault(fault *activeFault) {
+	fi.mu.Lock()
+	if fault.started || fault.ended {
+		fi.mu.Unlock()
+		return
+	}
+	fault.started = true
+	fi.mu.Unlock()
+
+	fi.publishFaultEvent(fault, eventbus.FaultStarted)
+}
+
+// endFault anuncia el fin de una falla y la descarta; si el timer de inicio
+// aún no corrió, publica también su inicio para que los eventos vayan en pares
+func (fi *FaultInjector) endFault(fault *activeFault) {
+	fi.mu.Lock()
+	if fault.ended {
+		fi.mu.Unlock()
+		return
+	}
+	fault.ended = true
+	wasStarted := fault.started
+	fault.started = true
+
+	remaining := fi.faults[:0]
+	for _, existing := range fi.faults {
+		if existing != fault {
+			remaining = append(remaining, existing)
+		}
+	}
+	fi.faults = remaining
+	fi.mu.Unlock()
+
+	if !wasStarted {
+		fi.publishFaultEvent(fault, eventbus.FaultStarted)
+	}
+	fi.publishFaultEvent(fault, eventbus.FaultEnded)
+}
+
+// distort aplica sesgo, picos o saturación al valor principal de cada sensor
+func (fi *FaultInjector) distort(sensor string, data interface{}, fault config.FaultConfig) interface{} {
+	magnitude := fault.Magnitude
+
+	switch d := data.(type) {
+	case eventbus.GPSData:
+		d.Speed = distortValue(d.Speed, fault.Type, magnitude, gpsSaturationKmh, fi.rng)
+		if d.Speed < 0 {
+			d.Speed = 0
+		}
+		return d
+
+	case eventbus.MPUData:
+		d.AccelX = distortValue(d.AccelX, fault.Type, magnitude, mpuSaturationMS2, fi.rng)
+		d.AccelSmooth = distortValue(d.AccelSmooth, fault.Type, magnitude, mpuSaturationMS2, fi.rng)
+		return d
+
+	case eventbus.DoorData:
+		distance := float64(d.DistanceMM)
+		if fault.Type == FaultOutOfRange {
+			distance = vl53l0xOutOfRangeMM
+		} else {
+			distance = distortValue(distance, fault.Type, magnitude, vl53l0xSaturationMM, fi.rng)
+		}
+		if distance < 0 {
+			distance = 0
+		}
+		d.DistanceMM = int(distance)
+		d.IsOpen = d.DistanceMM >= fi.threshold
+		return d
+
+	case eventbus.CameraData:
+		persons := distortValue(float64(d.DetectedPersons), fault.Type, magnitude, cameraSaturationPers, fi.rng)
+		if persons < 0 {
+			persons = 0
+		}
+		d.DetectedPersons = int(persons)
+		return d
+	}
+
+	fmt.Fprintf(fi.out, "⚠️  [Faults] Datos no soportados para %s\n", sensor)
+	return data
+}
+
+// distortValue aplica una falla numérica a un valor
+func distortValue(value float64, faultType string, magnitude, saturation float64, rng *Random) float64 {
+	switch faultType {
+	case FaultBias:
+		return value + magnitude
+	case FaultSpike:
+		return value + (rng.Float64()*2-1)*magnitude
+	case FaultSaturate:
+		if magnitude != 0 {
+			return magnitude
+		}
+		return saturation
+	}
+	return value
+}
+
+// publishFaultEvent registra en el bus el inicio, las muestras alteradas o el fin de una falla
+func (fi *FaultInjector) publishFaultEvent(fault *activeFault, status string) {
+	fi.mu.Lock()
+	injections := fault.injections
+	fi.mu.Unlock()
+
+	fi.bus.Publish(eventbus.Event{
+		Type:      eventbus.EventFault,
+		Timestamp: clock.Now(),
+		Data: eventbus.FaultEventData{
+			Sensor:     fault.config.Sensor,
+			FaultType:  fault.config.Type,
+			Status:     status,
+			Magnitude:  fault.config.Magnitude,
+			Injections: injections,
+			Timestamp:  clock.Now(),
+		},
+	})
+
+	if status == eventbus.FaultInjected {
+		return // Sin log por muestra: el evento ya va limitado a uno por segundo
+	}
+	fmt.Fprintf(fi.out, "💥 [Faults] %s/%s %s (muestras afectadas: %d)\n",
+		fault.config.Sensor, fault.config.Type, status, injections)
+}
+
+// publishWithFaults publica un evento de sensor pasando por el inyector (si existe)
+func publishWithFaults(bus *eventbus.EventBus, faults *FaultInjector, sensor string, event eventbus.Event) {
+	if faults == nil {
+		bus.Publish(event)
+		return
+	}
+	faults.Publish(sensor, event)
+}
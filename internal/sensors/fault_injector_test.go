@@ -0,0 +1,322 @@
+package sensors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/clock"
+	"github.com/MarcosBrindi/transporte-simulator/internal/config"
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+)
+
+// newTestInjector crea un inyector sin fallas del config (umbral del VL53L0X: 300mm)
+func newTestInjector() (*FaultInjector, *eventbus.EventBus) {
+	var cfg config.Config
+	cfg.Sensors.VL53L0X.Threshold = 300
+
+	bus := eventbus.NewEventBus()
+	return NewFaultInjector(bus, cfg), bus
+}
+
+// drain retorna los eventos pendientes de un canal sin bloquear
+func drain(channel <-chan eventbus.Event) []eventbus.Event {
+	events := make([]eventbus.Event, 0)
+	for {
+		select {
+		case event := <-channel:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestFaultConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		fault   config.FaultConfig
+		wantErr bool
+	}{
+		{"drop de gps", config.FaultConfig{Sensor: SensorGPS, Type: FaultDrop}, false},
+		{"sesgo de cámara con probabilidad", config.FaultConfig{Sensor: SensorCamera, Type: FaultBias, Probability: 0.5}, false},
+		{"fuera de rango del láser", config.FaultConfig{Sensor: SensorVL53L0X, Type: FaultOutOfRange}, false},
+		{"delay con retardo", config.FaultConfig{Sensor: SensorMPU6050, Type: FaultDelay, DelayMS: 200}, false},
+		{"sensor desconocido", config.FaultConfig{Sensor: "lidar", Type: FaultDrop}, true},
+		{"tipo desconocido", config.FaultConfig{Sensor: SensorGPS, Type: "explode"}, true},
+		{"delay sin retardo", config.FaultConfig{Sensor: SensorGPS, Type: FaultDelay}, true},
+		{"fuera de rango en gps", config.FaultConfig{Sensor: SensorGPS, Type: FaultOutOfRange}, true},
+		{"start negativo", config.FaultConfig{Sensor: SensorGPS, Type: FaultDrop, Start: -1}, true},
+		{"duración negativa", config.FaultConfig{Sensor: SensorGPS, Type: FaultDrop, Duration: -1}, true},
+		{"probabilidad mayor que 1", config.FaultConfig{Sensor: SensorGPS, Type: FaultDrop, Probability: 1.5}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.fault.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFaultInjectorWindow(t *testing.T) {
+	injector, bus := newTestInjector()
+	gpsChannel := bus.Subscribe(eventbus.EventGPS)
+
+	// Falla de 10s a 15s desde la inyección; la ventana se evalúa con el
+	// Timestamp de cada muestra (now queda apenas antes de la inyección)
+	now := time.Now()
+	if err := injector.InjectFault(config.FaultConfig{Sensor: SensorGPS, Type: FaultDrop, Start: 10, Duration: 5}); err != nil {
+		t.Fatalf("InjectFault: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		offset    time.Duration
+		published bool
+	}{
+		{"antes de la ventana", 5 * time.Second, true},
+		{"recién iniciada", 10*time.Second + 10*time.Millisecond, false},
+		{"dentro", 12 * time.Second, false},
+		{"recién terminada", 15*time.Second + 10*time.Millisecond, true},
+		{"después", 20 * time.Second, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			injector.Publish(SensorGPS, eventbus.Event{
+				Type:      eventbus.EventGPS,
+				Timestamp: now.Add(tt.offset),
+				Data:      eventbus.GPSData{Speed: 30},
+			})
+			if got := len(drain(gpsChannel)) > 0; got != tt.published {
+				t.Errorf("publicado = %v, want %v", got, tt.published)
+			}
+		})
+	}
+}
+
+func TestFaultInjectorOtherSensorUnaffected(t *testing.T) {
+	injector, bus := newTestInjector()
+	mpuChannel := bus.Subscribe(eventbus.EventMPU)
+
+	now := time.Now()
+	if err := injector.InjectFault(config.FaultConfig{Sensor: SensorGPS, Type: FaultDrop}); err != nil {
+		t.Fatalf("InjectFault: %v", err)
+	}
+
+	injector.Publish(SensorMPU6050, eventbus.Event{Type: eventbus.EventMPU, Timestamp: now.Add(time.Second), Data: eventbus.MPUData{AccelX: 1}})
+	if got := len(drain(mpuChannel)); got != 1 {
+		t.Errorf("eventos del MPU = %d, want 1", got)
+	}
+}
+
+func TestFaultInjectorDistortion(t *testing.T) {
+	tests := []struct {
+		name   string
+		fault  config.FaultConfig
+		sensor string
+		event  eventbus.Event
+		check  func(t *testing.T, events []eventbus.Event)
+	}{
+		{
+			name:   "sesgo de velocidad",
+			fault:  config.FaultConfig{Sensor: SensorGPS, Type: FaultBias, Magnitude: 5},
+			sensor: SensorGPS,
+			event:  eventbus.Event{Type: eventbus.EventGPS, Data: eventbus.GPSData{Speed: 30}},
+			check: func(t *testing.T, events []eventbus.Event) {
+				if speed := events[0].Data.(eventbus.GPSData).Speed; speed != 35 {
+					t.Errorf("velocidad = %v, want 35", speed)
+				}
+			},
+		},
+		{
+			name:   "sesgo negativo no baja de 0",
+			fault:  config.FaultConfig{Sensor: SensorGPS, Type: FaultBias, Magnitude: -50},
+			sensor: SensorGPS,
+			event:  eventbus.Event{Type: eventbus.EventGPS, Data: eventbus.GPSData{Speed: 30}},
+			check: func(t *testing.T, events []eventbus.Event) {
+				if speed := events[0].Data.(eventbus.GPSData).Speed; speed != 0 {
+					t.Errorf("velocidad = %v, want 0", speed)
+				}
+			},
+		},
+		{
+			name:   "saturación por defecto",
+			fault:  config.FaultConfig{Sensor: SensorCamera, Type: FaultSaturate},
+			sensor: SensorCamera,
+			event:  eventbus.Event{Type: eventbus.EventCamera, Data: eventbus.CameraData{DetectedPersons: 2}},
+			check: func(t *testing.T, events []eventbus.Event) {
+				if persons := events[0].Data.(eventbus.CameraData).DetectedPersons; persons != cameraSaturationPers {
+					t.Errorf("personas = %d, want %d", persons, cameraSaturationPers)
+				}
+			},
+		},
+		{
+			name:   "fuera de rango abre la puerta",
+			fault:  config.FaultConfig{Sensor: SensorVL53L0X, Type: FaultOutOfRange},
+			sensor: SensorVL53L0X,
+			event:  eventbus.Event{Type: eventbus.EventDoor, Data: eventbus.DoorData{DistanceMM: 50}},
+			check: func(t *testing.T, events []eventbus.Event) {
+				data := events[0].Data.(eventbus.DoorData)
+				if data.DistanceMM != vl53l0xOutOfRangeMM || !data.IsOpen {
+					t.Errorf("puerta = %+v, want %dmm abierta", data, vl53l0xOutOfRangeMM)
+				}
+			},
+		},
+		{
+			name:   "duplicado",
+			fault:  config.FaultConfig{Sensor: SensorGPS, Type: FaultDuplicate},
+			sensor: SensorGPS,
+			event:  eventbus.Event{Type: eventbus.EventGPS, Data: eventbus.GPSData{Speed: 30}},
+			check: func(t *testing.T, events []eventbus.Event) {
+				if len(events) != 2 {
+					t.Errorf("eventos = %d, want 2", len(events))
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			injector, bus := newTestInjector()
+			channel := bus.Subscribe(tt.event.Type)
+
+			if err := injector.InjectFault(tt.fault); err != nil {
+				t.Fatalf("InjectFault: %v", err)
+			}
+			tt.event.Timestamp = time.Now().Add(time.Second)
+			injector.Publish(tt.sensor, tt.event)
+
+			events := drain(channel)
+			if len(events) == 0 {
+				t.Fatal("no se publicó el evento")
+			}
+			tt.check(t, events)
+		})
+	}
+}
+
+func TestFaultInjectorFreezeRepeatsLastGood(t *testing.T) {
+	injector, bus := newTestInjector()
+	gpsChannel := bus.Subscribe(eventbus.EventGPS)
+
+	now := time.Now()
+	injector.Publish(SensorGPS, eventbus.Event{Type: eventbus.EventGPS, Timestamp: now, Data: eventbus.GPSData{Speed: 20}})
+	if err := injector.InjectFault(config.FaultConfig{Sensor: SensorGPS, Type: FaultFreeze, Start: 1}); err != nil {
+		t.Fatalf("InjectFault: %v", err)
+	}
+	injector.Publish(SensorGPS, eventbus.Event{Type: eventbus.EventGPS, Timestamp: now.Add(2 * time.Second), Data: eventbus.GPSData{Speed: 45}})
+
+	events := drain(gpsChannel)
+	if len(events) != 2 {
+		t.Fatalf("eventos = %d, want 2", len(events))
+	}
+	if speed := events[1].Data.(eventbus.GPSData).Speed; speed != 20 {
+		t.Errorf("velocidad congelada = %v, want 20", speed)
+	}
+}
+
+// awaitFaultEvents espera n eventos de falla (los timers del reloj virtual
+// corren en su propia goroutine, como time.AfterFunc) y retorna los que
+// llegaron; después de los n espera un poco más por si sobra alguno
+func awaitFaultEvents(channel <-chan eventbus.Event, n int) []eventbus.FaultEventData {
+	result := make([]eventbus.FaultEventData, 0)
+	timeout := time.After(time.Second)
+	for len(result) < n {
+		select {
+		case event := <-channel:
+			result = append(result, event.Data.(eventbus.FaultEventData))
+		case <-timeout:
+			return result
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	for _, event := range drain(channel) {
+		result = append(result, event.Data.(eventbus.FaultEventData))
+	}
+	return result
+}
+
+// faultStatuses espera n eventos de falla y retorna sus estados
+func faultStatuses(channel <-chan eventbus.Event, n int) []string {
+	result := make([]string, 0)
+	for _, data := range awaitFaultEvents(channel, n) {
+		result = append(result, data.Status)
+	}
+	return result
+}
+
+func TestFaultInjectorLifecycleEvents(t *testing.T) {
+	virtual := clock.NewVirtual(time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	clock.Set(virtual)
+	defer clock.Set(nil)
+
+	injector, bus := newTestInjector()
+	faultChannel := bus.Subscribe(eventbus.EventFault)
+
+	now := virtual.Now()
+	if err := injector.InjectFault(config.FaultConfig{Sensor: SensorGPS, Type: FaultBias, Magnitude: 1, Start: 1, Duration: 2}); err != nil {
+		t.Fatalf("InjectFault: %v", err)
+	}
+	publish := func(offset time.Duration) {
+		injector.Publish(SensorGPS, eventbus.Event{Type: eventbus.EventGPS, Timestamp: now.Add(offset), Data: eventbus.GPSData{Speed: 10}})
+	}
+
+	// El inicio lo anuncia el timer, aunque el sensor no publique
+	virtual.Advance(time.Second)
+	if got := faultStatuses(faultChannel, 1); len(got) != 1 || got[0] != eventbus.FaultStarted {
+		t.Fatalf("al entrar en la ventana: %v, want [STARTED]", got)
+	}
+
+	// Un INJECTED por segundo como máximo
+	publish(1500 * time.Millisecond)
+	publish(2 * time.Second)
+	publish(2700 * time.Millisecond)
+	if got := faultStatuses(faultChannel, 2); len(got) != 2 || got[0] != eventbus.FaultInjected || got[1] != eventbus.FaultInjected {
+		t.Fatalf("con 3 muestras alteradas: %v, want 2 INJECTED", got)
+	}
+
+	virtual.Advance(2 * time.Second)
+	events := awaitFaultEvents(faultChannel, 1)
+	if len(events) != 1 {
+		t.Fatalf("al salir de la ventana: %d eventos, want 1", len(events))
+	}
+	data := events[0]
+	if data.Status != eventbus.FaultEnded || data.Injections != 3 {
+		t.Errorf("fin = %s con %d muestras, want ENDED con 3", data.Status, data.Injections)
+	}
+}
+
+func TestFaultInjectorClearFaults(t *testing.T) {
+	virtual := clock.NewVirtual(time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	clock.Set(virtual)
+	defer clock.Set(nil)
+
+	injector, bus := newTestInjector()
+	faultChannel := bus.Subscribe(eventbus.EventFault)
+
+	for _, fault := range []config.FaultConfig{
+		{Sensor: SensorGPS, Type: FaultDrop, Duration: 5},
+		{Sensor: SensorCamera, Type: FaultDrop, Start: 3, Duration: 5},
+	} {
+		if err := injector.InjectFault(fault); err != nil {
+			t.Fatalf("InjectFault: %v", err)
+		}
+	}
+	virtual.Advance(time.Second)
+	if got := faultStatuses(faultChannel, 1); len(got) != 1 || got[0] != eventbus.FaultStarted {
+		t.Fatalf("al inyectar: %v, want [STARTED]", got)
+	}
+
+	// Solo la falla en curso informa CLEARED; los timers pendientes no publican
+	injector.ClearFaults()
+	if got := faultStatuses(faultChannel, 1); len(got) != 1 || got[0] != eventbus.FaultCleared {
+		t.Errorf("al limpiar: %v, want [CLEARED]", got)
+	}
+	virtual.Advance(10 * time.Second)
+	if got := faultStatuses(faultChannel, 0); len(got) != 0 {
+		t.Errorf("tras limpiar: %v, want sin eventos", got)
+	}
+}
@@ -2,10 +2,13 @@ package sensors
 
 import (
 	"fmt"
+	"io"
 	"math"
+	"os"
 	"sync"
 	"time"
 
+	"github.com/MarcosBrindi/transporte-simulator/internal/clock"
 	"github.com/MarcosBrindi/transporte-simulator/internal/config"
 	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
 	"github.com/MarcosBrindi/transporte-simulator/internal/scenario"
@@ -14,15 +17,20 @@ import (
 // GPSSimulator simula un sensor GPS
 type GPSSimulator struct {
 	bus    *eventbus.EventBus
+	out    io.Writer // Destino de los logs (os.Stdout por defecto)
 	config config.GPSConfig
 	route  *scenario.Route
+	faults *FaultInjector           // Inyector de fallas (opcional)
+	trips  *scenario.TripController // Controlador de viajes (opcional)
 
 	// Campos protegidos por mutex
-	mu       sync.RWMutex
-	running  bool
-	paused   bool
-	speed    float64 // Velocidad actual en km/h
-	progress float64 // Progreso en la ruta (0.0 a 1.0)
+	mu        sync.RWMutex
+	running   bool
+	paused    bool
+	speed     float64 // Velocidad actual en km/h
+	progress  float64 // Progreso en la ruta (0.0 a 1.0)
+	direction float64 // +1 ida (hacia 1.0), -1 regreso (hacia 0.0)
+	fixLost   bool    // Sin fix (ordenado por el escenario)
 
 	// Campos de estado actual
 	currentLat float64
@@ -34,16 +42,23 @@ type GPSSimulator struct {
 // NewGPSSimulator crea un nuevo simulador GPS
 func NewGPSSimulator(bus *eventbus.EventBus, cfg config.GPSConfig, route *scenario.Route) *GPSSimulator {
 	return &GPSSimulator{
-		bus:      bus,
-		config:   cfg,
-		route:    route,
-		running:  false,
-		paused:   false,
-		speed:    0.0,
-		progress: 0.0,
+		bus:       bus,
+		out:       os.Stdout,
+		config:    cfg,
+		route:     route,
+		running:   false,
+		paused:    false,
+		speed:     0.0,
+		progress:  0.0,
+		direction: 1,
 	}
 }
 
+// SetOutput redirige los logs del GPS (io.Discard los silencia); llamar antes de Start
+func (gps *GPSSimulator) SetOutput(w io.Writer) {
+	gps.out = w
+}
+
 // Start inicia el simulador en su propia goroutine
 func (gps *GPSSimulator) Start() {
 	gps.mu.Lock()
@@ -52,8 +67,8 @@ func (gps *GPSSimulator) Start() {
 
 	go gps.loop()
 
-	fmt.Println("✅ [GPS] Simulador iniciado")
-	fmt.Printf("📍 [GPS] Posición inicial: %.6f°, %.6f°\n",
+	fmt.Fprintln(gps.out, "✅ [GPS] Simulador iniciado")
+	fmt.Fprintf(gps.out, "📍 [GPS] Posición inicial: %.6f°, %.6f°\n",
 		gps.config.InitialPosition.Latitude,
 		gps.config.InitialPosition.Longitude)
 }
@@ -64,7 +79,7 @@ func (gps *GPSSimulator) Stop() {
 	gps.running = false
 	gps.mu.Unlock()
 
-	fmt.Println("🛑 [GPS] Simulador detenido")
+	fmt.Fprintln(gps.out, "🛑 [GPS] Simulador detenido")
 }
 
 // Pause pausa el simulador
@@ -88,9 +103,68 @@ func (gps *GPSSimulator) SetSpeed(speed float64) {
 	gps.mu.Unlock()
 }
 
+// SetProgress mueve el vehículo a un punto de la ruta (0.0 a 1.0)
+func (gps *GPSSimulator) SetProgress(progress float64) {
+	gps.mu.Lock()
+	gps.progress = math.Max(0.0, math.Min(1.0, progress))
+	gps.mu.Unlock()
+}
+
+// SetFixLost pierde (true) o recupera (false) el fix del GPS
+func (gps *GPSSimulator) SetFixLost(lost bool) {
+	gps.mu.Lock()
+	gps.fixLost = lost
+	gps.mu.Unlock()
+}
+
+// GetPose retorna el estado del vehículo en la ruta (para Seek del escenario)
+func (gps *GPSSimulator) GetPose() scenario.VehiclePose {
+	gps.mu.RLock()
+	defer gps.mu.RUnlock()
+
+	return scenario.VehiclePose{
+		Progress:  gps.progress,
+		Direction: int(gps.direction),
+		Speed:     gps.speed,
+		FixLost:   gps.fixLost,
+	}
+}
+
+// SetPose reemplaza el estado del vehículo en la ruta (Direction 0 conserva el sentido)
+func (gps *GPSSimulator) SetPose(pose scenario.VehiclePose) {
+	gps.mu.Lock()
+	defer gps.mu.Unlock()
+
+	gps.progress = math.Max(0.0, math.Min(1.0, pose.Progress))
+	if pose.Direction != 0 {
+		gps.direction = float64(pose.Direction)
+	}
+	gps.speed = pose.Speed
+	gps.fixLost = pose.FixLost
+}
+
+// GetRoute retorna la ruta que recorre el GPS
+func (gps *GPSSimulator) GetRoute() *scenario.Route {
+	return gps.route
+}
+
+// SetFaultInjector asigna el inyector de fallas del sensor
+func (gps *GPSSimulator) SetFaultInjector(faults *FaultInjector) {
+	gps.mu.Lock()
+	gps.faults = faults
+	gps.mu.Unlock()
+}
+
+// SetTripController asigna el controlador de viajes notificado en cada terminal
+func (gps *GPSSimulator) SetTripController(trips *scenario.TripController) {
+	gps.mu.Lock()
+	gps.trips = trips
+	gps.mu.Unlock()
+}
+
 // loop es el bucle principal del simulador
 func (gps *GPSSimulator) loop() {
-	ticker := time.NewTicker(time.Duration(1000.0/gps.config.Frequency) * time.Millisecond)
+	ticker := clock.NewTicker(time.Duration(1000.0/gps.config.Frequency) * time.Millisecond)
 	defer ticker.Stop()
 
 	for {
@@ -113,10 +187,14 @@ func (gps *GPSSimulator) loop() {
 		// Generar datos GPS
 		data := gps.generateData()
 
-		// Publicar evento
-		gps.bus.Publish(eventbus.Event{
+		// Publicar evento (pasando por el inyector de fallas)
+		gps.mu.RLock()
+		faults := gps.faults
+		gps.mu.RUnlock()
+
+		publishWithFaults(gps.bus, faults, SensorGPS, eventbus.Event{
 			Type:      eventbus.EventGPS,
-			Timestamp: time.Now(),
+			Timestamp: clock.Now(),
 			Data:      data,
 		})
 	}
@@ -135,12 +213,12 @@ func (gps *GPSSimulator) generateData() eventbus.GPSData {
 		// Progreso = distancia / longitud total de la ruta
 		progressDelta := distanceKm / gps.route.Length
 
-		gps.progress += progressDelta
+		gps.progress += progressDelta * gps.direction
 
-		// Loop: si llegamos al final, volver al inicio
-		if gps.progress >= 1.0 {
-			gps.progress = 0.0
-			fmt.Println("🔄 [GPS] Completó la ruta, reiniciando...")
+		// Terminal: invertir el sentido en lugar de volver al inicio
+		if gps.progress >= 1.0 || gps.progress <= 0.0 {
+			gps.progress = math.Max(0.0, math.Min(1.0, gps.progress))
+			gps.reachTerminal()
 		}
 	}
 
@@ -150,7 +228,7 @@ func (gps *GPSSimulator) generateData() eventbus.GPSData {
 	// Calcular rumbo (course) basado en la dirección de la ruta
 	course := gps.calculateCourse()
 
-	return eventbus.GPSData{
+	data := eventbus.GPSData{
 		Latitude:   lat,
 		Longitude:  lon,
 		Altitude:   2240.0, // Ciudad de México promedio
@@ -159,27 +237,40 @@ func (gps *GPSSimulator) generateData() eventbus.GPSData {
 		Satellites: 8,
 		FixQuality: 1,
 		Progress:   gps.progress,
+		Direction:  int(gps.direction),
+	}
+
+	// Sin fix: el receptor no reporta satélites ni calidad
+	if gps.fixLost {
+		data.Satellites = 0
+		data.FixQuality = 0
 	}
+
+	return data
 }
 
-// calculateCourse calcula el rumbo en grados (0-360)
-// NOTA: No necesita mutex porque solo lee campos de route (inmutables)
-func (gps *GPSSimulator) calculateCourse() float64 {
-	// Diferencia entre punto final e inicial
-	deltaLat := gps.route.EndLat - gps.route.StartLat
-	deltaLon := gps.route.EndLon - gps.route.StartLon
+// reachTerminal invierte el sentido al llegar a una terminal (llamar con mu tomado)
+func (gps *GPSSimulator) reachTerminal() {
+	gps.direction = -gps.direction
 
-	// Calcular ángulo (en radianes)
-	angleRad := math.Atan2(deltaLon, deltaLat)
+	if gps.trips != nil {
+		gps.trips.ReachTerminal(clock.Now())
+	}
 
-	// Convertir a grados (0-360)
-	angleDeg := angleRad * 180.0 / math.Pi
+	fmt.Fprintln(gps.out, "🔄 [GPS] Llegó a la terminal, invirtiendo sentido")
+}
+
+// calculateCourse calcula el rumbo en grados (0-360) según el trazado de la ruta
+// NOTA: Se llama con mu tomado (lee progress y direction)
+func (gps *GPSSimulator) calculateCourse() float64 {
+	angleDeg := gps.route.GetCourseAtProgress(gps.progress)
 
-	if angleDeg < 0 {
-		angleDeg += 360.0
+	// De regreso el rumbo es el opuesto
+	if gps.direction < 0 {
+		angleDeg += 180.0
 	}
 
-	return angleDeg
+	return math.Mod(angleDeg, 360.0)
 }
 
 // GetProgress retorna el progreso actual en la ruta (0.0 a 1.0)
@@ -209,9 +300,10 @@ func (gps *GPSSimulator) GetCurrentStop() *scenario.Stop {
 func (gps *GPSSimulator) GetNextStop() *scenario.Stop {
 	gps.mu.RLock()
 	progress := gps.progress
+	direction := int(gps.direction)
 	gps.mu.RUnlock()
 
-	return gps.route.GetNextStop(progress)
+	return gps.route.GetNextStopInDirection(progress, direction)
 }
 
 // Reset reinicia el GPS a su estado inicial
@@ -221,12 +313,19 @@ func (gps *GPSSimulator) Reset() {
 
 	gps.speed = 0.0
 	gps.progress = 0.0
+	gps.direction = 1
+	gps.fixLost = false
 	gps.currentLat = gps.config.InitialPosition.Latitude
 	gps.currentLon = gps.config.InitialPosition.Longitude
 	gps.altitude = 2240.0
 	gps.course = 0.0
 
-	fmt.Println("🔄 [GPS] Reset a posición inicial")
+	// Nuevo viaje de ida desde la terminal inicial
+	if gps.trips != nil {
+		gps.trips.Start(clock.Now())
+	}
+
+	fmt.Fprintln(gps.out, "🔄 [GPS] Reset a posición inicial")
 }
 
 // SetFrequency cambia la frecuencia de actualización
@@ -2,11 +2,13 @@ package sensors
 
 import (
 	"fmt"
+	"io"
 	"math"
-	"math/rand"
+	"os"
 	"sync"
 	"time"
 
+	"github.com/MarcosBrindi/transporte-simulator/internal/clock"
 	"github.com/MarcosBrindi/transporte-simulator/internal/config"
 	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
 )
@@ -14,7 +16,10 @@ import (
 // MPU6050Simulator simula un sensor MPU6050 (acelerómetro + giroscopio)
 type MPU6050Simulator struct {
 	bus    *eventbus.EventBus
+	out    io.Writer // Destino de los logs (os.Stdout por defecto)
 	config config.MPU6050Config
+	faults *FaultInjector // Inyector de fallas (opcional)
+	rng    *Random        // Ruido de las lecturas
 
 	// Campos protegidos por mutex
 	mu             sync.RWMutex
@@ -35,26 +40,38 @@ type MPU6050Simulator struct {
 func NewMPU6050Simulator(bus *eventbus.EventBus, cfg config.MPU6050Config) *MPU6050Simulator {
 	return &MPU6050Simulator{
 		bus:            bus,
+		out:            os.Stdout,
 		config:         cfg,
 		running:        false,
 		paused:         false,
 		currentSpeed:   0.0,
 		previousSpeed:  0.0,
 		accelBuffer:    make([]float64, 0, 20), // Buffer de 20 muestras (10s a 2Hz)
-		lastUpdateTime: time.Now(),
+		lastUpdateTime: clock.Now(),
+		rng:            NewRandom(),
 	}
 }
 
+// SetOutput redirige los logs del MPU6050 (io.Discard los silencia); llamar antes de Start
+func (mpu *MPU6050Simulator) SetOutput(w io.Writer) {
+	mpu.out = w
+}
+
+// SetSeed fija la semilla del ruido
+func (mpu *MPU6050Simulator) SetSeed(seed int64) {
+	mpu.rng.Seed(seed)
+}
+
 // Start inicia el simulador en su propia goroutine
 func (mpu *MPU6050Simulator) Start() {
 	mpu.mu.Lock()
 	mpu.running = true
-	mpu.lastUpdateTime = time.Now()
+	mpu.lastUpdateTime = clock.Now()
 	mpu.mu.Unlock()
 
 	go mpu.loop()
 
-	fmt.Println("✅ [MPU6050] Simulador iniciado")
+	fmt.Fprintln(mpu.out, "✅ [MPU6050] Simulador iniciado")
 }
 
 // Stop detiene el simulador
@@ -63,7 +80,7 @@ func (mpu *MPU6050Simulator) Stop() {
 	mpu.running = false
 	mpu.mu.Unlock()
 
-	fmt.Println("[MPU6050] Simulador detenido")
+	fmt.Fprintln(mpu.out, "[MPU6050] Simulador detenido")
 }
 
 // Pause pausa el simulador
@@ -87,9 +104,16 @@ func (mpu *MPU6050Simulator) UpdateSpeed(speed float64) {
 	mpu.mu.Unlock()
 }
 
+// SetFaultInjector asigna el inyector de fallas del sensor
+func (mpu *MPU6050Simulator) SetFaultInjector(faults *FaultInjector) {
+	mpu.mu.Lock()
+	mpu.faults = faults
+	mpu.mu.Unlock()
+}
+
 // loop es el bucle principal del simulador
 func (mpu *MPU6050Simulator) loop() {
-	ticker := time.NewTicker(time.Duration(1000.0/mpu.config.Frequency) * time.Millisecond)
+	ticker := clock.NewTicker(time.Duration(1000.0/mpu.config.Frequency) * time.Millisecond)
 	defer ticker.Stop()
 
 	for {
@@ -112,10 +136,14 @@ func (mpu *MPU6050Simulator) loop() {
 		// Generar datos MPU
 		data := mpu.generateData()
 
-		// Publicar evento
-		mpu.bus.Publish(eventbus.Event{
+		// Publicar evento (pasando por el inyector de fallas)
+		mpu.mu.RLock()
+		faults := mpu.faults
+		mpu.mu.RUnlock()
+
+		publishWithFaults(mpu.bus, faults, SensorMPU6050, eventbus.Event{
 			Type:      eventbus.EventMPU,
-			Timestamp: time.Now(),
+			Timestamp: clock.Now(),
 			Data:      data,
 		})
 	}
@@ -127,7 +155,7 @@ func (mpu *MPU6050Simulator) generateData() eventbus.MPUData {
 	defer mpu.mu.Unlock()
 
 	// Calcular delta de tiempo
-	now := time.Now()
+	now := clock.Now()
 	deltaTime := now.Sub(mpu.lastUpdateTime).Seconds()
 	mpu.lastUpdateTime = now
 
@@ -143,7 +171,7 @@ func (mpu *MPU6050Simulator) generateData() eventbus.MPUData {
 	}
 
 	// Agregar ruido realista
-	noise := (rand.Float64() - 0.5) * 0.1 // ±0.05 m/s²
+	noise := (mpu.rng.Float64() - 0.5) * 0.1 // ±0.05 m/s²
 	accelLongitudinal += noise
 
 	// Actualizar velocidad anterior
@@ -170,21 +198,21 @@ func (mpu *MPU6050Simulator) generateData() eventbus.MPUData {
 	// Y: lateral (izquierda/derecha)
 	// Z: vertical (arriba/abajo) - gravedad + vibraciones
 	accelX := accelLongitudinal
-	accelY := (rand.Float64() - 0.5) * 0.2    // Pequeñas variaciones laterales
-	accelZ := 9.81 + (rand.Float64()-0.5)*0.3 // Gravedad + vibraciones
+	accelY := (mpu.rng.Float64() - 0.5) * 0.2    // Pequeñas variaciones laterales
+	accelZ := 9.81 + (mpu.rng.Float64()-0.5)*0.3 // Gravedad + vibraciones
 
 	// Simular giroscopio (grados/segundo)
 	// Giro solo si hay velocidad (no gira si está detenido)
 	gyroZ := 0.0
 	if mpu.currentSpeed > 5.0 { // Solo gira si va a más de 5 km/h
 		// Giros ocasionales (20% de probabilidad)
-		if rand.Float64() < 0.2 {
-			gyroZ = (rand.Float64() - 0.5) * 60.0 // ±30°/s
+		if mpu.rng.Float64() < 0.2 {
+			gyroZ = (mpu.rng.Float64() - 0.5) * 60.0 // ±30°/s
 		}
 	}
 
-	gyroX := (rand.Float64() - 0.5) * 2.0 // Pitch mínimo
-	gyroY := (rand.Float64() - 0.5) * 2.0 // Roll mínimo
+	gyroX := (mpu.rng.Float64() - 0.5) * 2.0 // Pitch mínimo
+	gyroY := (mpu.rng.Float64() - 0.5) * 2.0 // Roll mínimo
 
 	// Detectar estados (umbrales del config.yaml)
 	isAccelerating := accelSmooth > mpu.config.AccelThreshold
@@ -228,7 +256,7 @@ func (mpu *MPU6050Simulator) Reset() {
 	mpu.accelSmooth = 0.0
 	mpu.gyroZ = 0.0
 
-	fmt.Println("🔄 [MPU6050] Reset completado")
+	fmt.Fprintln(mpu.out, "🔄 [MPU6050] Reset completado")
 }
 
 // SetFrequency cambia la frecuencia de actualización
@@ -0,0 +1,47 @@
+package sensors
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Random generador aleatorio propio de cada simulador: con la misma semilla
+// repite la misma secuencia de números. Es seguro entre goroutines.
+type Random struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewRandom crea un generador con semilla tomada del reloj real
+func NewRandom() *Random {
+	return &Random{rng: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// Seed reinicia la secuencia con una semilla fija
+func (r *Random) Seed(seed int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rng = rand.New(rand.NewSource(seed))
+}
+
+// Float64 retorna un número en [0, 1)
+func (r *Random) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rng.Float64()
+}
+
+// Intn retorna un entero en [0, n)
+func (r *Random) Intn(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rng.Intn(n)
+}
+
+// NormFloat64 retorna un número con distribución normal estándar
+func (r *Random) NormFloat64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rng.NormFloat64()
+}
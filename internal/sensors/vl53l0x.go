@@ -2,19 +2,38 @@ package sensors
 
 import (
 	"fmt"
-	"math/rand"
+	"io"
+	"os"
 	"sync"
 	"time"
 
+	"github.com/MarcosBrindi/transporte-simulator/internal/clock"
 	"github.com/MarcosBrindi/transporte-simulator/internal/config"
 	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
 )
 
+// Distancia medida cuando una persona cruza el haz con la puerta abierta
+const (
+	personDistanceMM     = 340 // mm
+	personDistanceJitter = 20  // ±mm
+	doorwayMinPosition   = 0.9 // Posición mínima de la hoja para que el haz apunte al pasillo
+)
+
+// DoorwayProbe indica si hay una persona cruzando el umbral de la puerta
+type DoorwayProbe interface {
+	IsDoorwayOccupied() bool
+}
+
 // VL53L0XSimulator simula un sensor VL53L0X (láser de distancia para puerta)
 type VL53L0XSimulator struct {
 	bus       *eventbus.EventBus
+	out       io.Writer // Destino de los logs (os.Stdout por defecto)
 	config    config.VL53L0XConfig
-	threshold int // Umbral en mm (>= threshold = puerta abierta)
+	threshold int            // Umbral en mm (>= threshold = puerta abierta)
+	actuator  *DoorActuator  // Actuador que mueve la puerta
+	faults    *FaultInjector // Inyector de fallas (opcional)
+	doorway   DoorwayProbe   // Fuente de personas en el umbral (opcional)
+	rng       *Random        // Ruido de la distancia
 
 	// Campos protegidos por mutex
 	mu              sync.RWMutex
@@ -31,22 +50,35 @@ type VL53L0XSimulator struct {
 	isVehicleStopped bool // Si el vehículo está detenido
 }
 
-// NewVL53L0XSimulator crea un nuevo simulador VL53L0X
-func NewVL53L0XSimulator(bus *eventbus.EventBus, cfg config.VL53L0XConfig) *VL53L0XSimulator {
+// NewVL53L0XSimulator crea un nuevo simulador VL53L0X que mide la posición del actuador
+func NewVL53L0XSimulator(bus *eventbus.EventBus, cfg config.VL53L0XConfig, actuator *DoorActuator) *VL53L0XSimulator {
 	return &VL53L0XSimulator{
 		bus:             bus,
+		out:             os.Stdout,
 		config:          cfg,
 		threshold:       cfg.Threshold,
+		actuator:        actuator,
 		running:         false,
 		paused:          false,
 		distanceMM:      100, // Inicialmente cerrada (cerca)
 		isOpen:          false,
 		vehicleStopped:  false,
 		simulationCycle: 0,
-		lastOpenTime:    time.Now(),
+		lastOpenTime:    clock.Now(),
+		rng:             NewRandom(),
 	}
 }
 
+// SetOutput redirige los logs del VL53L0X (io.Discard los silencia); llamar antes de Start
+func (vl *VL53L0XSimulator) SetOutput(w io.Writer) {
+	vl.out = w
+}
+
+// SetSeed fija la semilla del ruido
+func (vl *VL53L0XSimulator) SetSeed(seed int64) {
+	vl.rng.Seed(seed)
+}
+
 // Start inicia el simulador en su propia goroutine
 func (vl *VL53L0XSimulator) Start() {
 	vl.mu.Lock()
@@ -55,8 +87,8 @@ func (vl *VL53L0XSimulator) Start() {
 
 	go vl.loop()
 
-	fmt.Println("[VL53L0X] Simulador iniciado")
-	fmt.Printf("[VL53L0X] Umbral puerta: %dmm (>= abierta, < cerrada)\n", vl.threshold)
+	fmt.Fprintln(vl.out, "[VL53L0X] Simulador iniciado")
+	fmt.Fprintf(vl.out, "[VL53L0X] Umbral puerta: %dmm (>= abierta, < cerrada)\n", vl.threshold)
 }
 
 // Stop detiene el simulador
@@ -65,7 +97,7 @@ func (vl *VL53L0XSimulator) Stop() {
 	vl.running = false
 	vl.mu.Unlock()
 
-	fmt.Println("[VL53L0X] Simulador detenido")
+	fmt.Fprintln(vl.out, "[VL53L0X] Simulador detenido")
 }
 
 // Pause pausa el simulador
@@ -89,9 +121,23 @@ func (vl *VL53L0XSimulator) UpdateVehicleState(isStopped bool) {
 	vl.mu.Unlock()
 }
 
+// SetFaultInjector asigna el inyector de fallas del sensor
+func (vl *VL53L0XSimulator) SetFaultInjector(faults *FaultInjector) {
+	vl.mu.Lock()
+	vl.faults = faults
+	vl.mu.Unlock()
+}
+
+// SetDoorwayProbe asigna la fuente que indica si alguien cruza el haz
+func (vl *VL53L0XSimulator) SetDoorwayProbe(probe DoorwayProbe) {
+	vl.mu.Lock()
+	vl.doorway = probe
+	vl.mu.Unlock()
+}
+
 // loop es el bucle principal del simulador
 func (vl *VL53L0XSimulator) loop() {
-	ticker := time.NewTicker(time.Duration(1000.0/vl.config.Frequency) * time.Millisecond)
+	ticker := clock.NewTicker(time.Duration(1000.0/vl.config.Frequency) * time.Millisecond)
 	defer ticker.Stop()
 
 	for {
@@ -114,10 +160,14 @@ func (vl *VL53L0XSimulator) loop() {
 		// Generar datos del sensor
 		data := vl.generateData()
 
-		// Publicar evento
-		vl.bus.Publish(eventbus.Event{
+		// Publicar evento (pasando por el inyector de fallas)
+		vl.mu.RLock()
+		faults := vl.faults
+		vl.mu.RUnlock()
+
+		publishWithFaults(vl.bus, faults, SensorVL53L0X, eventbus.Event{
 			Type:      eventbus.EventDoor,
-			Timestamp: time.Now(),
+			Timestamp: clock.Now(),
 			Data:      data,
 		})
 	}
@@ -128,18 +178,29 @@ func (vl *VL53L0XSimulator) generateData() eventbus.DoorData {
 	vl.mu.Lock()
 	defer vl.mu.Unlock()
 
-	// Simular comportamiento de puerta basado en si el vehículo está detenido
-	if vl.vehicleStopped {
-		// Vehículo detenido: simular ciclo de apertura/cierre de puerta
-		vl.simulateDoorCycle()
-	} else {
-		// Vehículo en movimiento: puerta siempre cerrada
-		vl.distanceMM = 100 + rand.Intn(50) // 100-150mm (cerrada)
-		vl.isOpen = false
+	// Sin control de escenario: ciclo automático de apertura/cierre
+	if !vl.actuator.IsScenarioControlled() {
+		if vl.vehicleStopped {
+			// Vehículo detenido: simular ciclo de apertura/cierre de puerta
+			vl.simulateDoorCycle()
+		} else {
+			// Vehículo en movimiento: puerta cerrada
+			vl.actuator.Command(DoorCommandClose)
+			vl.lastOpenTime = clock.Now()
+		}
+	}
+
+	// La distancia sigue la posición real de la hoja de la puerta
+	position := vl.actuator.Update()
+	vl.distanceMM = vl.actuator.DistanceAt(position)
+
+	// Persona cruzando el umbral: el haz rebota en ella (caída de distancia)
+	if position >= doorwayMinPosition && vl.doorway != nil && vl.doorway.IsDoorwayOccupied() {
+		vl.distanceMM = personDistanceMM + vl.rng.Intn(2*personDistanceJitter+1) - personDistanceJitter
 	}
 
 	// Agregar ruido realista
-	noise := rand.Intn(20) - 10 // ±10mm
+	noise := vl.rng.Intn(20) - 10 // ±10mm
 	distanceWithNoise := vl.distanceMM + noise
 
 	// Clamp para evitar valores negativos
@@ -149,6 +210,7 @@ func (vl *VL53L0XSimulator) generateData() eventbus.DoorData {
 
 	// Determinar si la puerta está abierta según el umbral
 	isOpen := distanceWithNoise >= vl.threshold
+	vl.isOpen = isOpen
 
 	return eventbus.DoorData{
 		DistanceMM: distanceWithNoise,
@@ -156,9 +218,10 @@ func (vl *VL53L0XSimulator) generateData() eventbus.DoorData {
 	}
 }
 
-// simulateDoorCycle simula el ciclo de apertura/cierre de puerta
+// simulateDoorCycle ordena al actuador el ciclo automático de apertura/cierre
+// Solo se usa cuando ningún escenario controla la puerta
 func (vl *VL53L0XSimulator) simulateDoorCycle() {
-	now := time.Now()
+	now := clock.Now()
 	timeSinceLastOpen := now.Sub(vl.lastOpenTime).Seconds()
 
 	// Ciclo de simulación:
@@ -174,13 +237,9 @@ func (vl *VL53L0XSimulator) simulateDoorCycle() {
 	}
 
 	if cyclePosition < openDuration {
-		// Puerta ABIERTA (distancia grande)
-		vl.distanceMM = 350 + rand.Intn(100) // 350-450mm
-		vl.isOpen = true
+		vl.actuator.Command(DoorCommandOpen)
 	} else {
-		// Puerta CERRADA (distancia pequeña)
-		vl.distanceMM = 100 + rand.Intn(50) // 100-150mm
-		vl.isOpen = false
+		vl.actuator.Command(DoorCommandClose)
 	}
 }
 
@@ -198,8 +257,10 @@ func (vl *VL53L0XSimulator) Reset() {
 
 	vl.distance = vl.config.Threshold - 50
 	vl.isVehicleStopped = false
+	vl.lastOpenTime = clock.Now()
+	vl.actuator.Reset()
 
-	fmt.Println("🔄 [VL53L0X] Reset completado")
+	fmt.Fprintln(vl.out, "🔄 [VL53L0X] Reset completado")
 }
 
 // SetFrequency cambia la frecuencia de actualización
@@ -7,14 +7,20 @@ import (
 	"time"
 
 	"github.com/MarcosBrindi/transporte-simulator/internal/config"
+	"github.com/MarcosBrindi/transporte-simulator/internal/gtfs"
+	"github.com/MarcosBrindi/transporte-simulator/internal/gtfsrt"
 	"github.com/MarcosBrindi/transporte-simulator/internal/scenario"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
-// RunHeadless ejecuta múltiples instancias de vehículos sin UI
-func RunHeadless(numInstances int, cfg *config.Config) error {
+// RunHeadless ejecuta múltiples instancias de vehículos sin UI; con una lista
+// de escenarios cada vehículo la recorre en lugar del patrón de conducción fijo
+func RunHeadless(numInstances int, cfg *config.Config, playlist *scenario.Playlist) error {
 	fmt.Println("\n🚀 === MODO HEADLESS (SIN UI) ===")
 	fmt.Printf("📊 Instancias a ejecutar: %d\n", numInstances)
+	if playlist != nil {
+		fmt.Printf("📜 %s\n", playlist)
+	}
 	fmt.Println()
 
 	// Conectar a RabbitMQ UNA sola vez
@@ -44,8 +50,28 @@ func RunHeadless(numInstances int, cfg *config.Config) error {
 	// WaitGroup para sincronizar goroutines
 	var wg sync.WaitGroup
 
-	// Crear ruta (compartida para todas)
+	// Crear ruta (compartida para todas): viaje GTFS si está habilitado
 	route := scenario.NewDefaultRoute()
+	if cfg.GTFS.Enabled {
+		gtfsRoute, _, err := gtfs.LoadTrip(cfg.GTFS)
+		if err != nil {
+			fmt.Printf("⚠️  [GTFS] No se pudo importar el feed: %v\n", err)
+		} else {
+			route = gtfsRoute
+		}
+	}
+
+	// Feed GTFS-Realtime de toda la flota (opcional)
+	var realtime *gtfsrt.Server
+	if cfg.GTFSRT.Enabled {
+		realtime = gtfsrt.NewServer(cfg.GTFSRT)
+		if err := realtime.Start(); err != nil {
+			fmt.Printf("⚠️  [GTFS-RT] No se pudo iniciar: %v\n", err)
+			realtime = nil
+		} else {
+			defer realtime.Stop()
+		}
+	}
 
 	// Lanzar N vehículos
 	fmt.Printf("🚌 Lanzando %d vehículos...\n", numInstances)
@@ -56,7 +82,7 @@ func RunHeadless(numInstances int, cfg *config.Config) error {
 		delayMs := (i % 10) * 100
 		go func(id int, delayMs int) {
 			time.Sleep(time.Duration(delayMs) * time.Millisecond)
-			SimulateVehicle(ctx, id, conn, cfg, route, &wg)
+			SimulateVehicle(ctx, id, conn, cfg, route, realtime, playlist, &wg)
 		}(i, delayMs)
 
 		// Log cada 100 instancias
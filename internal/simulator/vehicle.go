@@ -9,6 +9,7 @@ import (
 
 	"github.com/MarcosBrindi/transporte-simulator/internal/config"
 	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+	"github.com/MarcosBrindi/transporte-simulator/internal/gtfsrt"
 	"github.com/MarcosBrindi/transporte-simulator/internal/mqtt"
 	"github.com/MarcosBrindi/transporte-simulator/internal/scenario"
 	"github.com/MarcosBrindi/transporte-simulator/internal/sensors"
@@ -23,6 +24,7 @@ type VehicleSimulator struct {
 	GPS            *sensors.GPSSimulator
 	MPU            *sensors.MPU6050Simulator
 	VL53L0X        *sensors.VL53L0XSimulator
+	Door           *sensors.DoorActuator
 	Camera         *sensors.CameraSimulator
 	StateManager   *statemanager.StateManager
 	Publisher      *mqtt.RabbitMQPublisher
@@ -37,6 +39,8 @@ func SimulateVehicle(
 	sharedConn *amqp.Connection,
 	cfg *config.Config,
 	route *scenario.Route,
+	realtime *gtfsrt.Server,
+	playlist *scenario.Playlist,
 	wg *sync.WaitGroup,
 ) {
 	defer wg.Done()
@@ -58,16 +62,41 @@ func SimulateVehicle(
 	// Crear sensores
 	gps := sensors.NewGPSSimulator(bus, cfg.Sensors.GPS, route)
 	mpu := sensors.NewMPU6050Simulator(bus, cfg.Sensors.MPU6050)
-	vl53l0x := sensors.NewVL53L0XSimulator(bus, cfg.Sensors.VL53L0X)
+	door := sensors.NewDoorActuator(cfg.Sensors.Door)
+	vl53l0x := sensors.NewVL53L0XSimulator(bus, cfg.Sensors.VL53L0X, door)
 	camera := sensors.NewCameraSimulator(bus, cfg.Sensors.Camera)
 
-	// Crear State Manager
-	stateMgr := statemanager.NewStateManager(bus, *cfg)
+	// Inyector de fallas (fallas del config.yaml)
+	faults := sensors.NewFaultInjector(bus, *cfg)
+	gps.SetFaultInjector(faults)
+	mpu.SetFaultInjector(faults)
+	vl53l0x.SetFaultInjector(faults)
+	camera.SetFaultInjector(faults)
+	vl53l0x.SetDoorwayProbe(camera)
+
+	// Controlador de viajes (ida/regreso entre terminales)
+	trips := scenario.NewTripController(bus, route, deviceID)
+	gps.SetTripController(trips)
+
+	// Crear State Manager (con el device ID de esta instancia)
+	vehicleCfg := *cfg
+	vehicleCfg.DeviceID = deviceID
+	stateMgr := statemanager.NewStateManager(bus, vehicleCfg)
+	stateMgr.SetRoute(route)
+	stateMgr.SetTripSource(trips)
+
+	// Agregar el vehículo al feed GTFS-Realtime de la flota
+	if realtime != nil {
+		realtime.Track(deviceID, bus, route)
+		defer realtime.Untrack(deviceID)
+	}
 
 	// Crear Publisher con canal compartido
 	publisher := mqtt.NewRabbitMQPublisher(ch, cfg.RabbitMQ, deviceID, bus)
+	publisher.SetTripProvider(trips)
 
 	// Iniciar componentes
+	trips.Start(time.Now())
 	gps.Start()
 	mpu.Start()
 	vl53l0x.Start()
@@ -109,6 +138,35 @@ func SimulateVehicle(
 		}
 	}()
 
+	// Detener el vehículo y sus componentes
+	shutdown := func() {
+		fmt.Printf("🛑 [%s] Deteniendo vehículo\n", deviceID)
+		trips.Abort(time.Now())
+		publisher.Stop()
+		gps.Stop()
+		mpu.Stop()
+		vl53l0x.Stop()
+		camera.Stop()
+		stateMgr.Stop()
+	}
+
+	// Lista de escenarios (opcional): reemplaza el patrón de conducción fijo
+	if playlist != nil {
+		executor := scenario.NewExecutor(playlist.Scenario(0), gps, bus)
+		executor.SetDoorController(door)
+		executor.SetFaultController(faults)
+		executor.SetPassengerController(camera)
+		executor.SetPositionController(gps)
+		executor.SetStateInspector(stateMgr)
+		executor.SetPlaylist(playlist)
+		executor.Start()
+
+		<-ctx.Done()
+		executor.Stop()
+		shutdown()
+		return
+	}
+
 	// Simular patrón de conducción con variaciones
 	baseSpeed := 30.0
 	speedVariation := (rand.Float64() * 6) - 3 // ±3 km/h
@@ -138,13 +196,7 @@ func SimulateVehicle(
 		select {
 		case <-ctx.Done():
 			// Shutdown graceful
-			fmt.Printf("🛑 [%s] Deteniendo vehículo\n", deviceID)
-			publisher.Stop()
-			gps.Stop()
-			mpu.Stop()
-			vl53l0x.Stop()
-			camera.Stop()
-			stateMgr.Stop()
+			shutdown()
 			return
 
 		case <-ticker.C:
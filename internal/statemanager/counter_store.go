@@ -0,0 +1,138 @@
+package statemanager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/config"
+)
+
+// CounterSnapshot contadores de pasajeros persistidos por dispositivo
+type CounterSnapshot struct {
+	DeviceID       string    `json:"device_id"`
+	ServiceDate    string    `json:"service_date"`
+	PassengerCount int       `json:"passenger_count"`
+	DailyEntries   int       `json:"daily_entries"`
+	DailyExits     int       `json:"daily_exits"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// CounterStore guarda los contadores en un archivo JSON por dispositivo
+type CounterStore struct {
+	path       string
+	location   *time.Location
+	dayOffset  time.Duration // Desplazamiento del inicio del día de servicio
+	hasStorage bool
+}
+
+// NewCounterStore crea el almacén de contadores de un dispositivo
+func NewCounterStore(cfg config.StorageConfig, deviceID string) *CounterStore {
+	store := &CounterStore{
+		location:   time.Local,
+		hasStorage: cfg.Enabled,
+	}
+
+	if cfg.Timezone != "" {
+		location, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			fmt.Printf("⚠️  [Storage] Zona horaria '%s' no válida, usando local: %v\n", cfg.Timezone, err)
+		} else {
+			store.location = location
+		}
+	}
+
+	offset, err := parseClock(cfg.ServiceDayStart)
+	if err != nil {
+		fmt.Printf("⚠️  [Storage] service_day_start no válido, usando 00:00: %v\n", err)
+	}
+	store.dayOffset = offset
+
+	directory := cfg.Directory
+	if directory == "" {
+		directory = "data"
+	}
+	store.path = filepath.Join(directory, fmt.Sprintf("counters_%s.json", sanitizeFileName(deviceID)))
+
+	return store
+}
+
+// ServiceDate retorna el día de servicio (YYYY-MM-DD) al que pertenece un instante
+func (cs *CounterStore) ServiceDate(t time.Time) string {
+	return t.In(cs.location).Add(-cs.dayOffset).Format("2006-01-02")
+}
+
+// Load lee los contadores guardados; retorna false si no hay archivo
+func (cs *CounterStore) Load() (CounterSnapshot, bool, error) {
+	var snapshot CounterSnapshot
+
+	if !cs.hasStorage {
+		return snapshot, false, nil
+	}
+
+	data, err := os.ReadFile(cs.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return snapshot, false, nil
+	}
+	if err != nil {
+		return snapshot, false, fmt.Errorf("error leyendo %s: %w", cs.path, err)
+	}
+
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return snapshot, false, fmt.Errorf("error parseando %s: %w", cs.path, err)
+	}
+
+	return snapshot, true, nil
+}
+
+// Save escribe los contadores de forma atómica (archivo temporal + rename)
+func (cs *CounterStore) Save(snapshot CounterSnapshot) error {
+	if !cs.hasStorage {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cs.path), 0o755); err != nil {
+		return fmt.Errorf("error creando directorio: %w", err)
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializando contadores: %w", err)
+	}
+
+	tmpPath := cs.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("error escribiendo %s: %w", tmpPath, err)
+	}
+
+	return os.Rename(tmpPath, cs.path)
+}
+
+// parseClock convierte "HH:MM" en un desplazamiento desde medianoche
+func parseClock(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+}
+
+// sanitizeFileName reemplaza caracteres no válidos en nombres de archivo
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,132 @@
+package statemanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/config"
+)
+
+// newTestStore crea un almacén en un directorio temporal (día de servicio desde las 03:00 UTC)
+func newTestStore(t *testing.T) *CounterStore {
+	t.Helper()
+	return NewCounterStore(config.StorageConfig{
+		Enabled:         true,
+		Directory:       t.TempDir(),
+		ServiceDayStart: "03:00",
+		Timezone:        "UTC",
+	}, "COMBI-01")
+}
+
+func TestServiceDate(t *testing.T) {
+	store := newTestStore(t)
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want string
+	}{
+		{"mediodía", time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC), "2025-03-10"},
+		{"madrugada antes del corte", time.Date(2025, 3, 10, 2, 59, 0, 0, time.UTC), "2025-03-09"},
+		{"justo en el corte", time.Date(2025, 3, 10, 3, 0, 0, 0, time.UTC), "2025-03-10"},
+		{"otra zona horaria", time.Date(2025, 3, 10, 1, 0, 0, 0, time.FixedZone("UTC-6", -6*3600)), "2025-03-10"},
+		{"cambio de año", time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC), "2024-12-31"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := store.ServiceDate(tt.at); got != tt.want {
+				t.Errorf("ServiceDate(%v) = %s, want %s", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"03:00", 3 * time.Hour, false},
+		{"23:45", 23*time.Hour + 45*time.Minute, false},
+		{"3am", 0, true},
+		{"25:00", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseClock(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseClock(%q) = %v, %v; want %v, wantErr %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	if got := sanitizeFileName("COMBI 01/../x_y-z"); got != "COMBI_01____x_y-z" {
+		t.Errorf("sanitizeFileName = %q", got)
+	}
+}
+
+func TestCounterStoreRoundTrip(t *testing.T) {
+	store := newTestStore(t)
+
+	if _, found, err := store.Load(); found || err != nil {
+		t.Fatalf("Load sin archivo = found %v, err %v", found, err)
+	}
+
+	saved := CounterSnapshot{
+		DeviceID:       "COMBI-01",
+		ServiceDate:    "2025-03-10",
+		PassengerCount: 7,
+		DailyEntries:   20,
+		DailyExits:     13,
+		UpdatedAt:      time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC),
+	}
+	if err := store.Save(saved); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, err := os.Stat(store.path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("quedó el archivo temporal: %v", err)
+	}
+
+	loaded, found, err := store.Load()
+	if err != nil || !found {
+		t.Fatalf("Load = found %v, err %v", found, err)
+	}
+	if loaded != saved {
+		t.Errorf("Load = %+v, want %+v", loaded, saved)
+	}
+}
+
+func TestCounterStoreCorruptFile(t *testing.T) {
+	store := newTestStore(t)
+	if err := os.WriteFile(store.path, []byte("{no es json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, found, err := store.Load(); found || err == nil {
+		t.Errorf("Load = found %v, err %v; want error", found, err)
+	}
+}
+
+func TestCounterStoreDisabled(t *testing.T) {
+	directory := t.TempDir()
+	store := NewCounterStore(config.StorageConfig{Directory: directory}, "COMBI-01")
+
+	if err := store.Save(CounterSnapshot{PassengerCount: 3}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if entries, _ := os.ReadDir(directory); len(entries) != 0 {
+		t.Errorf("se escribieron %d archivos con la persistencia deshabilitada", len(entries))
+	}
+	if _, found, _ := store.Load(); found {
+		t.Error("Load encontró contadores con la persistencia deshabilitada")
+	}
+	if filepath.Base(store.path) != "counters_COMBI-01.json" {
+		t.Errorf("archivo = %s", store.path)
+	}
+}
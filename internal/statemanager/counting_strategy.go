@@ -0,0 +1,379 @@
+package statemanager
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/config"
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+)
+
+// Tipos de paso producidos por una estrategia de conteo
+const (
+	DecisionEntry = "ENTRY"
+	DecisionExit  = "EXIT"
+)
+
+// bulkConfidence confianza de los pasos estimados sin track asociado
+const bulkConfidence = 0.85
+
+// staleTrackTimeout segundos sin ver un track para olvidar su lado de la línea
+const staleTrackTimeout = 30.0
+
+// CountDecision es un paso (entrada/salida) decidido por una estrategia de conteo
+type CountDecision struct {
+	EventType  string // DecisionEntry o DecisionExit
+	TrackID    int    // 0 = estimado sin track asociado
+	Confidence float64
+	Timestamp  time.Time
+}
+
+// CountingStrategy decide entradas y salidas a partir de la cámara y la puerta
+//
+// Las estrategias "en vivo" devuelven pasos en OnCameraFrame (el tracker los
+// confirma con sus timeouts); las estrategias "al cierre" los devuelven todos
+// en OnDoorClosed.
+type CountingStrategy interface {
+	Name() string
+	OnDoorOpened(at time.Time)
+	OnCameraFrame(data eventbus.CameraData, at time.Time) []CountDecision
+	OnDoorClosing()
+	OnDoorClosed(onboard int, at time.Time) []CountDecision
+	RequiresBeamConfirmation() bool // Solo acepta pasos confirmados por el láser
+}
+
+// strategyOutput lo implementan las estrategias que escriben logs
+type strategyOutput interface {
+	setOutput(w io.Writer)
+}
+
+// NewCountingStrategy crea la estrategia de conteo configurada
+func NewCountingStrategy(mode string, cfg config.CountingConfig) (CountingStrategy, error) {
+	switch mode {
+	case config.CountingModeDelta, "":
+		return newDeltaStrategy(), nil
+	case config.CountingModeLine:
+		return newLineStrategy(cfg), nil
+	case config.CountingModeFusion:
+		return &fusionStrategy{lineStrategy: newLineStrategy(cfg)}, nil
+	case config.CountingModeMaxOccupancy:
+		return newMaxOccupancyStrategy(), nil
+	}
+	return nil, fmt.Errorf("estrategia de conteo '%s' no válida", mode)
+}
+
+// ========================================
+// DELTA: diferencia de personas entre apertura y cierre
+// ========================================
+
+type deltaStrategy struct {
+	out                io.Writer
+	initialPersonCount int  // Conteo al abrir puerta
+	lastDetectedCount  int  // Último conteo detectado antes del cierre
+	doorClosing        bool // La puerta empezó a cerrarse
+	doorOpenTime       time.Time
+}
+
+func newDeltaStrategy() *deltaStrategy {
+	return &deltaStrategy{out: os.Stdout}
+}
+
+func (ds *deltaStrategy) setOutput(w io.Writer) { ds.out = w }
+
+func (ds *deltaStrategy) Name() string { return config.CountingModeDelta }
+
+func (ds *deltaStrategy) RequiresBeamConfirmation() bool { return false }
+
+func (ds *deltaStrategy) OnDoorOpened(at time.Time) {
+	// Reabierta antes de confirmar el cierre: se mantiene el conteo inicial
+	if ds.doorClosing {
+		ds.doorClosing = false
+		return
+	}
+
+	ds.initialPersonCount = ds.lastDetectedCount
+	ds.doorOpenTime = at
+	ds.doorClosing = false
+}
+
+func (ds *deltaStrategy) OnCameraFrame(data eventbus.CameraData, at time.Time) []CountDecision {
+	// Guardar último conteo detectado (solo cuando puerta está abierta)
+	if !ds.doorClosing {
+		ds.lastDetectedCount = data.DetectedPersons
+	}
+	return nil
+}
+
+func (ds *deltaStrategy) OnDoorClosing() {
+	ds.doorClosing = true
+}
+
+func (ds *deltaStrategy) OnDoorClosed(onboard int, at time.Time) []CountDecision {
+	currentCount := ds.lastDetectedCount
+	passengerDelta := currentCount - ds.initialPersonCount
+
+	fmt.Fprintf(ds.out, "   Conteo inicial: %d\n", ds.initialPersonCount)
+	fmt.Fprintf(ds.out, "   Conteo final: %d\n", currentCount)
+	fmt.Fprintf(ds.out, "   Delta: %+d\n", passengerDelta)
+	fmt.Fprintf(ds.out, "   Duración: %.1fs\n", at.Sub(ds.doorOpenTime).Seconds())
+
+	ds.doorClosing = false
+	ds.lastDetectedCount = 0
+
+	// Caso especial: Salidas detectadas (sistema cree que hay personas pero YOLO no ve ninguna)
+	if onboard > 0 && currentCount == 0 {
+		fmt.Fprintf(ds.out, "🔍 [Passengers] DETECCIÓN ESPECIAL DE SALIDA:\n")
+		fmt.Fprintf(ds.out, "   Sistema creía: %d personas a bordo\n", onboard)
+		fmt.Fprintf(ds.out, "   👁️YOLO detecta: %d personas\n", currentCount)
+		fmt.Fprintf(ds.out, "   Salidas estimadas: %d\n", onboard)
+
+		return bulkDecisions(DecisionExit, onboard, at)
+	}
+
+	if passengerDelta == 0 && onboard != currentCount {
+		fmt.Fprintf(ds.out, "   ⚠️  INCONSISTENCIA: Sistema=%d, YOLO=%d\n", onboard, currentCount)
+	}
+
+	if passengerDelta > 0 {
+		return bulkDecisions(DecisionEntry, passengerDelta, at)
+	}
+	return bulkDecisions(DecisionExit, -passengerDelta, at)
+}
+
+// ========================================
+// LINE: cruce de cada track por una línea virtual
+// ========================================
+
+// Lados de la línea de conteo (en coordenadas de imagen)
+const (
+	lineSideUnknown = 0
+	lineSideAbove   = -1 // Y menor que la línea
+	lineSideBelow   = 1  // Y mayor que la línea
+)
+
+type lineStrategy struct {
+	lineY      float64
+	margin     float64
+	insideSide int // Lado de la línea que corresponde al interior del vehículo
+
+	sides    map[int]int       // Último lado visto de cada track
+	lastSeen map[int]time.Time // Última vez que se vio cada track
+}
+
+func newLineStrategy(cfg config.CountingConfig) *lineStrategy {
+	// Entrada hacia arriba: el interior queda por encima de la línea
+	insideSide := lineSideAbove
+	if cfg.EntryDirection == "down" {
+		insideSide = lineSideBelow
+	}
+
+	return &lineStrategy{
+		lineY:      cfg.LineY,
+		margin:     cfg.LineMargin,
+		insideSide: insideSide,
+		sides:      make(map[int]int),
+		lastSeen:   make(map[int]time.Time),
+	}
+}
+
+func (ls *lineStrategy) Name() string { return config.CountingModeLine }
+
+func (ls *lineStrategy) RequiresBeamConfirmation() bool { return false }
+
+func (ls *lineStrategy) OnDoorOpened(at time.Time) {}
+
+func (ls *lineStrategy) OnDoorClosing() {}
+
+func (ls *lineStrategy) OnDoorClosed(onboard int, at time.Time) []CountDecision {
+	return nil
+}
+
+func (ls *lineStrategy) OnCameraFrame(data eventbus.CameraData, at time.Time) []CountDecision {
+	decisions := make([]CountDecision, 0)
+
+	for _, track := range data.Tracks {
+		ls.lastSeen[track.TrackID] = at
+
+		centroidY := (track.BoundingBox.Y1 + track.BoundingBox.Y2) / 2
+		side := ls.lineSide(centroidY)
+		if side == lineSideUnknown {
+			continue // Dentro de la banda muerta
+		}
+
+		previousSide := ls.sides[track.TrackID]
+		ls.sides[track.TrackID] = side
+
+		if previousSide == lineSideUnknown || previousSide == side {
+			continue
+		}
+
+		eventType := DecisionExit
+		if side == ls.insideSide {
+			eventType = DecisionEntry
+		}
+
+		decisions = append(decisions, CountDecision{
+			EventType:  eventType,
+			TrackID:    track.TrackID,
+			Confidence: track.Confidence,
+			Timestamp:  at,
+		})
+	}
+
+	// Olvidar tracks que ya no se ven
+	for trackID, seen := range ls.lastSeen {
+		if at.Sub(seen).Seconds() > staleTrackTimeout {
+			delete(ls.lastSeen, trackID)
+			delete(ls.sides, trackID)
+		}
+	}
+
+	return decisions
+}
+
+// lineSide retorna de qué lado de la línea está una posición Y
+func (ls *lineStrategy) lineSide(y float64) int {
+	if y < ls.lineY-ls.margin {
+		return lineSideAbove
+	}
+	if y > ls.lineY+ls.margin {
+		return lineSideBelow
+	}
+	return lineSideUnknown
+}
+
+// ========================================
+// FUSION: cruce de línea confirmado por el láser
+// ========================================
+
+type fusionStrategy struct {
+	*lineStrategy
+}
+
+func (fs *fusionStrategy) Name() string { return config.CountingModeFusion }
+
+func (fs *fusionStrategy) RequiresBeamConfirmation() bool { return true }
+
+// ========================================
+// MAX_OCCUPANCY: pico de personas vistas durante la parada
+// ========================================
+
+type maxOccupancyStrategy struct {
+	out          io.Writer
+	initialCount int
+	lastCount    int
+	peakCount    int
+	doorClosing  bool
+}
+
+func newMaxOccupancyStrategy() *maxOccupancyStrategy {
+	return &maxOccupancyStrategy{out: os.Stdout}
+}
+
+func (ms *maxOccupancyStrategy) setOutput(w io.Writer) { ms.out = w }
+
+func (ms *maxOccupancyStrategy) Name() string { return config.CountingModeMaxOccupancy }
+
+func (ms *maxOccupancyStrategy) RequiresBeamConfirmation() bool { return false }
+
+func (ms *maxOccupancyStrategy) OnDoorOpened(at time.Time) {
+	// Reabierta antes de confirmar el cierre: se mantiene el pico de la parada
+	if ms.doorClosing {
+		ms.doorClosing = false
+		return
+	}
+
+	ms.initialCount = ms.lastCount
+	ms.peakCount = ms.lastCount
+	ms.doorClosing = false
+}
+
+func (ms *maxOccupancyStrategy) OnCameraFrame(data eventbus.CameraData, at time.Time) []CountDecision {
+	if !ms.doorClosing {
+		ms.lastCount = data.DetectedPersons
+		if ms.lastCount > ms.peakCount {
+			ms.peakCount = ms.lastCount
+		}
+	}
+	return nil
+}
+
+func (ms *maxOccupancyStrategy) OnDoorClosing() {
+	ms.doorClosing = true
+}
+
+// OnDoorClosed estima que quienes se sumaron hasta el pico subieron y
+// quienes faltan al cierre respecto del pico bajaron
+func (ms *maxOccupancyStrategy) OnDoorClosed(onboard int, at time.Time) []CountDecision {
+	entries := ms.peakCount - ms.initialCount
+	exits := ms.peakCount - ms.lastCount
+
+	fmt.Fprintf(ms.out, "   Conteo inicial: %d | pico: %d | final: %d\n", ms.initialCount, ms.peakCount, ms.lastCount)
+
+	ms.doorClosing = false
+	ms.lastCount = 0
+
+	decisions := bulkDecisions(DecisionEntry, entries, at)
+	return append(decisions, bulkDecisions(DecisionExit, exits, at)...)
+}
+
+// bulkDecisions crea pasos estimados sin track asociado
+func bulkDecisions(eventType string, count int, at time.Time) []CountDecision {
+	decisions := make([]CountDecision, 0, count)
+	for i := 0; i < count; i++ {
+		decisions = append(decisions, CountDecision{
+			EventType:  eventType,
+			Confidence: bulkConfidence,
+			Timestamp:  at,
+		})
+	}
+	return decisions
+}
+
+// ========================================
+// COMPARACIÓN A/B (estrategias en sombra)
+// ========================================
+
+// strategyTally acumula los pasos de una estrategia en sombra durante una parada
+type strategyTally struct {
+	strategy  CountingStrategy
+	decisions []CountDecision
+}
+
+// add agrega un paso; un cruce de regreso del mismo track anula el anterior
+func (st *strategyTally) add(decision CountDecision) {
+	if decision.TrackID != 0 {
+		for i := len(st.decisions) - 1; i >= 0; i-- {
+			previous := st.decisions[i]
+			if previous.TrackID != decision.TrackID {
+				continue
+			}
+			if previous.EventType != decision.EventType {
+				st.decisions = append(st.decisions[:i], st.decisions[i+1:]...)
+				return
+			}
+			break
+		}
+	}
+	st.decisions = append(st.decisions, decision)
+}
+
+// summary cuenta entradas y salidas (verificando el láser si la estrategia lo requiere)
+func (st *strategyTally) summary(fusion *SensorFusion) eventbus.StrategyCount {
+	count := eventbus.StrategyCount{Strategy: st.strategy.Name()}
+
+	for _, decision := range st.decisions {
+		if st.strategy.RequiresBeamConfirmation() && !fusion.HasBreakNear(decision.Timestamp) {
+			continue
+		}
+		if decision.EventType == DecisionEntry {
+			count.Entries++
+		} else {
+			count.Exits++
+		}
+	}
+
+	return count
+}
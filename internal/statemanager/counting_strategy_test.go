@@ -0,0 +1,197 @@
+package statemanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/config"
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+)
+
+// frameWithPersons arma un frame con n personas detectadas
+func frameWithPersons(n int) eventbus.CameraData {
+	return eventbus.CameraData{DetectedPersons: n}
+}
+
+func TestNewCountingStrategy(t *testing.T) {
+	tests := []struct {
+		mode     string
+		wantName string
+		wantBeam bool
+		wantErr  bool
+	}{
+		{"", config.CountingModeDelta, false, false},
+		{config.CountingModeDelta, config.CountingModeDelta, false, false},
+		{config.CountingModeLine, config.CountingModeLine, false, false},
+		{config.CountingModeFusion, config.CountingModeFusion, true, false},
+		{config.CountingModeMaxOccupancy, config.CountingModeMaxOccupancy, false, false},
+		{"magic", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			strategy, err := NewCountingStrategy(tt.mode, config.Default().Counting)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if strategy.Name() != tt.wantName || strategy.RequiresBeamConfirmation() != tt.wantBeam {
+				t.Errorf("estrategia %s (láser %v), want %s (láser %v)",
+					strategy.Name(), strategy.RequiresBeamConfirmation(), tt.wantName, tt.wantBeam)
+			}
+		})
+	}
+}
+
+func TestDeltaStrategy(t *testing.T) {
+	tests := []struct {
+		name        string
+		before      int   // Personas vistas antes de abrir
+		frames      []int // Personas vistas con la puerta abierta
+		onboard     int
+		wantEntries int
+		wantExits   int
+	}{
+		{"suben dos", 1, []int{1, 2, 3}, 1, 2, 0},
+		{"baja uno", 2, []int{2, 1}, 3, 0, 1},
+		{"sin cambios", 2, []int{3, 2}, 2, 0, 0},
+		{"no se ve a nadie con pasajeros a bordo", 2, []int{1, 0}, 4, 0, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			strategy := newDeltaStrategy()
+			strategy.OnCameraFrame(frameWithPersons(tt.before), now)
+			strategy.OnDoorOpened(now)
+			for _, persons := range tt.frames {
+				strategy.OnCameraFrame(frameWithPersons(persons), now)
+			}
+			strategy.OnDoorClosing()
+			// Lo que se ve con la puerta cerrándose no cuenta
+			strategy.OnCameraFrame(frameWithPersons(9), now)
+
+			entries, exits := countDecisions(strategy.OnDoorClosed(tt.onboard, now))
+			if entries != tt.wantEntries || exits != tt.wantExits {
+				t.Errorf("entradas=%d salidas=%d, want %d y %d", entries, exits, tt.wantEntries, tt.wantExits)
+			}
+		})
+	}
+}
+
+func TestDeltaStrategyReopenKeepsInitialCount(t *testing.T) {
+	now := time.Now()
+	strategy := newDeltaStrategy()
+	strategy.OnCameraFrame(frameWithPersons(1), now)
+	strategy.OnDoorOpened(now)
+	strategy.OnCameraFrame(frameWithPersons(3), now)
+
+	// Se reabre antes de confirmar el cierre
+	strategy.OnDoorClosing()
+	strategy.OnDoorOpened(now)
+	strategy.OnCameraFrame(frameWithPersons(4), now)
+	strategy.OnDoorClosing()
+
+	if entries, exits := countDecisions(strategy.OnDoorClosed(1, now)); entries != 3 || exits != 0 {
+		t.Errorf("entradas=%d salidas=%d, want 3 y 0", entries, exits)
+	}
+}
+
+func TestMaxOccupancyStrategy(t *testing.T) {
+	now := time.Now()
+	strategy := newMaxOccupancyStrategy()
+	strategy.OnCameraFrame(frameWithPersons(1), now)
+	strategy.OnDoorOpened(now)
+	for _, persons := range []int{1, 3, 4, 2} {
+		strategy.OnCameraFrame(frameWithPersons(persons), now)
+	}
+	strategy.OnDoorClosing()
+
+	// Pico de 4: subieron 3 (de 1 a 4) y bajaron 2 (de 4 a 2)
+	if entries, exits := countDecisions(strategy.OnDoorClosed(1, now)); entries != 3 || exits != 2 {
+		t.Errorf("entradas=%d salidas=%d, want 3 y 2", entries, exits)
+	}
+}
+
+func TestLineSide(t *testing.T) {
+	strategy := newLineStrategy(config.Default().Counting)
+
+	tests := []struct {
+		y    float64
+		want int
+	}{
+		{300, lineSideAbove},
+		{364.9, lineSideAbove},
+		{370, lineSideUnknown},
+		{395, lineSideUnknown},
+		{395.1, lineSideBelow},
+		{460, lineSideBelow},
+	}
+
+	for _, tt := range tests {
+		if got := strategy.lineSide(tt.y); got != tt.want {
+			t.Errorf("lineSide(%v) = %d, want %d", tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestLineStrategyForgetsStaleTracks(t *testing.T) {
+	now := time.Now()
+	strategy := newLineStrategy(config.Default().Counting)
+	strategy.OnCameraFrame(frameWithTrack(1, 460), now)
+
+	// Otro track mucho después: el 1 se olvida y no cuenta al reaparecer arriba
+	later := now.Add(time.Duration(staleTrackTimeout+1) * time.Second)
+	strategy.OnCameraFrame(frameWithTrack(2, 460), later)
+	if decisions := strategy.OnCameraFrame(frameWithTrack(1, 300), later); len(decisions) != 0 {
+		t.Errorf("pasos = %+v, want ninguno para un track olvidado", decisions)
+	}
+}
+
+func TestStrategyTallyAdd(t *testing.T) {
+	entry := func(trackID int) CountDecision { return CountDecision{EventType: DecisionEntry, TrackID: trackID} }
+	exit := func(trackID int) CountDecision { return CountDecision{EventType: DecisionExit, TrackID: trackID} }
+
+	tests := []struct {
+		name        string
+		decisions   []CountDecision
+		wantEntries int
+		wantExits   int
+	}{
+		{"pasos de tracks distintos", []CountDecision{entry(1), exit(2)}, 1, 1},
+		{"cruce de regreso anula", []CountDecision{entry(1), exit(1)}, 0, 0},
+		{"regresa y vuelve a subir", []CountDecision{entry(1), exit(1), entry(1)}, 1, 0},
+		{"estimados sin track no se anulan", []CountDecision{entry(0), exit(0)}, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tally := &strategyTally{strategy: newLineStrategy(config.Default().Counting)}
+			for _, decision := range tt.decisions {
+				tally.add(decision)
+			}
+
+			count := tally.summary(newTestFusion())
+			if count.Entries != tt.wantEntries || count.Exits != tt.wantExits {
+				t.Errorf("entradas=%d salidas=%d, want %d y %d", count.Entries, count.Exits, tt.wantEntries, tt.wantExits)
+			}
+		})
+	}
+}
+
+func TestStrategyTallyRequiresBeam(t *testing.T) {
+	start := time.Now()
+	fusion := newTestFusion()
+	feedDoor(fusion, start, 800, 330, 800)
+
+	tally := &strategyTally{strategy: &fusionStrategy{lineStrategy: newLineStrategy(config.Default().Counting)}}
+	tally.add(CountDecision{EventType: DecisionEntry, TrackID: 1, Timestamp: start.Add(time.Second)})
+	tally.add(CountDecision{EventType: DecisionEntry, TrackID: 2, Timestamp: start.Add(time.Minute)})
+
+	// Solo el paso cercano al corte del haz cuenta
+	if count := tally.summary(fusion); count.Strategy != config.CountingModeFusion || count.Entries != 1 {
+		t.Errorf("conteo = %+v, want 1 entrada de fusion", count)
+	}
+}
@@ -2,15 +2,34 @@ package statemanager
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"time"
 
+	"github.com/MarcosBrindi/transporte-simulator/internal/clock"
 	"github.com/MarcosBrindi/transporte-simulator/internal/config"
 	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+	"github.com/MarcosBrindi/transporte-simulator/internal/scenario"
 )
 
+// DwellSession es una sesión de monitoreo de puerta en una parada
+type DwellSession struct {
+	ID          string
+	StopID      int // 0 = parada desconocida
+	StopName    string
+	Latitude    float64 // Posición del vehículo al abrir la puerta
+	Longitude   float64
+	OpenedAt    time.Time
+	ClosedAt    time.Time
+	ConfirmedAt time.Time // Cero si terminó por timeout
+	TimedOut    bool
+}
+
 // DoorStateManager gestiona la máquina de estados de la puerta
 type DoorStateManager struct {
 	config config.Config
+	bus    *eventbus.EventBus
+	out    io.Writer // Destino de los logs (os.Stdout por defecto)
 
 	// Estado actual
 	currentState         eventbus.DoorState
@@ -20,37 +39,50 @@ type DoorStateManager struct {
 	doorCloseStart       time.Time
 	doorCloseConfirmed   bool
 	initialPersonCount   int // Conteo inicial al abrir puerta
-	wasMonitoring        bool
+
+	// Sesión de parada
+	route        *scenario.Route
+	session      DwellSession // Sesión actual (o la última finalizada)
+	sessionCount int
 }
 
 // NewDoorStateManager crea un nuevo gestor de estado de puerta
-func NewDoorStateManager(cfg config.Config) *DoorStateManager {
+func NewDoorStateManager(bus *eventbus.EventBus, cfg config.Config) *DoorStateManager {
 	return &DoorStateManager{
 		config:               cfg,
+		bus:                  bus,
+		out:                  os.Stdout,
 		currentState:         eventbus.DoorIdle,
 		previousDoorOpen:     false,
 		doorMonitoringActive: false,
 		doorCloseConfirmed:   false,
 		initialPersonCount:   0,
-		wasMonitoring:        false,
 	}
 }
 
+// SetOutput redirige los logs de la máquina de estados de la puerta (io.Discard los silencia)
+func (dsm *DoorStateManager) SetOutput(w io.Writer) {
+	dsm.out = w
+}
+
 // Update actualiza la máquina de estados según datos de puerta y vehículo
-func (dsm *DoorStateManager) Update(doorData eventbus.DoorData, vehicleState eventbus.VehicleStateData) {
-	currentTime := time.Now()
+func (dsm *DoorStateManager) Update(doorData eventbus.DoorData, vehicleState eventbus.VehicleStateData, gpsData eventbus.GPSData) {
+	currentTime := clock.Now()
 
 	// Detectar cambio de estado de la puerta
 	if doorData.IsOpen != dsm.previousDoorOpen {
 		if doorData.IsOpen {
 			// PUERTA SE ABRIÓ
-			dsm.handleDoorOpened(doorData, vehicleState, currentTime)
+			dsm.handleDoorOpened(doorData, vehicleState, gpsData, currentTime)
 		} else {
 			// PUERTA SE CERRÓ
 			dsm.handleDoorClosed(doorData, currentTime)
 		}
 
 		dsm.previousDoorOpen = doorData.IsOpen
+	} else if dsm.currentState == eventbus.DoorOpened && doorData.IsOpen {
+		// Puerta sigue abierta: monitoreo activo de pasajeros
+		dsm.setState(eventbus.DoorMonitoringActive, currentTime)
 	}
 
 	// Verificar confirmación de cierre
@@ -65,20 +97,33 @@ func (dsm *DoorStateManager) Update(doorData eventbus.DoorData, vehicleState eve
 }
 
 // handleDoorOpened maneja cuando la puerta se abre
-func (dsm *DoorStateManager) handleDoorOpened(doorData eventbus.DoorData, vehicleState eventbus.VehicleStateData, currentTime time.Time) {
+func (dsm *DoorStateManager) handleDoorOpened(doorData eventbus.DoorData, vehicleState eventbus.VehicleStateData, gpsData eventbus.GPSData, currentTime time.Time) {
+	// Puerta reabierta antes de confirmar el cierre: misma sesión
+	if dsm.doorMonitoringActive {
+		dsm.doorCloseStart = time.Time{}
+		dsm.session.ClosedAt = time.Time{}
+		dsm.setState(eventbus.DoorMonitoringActive, currentTime)
+
+		fmt.Fprintf(dsm.out, "🚪 [DoorState] PUERTA REABIERTA (distancia: %dmm) - sesión %s\n", doorData.DistanceMM, dsm.session.ID)
+		return
+	}
+
 	// Solo iniciar monitoreo si el vehículo está detenido
 	if vehicleState.IsStopped {
 		dsm.doorMonitoringActive = true
 		dsm.doorMonitoringStart = currentTime
 		dsm.doorCloseConfirmed = false
 		dsm.doorCloseStart = time.Time{} // Reset
-		dsm.currentState = eventbus.DoorOpened
+		dsm.openSession(gpsData, currentTime)
+		dsm.setState(eventbus.DoorOpened, currentTime)
 
-		fmt.Printf("🚪 [DoorState] PUERTA ABIERTA (distancia: %dmm)\n", doorData.DistanceMM)
-		fmt.Printf("⏱️  Iniciando monitoreo (hasta cierre confirmado)\n")
-		fmt.Printf("🔄 Estado: %s - %s\n", dsm.currentState, dsm.currentState.Description())
+		fmt.Fprintf(dsm.out, "🚪 [DoorState] PUERTA ABIERTA (distancia: %dmm)\n", doorData.DistanceMM)
+		if dsm.session.StopID != 0 {
+			fmt.Fprintf(dsm.out, "🚏 Parada: %s (ID: %d) - sesión %s\n", dsm.session.StopName, dsm.session.StopID, dsm.session.ID)
+		}
+		fmt.Fprintf(dsm.out, "⏱️  Iniciando monitoreo (hasta cierre confirmado)\n")
 	} else {
-		fmt.Printf("🚫 [DoorState] Puerta abierta pero vehículo en movimiento (%s) - ignorando\n", vehicleState.State)
+		fmt.Fprintf(dsm.out, "🚫 [DoorState] Puerta abierta pero vehículo en movimiento (%s) - ignorando\n", vehicleState.State)
 	}
 }
 
@@ -86,11 +131,11 @@ func (dsm *DoorStateManager) handleDoorOpened(doorData eventbus.DoorData, vehicl
 func (dsm *DoorStateManager) handleDoorClosed(doorData eventbus.DoorData, currentTime time.Time) {
 	if dsm.doorMonitoringActive {
 		dsm.doorCloseStart = currentTime
-		dsm.currentState = eventbus.DoorClosing
+		dsm.session.ClosedAt = currentTime
+		dsm.setState(eventbus.DoorClosing, currentTime)
 
-		fmt.Printf("🚪 [DoorState] PUERTA CERRADA (distancia: %dmm)\n", doorData.DistanceMM)
-		fmt.Printf("   Iniciando confirmación de cierre (%.0fs)\n", dsm.config.Timeouts.DoorCloseConfirm)
-		fmt.Printf("   🔄 Estado: %s - %s\n", dsm.currentState, dsm.currentState.Description())
+		fmt.Fprintf(dsm.out, "🚪 [DoorState] PUERTA CERRADA (distancia: %dmm)\n", doorData.DistanceMM)
+		fmt.Fprintf(dsm.out, "   Iniciando confirmación de cierre (%.0fs)\n", dsm.config.Timeouts.DoorCloseConfirm)
 	}
 }
 
@@ -105,10 +150,10 @@ func (dsm *DoorStateManager) checkCloseConfirmation(currentTime time.Time) {
 	if closeDuration >= dsm.config.Timeouts.DoorCloseConfirm {
 		// Cierre confirmado
 		dsm.doorCloseConfirmed = true
-		dsm.currentState = eventbus.DoorAnalyzingChanges
+		dsm.session.ConfirmedAt = currentTime
+		dsm.setState(eventbus.DoorAnalyzingChanges, currentTime)
 
-		fmt.Printf("[DoorState] Cierre CONFIRMADO después de %.1fs\n", closeDuration)
-		fmt.Printf("   🔄 Estado: %s - %s\n", dsm.currentState, dsm.currentState.Description())
+		fmt.Fprintf(dsm.out, "[DoorState] Cierre CONFIRMADO después de %.1fs\n", closeDuration)
 
 		// Finalizar monitoreo
 		dsm.finalizeDoorMonitoring()
@@ -120,9 +165,11 @@ func (dsm *DoorStateManager) checkMonitoringTimeout(currentTime time.Time) {
 	monitoringDuration := currentTime.Sub(dsm.doorMonitoringStart).Seconds()
 
 	if monitoringDuration >= dsm.config.Timeouts.MaxMonitoring {
-		fmt.Printf("⏰ [DoorState] TIMEOUT DE SEGURIDAD - Monitoreo excedió %.0fs\n", dsm.config.Timeouts.MaxMonitoring)
-		fmt.Printf("   ⚠️  Posible puerta bloqueada o persona en puerta por tiempo prolongado\n")
-		fmt.Printf("   🔄 Finalizando monitoreo por seguridad\n")
+		fmt.Fprintf(dsm.out, "⏰ [DoorState] TIMEOUT DE SEGURIDAD - Monitoreo excedió %.0fs\n", dsm.config.Timeouts.MaxMonitoring)
+		fmt.Fprintf(dsm.out, "   ⚠️  Posible puerta bloqueada o persona en puerta por tiempo prolongado\n")
+		fmt.Fprintf(dsm.out, "   🔄 Finalizando monitoreo por seguridad\n")
+
+		dsm.session.TimedOut = true
 
 		dsm.finalizeDoorMonitoring()
 	}
@@ -130,19 +177,85 @@ func (dsm *DoorStateManager) checkMonitoringTimeout(currentTime time.Time) {
 
 // finalizeDoorMonitoring finaliza el monitoreo de puerta
 func (dsm *DoorStateManager) finalizeDoorMonitoring() {
-	monitoringDuration := time.Since(dsm.doorMonitoringStart).Seconds()
+	monitoringDuration := clock.Since(dsm.doorMonitoringStart).Seconds()
 
-	fmt.Printf("🔍 [DoorState] FINALIZANDO MONITOREO DE PUERTA\n")
-	fmt.Printf("   ⏱️  Duración total: %.1fs\n", monitoringDuration)
-	fmt.Printf("   🔄 Estado: IDLE\n")
+	fmt.Fprintf(dsm.out, "🔍 [DoorState] FINALIZANDO MONITOREO DE PUERTA\n")
+	fmt.Fprintf(dsm.out, "   ⏱️  Duración total: %.1fs\n", monitoringDuration)
 
-	// TODO (Fase 6): Aquí se procesarán cambios de pasajeros
-	// Por ahora solo reseteamos el estado
+	if dsm.session.ClosedAt.IsZero() {
+		dsm.session.ClosedAt = clock.Now()
+	}
 
 	dsm.doorMonitoringActive = false
 	dsm.doorCloseStart = time.Time{}
 	dsm.doorCloseConfirmed = false
-	dsm.currentState = eventbus.DoorIdle
+
+	// El StateManager confirma las entradas/salidas y luego cierra la sesión
+	dsm.setState(eventbus.DoorConfirmingEvents, clock.Now())
+}
+
+// CompleteSession vuelve a IDLE después de confirmar los eventos de pasajeros
+func (dsm *DoorStateManager) CompleteSession() {
+	dsm.setState(eventbus.DoorIdle, clock.Now())
+}
+
+// setState cambia de estado y publica la transición en el bus
+func (dsm *DoorStateManager) setState(newState eventbus.DoorState, currentTime time.Time) {
+	previousState := dsm.currentState
+	if previousState == newState {
+		return
+	}
+	dsm.currentState = newState
+
+	fmt.Fprintf(dsm.out, "   🔄 Estado: %s → %s - %s\n", previousState, newState, newState.Description())
+
+	if dsm.bus == nil {
+		return
+	}
+
+	dsm.bus.Publish(eventbus.Event{
+		Type:      eventbus.EventDoorState,
+		Timestamp: currentTime,
+		Data: eventbus.DoorStateEventData{
+			From:           previousState.String(),
+			To:             newState.String(),
+			DwellSessionID: dsm.session.ID,
+			StopID:         dsm.session.StopID,
+			DeviceID:       dsm.config.DeviceID,
+			Timestamp:      currentTime,
+		},
+	})
+}
+
+// SetRoute asigna la ruta usada para resolver la parada de cada sesión
+func (dsm *DoorStateManager) SetRoute(route *scenario.Route) {
+	dsm.route = route
+}
+
+// openSession inicia una sesión de parada resolviendo la parada más cercana
+func (dsm *DoorStateManager) openSession(gpsData eventbus.GPSData, currentTime time.Time) {
+	dsm.sessionCount++
+
+	dsm.session = DwellSession{
+		ID:        fmt.Sprintf("%s-%s-%03d", dsm.config.DeviceID, currentTime.Format("20060102T150405"), dsm.sessionCount),
+		Latitude:  gpsData.Latitude,
+		Longitude: gpsData.Longitude,
+		OpenedAt:  currentTime,
+	}
+
+	if dsm.route == nil {
+		return
+	}
+
+	if stop := dsm.route.GetNearestStop(gpsData.Progress); stop != nil {
+		dsm.session.StopID = stop.ID
+		dsm.session.StopName = stop.Name
+	}
+}
+
+// GetSession retorna la sesión de parada actual (o la última finalizada)
+func (dsm *DoorStateManager) GetSession() DwellSession {
+	return dsm.session
 }
 
 // GetCurrentState retorna el estado actual
@@ -2,20 +2,28 @@ package statemanager
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"sync"
 	"time"
 
+	"github.com/MarcosBrindi/transporte-simulator/internal/clock"
 	"github.com/MarcosBrindi/transporte-simulator/internal/config"
 	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+	"github.com/MarcosBrindi/transporte-simulator/internal/scenario"
 )
 
 // StateManager gestiona el estado del vehículo
 type StateManager struct {
 	bus              *eventbus.EventBus
+	out              io.Writer // Destino de los logs (os.Stdout por defecto)
 	cfg              config.Config
 	calculator       *VehicleStateCalculator
+	stopDetector     *StopDetector
 	doorState        *DoorStateManager
 	passengerTracker *PassengerTracker
+	route            *scenario.Route // Ruta para resolver paradas (opcional)
+	trips            TripSource      // Viaje en curso para la desviación de horario (opcional)
 
 	// Channels de suscripción
 	gpsEvents    chan eventbus.Event
@@ -48,9 +56,11 @@ type StateManager struct {
 func NewStateManager(bus *eventbus.EventBus, cfg config.Config) *StateManager {
 	return &StateManager{
 		bus:              bus,
+		out:              os.Stdout,
 		cfg:              cfg,
-		calculator:       NewVehicleStateCalculator(cfg.Thresholds.MovementKmh),
-		doorState:        NewDoorStateManager(cfg),
+		calculator:       NewVehicleStateCalculator(cfg),
+		stopDetector:     NewStopDetector(bus, cfg),
+		doorState:        NewDoorStateManager(bus, cfg),
 		passengerTracker: NewPassengerTracker(bus, cfg),
 		gpsEvents:        make(chan eventbus.Event, 10),
 		mpuEvents:        make(chan eventbus.Event, 10),
@@ -66,6 +76,23 @@ func NewStateManager(bus *eventbus.EventBus, cfg config.Config) *StateManager {
 	}
 }
 
+// SetOutput redirige los logs del state manager y sus componentes
+// (io.Discard los silencia); llamar antes de Start
+func (sm *StateManager) SetOutput(w io.Writer) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.out = w
+	sm.applyOutput()
+}
+
+// applyOutput pasa el destino de logs a los componentes (llamar con mu tomado)
+func (sm *StateManager) applyOutput() {
+	sm.stopDetector.SetOutput(sm.out)
+	sm.doorState.SetOutput(sm.out)
+	sm.passengerTracker.SetOutput(sm.out)
+}
+
 // Start inicia el State Manager
 func (sm *StateManager) Start() {
 	sm.mu.Lock()
@@ -127,7 +154,7 @@ func (sm *StateManager) Start() {
 	// Goroutine principal
 	go sm.loop()
 
-	fmt.Println("✅ [StateManager] Iniciado")
+	fmt.Fprintln(sm.out, "✅ [StateManager] Iniciado")
 }
 
 // Stop detiene el State Manager
@@ -136,7 +163,7 @@ func (sm *StateManager) Stop() {
 	sm.running = false
 	sm.mu.Unlock()
 
-	fmt.Println("🛑 [StateManager] Detenido")
+	fmt.Fprintln(sm.out, "🛑 [StateManager] Detenido")
 }
 
 // Pause pausa el State Manager
@@ -169,7 +196,7 @@ func (sm *StateManager) isPaused() bool {
 
 // loop es el bucle principal del State Manager
 func (sm *StateManager) loop() {
-	ticker := time.NewTicker(100 * time.Millisecond)
+	ticker := clock.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
 	for sm.isRunning() {
@@ -227,15 +254,19 @@ func (sm *StateManager) handleDoor(event eventbus.Event) {
 
 	// Actualizar máquina de estados de puerta
 	if sm.hasGPSData && sm.hasMPUData {
-		sm.doorState.Update(data, sm.currentState)
+		sm.doorState.Update(data, sm.currentState, sm.latestGPS)
 	}
+	session := sm.doorState.GetSession()
 
 	sm.mu.Unlock()
 
+	// Fusión láser + cámara
+	sm.passengerTracker.ProcessDoorData(data, event.Timestamp)
+
 	// Notificar a PassengerTracker sobre cambios de puerta
 	if !previousDoorOpen && data.IsOpen {
 		// Puerta se abrió
-		sm.passengerTracker.OnDoorOpened()
+		sm.passengerTracker.OnDoorOpened(session)
 	} else if previousDoorOpen && !data.IsOpen {
 		// Puerta EMPIEZA a cerrarse (antes de confirmación)
 		sm.passengerTracker.OnDoorClosing() // ← NUEVO
@@ -273,19 +304,27 @@ func (sm *StateManager) calculateAndPublishState() {
 		state.DoorOpen = sm.latestDoor.IsOpen
 	}
 
+	// Ocupación del vehículo
+	occupancy := sm.passengerTracker.GetOccupancy()
+	state.PassengerCount = occupancy.PassengerCount
+	state.Capacity = occupancy.Capacity
+	state.LoadFactor = occupancy.LoadFactor
+	state.CrowdingLevel = occupancy.CrowdingLevel
+
 	// Detectar cambio de estado
 	stateChanged := state.State != sm.previousState
 
 	// Actualizar estado actual
 	sm.currentState = state
 	sm.previousState = state.State
+	gpsData := sm.latestGPS
 
 	sm.mu.Unlock()
 
 	// Publicar evento de estado
 	sm.bus.Publish(eventbus.Event{
 		Type:      eventbus.EventVehicle,
-		Timestamp: time.Now(),
+		Timestamp: clock.Now(),
 		Data:      state,
 	})
 
@@ -295,40 +334,105 @@ func (sm *StateManager) calculateAndPublishState() {
 		if state.DoorOpen {
 			doorStatus = "🟢"
 		}
-		fmt.Printf("🚗 [StateManager] Estado: %s | Speed: %.1f km/h | Puerta: %s\n",
+		fmt.Fprintf(sm.out, "🚗 [StateManager] Estado: %s | Speed: %.1f km/h | Puerta: %s\n",
 			state.State,
 			state.Speed,
 			doorStatus,
 		)
 	}
 
+	// Detectar llegadas y salidas de paradas
+	sm.stopDetector.Update(gpsData, state, state.Timestamp)
+
 	// Verificar transiciones de estado de puerta
 	sm.checkDoorStateTransitions()
 }
 
 // checkDoorStateTransitions verifica cambios en el estado de la puerta
 func (sm *StateManager) checkDoorStateTransitions() {
-	doorState := sm.doorState.GetCurrentState()
-
-	// Cuando la puerta confirma el cierre (IDLE después de monitoreo)
-	if doorState == eventbus.DoorIdle && sm.doorState.wasMonitoring {
-		sm.passengerTracker.OnDoorClosed()
-		sm.doorState.wasMonitoring = false
+	// La máquina de puerta se lee desde otras goroutines (GetDoorState): se
+	// consulta y se cierra la sesión con el lock tomado
+	sm.mu.Lock()
+	if sm.doorState.GetCurrentState() != eventbus.DoorConfirmingEvents {
+		sm.mu.Unlock()
+		return
 	}
+	session := sm.doorState.GetSession()
+	sm.doorState.CompleteSession()
+	sm.mu.Unlock()
 
-	// Actualizar flag de monitoreo
-	if sm.doorState.IsMonitoring() {
-		sm.doorState.wasMonitoring = true
+	// Cierre confirmado (o timeout): confirmar entradas/salidas y publicar el resumen
+	sm.passengerTracker.OnDoorClosed()
+	sm.publishDwellSummary(session)
+}
+
+// publishDwellSummary publica el resumen de la parada al confirmar el cierre
+func (sm *StateManager) publishDwellSummary(session DwellSession) {
+	entries, exits, maxDetected := sm.passengerTracker.GetSessionStats()
+	current, _, _ := sm.passengerTracker.GetStats()
+	shadowCounts := sm.passengerTracker.GetShadowCounts()
+
+	sm.bus.Publish(eventbus.Event{
+		Type:      eventbus.EventDwell,
+		Timestamp: clock.Now(),
+		Data: eventbus.DwellSummaryData{
+			DwellSessionID:     session.ID,
+			StopID:             session.StopID,
+			StopName:           session.StopName,
+			Latitude:           session.Latitude,
+			Longitude:          session.Longitude,
+			OpenedAt:           session.OpenedAt,
+			ClosedAt:           session.ClosedAt,
+			ConfirmedAt:        session.ConfirmedAt,
+			DurationSec:        session.ClosedAt.Sub(session.OpenedAt).Seconds(),
+			TimedOut:           session.TimedOut,
+			Entries:            entries,
+			Exits:              exits,
+			CurrentCount:       current,
+			MaxDetectedPersons: maxDetected,
+			CountingStrategy:   sm.passengerTracker.StrategyName(),
+			ShadowCounts:       shadowCounts,
+			DeviceID:           sm.cfg.DeviceID,
+			Timestamp:          clock.Now(),
+		},
+	})
+
+	fmt.Fprintf(sm.out, "🚏 [StateManager] Resumen de parada %s (%s): +%d / -%d, a bordo: %d\n",
+		session.StopName, session.ID, entries, exits, current)
+	for _, shadow := range shadowCounts {
+		fmt.Fprintf(sm.out, "   🅱️  %s: +%d / -%d\n", shadow.Strategy, shadow.Entries, shadow.Exits)
 	}
 }
 
+// SetRoute asigna la ruta para atribuir los pasajeros a cada parada
+func (sm *StateManager) SetRoute(route *scenario.Route) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.route = route
+	sm.calculator.SetRoute(route)
+	sm.stopDetector.SetRoute(route)
+	sm.doorState.SetRoute(route)
+}
+
+// SetTripSource asigna el viaje en curso para comparar las llegadas con el horario
+func (sm *StateManager) SetTripSource(trips TripSource) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.trips = trips
+	sm.stopDetector.SetTripSource(trips)
+}
+
 // checkPassengerConfirmations verifica confirmaciones pendientes de pasajeros
 func (sm *StateManager) checkPassengerConfirmations() {
 	sm.mu.RLock()
 	isStopped := sm.currentState.IsStopped
 	sm.mu.RUnlock()
 
-	sm.passengerTracker.CheckPendingConfirmations(time.Now(), isStopped)
+	now := clock.Now()
+	sm.passengerTracker.CheckServiceDay(now)
+	sm.passengerTracker.CheckPendingConfirmations(now, isStopped)
 }
 
 // GetCurrentState retorna el estado actual (thread-safe)
@@ -343,6 +447,13 @@ func (sm *StateManager) GetPassengerStats() (current, entries, exits int) {
 	return sm.passengerTracker.GetStats()
 }
 
+// GetDoorState retorna el estado de la máquina de estados de puerta
+func (sm *StateManager) GetDoorState() eventbus.DoorState {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.doorState.GetCurrentState()
+}
+
 // Reset reinicia el state manager
 func (sm *StateManager) Reset() {
 	sm.mu.Lock()
@@ -372,11 +483,23 @@ func (sm *StateManager) Reset() {
 	sm.latestDoor = eventbus.DoorData{}
 	sm.latestCamera = eventbus.CameraData{}
 
+	// Recrear calculador de estado (sin histéresis ni debounce previos)
+	sm.calculator = NewVehicleStateCalculator(sm.cfg)
+	sm.calculator.SetRoute(sm.route)
+
+	// Recrear detector de paradas
+	sm.stopDetector = NewStopDetector(sm.bus, sm.cfg)
+	sm.stopDetector.SetRoute(sm.route)
+	sm.stopDetector.SetTripSource(sm.trips)
+
 	// Recrear DoorStateManager
-	sm.doorState = NewDoorStateManager(sm.cfg)
+	sm.doorState = NewDoorStateManager(sm.bus, sm.cfg)
+	sm.doorState.SetRoute(sm.route)
 
-	// Recrear PassengerTracker
-	sm.passengerTracker = NewPassengerTracker(sm.bus, sm.cfg)
+	// Recrear PassengerTracker con los contadores en cero (también los guardados)
+	sm.passengerTracker = newPassengerTracker(sm.bus, sm.cfg)
+	sm.applyOutput()
+	sm.passengerTracker.ClearCounters()
 
-	fmt.Println("🔄 [StateManager] Reset completado")
+	fmt.Fprintln(sm.out, "🔄 [StateManager] Reset completado")
 }
@@ -0,0 +1,170 @@
+package statemanager
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/clock"
+	"github.com/MarcosBrindi/transporte-simulator/internal/config"
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+)
+
+// Fracciones para los niveles de ocupación
+const (
+	manySeatsFraction     = 0.5 // Hasta la mitad de los asientos ocupados
+	crushedStandingFactor = 0.8 // Más del 80% del espacio de pie ocupado
+)
+
+// OccupancyInfo ocupación actual del vehículo
+type OccupancyInfo struct {
+	PassengerCount int
+	Capacity       int
+	LoadFactor     float64
+	CrowdingLevel  string
+}
+
+// OccupancyMonitor calcula el factor de carga y genera alarmas de capacidad
+type OccupancyMonitor struct {
+	bus      *eventbus.EventBus
+	out      io.Writer // Destino de los logs (os.Stdout por defecto)
+	deviceID string
+	capacity config.CapacityConfig
+	alarms   []float64
+
+	mu      sync.RWMutex
+	current OccupancyInfo
+}
+
+// NewOccupancyMonitor crea un monitor de ocupación
+func NewOccupancyMonitor(bus *eventbus.EventBus, cfg config.Config) *OccupancyMonitor {
+	om := &OccupancyMonitor{
+		bus:      bus,
+		out:      os.Stdout,
+		deviceID: cfg.DeviceID,
+		capacity: cfg.Vehicle.Capacity,
+		alarms:   cfg.Vehicle.OccupancyAlarms,
+	}
+	om.current = om.calculate(0)
+
+	return om
+}
+
+// SetOutput redirige los logs de la ocupación (io.Discard los silencia)
+func (om *OccupancyMonitor) SetOutput(w io.Writer) {
+	om.out = w
+}
+
+// Initialize fija la ocupación inicial sin generar alarmas (contadores restaurados)
+func (om *OccupancyMonitor) Initialize(count int) {
+	om.mu.Lock()
+	om.current = om.calculate(count)
+	om.mu.Unlock()
+}
+
+// Update recalcula la ocupación y publica las alarmas de umbral cruzadas
+func (om *OccupancyMonitor) Update(count int) OccupancyInfo {
+	om.mu.Lock()
+	previous := om.current
+	current := om.calculate(count)
+	om.current = current
+	om.mu.Unlock()
+
+	for _, threshold := range om.alarms {
+		if previous.LoadFactor < threshold && current.LoadFactor >= threshold {
+			om.publishAlarm(eventbus.OccupancyThresholdUp, threshold, current)
+		} else if previous.LoadFactor >= threshold && current.LoadFactor < threshold {
+			om.publishAlarm(eventbus.OccupancyThresholdDown, threshold, current)
+		}
+	}
+
+	if previous.CrowdingLevel != current.CrowdingLevel {
+		fmt.Fprintf(om.out, "🚌 [Occupancy] %s → %s (%d/%d, %.0f%%)\n",
+			previous.CrowdingLevel, current.CrowdingLevel,
+			current.PassengerCount, current.Capacity, current.LoadFactor*100)
+	}
+
+	return current
+}
+
+// ReportNegativeClamp publica una alarma cuando una salida dejaría el conteo en negativo
+func (om *OccupancyMonitor) ReportNegativeClamp() {
+	om.mu.RLock()
+	current := om.current
+	om.mu.RUnlock()
+
+	om.publishAlarm(eventbus.OccupancyNegativeClamp, 0, current)
+}
+
+// GetOccupancy retorna la ocupación actual
+func (om *OccupancyMonitor) GetOccupancy() OccupancyInfo {
+	om.mu.RLock()
+	defer om.mu.RUnlock()
+	return om.current
+}
+
+// calculate obtiene el factor de carga y el nivel de ocupación para un conteo
+func (om *OccupancyMonitor) calculate(count int) OccupancyInfo {
+	total := om.capacity.Total()
+	info := OccupancyInfo{
+		PassengerCount: count,
+		Capacity:       total,
+		CrowdingLevel:  om.crowdingLevel(count),
+	}
+
+	if total > 0 {
+		info.LoadFactor = float64(count) / float64(total)
+	}
+
+	return info
+}
+
+// crowdingLevel clasifica la ocupación según GTFS-RT OccupancyStatus
+func (om *OccupancyMonitor) crowdingLevel(count int) string {
+	seated := float64(om.capacity.Seated)
+	standing := float64(om.capacity.Standing)
+	passengers := float64(count)
+
+	switch {
+	case count <= 0:
+		return eventbus.CrowdingEmpty
+	case om.capacity.Total() <= 0:
+		return eventbus.CrowdingManySeatsAvailable // Capacidad no configurada
+	case passengers <= seated*manySeatsFraction:
+		return eventbus.CrowdingManySeatsAvailable
+	case passengers < seated:
+		return eventbus.CrowdingFewSeatsAvailable
+	case passengers <= seated+standing*crushedStandingFactor:
+		return eventbus.CrowdingStandingRoomOnly
+	case passengers < seated+standing:
+		return eventbus.CrowdingCrushedStandingRoomOnly
+	default:
+		return eventbus.CrowdingFull
+	}
+}
+
+// publishAlarm publica una alarma de ocupación en el bus
+func (om *OccupancyMonitor) publishAlarm(alarm string, threshold float64, info OccupancyInfo) {
+	om.bus.Publish(eventbus.Event{
+		Type:      eventbus.EventOccupancy,
+		Timestamp: clock.Now(),
+		Data: eventbus.OccupancyEventData{
+			Alarm:         alarm,
+			Threshold:     threshold,
+			LoadFactor:    info.LoadFactor,
+			CurrentCount:  info.PassengerCount,
+			Capacity:      info.Capacity,
+			CrowdingLevel: info.CrowdingLevel,
+			DeviceID:      om.deviceID,
+			Timestamp:     clock.Now(),
+		},
+	})
+
+	if alarm == eventbus.OccupancyNegativeClamp {
+		fmt.Fprintf(om.out, "⚠️  [Occupancy] Salida con 0 pasajeros a bordo - posible error de conteo\n")
+		return
+	}
+	fmt.Fprintf(om.out, "🚨 [Occupancy] %s %.0f%% (%d/%d pasajeros)\n",
+		alarm, threshold*100, info.PassengerCount, info.Capacity)
+}
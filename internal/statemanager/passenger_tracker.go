@@ -2,9 +2,12 @@ package statemanager
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"sync"
 	"time"
 
+	"github.com/MarcosBrindi/transporte-simulator/internal/clock"
 	"github.com/MarcosBrindi/transporte-simulator/internal/config"
 	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
 )
@@ -13,6 +16,7 @@ import (
 type PassengerTracker struct {
 	config config.Config
 	bus    *eventbus.EventBus
+	out    io.Writer // Destino de los logs (os.Stdout por defecto)
 
 	// Mutex para proteger contadores
 	mu sync.RWMutex
@@ -23,15 +27,37 @@ type PassengerTracker struct {
 	dailyExits            int // Total de salidas del día
 
 	// Estado de tracking
-	trackHistory       map[int]*TrackInfo // Historial de tracks
-	pendingEntries     map[int]*PendingEntry
-	pendingExits       map[int]*PendingExit
-	initialPersonCount int       // Conteo al abrir puerta
-	lastDetectedCount  int       // Último conteo detectado antes del cierre
-	doorOpenTime       time.Time // Cuando se abrió la puerta por última vez
-	doorClosing        bool      // Flag para saber si puerta está cerrando
+	trackHistory      map[int]*TrackInfo // Historial de tracks
+	pendingEntries    map[int]*PendingEntry
+	pendingExits      map[int]*PendingExit
+	lastDetectedCount int  // Último conteo detectado antes del cierre
+	doorClosing       bool // Flag para saber si puerta está cerrando
+
+	// Estrategia de conteo y estrategias en sombra (comparación A/B)
+	strategy     CountingStrategy
+	shadows      []*strategyTally
+	shadowCounts []eventbus.StrategyCount // Resultado de las sombras en la última parada
+
+	// Fusión láser + cámara
+	fusion *SensorFusion
+
+	// Sesión de parada actual
+	session            DwellSession
+	sessionEntries     int
+	sessionExits       int
+	sessionMaxDetected int // Máximo de personas detectadas en la sesión
+
+	// Ocupación
+	occupancy *OccupancyMonitor
+
+	// Persistencia de contadores
+	store       *CounterStore
+	serviceDate string // Día de servicio de los contadores diarios
 }
 
+// trackLostTimeout segundos sin ver un track para considerarlo perdido
+const trackLostTimeout = 2.0
+
 // TrackInfo mantiene información de un track
 type TrackInfo struct {
 	TrackID         int
@@ -59,87 +85,309 @@ type PendingExit struct {
 	FramesMissing  int
 }
 
-// NewPassengerTracker crea un nuevo tracker de pasajeros
+// NewPassengerTracker crea un nuevo tracker de pasajeros con los contadores
+// guardados del dispositivo
 func NewPassengerTracker(bus *eventbus.EventBus, cfg config.Config) *PassengerTracker {
-	return &PassengerTracker{
+	pt := newPassengerTracker(bus, cfg)
+	pt.restoreCounters(clock.Now())
+	pt.occupancy.Initialize(pt.passengerCountCurrent)
+	return pt
+}
+
+// newPassengerTracker crea el tracker sin leer los contadores guardados
+func newPassengerTracker(bus *eventbus.EventBus, cfg config.Config) *PassengerTracker {
+	strategy, err := NewCountingStrategy(cfg.Counting.Mode, cfg.Counting)
+	if err != nil {
+		fmt.Printf("⚠️  [Passengers] %v, usando '%s'\n", err, config.CountingModeDelta)
+		strategy = newDeltaStrategy()
+	}
+	if strategy.RequiresBeamConfirmation() && !cfg.Counting.Fusion.Enabled {
+		fmt.Printf("⚠️  [Passengers] Estrategia '%s' requiere counting.fusion.enabled, usando '%s'\n",
+			strategy.Name(), config.CountingModeLine)
+		strategy = newLineStrategy(cfg.Counting)
+	}
+
+	shadows := make([]*strategyTally, 0, len(cfg.Counting.Shadow))
+	for _, mode := range cfg.Counting.Shadow {
+		shadow, err := NewCountingStrategy(mode, cfg.Counting)
+		if err != nil {
+			fmt.Printf("⚠️  [Passengers] Estrategia en sombra ignorada: %v\n", err)
+			continue
+		}
+		if shadow.Name() == strategy.Name() {
+			continue
+		}
+		shadows = append(shadows, &strategyTally{strategy: shadow})
+	}
+
+	pt := &PassengerTracker{
 		config:                cfg,
 		bus:                   bus,
+		out:                   os.Stdout,
 		passengerCountCurrent: 0,
 		dailyEntries:          0,
 		dailyExits:            0,
 		trackHistory:          make(map[int]*TrackInfo),
 		pendingEntries:        make(map[int]*PendingEntry),
 		pendingExits:          make(map[int]*PendingExit),
-		initialPersonCount:    0,
 		lastDetectedCount:     0,
 		doorClosing:           false,
+		strategy:              strategy,
+		shadows:               shadows,
+		shadowCounts:          []eventbus.StrategyCount{},
+		fusion:                NewSensorFusion(cfg.Counting.Fusion),
+		store:                 NewCounterStore(cfg.Storage, cfg.DeviceID),
+		occupancy:             NewOccupancyMonitor(bus, cfg),
 	}
+
+	return pt
 }
 
-// OnDoorOpened maneja cuando la puerta se abre
-func (pt *PassengerTracker) OnDoorOpened() {
-	pt.initialPersonCount = pt.lastDetectedCount
-	pt.doorOpenTime = time.Now()
+// SetOutput redirige los logs del conteo de pasajeros, sus estrategias, la
+// fusión y la ocupación (io.Discard los silencia)
+func (pt *PassengerTracker) SetOutput(w io.Writer) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	pt.out = w
+	pt.fusion.SetOutput(w)
+	pt.occupancy.SetOutput(w)
+	if strategy, ok := pt.strategy.(strategyOutput); ok {
+		strategy.setOutput(w)
+	}
+	for _, shadow := range pt.shadows {
+		if strategy, ok := shadow.strategy.(strategyOutput); ok {
+			strategy.setOutput(w)
+		}
+	}
+}
+
+// restoreCounters recupera los contadores guardados del dispositivo
+func (pt *PassengerTracker) restoreCounters(now time.Time) {
+	pt.serviceDate = pt.store.ServiceDate(now)
+
+	snapshot, found, err := pt.store.Load()
+	if err != nil {
+		fmt.Fprintf(pt.out, "⚠️  [Passengers] No se pudieron restaurar contadores: %v\n", err)
+		return
+	}
+	if !found {
+		return
+	}
+
+	pt.mu.Lock()
+	pt.passengerCountCurrent = snapshot.PassengerCount
+	if snapshot.ServiceDate == pt.serviceDate {
+		pt.dailyEntries = snapshot.DailyEntries
+		pt.dailyExits = snapshot.DailyExits
+	}
+	pt.mu.Unlock()
+
+	fmt.Fprintf(pt.out, "💾 [Passengers] Contadores restaurados (%s): a bordo=%d, entradas=%d, salidas=%d\n",
+		snapshot.ServiceDate, snapshot.PassengerCount, snapshot.DailyEntries, snapshot.DailyExits)
+
+	// El día guardado ya terminó mientras el simulador estaba detenido
+	if snapshot.ServiceDate != pt.serviceDate {
+		pt.publishDailySummary(snapshot.ServiceDate, snapshot.DailyEntries, snapshot.DailyExits, snapshot.PassengerCount)
+		pt.persistCounters()
+	}
+}
+
+// ClearCounters pone en cero los pasajeros a bordo y los contadores del día
+// y guarda los ceros (el próximo arranque no restaura los anteriores)
+func (pt *PassengerTracker) ClearCounters() {
+	pt.mu.Lock()
+	pt.serviceDate = pt.store.ServiceDate(clock.Now())
+	pt.passengerCountCurrent = 0
+	pt.dailyEntries = 0
+	pt.dailyExits = 0
+	pt.mu.Unlock()
+
+	pt.persistCounters()
+	pt.occupancy.Initialize(0)
+
+	fmt.Fprintln(pt.out, "🧹 [Passengers] Contadores en cero")
+}
+
+// persistCounters guarda los contadores actuales
+func (pt *PassengerTracker) persistCounters() {
+	pt.mu.RLock()
+	snapshot := CounterSnapshot{
+		DeviceID:       pt.config.DeviceID,
+		ServiceDate:    pt.serviceDate,
+		PassengerCount: pt.passengerCountCurrent,
+		DailyEntries:   pt.dailyEntries,
+		DailyExits:     pt.dailyExits,
+		UpdatedAt:      clock.Now(),
+	}
+	pt.mu.RUnlock()
+
+	if err := pt.store.Save(snapshot); err != nil {
+		fmt.Fprintf(pt.out, "⚠️  [Passengers] No se pudieron guardar contadores: %v\n", err)
+	}
+}
+
+// onCountersChanged guarda los contadores y recalcula la ocupación
+func (pt *PassengerTracker) onCountersChanged() {
+	pt.persistCounters()
+
+	pt.mu.RLock()
+	current := pt.passengerCountCurrent
+	pt.mu.RUnlock()
+
+	pt.occupancy.Update(current)
+}
+
+// CheckServiceDay cierra el día de servicio al cruzar la hora de corte
+func (pt *PassengerTracker) CheckServiceDay(now time.Time) {
+	serviceDate := pt.store.ServiceDate(now)
+	if serviceDate == pt.serviceDate {
+		return
+	}
+
+	pt.mu.Lock()
+	entries := pt.dailyEntries
+	exits := pt.dailyExits
+	onboard := pt.passengerCountCurrent
+	pt.dailyEntries = 0
+	pt.dailyExits = 0
+	pt.mu.Unlock()
+
+	closedDate := pt.serviceDate
+	pt.serviceDate = serviceDate
+
+	pt.publishDailySummary(closedDate, entries, exits, onboard)
+	pt.persistCounters()
+}
+
+// publishDailySummary publica el resumen de un día de servicio
+func (pt *PassengerTracker) publishDailySummary(serviceDate string, entries, exits, onboard int) {
+	pt.bus.Publish(eventbus.Event{
+		Type:      eventbus.EventDaily,
+		Timestamp: clock.Now(),
+		Data: eventbus.DailySummaryData{
+			ServiceDate:    serviceDate,
+			TotalEntries:   entries,
+			TotalExits:     exits,
+			OnboardAtClose: onboard,
+			DeviceID:       pt.config.DeviceID,
+			Timestamp:      clock.Now(),
+		},
+	})
+
+	fmt.Fprintf(pt.out, "📅 [Passengers] Cierre del día %s: %d entradas, %d salidas, %d a bordo\n",
+		serviceDate, entries, exits, onboard)
+}
+
+// OnDoorOpened maneja cuando la puerta se abre en la sesión de parada indicada
+func (pt *PassengerTracker) OnDoorOpened(session DwellSession) {
+	now := clock.Now()
 	pt.doorClosing = false
-	fmt.Printf("👥 [Passengers] Conteo inicial al abrir puerta: %d personas\n", pt.initialPersonCount)
+
+	// Puerta reabierta en la misma parada: se continúa la sesión
+	if session.ID != "" && session.ID == pt.session.ID {
+		pt.strategy.OnDoorOpened(now)
+		for _, shadow := range pt.shadows {
+			shadow.strategy.OnDoorOpened(now)
+		}
+		fmt.Fprintf(pt.out, "👥 [Passengers] Puerta reabierta - continuando sesión %s\n", session.ID)
+		return
+	}
+
+	pt.session = session
+	pt.mu.Lock()
+	pt.sessionEntries = 0
+	pt.sessionExits = 0
+	pt.sessionMaxDetected = 0
+	pt.mu.Unlock()
+
+	pt.strategy.OnDoorOpened(now)
+	for _, shadow := range pt.shadows {
+		shadow.decisions = nil
+		shadow.strategy.OnDoorOpened(now)
+	}
+
+	fmt.Fprintf(pt.out, "👥 [Passengers] Monitoreo iniciado con estrategia '%s' (%d personas detectadas)\n",
+		pt.strategy.Name(), pt.lastDetectedCount)
 }
 
 // OnDoorClosed maneja cuando la puerta se cierra (confirmado)
 func (pt *PassengerTracker) OnDoorClosed() {
-	currentTime := time.Now()
-	currentCount := pt.lastDetectedCount
-	passengerDelta := currentCount - pt.initialPersonCount
-	monitoringDuration := currentTime.Sub(pt.doorOpenTime).Seconds()
-
-	fmt.Printf("🔍 [Passengers] FINALIZANDO MONITOREO DE PASAJEROS\n")
-	fmt.Printf("   Conteo inicial: %d\n", pt.initialPersonCount)
-	fmt.Printf("   Conteo final: %d\n", currentCount)
-	fmt.Printf("   Delta: %+d\n", passengerDelta)
-	fmt.Printf("   A bordo: %d\n", pt.passengerCountCurrent)
-	fmt.Printf("   Duración: %.1fs\n", monitoringDuration)
-
-	// Caso especial: Salidas detectadas (sistema cree que hay personas pero YOLO no ve ninguna)
-	if pt.passengerCountCurrent > 0 && currentCount == 0 {
-		estimatedExits := pt.passengerCountCurrent
-
-		fmt.Printf("🔍 [Passengers] DETECCIÓN ESPECIAL DE SALIDA:\n")
-		fmt.Printf("   Sistema creía: %d personas a bordo\n", pt.passengerCountCurrent)
-		fmt.Printf("   👁️YOLO detecta: %d personas\n", currentCount)
-		fmt.Printf("   Salidas estimadas: %d\n", estimatedExits)
-
-		pt.processBulkExits(estimatedExits)
-		return
-	}
+	currentTime := clock.Now()
+
+	pt.mu.RLock()
+	onboard := pt.passengerCountCurrent
+	pt.mu.RUnlock()
 
-	// Procesar cambios normales
-	if passengerDelta > 0 {
-		// Entradas
-		fmt.Printf("   🟢 Detectadas %d entradas\n", passengerDelta)
-		pt.processBulkEntries(passengerDelta)
-	} else if passengerDelta < 0 {
-		// Salidas
-		exits := -passengerDelta
-		fmt.Printf("   🔴 Detectadas %d salidas\n", exits)
+	fmt.Fprintf(pt.out, "🔍 [Passengers] FINALIZANDO MONITOREO DE PASAJEROS (%s)\n", pt.strategy.Name())
+	fmt.Fprintf(pt.out, "   A bordo: %d\n", onboard)
+
+	entries, exits := countDecisions(pt.strategy.OnDoorClosed(onboard, currentTime))
+	if entries > 0 {
+		fmt.Fprintf(pt.out, "   🟢 Detectadas %d entradas\n", entries)
+		pt.processBulkEntries(entries)
+	}
+	if exits > 0 {
+		fmt.Fprintf(pt.out, "   🔴 Detectadas %d salidas\n", exits)
 		pt.processBulkExits(exits)
-	} else {
-		// Sin cambios
-		if pt.passengerCountCurrent != currentCount {
-			fmt.Printf("   ⚠️  INCONSISTENCIA: Sistema=%d, YOLO=%d\n",
-				pt.passengerCountCurrent, currentCount)
-		}
-		fmt.Printf("   Sin cambios en pasajeros\n")
 	}
+
+	// Con la puerta cerrada ya no hay cruces de regreso: se confirman los pendientes
+	pending := len(pt.pendingEntries) + len(pt.pendingExits)
+	pt.finishPendingCrossings()
+
+	if entries == 0 && exits == 0 && pending == 0 {
+		fmt.Fprintf(pt.out, "   Sin cambios en pasajeros\n")
+	}
+
+	pt.closeShadows(onboard, currentTime)
+
 	pt.doorClosing = false
 	pt.lastDetectedCount = 0
 }
 
+// closeShadows cierra la parada en las estrategias en sombra y guarda sus conteos
+func (pt *PassengerTracker) closeShadows(onboard int, currentTime time.Time) {
+	counts := make([]eventbus.StrategyCount, 0, len(pt.shadows))
+
+	for _, shadow := range pt.shadows {
+		fmt.Fprintf(pt.out, "🅱️  [Passengers] Estrategia en sombra '%s'\n", shadow.strategy.Name())
+		for _, decision := range shadow.strategy.OnDoorClosed(onboard, currentTime) {
+			shadow.add(decision)
+		}
+		counts = append(counts, shadow.summary(pt.fusion))
+	}
+
+	pt.mu.Lock()
+	pt.shadowCounts = counts
+	pt.mu.Unlock()
+}
+
+// countDecisions cuenta las entradas y salidas de una lista de pasos
+func countDecisions(decisions []CountDecision) (entries, exits int) {
+	for _, decision := range decisions {
+		if decision.EventType == DecisionEntry {
+			entries++
+		} else {
+			exits++
+		}
+	}
+	return entries, exits
+}
+
 // ProcessCameraData procesa datos de la cámara
 func (pt *PassengerTracker) ProcessCameraData(data eventbus.CameraData) {
 	// Actualizar historial de tracks
-	currentTime := time.Now()
+	currentTime := clock.Now()
 	//Guardar último conteo detectado (solo cuando puerta está abierta)
 	if !pt.doorClosing {
 		pt.lastDetectedCount = data.DetectedPersons
+
+		pt.mu.Lock()
+		if data.DetectedPersons > pt.sessionMaxDetected {
+			pt.sessionMaxDetected = data.DetectedPersons
+		}
+		pt.mu.Unlock()
 	}
 	// Actualizar historial de tracks
 	for _, track := range data.Tracks {
@@ -158,10 +406,31 @@ func (pt *PassengerTracker) ProcessCameraData(data eventbus.CameraData) {
 		}
 	}
 
+	// Pasos en vivo de la estrategia (quedan pendientes de confirmación)
+	for _, decision := range pt.strategy.OnCameraFrame(data, currentTime) {
+		if decision.EventType == DecisionEntry {
+			pt.onEntryCrossing(decision)
+		} else {
+			pt.onExitCrossing(decision)
+		}
+	}
+	for _, shadow := range pt.shadows {
+		for _, decision := range shadow.strategy.OnCameraFrame(data, currentTime) {
+			shadow.add(decision)
+		}
+	}
+
+	pt.updateMissingFrames(data.Tracks)
+
 	// Limpiar tracks antiguos (no vistos en más de 10 segundos)
 	pt.cleanupOldTracks(currentTime)
 }
 
+// ProcessDoorData pasa las lecturas del VL53L0X a la etapa de fusión
+func (pt *PassengerTracker) ProcessDoorData(data eventbus.DoorData, timestamp time.Time) {
+	pt.fusion.ProcessDoorData(data, timestamp)
+}
+
 // CheckPendingConfirmations verifica entradas/salidas pendientes
 func (pt *PassengerTracker) CheckPendingConfirmations(currentTime time.Time, isStopped bool) {
 	// Solo confirmar si el vehículo está detenido
@@ -183,13 +452,13 @@ func (pt *PassengerTracker) checkPendingEntries(currentTime time.Time) {
 	for trackID, entry := range pt.pendingEntries {
 		timePending := currentTime.Sub(entry.Timestamp).Seconds()
 
-		if timePending >= pt.config.Timeouts.EntryMin {
-			// Confirmar entrada
-			pt.confirmEntry(trackID, entry)
-			entriesToConfirm = append(entriesToConfirm, trackID)
-		} else if timePending >= pt.config.Timeouts.EntryMax {
+		if timePending >= pt.config.Timeouts.EntryMax {
 			// Timeout - cancelar
-			fmt.Printf("⏰ [Passengers] ENTRADA CANCELADA por timeout - Track ID: %d\n", trackID)
+			fmt.Fprintf(pt.out, "⏰ [Passengers] ENTRADA CANCELADA por timeout - Track ID: %d\n", trackID)
+			entriesToConfirm = append(entriesToConfirm, trackID)
+		} else if timePending >= pt.config.Timeouts.EntryMin && pt.isTrackVisible(trackID, currentTime) {
+			// Confirmar entrada (la persona sigue a la vista dentro del vehículo)
+			pt.confirmEntry(trackID, entry)
 			entriesToConfirm = append(entriesToConfirm, trackID)
 		}
 	}
@@ -223,16 +492,24 @@ func (pt *PassengerTracker) checkPendingExits(currentTime time.Time) {
 
 // confirmEntry confirma una entrada
 func (pt *PassengerTracker) confirmEntry(trackID int, entry *PendingEntry) {
+	pt.fuseEntry(trackID, entry)
+
+	if pt.strategy.RequiresBeamConfirmation() && entry.SensorDistance == nil {
+		fmt.Fprintf(pt.out, "❌ [Passengers] ENTRADA DESCARTADA sin corte del haz - Track ID: %d\n", trackID)
+		return
+	}
+
 	event := pt.createPassengerEvent(trackID, "ENTRY", entry.Confidence, entry.SensorDistance)
 	pt.bus.Publish(eventbus.Event{
 		Type:      eventbus.EventPassenger,
-		Timestamp: time.Now(),
+		Timestamp: clock.Now(),
 		Data:      event,
 	})
 
 	pt.mu.Lock()
 	pt.passengerCountCurrent++
 	pt.dailyEntries++
+	pt.sessionEntries++
 	current := pt.passengerCountCurrent
 	pt.mu.Unlock()
 
@@ -241,39 +518,182 @@ func (pt *PassengerTracker) confirmEntry(trackID int, entry *PendingEntry) {
 		track.IsOnboard = true
 	}
 
-	fmt.Printf("✅ [Passengers] ENTRADA CONFIRMADA - Track ID: %d\n", trackID)
-	fmt.Printf("   🚌 A bordo: %d\n", current)
+	pt.onCountersChanged()
+
+	fmt.Fprintf(pt.out, "✅ [Passengers] ENTRADA CONFIRMADA - Track ID: %d\n", trackID)
+	fmt.Fprintf(pt.out, "   🚌 A bordo: %d\n", current)
 }
 
 // confirmExit confirma una salida
 func (pt *PassengerTracker) confirmExit(trackID int, exit *PendingExit) {
+	pt.fuseExit(trackID, exit)
+
+	if pt.strategy.RequiresBeamConfirmation() && exit.SensorDistance == nil {
+		fmt.Fprintf(pt.out, "❌ [Passengers] SALIDA DESCARTADA sin corte del haz - Track ID: %d\n", trackID)
+		return
+	}
+
 	event := pt.createPassengerEvent(trackID, "EXIT", exit.Confidence, exit.SensorDistance)
 	pt.bus.Publish(eventbus.Event{
 		Type:      eventbus.EventPassenger,
-		Timestamp: time.Now(),
+		Timestamp: clock.Now(),
 		Data:      event,
 	})
 
 	pt.mu.Lock()
 	pt.passengerCountCurrent--
-	if pt.passengerCountCurrent < 0 {
+	clamped := pt.passengerCountCurrent < 0
+	if clamped {
 		pt.passengerCountCurrent = 0
 	}
 	pt.dailyExits++
+	pt.sessionExits++
 	current := pt.passengerCountCurrent
 	pt.mu.Unlock()
 
-	fmt.Printf("✅ [Passengers] SALIDA CONFIRMADA - Track ID: %d\n", trackID)
-	fmt.Printf("   🚌 A bordo: %d\n", current)
+	if clamped {
+		pt.occupancy.ReportNegativeClamp()
+	}
+
+	pt.onCountersChanged()
+
+	fmt.Fprintf(pt.out, "✅ [Passengers] SALIDA CONFIRMADA - Track ID: %d (frames sin ver: %d)\n", trackID, exit.FramesMissing)
+	fmt.Fprintf(pt.out, "   🚌 A bordo: %d\n", current)
+}
+
+// onEntryCrossing registra un cruce hacia el interior
+func (pt *PassengerTracker) onEntryCrossing(decision CountDecision) {
+	// Regresó antes de confirmar la salida: no bajó
+	if _, exists := pt.pendingExits[decision.TrackID]; exists {
+		delete(pt.pendingExits, decision.TrackID)
+		fmt.Fprintf(pt.out, "↩️  [Passengers] SALIDA REVERTIDA - Track ID: %d\n", decision.TrackID)
+		return
+	}
+
+	pt.pendingEntries[decision.TrackID] = &PendingEntry{
+		TrackID:    decision.TrackID,
+		Timestamp:  decision.Timestamp,
+		Confidence: decision.Confidence,
+	}
+
+	fmt.Fprintf(pt.out, "⬆️  [Passengers] Cruce de ENTRADA - Track ID: %d (pendiente)\n", decision.TrackID)
+}
+
+// onExitCrossing registra un cruce hacia el exterior
+func (pt *PassengerTracker) onExitCrossing(decision CountDecision) {
+	// Regresó antes de confirmar la entrada: no subió
+	if _, exists := pt.pendingEntries[decision.TrackID]; exists {
+		delete(pt.pendingEntries, decision.TrackID)
+		fmt.Fprintf(pt.out, "↩️  [Passengers] ENTRADA REVERTIDA - Track ID: %d\n", decision.TrackID)
+		return
+	}
+
+	pt.pendingExits[decision.TrackID] = &PendingExit{
+		TrackID:    decision.TrackID,
+		Timestamp:  decision.Timestamp,
+		Confidence: decision.Confidence,
+	}
+
+	fmt.Fprintf(pt.out, "⬇️  [Passengers] Cruce de SALIDA - Track ID: %d (pendiente)\n", decision.TrackID)
+}
+
+// updateMissingFrames cuenta los frames en que no se ve a quien está saliendo
+func (pt *PassengerTracker) updateMissingFrames(tracks []eventbus.PersonTrack) {
+	seen := make(map[int]bool, len(tracks))
+	for _, track := range tracks {
+		seen[track.TrackID] = true
+	}
+
+	for trackID, exit := range pt.pendingExits {
+		if seen[trackID] {
+			exit.FramesMissing = 0
+		} else {
+			exit.FramesMissing++
+		}
+	}
+}
+
+// isTrackVisible retorna si un track se vio recientemente
+func (pt *PassengerTracker) isTrackVisible(trackID int, currentTime time.Time) bool {
+	track, exists := pt.trackHistory[trackID]
+	if !exists {
+		return false
+	}
+	return currentTime.Sub(track.LastSeen).Seconds() <= trackLostTimeout
+}
+
+// finishPendingCrossings confirma los cruces pendientes al cerrar la puerta
+func (pt *PassengerTracker) finishPendingCrossings() {
+	if len(pt.pendingEntries) == 0 && len(pt.pendingExits) == 0 {
+		return
+	}
+
+	fmt.Fprintf(pt.out, "   Entradas pendientes: %d\n", len(pt.pendingEntries))
+	fmt.Fprintf(pt.out, "   Salidas pendientes: %d\n", len(pt.pendingExits))
+
+	for trackID, entry := range pt.pendingEntries {
+		pt.confirmEntry(trackID, entry)
+	}
+	for trackID, exit := range pt.pendingExits {
+		pt.confirmExit(trackID, exit)
+		delete(pt.trackHistory, trackID)
+	}
+
+	pt.pendingEntries = make(map[int]*PendingEntry)
+	pt.pendingExits = make(map[int]*PendingExit)
+
+	pt.mu.RLock()
+	current := pt.passengerCountCurrent
+	pt.mu.RUnlock()
+
+	fmt.Fprintf(pt.out, "   🚌 A bordo: %d\n", current)
+}
+
+// fuseEntry correlaciona una entrada con un corte del haz del láser
+func (pt *PassengerTracker) fuseEntry(trackID int, entry *PendingEntry) {
+	if entry.SensorDistance != nil {
+		return
+	}
+
+	result := pt.fusion.Correlate(entry.Timestamp, entry.Confidence)
+	entry.Confidence = result.Confidence
+	entry.SensorDistance = result.SensorDistance
+	pt.recordCorrelation(trackID, result)
+}
+
+// fuseExit correlaciona una salida con un corte del haz del láser
+func (pt *PassengerTracker) fuseExit(trackID int, exit *PendingExit) {
+	if exit.SensorDistance != nil {
+		return
+	}
+
+	result := pt.fusion.Correlate(exit.Timestamp, exit.Confidence)
+	exit.Confidence = result.Confidence
+	exit.SensorDistance = result.SensorDistance
+	pt.recordCorrelation(trackID, result)
+}
+
+// recordCorrelation guarda en el historial del track el resultado de la fusión
+func (pt *PassengerTracker) recordCorrelation(trackID int, result FusionResult) {
+	if result.SensorDistance == nil {
+		return
+	}
+
+	if track, exists := pt.trackHistory[trackID]; exists {
+		track.CorrelationTime = result.Offset
+	}
+	fmt.Fprintf(pt.out, "📏 [Fusion] Track ID: %d confirmado por láser (%dmm, %+.1fs)\n",
+		trackID, *result.SensorDistance, result.Offset)
 }
 
 // processBulkEntries procesa múltiples entradas
 func (pt *PassengerTracker) processBulkEntries(count int) {
-	currentTime := time.Now()
+	currentTime := clock.Now()
 
 	for i := 0; i < count; i++ {
 		trackID := int(currentTime.UnixNano()) + i
-		event := pt.createPassengerEvent(trackID, "ENTRY", 0.85, nil)
+		fused := pt.fusion.Correlate(currentTime, 0.85)
+		event := pt.createPassengerEvent(trackID, "ENTRY", fused.Confidence, fused.SensorDistance)
 
 		pt.bus.Publish(eventbus.Event{
 			Type:      eventbus.EventPassenger,
@@ -284,25 +704,29 @@ func (pt *PassengerTracker) processBulkEntries(count int) {
 		pt.mu.Lock()
 		pt.passengerCountCurrent++
 		pt.dailyEntries++
+		pt.sessionEntries++
 		pt.mu.Unlock()
 
-		fmt.Printf("✅ [Passengers] ENTRADA #%d confirmada (ID: %d)\n", i+1, trackID)
+		fmt.Fprintf(pt.out, "✅ [Passengers] ENTRADA #%d confirmada (ID: %d)\n", i+1, trackID)
 	}
 
+	pt.onCountersChanged()
+
 	pt.mu.RLock()
 	current := pt.passengerCountCurrent
 	pt.mu.RUnlock()
 
-	fmt.Printf("   🚌 A bordo: %d\n", current)
+	fmt.Fprintf(pt.out, "   🚌 A bordo: %d\n", current)
 }
 
 // processBulkExits procesa múltiples salidas
 func (pt *PassengerTracker) processBulkExits(count int) {
-	currentTime := time.Now()
+	currentTime := clock.Now()
 
 	for i := 0; i < count; i++ {
 		trackID := int(currentTime.UnixNano()) + i
-		event := pt.createPassengerEvent(trackID, "EXIT", 0.85, nil)
+		fused := pt.fusion.Correlate(currentTime, 0.85)
+		event := pt.createPassengerEvent(trackID, "EXIT", fused.Confidence, fused.SensorDistance)
 
 		pt.bus.Publish(eventbus.Event{
 			Type:      eventbus.EventPassenger,
@@ -312,21 +736,29 @@ func (pt *PassengerTracker) processBulkExits(count int) {
 
 		pt.mu.Lock()
 		pt.passengerCountCurrent--
-		if pt.passengerCountCurrent < 0 {
+		clamped := pt.passengerCountCurrent < 0
+		if clamped {
 			pt.passengerCountCurrent = 0
 		}
 		pt.dailyExits++
+		pt.sessionExits++
 		pt.mu.Unlock()
 
-		fmt.Printf("✅ [Passengers] SALIDA #%d confirmada (ID: %d)\n", i+1, trackID)
+		if clamped {
+			pt.occupancy.ReportNegativeClamp()
+		}
+
+		fmt.Fprintf(pt.out, "✅ [Passengers] SALIDA #%d confirmada (ID: %d)\n", i+1, trackID)
 	}
 
+	pt.onCountersChanged()
+
 	// ← NUEVO: Leer con lock
 	pt.mu.RLock()
 	current := pt.passengerCountCurrent
 	pt.mu.RUnlock()
 
-	fmt.Printf("   🚌 A bordo: %d\n", current)
+	fmt.Fprintf(pt.out, "   🚌 A bordo: %d\n", current)
 }
 
 // createPassengerEvent crea un evento de pasajero
@@ -353,8 +785,13 @@ func (pt *PassengerTracker) createPassengerEvent(trackID int, eventType string,
 		CurrentCount:     currentCount,
 		TotalEntries:     totalEntries,
 		TotalExits:       totalExits,
+		StopID:           pt.session.StopID,
+		StopName:         pt.session.StopName,
+		Latitude:         pt.session.Latitude,
+		Longitude:        pt.session.Longitude,
+		DwellSessionID:   pt.session.ID,
 		DeviceID:         pt.config.DeviceID,
-		Timestamp:        time.Now(),
+		Timestamp:        clock.Now(),
 	}
 }
 
@@ -376,7 +813,7 @@ func (pt *PassengerTracker) cleanupOldTracks(currentTime time.Time) {
 // GetCurrentDetectedCount retorna el conteo actual detectado por YOLO
 func (pt *PassengerTracker) GetCurrentDetectedCount() int {
 	count := 0
-	currentTime := time.Now()
+	currentTime := clock.Now()
 
 	for _, track := range pt.trackHistory {
 		// Contar tracks vistos recientemente (últimos 2 segundos)
@@ -396,8 +833,39 @@ func (pt *PassengerTracker) GetStats() (current, entries, exits int) {
 	return pt.passengerCountCurrent, pt.dailyEntries, pt.dailyExits
 }
 
+// GetOccupancy retorna la ocupación actual del vehículo
+func (pt *PassengerTracker) GetOccupancy() OccupancyInfo {
+	return pt.occupancy.GetOccupancy()
+}
+
+// GetSessionStats retorna las entradas, salidas y el máximo de personas
+// detectadas en la sesión de parada actual
+func (pt *PassengerTracker) GetSessionStats() (entries, exits, maxDetected int) {
+	pt.mu.RLock()
+	defer pt.mu.RUnlock()
+
+	return pt.sessionEntries, pt.sessionExits, pt.sessionMaxDetected
+}
+
+// StrategyName retorna el nombre de la estrategia de conteo activa
+func (pt *PassengerTracker) StrategyName() string {
+	return pt.strategy.Name()
+}
+
+// GetShadowCounts retorna los conteos de las estrategias en sombra en la última parada
+func (pt *PassengerTracker) GetShadowCounts() []eventbus.StrategyCount {
+	pt.mu.RLock()
+	defer pt.mu.RUnlock()
+
+	return append([]eventbus.StrategyCount(nil), pt.shadowCounts...)
+}
+
 // OnDoorClosing se llama cuando la puerta EMPIEZA a cerrarse (antes de confirmación)
 func (pt *PassengerTracker) OnDoorClosing() {
 	pt.doorClosing = true
-	fmt.Printf("🚪 [Passengers] Puerta cerrándose - último conteo: %d personas\n", pt.lastDetectedCount)
+	pt.strategy.OnDoorClosing()
+	for _, shadow := range pt.shadows {
+		shadow.strategy.OnDoorClosing()
+	}
+	fmt.Fprintf(pt.out, "🚪 [Passengers] Puerta cerrándose - último conteo: %d personas\n", pt.lastDetectedCount)
 }
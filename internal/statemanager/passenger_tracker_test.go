@@ -0,0 +1,305 @@
+package statemanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/config"
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+)
+
+// testConfig retorna la configuración por defecto sin persistir contadores
+func testConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Storage.Enabled = false
+	return cfg
+}
+
+// newLineTracker crea un tracker en modo línea (Y=380, banda de 15 px)
+func newLineTracker(entryDirection string) *PassengerTracker {
+	cfg := testConfig()
+	cfg.Counting.Mode = config.CountingModeLine
+	cfg.Counting.EntryDirection = entryDirection
+	return NewPassengerTracker(eventbus.NewEventBus(), *cfg)
+}
+
+// frameWithTrack arma un frame con un único track centrado en centroidY
+func frameWithTrack(trackID int, centroidY float64) eventbus.CameraData {
+	return eventbus.CameraData{
+		DetectedPersons: 1,
+		Tracks: []eventbus.PersonTrack{{
+			TrackID:     trackID,
+			Confidence:  0.9,
+			BoundingBox: eventbus.Box{X1: 250, Y1: centroidY - 100, X2: 350, Y2: centroidY + 100},
+		}},
+	}
+}
+
+func TestNewPassengerTrackerStrategy(t *testing.T) {
+	tests := []struct {
+		name          string
+		mode          string
+		fusionEnabled bool
+		shadow        []string
+		wantStrategy  string
+		wantShadows   int
+	}{
+		{"por defecto", "", true, nil, config.CountingModeDelta, 0},
+		{"modo inválido", "magic", true, nil, config.CountingModeDelta, 0},
+		{"fusión habilitada", config.CountingModeFusion, true, nil, config.CountingModeFusion, 0},
+		{"fusión sin láser", config.CountingModeFusion, false, nil, config.CountingModeLine, 0},
+		{"sombras válidas", config.CountingModeLine, true, []string{"delta", "max_occupancy"}, config.CountingModeLine, 2},
+		{"sombra repetida o inválida", config.CountingModeLine, true, []string{"line", "magic"}, config.CountingModeLine, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Counting.Mode = tt.mode
+			cfg.Counting.Fusion.Enabled = tt.fusionEnabled
+			cfg.Counting.Shadow = tt.shadow
+
+			tracker := NewPassengerTracker(eventbus.NewEventBus(), *cfg)
+			if tracker.StrategyName() != tt.wantStrategy || len(tracker.shadows) != tt.wantShadows {
+				t.Errorf("estrategia=%s sombras=%d, want %s y %d",
+					tracker.StrategyName(), len(tracker.shadows), tt.wantStrategy, tt.wantShadows)
+			}
+		})
+	}
+}
+
+func TestLineCrossing(t *testing.T) {
+	tests := []struct {
+		name           string
+		entryDirection string
+		onboard        int
+		path           []float64 // Centroides Y sucesivos del track
+		wantCurrent    int
+		wantEntries    int
+		wantExits      int
+	}{
+		{"sube cruzando hacia arriba", "up", 0, []float64{460, 420, 300}, 1, 1, 0},
+		{"baja cruzando hacia abajo", "up", 1, []float64{250, 300, 460}, 0, 0, 1},
+		{"entrada hacia abajo", "down", 0, []float64{250, 460}, 1, 1, 0},
+		{"se queda en la banda muerta", "up", 0, []float64{460, 385, 370, 390}, 0, 0, 0},
+		{"sube y se vuelve", "up", 0, []float64{460, 300, 460}, 0, 0, 0},
+		{"baja y se vuelve", "up", 1, []float64{250, 460, 250}, 1, 0, 0},
+		{"aparece del lado de adentro", "up", 0, []float64{250, 200}, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newLineTracker(tt.entryDirection)
+			tracker.passengerCountCurrent = tt.onboard
+			tracker.OnDoorOpened(DwellSession{})
+
+			for _, y := range tt.path {
+				tracker.ProcessCameraData(frameWithTrack(1, y))
+			}
+			tracker.OnDoorClosing()
+			tracker.OnDoorClosed()
+
+			current, entries, exits := tracker.GetStats()
+			if current != tt.wantCurrent || entries != tt.wantEntries || exits != tt.wantExits {
+				t.Errorf("a bordo=%d entradas=%d salidas=%d, want %d/%d/%d",
+					current, entries, exits, tt.wantCurrent, tt.wantEntries, tt.wantExits)
+			}
+			if len(tracker.pendingEntries) != 0 || len(tracker.pendingExits) != 0 {
+				t.Errorf("quedaron pendientes tras cerrar: %d entradas, %d salidas", len(tracker.pendingEntries), len(tracker.pendingExits))
+			}
+		})
+	}
+}
+
+func TestLineEntryConfirmationNeedsVisibleTrack(t *testing.T) {
+	tracker := newLineTracker("up")
+	tracker.OnDoorOpened(DwellSession{})
+	tracker.ProcessCameraData(frameWithTrack(1, 460))
+	tracker.ProcessCameraData(frameWithTrack(1, 300))
+
+	entry := tracker.pendingEntries[1]
+	if entry == nil {
+		t.Fatal("el cruce no dejó una entrada pendiente")
+	}
+	minWait := time.Duration(tracker.config.Timeouts.EntryMin * float64(time.Second))
+
+	// Pasado el mínimo pero sin ver al track hace rato: sigue pendiente
+	tracker.trackHistory[1].LastSeen = entry.Timestamp.Add(-time.Minute)
+	tracker.CheckPendingConfirmations(entry.Timestamp.Add(minWait), true)
+	if current, _, _ := tracker.GetStats(); current != 0 || tracker.pendingEntries[1] == nil {
+		t.Fatalf("entrada confirmada sin ver al pasajero (a bordo=%d)", current)
+	}
+
+	// Visible dentro del vehículo: se confirma
+	tracker.trackHistory[1].LastSeen = entry.Timestamp.Add(minWait)
+	tracker.CheckPendingConfirmations(entry.Timestamp.Add(minWait), true)
+	if current, _, _ := tracker.GetStats(); current != 1 || len(tracker.pendingEntries) != 0 {
+		t.Errorf("a bordo=%d pendientes=%d, want 1 y 0", current, len(tracker.pendingEntries))
+	}
+}
+
+func TestLineExitCountsMissingFrames(t *testing.T) {
+	tracker := newLineTracker("up")
+	tracker.passengerCountCurrent = 1
+	tracker.OnDoorOpened(DwellSession{})
+	tracker.ProcessCameraData(frameWithTrack(1, 250))
+	tracker.ProcessCameraData(frameWithTrack(1, 460))
+
+	for i := 0; i < 3; i++ {
+		tracker.ProcessCameraData(eventbus.CameraData{})
+	}
+	if exit := tracker.pendingExits[1]; exit == nil || exit.FramesMissing != 3 {
+		t.Fatalf("salida pendiente = %+v, want 3 frames sin ver", exit)
+	}
+
+	tracker.ProcessCameraData(frameWithTrack(1, 470))
+	if exit := tracker.pendingExits[1]; exit.FramesMissing != 0 {
+		t.Errorf("frames sin ver = %d tras volver a verlo, want 0", exit.FramesMissing)
+	}
+}
+
+// newStoredTracker crea un tracker que persiste en directory (corte a las 03:00 UTC)
+func newStoredTracker(directory string) (*PassengerTracker, *eventbus.EventBus) {
+	cfg := config.Default()
+	cfg.DeviceID = "COMBI-01"
+	cfg.Storage = config.StorageConfig{
+		Enabled:         true,
+		Directory:       directory,
+		ServiceDayStart: "03:00",
+		Timezone:        "UTC",
+	}
+
+	bus := eventbus.NewEventBus()
+	return NewPassengerTracker(bus, *cfg), bus
+}
+
+func TestRestoreCounters(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		serviceDate string
+		wantEntries int
+		wantSummary bool
+	}{
+		{"mismo día de servicio", "", 12, false},
+		{"día ya cerrado", "2000-01-01", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directory := t.TempDir()
+			seed, _ := newStoredTracker(directory)
+			serviceDate := tt.serviceDate
+			if serviceDate == "" {
+				serviceDate = seed.store.ServiceDate(now)
+			}
+			if err := seed.store.Save(CounterSnapshot{DeviceID: "COMBI-01", ServiceDate: serviceDate, PassengerCount: 5, DailyEntries: 12, DailyExits: 7}); err != nil {
+				t.Fatal(err)
+			}
+
+			// El cierre ocurre dentro del constructor: se verifica con lo persistido
+			tracker, _ := newStoredTracker(directory)
+			current, entries, _ := tracker.GetStats()
+			if current != 5 || entries != tt.wantEntries {
+				t.Errorf("a bordo=%d entradas=%d, want 5 y %d", current, entries, tt.wantEntries)
+			}
+
+			snapshot, _, _ := tracker.store.Load()
+			rolledOver := snapshot.ServiceDate != serviceDate
+			if rolledOver != tt.wantSummary {
+				t.Errorf("día guardado = %s, want cierre del día %v", snapshot.ServiceDate, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestStateManagerResetClearsCounters(t *testing.T) {
+	directory := t.TempDir()
+	seed, _ := newStoredTracker(directory)
+	serviceDate := seed.store.ServiceDate(time.Now())
+	if err := seed.store.Save(CounterSnapshot{DeviceID: "COMBI-01", ServiceDate: serviceDate, PassengerCount: 5, DailyEntries: 12, DailyExits: 7}); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewStateManager(eventbus.NewEventBus(), seed.config)
+	if current, entries, exits := manager.passengerTracker.GetStats(); current != 5 || entries != 12 || exits != 7 {
+		t.Fatalf("al crear: a bordo=%d entradas=%d salidas=%d, want 5/12/7", current, entries, exits)
+	}
+
+	manager.Reset()
+	if current, entries, exits := manager.passengerTracker.GetStats(); current != 0 || entries != 0 || exits != 0 {
+		t.Errorf("tras Reset: a bordo=%d entradas=%d salidas=%d, want ceros", current, entries, exits)
+	}
+
+	// Los ceros quedan guardados: el próximo arranque no restaura lo anterior
+	restarted, _ := newStoredTracker(directory)
+	if current, entries, exits := restarted.GetStats(); current != 0 || entries != 0 || exits != 0 {
+		t.Errorf("tras reiniciar: a bordo=%d entradas=%d salidas=%d, want ceros", current, entries, exits)
+	}
+}
+
+func TestCheckServiceDayRollover(t *testing.T) {
+	tracker, bus := newStoredTracker(t.TempDir())
+	dailyChannel := bus.Subscribe(eventbus.EventDaily)
+
+	tracker.passengerCountCurrent = 4
+	tracker.dailyEntries = 30
+	tracker.dailyExits = 26
+	tracker.serviceDate = "2025-03-09"
+
+	// Mismo día de servicio (antes de las 03:00): no cierra
+	tracker.CheckServiceDay(time.Date(2025, 3, 10, 2, 30, 0, 0, time.UTC))
+	select {
+	case event := <-dailyChannel:
+		t.Fatalf("cierre antes del corte: %+v", event.Data)
+	default:
+	}
+
+	tracker.CheckServiceDay(time.Date(2025, 3, 10, 3, 0, 0, 0, time.UTC))
+
+	select {
+	case event := <-dailyChannel:
+		summary := event.Data.(eventbus.DailySummaryData)
+		if summary.ServiceDate != "2025-03-09" || summary.TotalEntries != 30 || summary.TotalExits != 26 || summary.OnboardAtClose != 4 {
+			t.Errorf("resumen = %+v", summary)
+		}
+	default:
+		t.Fatal("no se publicó el resumen diario")
+	}
+
+	current, entries, exits := tracker.GetStats()
+	if current != 4 || entries != 0 || exits != 0 || tracker.serviceDate != "2025-03-10" {
+		t.Errorf("tras el corte: a bordo=%d entradas=%d salidas=%d día=%s", current, entries, exits, tracker.serviceDate)
+	}
+
+	snapshot, _, _ := tracker.store.Load()
+	if snapshot.ServiceDate != "2025-03-10" || snapshot.PassengerCount != 4 || snapshot.DailyEntries != 0 {
+		t.Errorf("persistido = %+v", snapshot)
+	}
+}
+
+func TestShadowCounts(t *testing.T) {
+	cfg := testConfig()
+	cfg.Counting.Mode = config.CountingModeLine
+	cfg.Counting.Shadow = []string{config.CountingModeDelta}
+	tracker := NewPassengerTracker(eventbus.NewEventBus(), *cfg)
+
+	tracker.OnDoorOpened(DwellSession{ID: "s1"})
+	for _, y := range []float64{460, 420, 300} {
+		tracker.ProcessCameraData(frameWithTrack(1, y))
+	}
+	tracker.OnDoorClosing()
+	tracker.OnDoorClosed()
+
+	// La estrategia en sombra no modifica los contadores
+	if current, entries, _ := tracker.GetStats(); current != 1 || entries != 1 {
+		t.Errorf("a bordo=%d entradas=%d, want 1 y 1", current, entries)
+	}
+
+	// Delta: 0 personas al abrir, 1 al cerrar
+	counts := tracker.GetShadowCounts()
+	if len(counts) != 1 || counts[0].Strategy != config.CountingModeDelta || counts[0].Entries != 1 || counts[0].Exits != 0 {
+		t.Errorf("sombras = %+v, want delta con 1 entrada", counts)
+	}
+}
@@ -0,0 +1,191 @@
+package statemanager
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/config"
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+)
+
+// maxValidDistanceMM lecturas por encima se consideran inválidas (fuera de rango)
+const maxValidDistanceMM = 2000
+
+// BeamBreak es un corte del haz del VL53L0X con la puerta abierta
+type BeamBreak struct {
+	Start         time.Time
+	End           time.Time
+	MinDistanceMM int  // Distancia mínima medida durante el corte
+	Matched       bool // Ya se asoció a un pasajero
+}
+
+// Midpoint retorna el instante central del corte
+func (bb *BeamBreak) Midpoint() time.Time {
+	return bb.Start.Add(bb.End.Sub(bb.Start) / 2)
+}
+
+// FusionResult resultado de correlacionar un paso con el láser
+type FusionResult struct {
+	SensorDistance *int    // Distancia del corte asociado (nil si no hubo)
+	Confidence     float64 // Confianza ajustada
+	Offset         float64 // Segundos entre el paso y el corte
+}
+
+// SensorFusion correlaciona cortes del haz del láser con los pasos detectados por la cámara
+type SensorFusion struct {
+	out    io.Writer // Destino de los logs (os.Stdout por defecto)
+	config config.FusionConfig
+	window time.Duration
+
+	openBaseline    int        // Distancia máxima vista con la puerta abierta
+	current         *BeamBreak // Corte en curso
+	breaks          []*BeamBreak
+	lastOpenReading time.Time // Última lectura válida con la puerta abierta
+}
+
+// NewSensorFusion crea una nueva etapa de fusión láser + cámara
+func NewSensorFusion(cfg config.FusionConfig) *SensorFusion {
+	windowSeconds := cfg.CorrelationWindow
+	if windowSeconds <= 0 {
+		windowSeconds = eventbus.SensorCorrelationWindow
+	}
+
+	return &SensorFusion{
+		out:    os.Stdout,
+		config: cfg,
+		window: time.Duration(windowSeconds * float64(time.Second)),
+		breaks: make([]*BeamBreak, 0),
+	}
+}
+
+// SetOutput redirige los logs de la fusión de sensores (io.Discard los silencia)
+func (sf *SensorFusion) SetOutput(w io.Writer) {
+	sf.out = w
+}
+
+// ProcessDoorData detecta cortes del haz (caídas de distancia con la puerta abierta)
+func (sf *SensorFusion) ProcessDoorData(data eventbus.DoorData, timestamp time.Time) {
+	if !sf.config.Enabled {
+		return
+	}
+
+	sf.pruneBreaks(timestamp)
+
+	if !data.IsOpen {
+		// La puerta cerrando también baja la distancia: no es un corte
+		sf.current = nil
+		sf.openBaseline = 0
+		return
+	}
+
+	if data.DistanceMM > maxValidDistanceMM {
+		return // Lectura fuera de rango
+	}
+	sf.lastOpenReading = timestamp
+
+	if data.DistanceMM > sf.openBaseline {
+		sf.openBaseline = data.DistanceMM
+	}
+
+	isDip := data.DistanceMM < sf.openBaseline-sf.config.DipMarginMM
+
+	if isDip {
+		if sf.current == nil {
+			sf.current = &BeamBreak{
+				Start:         timestamp,
+				End:           timestamp,
+				MinDistanceMM: data.DistanceMM,
+			}
+		}
+		sf.current.End = timestamp
+		if data.DistanceMM < sf.current.MinDistanceMM {
+			sf.current.MinDistanceMM = data.DistanceMM
+		}
+		return
+	}
+
+	if sf.current != nil {
+		sf.breaks = append(sf.breaks, sf.current)
+		fmt.Fprintf(sf.out, "📏 [Fusion] Corte del haz: %dmm (%.1fs)\n",
+			sf.current.MinDistanceMM, sf.current.End.Sub(sf.current.Start).Seconds())
+		sf.current = nil
+	}
+}
+
+// Correlate asocia un paso (entrada/salida) con el corte del haz más cercano
+// dentro de la ventana de correlación y ajusta la confianza
+func (sf *SensorFusion) Correlate(at time.Time, confidence float64) FusionResult {
+	result := FusionResult{Confidence: confidence}
+
+	if !sf.config.Enabled {
+		return result
+	}
+
+	var best *BeamBreak
+	bestOffset := 0.0
+	for _, bb := range sf.breaks {
+		if bb.Matched {
+			continue
+		}
+		offset := bb.Midpoint().Sub(at)
+		if offset < -sf.window || offset > sf.window {
+			continue
+		}
+		if best == nil || math.Abs(offset.Seconds()) < math.Abs(bestOffset) {
+			best = bb
+			bestOffset = offset.Seconds()
+		}
+	}
+
+	if best != nil {
+		best.Matched = true
+		distance := best.MinDistanceMM
+		result.SensorDistance = &distance
+		result.Offset = bestOffset
+		result.Confidence = clampUnit(confidence + sf.config.ConfidenceBoost)
+		return result
+	}
+
+	// Sin lecturas válidas del láser (falla del sensor): no se penaliza
+	if sf.lastOpenReading.IsZero() || at.Sub(sf.lastOpenReading) > sf.window {
+		return result
+	}
+
+	result.Confidence = clampUnit(confidence - sf.config.ConfidencePenalty)
+	return result
+}
+
+// HasBreakNear retorna si hubo un corte del haz dentro de la ventana de
+// correlación de un instante, sin asociarlo a ningún paso
+func (sf *SensorFusion) HasBreakNear(at time.Time) bool {
+	if !sf.config.Enabled {
+		return false
+	}
+
+	for _, bb := range sf.breaks {
+		offset := bb.Midpoint().Sub(at)
+		if offset >= -sf.window && offset <= sf.window {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneBreaks descarta cortes fuera de cualquier ventana posible
+func (sf *SensorFusion) pruneBreaks(now time.Time) {
+	remaining := sf.breaks[:0]
+	for _, bb := range sf.breaks {
+		if now.Sub(bb.End) <= 2*sf.window {
+			remaining = append(remaining, bb)
+		}
+	}
+	sf.breaks = remaining
+}
+
+// clampUnit limita un valor al rango [0, 1]
+func clampUnit(value float64) float64 {
+	return math.Max(0, math.Min(1, value))
+}
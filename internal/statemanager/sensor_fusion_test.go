@@ -0,0 +1,154 @@
+package statemanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/config"
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+)
+
+// newTestFusion crea una fusión con ventana de 10s, margen de 40mm, +0.1 y -0.15
+func newTestFusion() *SensorFusion {
+	return NewSensorFusion(config.FusionConfig{
+		Enabled:           true,
+		CorrelationWindow: 10,
+		DipMarginMM:       40,
+		ConfidenceBoost:   0.1,
+		ConfidencePenalty: 0.15,
+	})
+}
+
+// feedDoor pasa lecturas de la puerta abierta cada 100ms desde start
+func feedDoor(sf *SensorFusion, start time.Time, distances ...int) time.Time {
+	at := start
+	for _, distance := range distances {
+		sf.ProcessDoorData(eventbus.DoorData{DistanceMM: distance, IsOpen: true}, at)
+		at = at.Add(100 * time.Millisecond)
+	}
+	return at
+}
+
+func TestSensorFusionBeamBreaks(t *testing.T) {
+	start := time.Now()
+
+	t.Run("caída y recuperación", func(t *testing.T) {
+		sf := newTestFusion()
+		feedDoor(sf, start, 800, 805, 340, 320, 350, 800)
+
+		if len(sf.breaks) != 1 {
+			t.Fatalf("cortes = %d, want 1", len(sf.breaks))
+		}
+		bb := sf.breaks[0]
+		if bb.MinDistanceMM != 320 || !bb.Start.Equal(start.Add(200*time.Millisecond)) || !bb.End.Equal(start.Add(400*time.Millisecond)) {
+			t.Errorf("corte = %+v, want 320mm de 200ms a 400ms", bb)
+		}
+	})
+
+	t.Run("ruido dentro del margen", func(t *testing.T) {
+		sf := newTestFusion()
+		feedDoor(sf, start, 800, 770, 790, 800)
+		if len(sf.breaks) != 0 || sf.current != nil {
+			t.Errorf("cortes = %d, en curso = %v, want ninguno", len(sf.breaks), sf.current)
+		}
+	})
+
+	t.Run("la puerta cerrando no es un corte", func(t *testing.T) {
+		sf := newTestFusion()
+		at := feedDoor(sf, start, 800, 400)
+		sf.ProcessDoorData(eventbus.DoorData{DistanceMM: 150, IsOpen: false}, at)
+		feedDoor(sf, at.Add(time.Second), 800)
+
+		if len(sf.breaks) != 0 {
+			t.Errorf("cortes = %d, want 0", len(sf.breaks))
+		}
+	})
+
+	t.Run("lecturas fuera de rango se ignoran", func(t *testing.T) {
+		sf := newTestFusion()
+		feedDoor(sf, start, 800, 8190, 800)
+		if len(sf.breaks) != 0 || sf.openBaseline != 800 {
+			t.Errorf("cortes = %d, base = %d, want 0 y 800", len(sf.breaks), sf.openBaseline)
+		}
+	})
+
+	t.Run("deshabilitada", func(t *testing.T) {
+		sf := NewSensorFusion(config.FusionConfig{DipMarginMM: 40})
+		feedDoor(sf, start, 800, 300, 800)
+		if len(sf.breaks) != 0 {
+			t.Errorf("cortes = %d, want 0", len(sf.breaks))
+		}
+	})
+}
+
+func TestSensorFusionCorrelate(t *testing.T) {
+	start := time.Now()
+
+	tests := []struct {
+		name           string
+		readings       []int         // Lecturas desde start cada 100ms
+		offset         time.Duration // Instante del paso respecto de start
+		wantConfidence float64
+		wantDistance   int // 0 = sin distancia del láser
+	}{
+		{"confirmado por el láser", []int{800, 330, 800}, 2 * time.Second, 0.8, 330},
+		{"corte fuera de la ventana", []int{800, 330, 800}, 15 * time.Second, 0.55, 0},
+		{"sin corte con el láser funcionando", []int{800, 800, 800}, time.Second, 0.55, 0},
+		{"sin lecturas del láser", nil, time.Second, 0.7, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sf := newTestFusion()
+			feedDoor(sf, start, tt.readings...)
+			// Lecturas recientes: el láser sigue vivo en el momento del paso
+			if tt.readings != nil {
+				feedDoor(sf, start.Add(tt.offset), 800)
+			}
+
+			result := sf.Correlate(start.Add(tt.offset), 0.7)
+			if diff := result.Confidence - tt.wantConfidence; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("confianza = %v, want %v", result.Confidence, tt.wantConfidence)
+			}
+
+			distance := 0
+			if result.SensorDistance != nil {
+				distance = *result.SensorDistance
+			}
+			if distance != tt.wantDistance {
+				t.Errorf("distancia = %d, want %d", distance, tt.wantDistance)
+			}
+		})
+	}
+}
+
+func TestSensorFusionMatchesEachBreakOnce(t *testing.T) {
+	start := time.Now()
+	sf := newTestFusion()
+	feedDoor(sf, start, 800, 330, 800)
+	feedDoor(sf, start.Add(3*time.Second), 800, 310, 800)
+
+	// El paso a los 3s se asocia al corte más cercano (310mm)
+	first := sf.Correlate(start.Add(3*time.Second), 0.7)
+	if first.SensorDistance == nil || *first.SensorDistance != 310 {
+		t.Fatalf("primer paso = %+v, want el corte de 310mm", first)
+	}
+
+	second := sf.Correlate(start.Add(3*time.Second), 0.7)
+	if second.SensorDistance == nil || *second.SensorDistance != 330 {
+		t.Fatalf("segundo paso = %+v, want el corte de 330mm", second)
+	}
+
+	if third := sf.Correlate(start.Add(3*time.Second), 0.7); third.SensorDistance != nil {
+		t.Errorf("tercer paso asociado a un corte ya usado: %dmm", *third.SensorDistance)
+	}
+}
+
+func TestClampUnit(t *testing.T) {
+	tests := []struct{ in, want float64 }{{-0.2, 0}, {0.5, 0.5}, {1.3, 1}}
+	for _, tt := range tests {
+		if got := clampUnit(tt.in); got != tt.want {
+			t.Errorf("clampUnit(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
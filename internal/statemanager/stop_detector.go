@@ -0,0 +1,262 @@
+package statemanager
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/config"
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+	"github.com/MarcosBrindi/transporte-simulator/internal/scenario"
+)
+
+// stopVisit paso del vehículo por la geocerca de una parada
+type stopVisit struct {
+	stop        scenario.Stop
+	arrived     bool      // Se detuvo o abrió la puerta dentro de la geocerca
+	arrivedAt   time.Time // Momento de la llegada
+	movingSince time.Time // Cuándo volvió a moverse tras la llegada (cero si sigue detenido)
+	doorOpened  bool
+}
+
+// TripSource provee el viaje en curso (para comparar llegadas con el horario)
+type TripSource interface {
+	CurrentTrip() (scenario.Trip, bool)
+}
+
+// StopDetector detecta llegadas, salidas y paradas omitidas combinando la
+// posición en la ruta (geocerca por parada), la velocidad y la puerta
+type StopDetector struct {
+	bus           *eventbus.EventBus
+	out           io.Writer // Destino de los logs (os.Stdout por defecto)
+	deviceID      string
+	defaultRadius float64 // Radio de geocerca cuando la parada no define uno (m)
+	route         *scenario.Route
+	trips         TripSource // Opcional: sin él no se calcula la desviación de horario
+
+	visit               *stopVisit // Parada en cuya geocerca está el vehículo
+	unscheduledReported bool       // Ya se reportó la parada no programada actual
+}
+
+// NewStopDetector crea un detector de paradas
+func NewStopDetector(bus *eventbus.EventBus, cfg config.Config) *StopDetector {
+	return &StopDetector{
+		bus:           bus,
+		out:           os.Stdout,
+		deviceID:      cfg.DeviceID,
+		defaultRadius: cfg.Stops.GeofenceRadiusM,
+	}
+}
+
+// SetOutput redirige los logs del detector de paradas (io.Discard los silencia)
+func (sd *StopDetector) SetOutput(w io.Writer) {
+	sd.out = w
+}
+
+// SetRoute asigna la ruta con las paradas a detectar
+func (sd *StopDetector) SetRoute(route *scenario.Route) {
+	sd.route = route
+	sd.visit = nil
+}
+
+// SetTripSource asigna la fuente del viaje en curso
+func (sd *StopDetector) SetTripSource(trips TripSource) {
+	sd.trips = trips
+}
+
+// Update evalúa la posición y el estado del vehículo
+func (sd *StopDetector) Update(gpsData eventbus.GPSData, vehicleState eventbus.VehicleStateData, currentTime time.Time) {
+	if sd.route == nil {
+		return
+	}
+
+	stop := sd.stopInGeofence(gpsData.Progress)
+
+	// Salió de la geocerca (o entró directamente a la de otra parada)
+	if sd.visit != nil && (stop == nil || stop.ID != sd.visit.stop.ID) {
+		sd.leaveStop(currentTime)
+	}
+
+	if stop == nil {
+		sd.checkUnscheduledStop(gpsData, vehicleState, currentTime)
+		return
+	}
+
+	if sd.visit == nil {
+		sd.visit = &stopVisit{stop: *stop}
+	}
+	sd.unscheduledReported = false
+
+	visit := sd.visit
+	stopped := vehicleState.IsStopped || vehicleState.DoorOpen
+
+	if vehicleState.DoorOpen {
+		visit.doorOpened = true
+	}
+
+	if !visit.arrived {
+		if stopped {
+			visit.arrived = true
+			visit.arrivedAt = currentTime
+			data := sd.publish(eventbus.StopArrived, visit, currentTime)
+			fmt.Fprintf(sd.out, "🚏 [Stops] LLEGADA a %s%s\n", visit.stop.Name, formatDeviation(data))
+		}
+		return
+	}
+
+	// Momento en que arrancó (se descarta si se vuelve a detener)
+	if stopped {
+		visit.movingSince = time.Time{}
+	} else if visit.movingSince.IsZero() {
+		visit.movingSince = currentTime
+	}
+}
+
+// leaveStop cierra la visita a la parada al salir de su geocerca
+func (sd *StopDetector) leaveStop(currentTime time.Time) {
+	visit := sd.visit
+	sd.visit = nil
+
+	if !visit.arrived {
+		sd.publish(eventbus.StopSkipped, visit, currentTime)
+		fmt.Fprintf(sd.out, "⏭️  [Stops] PARADA OMITIDA: %s\n", visit.stop.Name)
+		return
+	}
+
+	sd.publish(eventbus.StopDeparted, visit, currentTime)
+	fmt.Fprintf(sd.out, "🚏 [Stops] SALIDA de %s (%.0fs detenido)\n",
+		visit.stop.Name, sd.departureTime(visit, currentTime).Sub(visit.arrivedAt).Seconds())
+}
+
+// checkUnscheduledStop reporta una apertura de puerta con el vehículo
+// detenido fuera de cualquier geocerca
+func (sd *StopDetector) checkUnscheduledStop(gpsData eventbus.GPSData, vehicleState eventbus.VehicleStateData, currentTime time.Time) {
+	if vehicleState.IsMoving {
+		sd.unscheduledReported = false
+		return
+	}
+	if sd.unscheduledReported || !vehicleState.IsStopped || !vehicleState.DoorOpen {
+		return
+	}
+	sd.unscheduledReported = true
+
+	sd.bus.Publish(eventbus.Event{
+		Type:      eventbus.EventStop,
+		Timestamp: currentTime,
+		Data: eventbus.StopEventData{
+			EventType:  eventbus.StopUnscheduled,
+			Latitude:   gpsData.Latitude,
+			Longitude:  gpsData.Longitude,
+			ArrivedAt:  currentTime,
+			DoorOpened: true,
+			DeviceID:   sd.deviceID,
+			Timestamp:  currentTime,
+		},
+	})
+
+	fmt.Fprintf(sd.out, "⚠️  [Stops] PARADA NO PROGRAMADA en (%.6f, %.6f)\n", gpsData.Latitude, gpsData.Longitude)
+}
+
+// stopInGeofence retorna la parada más cercana cuya geocerca contiene el progreso
+func (sd *StopDetector) stopInGeofence(progress float64) *scenario.Stop {
+	var nearest *scenario.Stop
+	nearestDistance := 0.0
+
+	for i := range sd.route.Stops {
+		stop := &sd.route.Stops[i]
+		distanceM := math.Abs(progress-stop.Position) * sd.route.Length * 1000
+
+		radius := stop.Radius
+		if radius <= 0 {
+			radius = sd.defaultRadius
+		}
+
+		if distanceM > radius {
+			continue
+		}
+		if nearest == nil || distanceM < nearestDistance {
+			nearest = stop
+			nearestDistance = distanceM
+		}
+	}
+
+	return nearest
+}
+
+// departureTime retorna cuándo arrancó el vehículo desde la parada
+func (sd *StopDetector) departureTime(visit *stopVisit, currentTime time.Time) time.Time {
+	if visit.movingSince.IsZero() {
+		return currentTime
+	}
+	return visit.movingSince
+}
+
+// applySchedule agrega la llegada programada y la desviación respecto al horario
+func (sd *StopDetector) applySchedule(data *eventbus.StopEventData, visit *stopVisit) {
+	if !sd.route.Scheduled || sd.trips == nil || !visit.arrived {
+		return
+	}
+
+	trip, ok := sd.trips.CurrentTrip()
+	if !ok {
+		return
+	}
+
+	// De regreso las paradas se recorren al revés: el horario se refleja
+	offset := visit.stop.ScheduledOffset
+	if trip.Direction == scenario.DirectionInbound {
+		offset = sd.route.Stops[len(sd.route.Stops)-1].ScheduledOffset - offset
+	}
+
+	data.HasSchedule = true
+	data.ScheduledArrival = trip.StartTime.Add(time.Duration(offset * float64(time.Second)))
+	data.DeviationSec = visit.arrivedAt.Sub(data.ScheduledArrival).Seconds()
+}
+
+// formatDeviation describe la desviación de horario para los logs
+func formatDeviation(data eventbus.StopEventData) string {
+	if !data.HasSchedule {
+		return ""
+	}
+
+	switch {
+	case data.DeviationSec > 0:
+		return fmt.Sprintf(" (%.0fs tarde)", data.DeviationSec)
+	case data.DeviationSec < 0:
+		return fmt.Sprintf(" (%.0fs adelantado)", -data.DeviationSec)
+	}
+	return " (a tiempo)"
+}
+
+// publish publica un evento de parada programada
+func (sd *StopDetector) publish(eventType string, visit *stopVisit, currentTime time.Time) eventbus.StopEventData {
+	lat, lon := sd.route.GetPositionAtProgress(visit.stop.Position)
+
+	data := eventbus.StopEventData{
+		EventType:  eventType,
+		StopID:     visit.stop.ID,
+		StopName:   visit.stop.Name,
+		Latitude:   lat,
+		Longitude:  lon,
+		ArrivedAt:  visit.arrivedAt,
+		DoorOpened: visit.doorOpened,
+		DeviceID:   sd.deviceID,
+		Timestamp:  currentTime,
+	}
+
+	if eventType == eventbus.StopDeparted {
+		data.DepartedAt = sd.departureTime(visit, currentTime)
+		data.DwellSec = data.DepartedAt.Sub(visit.arrivedAt).Seconds()
+	}
+
+	sd.applySchedule(&data, visit)
+
+	sd.bus.Publish(eventbus.Event{
+		Type:      eventbus.EventStop,
+		Timestamp: currentTime,
+		Data:      data,
+	})
+	return data
+}
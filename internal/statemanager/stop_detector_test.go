@@ -0,0 +1,172 @@
+package statemanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/config"
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+	"github.com/MarcosBrindi/transporte-simulator/internal/scenario"
+)
+
+// newTestStopDetector crea un detector sobre una ruta de 1 km con paradas en
+// 0.2 (radio por defecto de 50 m) y 0.5 (radio propio de 100 m)
+func newTestStopDetector() (*StopDetector, <-chan eventbus.Event) {
+	cfg := config.Default()
+	cfg.Stops.GeofenceRadiusM = 50
+
+	bus := eventbus.NewEventBus()
+	detector := NewStopDetector(bus, *cfg)
+	detector.SetRoute(&scenario.Route{
+		Name:   "Prueba",
+		Length: 1,
+		Stops: []scenario.Stop{
+			{ID: 1, Name: "Centro", Position: 0.2},
+			{ID: 2, Name: "Mercado", Position: 0.5, Radius: 100},
+		},
+		EndLat: 0.01,
+		EndLon: 0.01,
+	})
+
+	return detector, bus.Subscribe(eventbus.EventStop)
+}
+
+// stopStep posición y estado del vehículo en un instante de la prueba
+type stopStep struct {
+	progress float64
+	stopped  bool
+	door     bool
+}
+
+// driveStops aplica un paso por segundo y retorna los eventos publicados
+func driveStops(detector *StopDetector, channel <-chan eventbus.Event, start time.Time, steps ...stopStep) []eventbus.StopEventData {
+	events := make([]eventbus.StopEventData, 0)
+
+	for i, step := range steps {
+		detector.Update(
+			eventbus.GPSData{Progress: step.progress},
+			eventbus.VehicleStateData{IsStopped: step.stopped, IsMoving: !step.stopped, DoorOpen: step.door},
+			start.Add(time.Duration(i)*time.Second),
+		)
+
+		for pending := true; pending; {
+			select {
+			case event := <-channel:
+				events = append(events, event.Data.(eventbus.StopEventData))
+			default:
+				pending = false
+			}
+		}
+	}
+
+	return events
+}
+
+// stopEventTypes retorna los tipos de una lista de eventos de parada
+func stopEventTypes(events []eventbus.StopEventData) []string {
+	types := make([]string, 0, len(events))
+	for _, event := range events {
+		types = append(types, event.EventType)
+	}
+	return types
+}
+
+func TestStopInGeofence(t *testing.T) {
+	detector, _ := newTestStopDetector()
+
+	tests := []struct {
+		name     string
+		progress float64
+		wantID   int // 0 = fuera de toda geocerca
+	}{
+		{"sobre la parada", 0.2, 1},
+		{"dentro del radio por defecto", 0.24, 1},
+		{"fuera del radio por defecto", 0.26, 0},
+		{"dentro del radio propio", 0.41, 2},
+		{"entre paradas", 0.35, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := 0
+			if stop := detector.stopInGeofence(tt.progress); stop != nil {
+				id = stop.ID
+			}
+			if id != tt.wantID {
+				t.Errorf("parada = %d, want %d", id, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestStopDetectorArrivalAndDeparture(t *testing.T) {
+	detector, channel := newTestStopDetector()
+	start := time.Now()
+
+	events := driveStops(detector, channel, start,
+		stopStep{progress: 0.1},
+		stopStep{progress: 0.2, stopped: true},             // 1s: llega
+		stopStep{progress: 0.2, stopped: true, door: true}, // 2s
+		stopStep{progress: 0.21},                           // 3s: arranca
+		stopStep{progress: 0.22, stopped: true},            // 4s: se detiene otra vez
+		stopStep{progress: 0.23},                           // 5s: arranca de nuevo
+		stopStep{progress: 0.3},                            // 6s: sale de la geocerca
+	)
+
+	if types := stopEventTypes(events); len(types) != 2 || types[0] != eventbus.StopArrived || types[1] != eventbus.StopDeparted {
+		t.Fatalf("eventos = %v, want [ARRIVED DEPARTED]", types)
+	}
+
+	arrived, departed := events[0], events[1]
+	if arrived.StopID != 1 || !arrived.ArrivedAt.Equal(start.Add(time.Second)) {
+		t.Errorf("llegada = %+v", arrived)
+	}
+	if !departed.DepartedAt.Equal(start.Add(5*time.Second)) || departed.DwellSec != 4 || !departed.DoorOpened {
+		t.Errorf("salida a %v tras %.0fs (puerta %v), want a los 5s tras 4s con puerta",
+			departed.DepartedAt.Sub(start), departed.DwellSec, departed.DoorOpened)
+	}
+}
+
+func TestStopDetectorSkippedStop(t *testing.T) {
+	detector, channel := newTestStopDetector()
+
+	events := driveStops(detector, channel, time.Now(),
+		stopStep{progress: 0.1},
+		stopStep{progress: 0.2},
+		stopStep{progress: 0.3},
+	)
+
+	if len(events) != 1 || events[0].EventType != eventbus.StopSkipped || events[0].StopID != 1 || !events[0].ArrivedAt.IsZero() {
+		t.Errorf("eventos = %+v, want SKIPPED de la parada 1", events)
+	}
+}
+
+func TestStopDetectorUnscheduledStop(t *testing.T) {
+	detector, channel := newTestStopDetector()
+
+	events := driveStops(detector, channel, time.Now(),
+		stopStep{progress: 0.35, stopped: true},             // Detenido sin puerta: no reporta
+		stopStep{progress: 0.35, stopped: true, door: true}, // Abre la puerta fuera de parada
+		stopStep{progress: 0.35, stopped: true, door: true}, // Se reporta una sola vez
+		stopStep{progress: 0.36},                            // Arranca
+		stopStep{progress: 0.37, stopped: true, door: true}, // Otra parada no programada
+	)
+
+	types := stopEventTypes(events)
+	if len(types) != 2 || types[0] != eventbus.StopUnscheduled || types[1] != eventbus.StopUnscheduled {
+		t.Errorf("eventos = %v, want dos UNSCHEDULED_STOP", types)
+	}
+	if len(events) > 0 && (events[0].StopID != 0 || !events[0].DoorOpened) {
+		t.Errorf("parada no programada = %+v", events[0])
+	}
+}
+
+func TestStopDetectorWithoutRoute(t *testing.T) {
+	bus := eventbus.NewEventBus()
+	channel := bus.Subscribe(eventbus.EventStop)
+	detector := NewStopDetector(bus, *config.Default())
+
+	if events := driveStops(detector, channel, time.Now(), stopStep{progress: 0.2, stopped: true, door: true}); len(events) != 0 {
+		t.Errorf("eventos sin ruta = %v", stopEventTypes(events))
+	}
+}
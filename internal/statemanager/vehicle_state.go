@@ -3,25 +3,65 @@ package statemanager
 import (
 	"time"
 
+	"github.com/MarcosBrindi/transporte-simulator/internal/clock"
+	"github.com/MarcosBrindi/transporte-simulator/internal/config"
 	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+	"github.com/MarcosBrindi/transporte-simulator/internal/scenario"
 )
 
+// stateFacts hechos evaluados por las reglas de estado
+type stateFacts struct {
+	gpsFix          bool
+	gpsMoving       bool
+	mpuMoving       bool
+	braking         bool
+	approachingStop bool
+	speed           float64
+}
+
 // VehicleStateCalculator calcula el estado del vehículo basado en GPS + MPU
 type VehicleStateCalculator struct {
 	movementThreshold float64 // Umbral de velocidad GPS (km/h)
+	hysteresis        float64 // Banda bajo el umbral para dejar de estar en movimiento (km/h)
+	debounce          time.Duration
+	approachDistanceM float64
+	rules             []config.StateRule
+	route             *scenario.Route // Para detectar la aproximación a paradas (opcional)
+
+	gpsMoving      bool      // Movimiento GPS con histéresis
+	state          string    // Estado aplicado
+	stateSince     time.Time // Desde cuándo está aplicado
+	candidate      string    // Estado nuevo esperando el debounce
+	candidateSince time.Time
 }
 
 // NewVehicleStateCalculator crea un nuevo calculador de estado
-func NewVehicleStateCalculator(movementThreshold float64) *VehicleStateCalculator {
+func NewVehicleStateCalculator(cfg config.Config) *VehicleStateCalculator {
+	rules := cfg.States.Rules
+	if len(rules) == 0 {
+		rules = config.DefaultStateRules()
+	}
+
 	return &VehicleStateCalculator{
-		movementThreshold: movementThreshold,
+		movementThreshold: cfg.Thresholds.MovementKmh,
+		hysteresis:        cfg.States.HysteresisKmh,
+		debounce:          time.Duration(cfg.States.Debounce * float64(time.Second)),
+		approachDistanceM: cfg.States.ApproachDistanceM,
+		rules:             rules,
 	}
 }
 
+// SetRoute asigna la ruta usada para detectar la aproximación a paradas
+func (vsc *VehicleStateCalculator) SetRoute(route *scenario.Route) {
+	vsc.route = route
+}
+
 // Calculate determina el estado del vehículo basado en GPS y MPU
 func (vsc *VehicleStateCalculator) Calculate(gpsData eventbus.GPSData, mpuData eventbus.MPUData) eventbus.VehicleStateData {
-	// Determinar si hay movimiento según GPS
-	gpsMoving := gpsData.Speed >= vsc.movementThreshold
+	now := clock.Now()
+
+	// Determinar si hay movimiento según GPS (con histéresis)
+	gpsMoving := vsc.updateGPSMoving(gpsData.Speed)
 
 	// Determinar si hay movimiento según MPU
 	mpuDetecting := mpuData.IsAccelerating || mpuData.IsTurning
@@ -29,41 +69,152 @@ func (vsc *VehicleStateCalculator) Calculate(gpsData eventbus.GPSData, mpuData e
 	// Determinar si hay fix GPS válido
 	hasGPSFix := gpsData.FixQuality > 0 && gpsData.Satellites >= 4
 
-	// Calcular estado híbrido
-	state := vsc.determineState(gpsMoving, mpuDetecting, hasGPSFix)
+	facts := stateFacts{
+		gpsFix:          hasGPSFix,
+		gpsMoving:       gpsMoving,
+		mpuMoving:       mpuDetecting,
+		braking:         mpuData.IsBraking,
+		approachingStop: vsc.isApproachingStop(gpsData.Progress, gpsData.Direction),
+		speed:           gpsData.Speed,
+	}
+
+	// Calcular estado híbrido (con debounce y permanencia mínima); detenido y
+	// en movimiento salen del estado aplicado para no contradecirlo
+	state := vsc.stabilize(vsc.determineState(facts), now)
+	stopped := isStoppedState(state)
 
 	return eventbus.VehicleStateData{
-		State:        state,
-		Speed:        gpsData.Speed,
-		Acceleration: mpuData.AccelSmooth,
-		TurnRate:     mpuData.GyroZ,
-		IsMoving:     gpsMoving || mpuDetecting,
-		IsStopped:    !gpsMoving && !mpuDetecting,
-		DoorOpen:     false, // Se actualizará cuando integremos VL53L0X
-		HasGPSFix:    hasGPSFix,
-		GPSQuality:   gpsData.FixQuality,
-		Timestamp:    time.Now(),
+		State:           state,
+		Speed:           gpsData.Speed,
+		Acceleration:    mpuData.AccelSmooth,
+		TurnRate:        mpuData.GyroZ,
+		IsMoving:        !stopped,
+		IsStopped:       stopped,
+		IsBraking:       mpuData.IsBraking,
+		ApproachingStop: facts.approachingStop,
+		DoorOpen:        false, // Se actualizará cuando integremos VL53L0X
+		HasGPSFix:       hasGPSFix,
+		GPSQuality:      gpsData.FixQuality,
+		Timestamp:       now,
 	}
 }
 
-// determineState determina el estado del vehículo
-func (vsc *VehicleStateCalculator) determineState(gpsMoving, mpuDetecting, hasGPSFix bool) string {
-	if hasGPSFix {
-		if gpsMoving && mpuDetecting {
-			return eventbus.VehicleMovimientoConfirmado
-		} else if gpsMoving {
-			return eventbus.VehicleGPSMovimiento
-		} else if mpuDetecting {
-			return eventbus.VehicleMPUMovimiento
-		} else {
-			return eventbus.VehicleDetenido
-		}
+// isStoppedState retorna si el estado corresponde al vehículo detenido
+func isStoppedState(state string) bool {
+	return state == eventbus.VehicleDetenido || state == eventbus.VehicleDetenidoSinGPS
+}
+
+// updateGPSMoving aplica la histéresis al umbral de movimiento GPS
+func (vsc *VehicleStateCalculator) updateGPSMoving(speed float64) bool {
+	if vsc.gpsMoving {
+		vsc.gpsMoving = speed >= vsc.movementThreshold-vsc.hysteresis
 	} else {
-		// Sin GPS fix
-		if mpuDetecting {
-			return eventbus.VehicleMPUMovimientoSinGPS
-		} else {
-			return eventbus.VehicleDetenidoSinGPS
+		vsc.gpsMoving = speed >= vsc.movementThreshold
+	}
+	return vsc.gpsMoving
+}
+
+// isApproachingStop retorna si la próxima parada está dentro de la distancia de aproximación
+func (vsc *VehicleStateCalculator) isApproachingStop(progress float64, direction int) bool {
+	if vsc.route == nil || vsc.approachDistanceM <= 0 {
+		return false
+	}
+
+	nextStop := vsc.route.GetNextStopInDirection(progress, direction)
+	if nextStop == nil {
+		return false
+	}
+
+	distanceM := vsc.route.GetDistanceToStop(progress, nextStop) * 1000
+	return distanceM <= vsc.approachDistanceM
+}
+
+// determineState determina el estado con la primera regla que se cumple
+func (vsc *VehicleStateCalculator) determineState(facts stateFacts) string {
+	for _, rule := range vsc.rules {
+		if ruleMatches(rule.When, facts) {
+			return rule.State
+		}
+	}
+
+	// Ninguna regla aplica: estados base
+	if facts.gpsFix {
+		return eventbus.VehicleDetenido
+	}
+	return eventbus.VehicleDetenidoSinGPS
+}
+
+// ruleMatches evalúa las condiciones de una regla
+func ruleMatches(when config.StateCondition, facts stateFacts) bool {
+	checks := []struct {
+		expected *bool
+		actual   bool
+	}{
+		{when.GPSFix, facts.gpsFix},
+		{when.GPSMoving, facts.gpsMoving},
+		{when.MPUMoving, facts.mpuMoving},
+		{when.Braking, facts.braking},
+		{when.ApproachingStop, facts.approachingStop},
+	}
+
+	for _, check := range checks {
+		if check.expected != nil && *check.expected != check.actual {
+			return false
+		}
+	}
+
+	if when.MinSpeedKmh != nil && facts.speed < *when.MinSpeedKmh {
+		return false
+	}
+	if when.MaxSpeedKmh != nil && facts.speed > *when.MaxSpeedKmh {
+		return false
+	}
+
+	return true
+}
+
+// stabilize aplica un estado nuevo solo si se mantuvo durante el debounce y
+// el estado actual cumplió su permanencia mínima
+func (vsc *VehicleStateCalculator) stabilize(candidate string, now time.Time) string {
+	if vsc.state == "" {
+		vsc.apply(candidate, now)
+		return vsc.state
+	}
+
+	if candidate == vsc.state {
+		vsc.candidate = ""
+		return vsc.state
+	}
+
+	if candidate != vsc.candidate {
+		vsc.candidate = candidate
+		vsc.candidateSince = now
+	}
+
+	if now.Sub(vsc.candidateSince) < vsc.debounce {
+		return vsc.state
+	}
+	if now.Sub(vsc.stateSince) < vsc.minDwell(vsc.state) {
+		return vsc.state
+	}
+
+	vsc.apply(candidate, now)
+	return vsc.state
+}
+
+// apply fija el estado actual
+func (vsc *VehicleStateCalculator) apply(state string, now time.Time) {
+	vsc.state = state
+	vsc.stateSince = now
+	vsc.candidate = ""
+}
+
+// minDwell retorna la permanencia mínima configurada para un estado
+func (vsc *VehicleStateCalculator) minDwell(state string) time.Duration {
+	for _, rule := range vsc.rules {
+		if rule.State == state {
+			return time.Duration(rule.MinDwell * float64(time.Second))
 		}
 	}
+	return 0
 }
@@ -0,0 +1,44 @@
+package statemanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/clock"
+	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+)
+
+func TestCalculateFollowsStabilizedState(t *testing.T) {
+	virtual := clock.NewVirtual(time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	clock.Set(virtual)
+	defer clock.Set(nil)
+
+	calculator := NewVehicleStateCalculator(*testConfig())
+	gps := func(speed float64) eventbus.GPSData {
+		return eventbus.GPSData{Speed: speed, FixQuality: 1, Satellites: 8}
+	}
+
+	// Cada paso avanza el reloj y calcula con la velocidad indicada
+	steps := []struct {
+		name        string
+		advance     time.Duration
+		speed       float64
+		wantState   string
+		wantStopped bool
+	}{
+		{"arranca detenido", 0, 0, eventbus.VehicleDetenido, true},
+		{"arranque dentro de la permanencia mínima", time.Second, 20, eventbus.VehicleDetenido, true},
+		{"arranque confirmado", 1500 * time.Millisecond, 20, eventbus.VehicleGPSMovimiento, false},
+		{"frenada dentro del debounce", 1500 * time.Millisecond, 0, eventbus.VehicleGPSMovimiento, false},
+		{"detención confirmada", time.Second, 0, eventbus.VehicleDetenido, true},
+	}
+
+	for _, step := range steps {
+		virtual.Advance(step.advance)
+		data := calculator.Calculate(gps(step.speed), eventbus.MPUData{})
+		if data.State != step.wantState || data.IsStopped != step.wantStopped || data.IsMoving == step.wantStopped {
+			t.Errorf("%s: estado=%s detenido=%v en movimiento=%v, want %s detenido=%v",
+				step.name, data.State, data.IsStopped, data.IsMoving, step.wantState, step.wantStopped)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"fmt"
 	"image/color"
 
 	"github.com/MarcosBrindi/transporte-simulator/internal/config"
@@ -42,6 +43,17 @@ type Controls struct {
 	speedMultiplier  int // 1x, 2x, 3x
 	selectedScenario string
 
+	// Avance del escenario en curso (eventos de escenario)
+	scenarioName  string
+	scenarioStep  int
+	scenarioSteps int
+	scenarioLoop  int
+
+	// Lista de escenarios en curso (vacío = escenario suelto)
+	playlistName    string
+	playlistEntry   int
+	playlistEntries int
+
 	// Colores
 	colorButton       color.RGBA
 	colorButtonHover  color.RGBA
@@ -191,6 +203,19 @@ func (c *Controls) Update() (action string) {
 		return "reset"
 	}
 
+	// Línea de tiempo del escenario
+	if inpututil.IsKeyJustPressed(ebiten.KeyN) {
+		return "step_next"
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft) {
+		return "seek_back"
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowRight) {
+		return "seek_forward"
+	}
+
 	if inpututil.IsKeyJustPressed(ebiten.Key1) {
 		c.speedMultiplier = 1
 		c.updateSpeedButton()
@@ -335,13 +360,44 @@ func (c *Controls) drawScenarioInfo(screen *ebiten.Image) {
 
 	// Texto
 	scenarioName := c.getScenarioDisplayName()
+	if c.scenarioName != "" {
+		scenarioName = c.scenarioName
+	}
 	ebitenutil.DebugPrintAt(screen, "Escenario: "+scenarioName, int(x+10), int(y+10))
+
+	// Paso y vuelta arriba del recuadro
+	if c.scenarioSteps > 0 {
+		progress := fmt.Sprintf("Paso %d/%d", c.scenarioStep+1, c.scenarioSteps)
+		if c.scenarioLoop > 1 {
+			progress += fmt.Sprintf("  Vuelta %d", c.scenarioLoop)
+		}
+		if c.playlistName != "" {
+			progress += fmt.Sprintf("  Lista %d/%d", c.playlistEntry, c.playlistEntries)
+		}
+		ebitenutil.DebugPrintAt(screen, progress, int(x+10), int(y-18))
+	}
+}
+
+// SetScenarioProgress actualiza el escenario en curso, su paso (-1 = sin
+// pasos ejecutados) y la vuelta
+func (c *Controls) SetScenarioProgress(name string, step, total, loop int) {
+	c.scenarioName = name
+	c.scenarioStep = step
+	c.scenarioSteps = total
+	c.scenarioLoop = loop
+}
+
+// SetPlaylistProgress actualiza la lista en curso y su entrada (nombre vacío = sin lista)
+func (c *Controls) SetPlaylistProgress(name string, entry, total int) {
+	c.playlistName = name
+	c.playlistEntry = entry
+	c.playlistEntries = total
 }
 
 // drawKeyboardShortcuts dibuja ayuda de atajos
 func (c *Controls) drawKeyboardShortcuts(screen *ebiten.Image) {
 	y := c.config.UI.Window.Height - 25
-	shortcuts := "[SPACE] Play/Pause  [R] Reset  [1/2/3] Velocidad  [ESC] Salir"
+	shortcuts := "[SPACE] Play/Pause  [N] Paso  [<-/->] -/+10s  [R] Reset  [1/2/3] Velocidad  [ESC] Salir"
 	ebitenutil.DebugPrintAt(screen, shortcuts, 20, y)
 }
 
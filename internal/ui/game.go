@@ -3,7 +3,6 @@ package ui
 import (
 	"fmt"
 	"image/color"
-	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -24,11 +23,16 @@ type Game struct {
 	stateMgr *statemanager.StateManager
 	executor *scenario.Executor
 
+	// Escenarios y listas YAML (descubre, valida y cachea)
+	repository *scenario.Repository
+
 	// Referencias a sensores para reset
 	gps     *sensors.GPSSimulator
 	mpu     *sensors.MPU6050Simulator
 	vl53l0x *sensors.VL53L0XSimulator
+	door    *sensors.DoorActuator
 	camera  *sensors.CameraSimulator
+	faults  *sensors.FaultInjector
 
 	// Componentes UI
 	vehicleView      *VehicleView
@@ -37,6 +41,7 @@ type Game struct {
 	scenarioSelector *ScenarioSelector
 	speedGraph       *SpeedGraph
 	cameraTracks     *CameraTracks
+	timeline         *Timeline
 
 	// Estado actual (thread-safe)
 	mu           sync.RWMutex
@@ -55,6 +60,7 @@ type Game struct {
 	vehicleEvents   chan eventbus.Event
 	passengerEvents chan eventbus.Event
 	cameraEvents    chan eventbus.Event
+	scenarioEvents  chan eventbus.Event
 }
 
 // NewScenarioSelectorWithOptions crea selector con opciones personalizadas
@@ -72,6 +78,7 @@ func NewScenarioSelectorWithOptions(x, y, width, height float32, options []Scena
 		colorBorder:     color.RGBA{100, 100, 120, 255},
 		colorText:       color.RGBA{255, 255, 255, 255},
 		colorDropdownBg: color.RGBA{40, 40, 60, 255},
+		colorInvalid:    color.RGBA{80, 35, 40, 255},
 		options:         options, // ← Usar opciones pasadas
 	}
 }
@@ -86,7 +93,9 @@ func NewGame(
 	gps *sensors.GPSSimulator,
 	mpu *sensors.MPU6050Simulator,
 	vl53l0x *sensors.VL53L0XSimulator,
+	door *sensors.DoorActuator,
 	camera *sensors.CameraSimulator,
+	faults *sensors.FaultInjector,
 ) *Game {
 	game := &Game{
 		bus:             bus,
@@ -97,12 +106,15 @@ func NewGame(
 		gps:             gps,
 		mpu:             mpu,
 		vl53l0x:         vl53l0x,
+		door:            door,
 		camera:          camera,
+		faults:          faults,
 		gpsEvents:       make(chan eventbus.Event, 10),
 		mpuEvents:       make(chan eventbus.Event, 10),
 		vehicleEvents:   make(chan eventbus.Event, 10),
 		passengerEvents: make(chan eventbus.Event, 10),
 		cameraEvents:    make(chan eventbus.Event, 10),
+		scenarioEvents:  make(chan eventbus.Event, 10),
 		running:         true,
 		hasData:         false,
 	}
@@ -112,16 +124,18 @@ func NewGame(
 	game.controls = NewControls(cfg)
 	game.eventLog = NewEventLog(15) // Mostrar últimos 15 eventos
 
-	// Descubrir escenarios disponibles
-	scenariosDir := "scenarios" // Directorio donde están los YAML
-	availableScenarios := scenario.DiscoverScenarios(scenariosDir)
+	// Descubrir escenarios disponibles (validados al listarlos)
+	game.repository = scenario.NewRepository(scenario.DefaultScenariosDir, scenario.DefaultPlaylistsDir)
+	availableScenarios := game.repository.List()
+	executor.SetScenarioLoader(game.repository)
 
 	// Convertir a formato del selector
 	selectorOptions := make([]ScenarioOption, len(availableScenarios))
 	for i, s := range availableScenarios {
 		selectorOptions[i] = ScenarioOption{
-			ID:   s.ID,
-			Name: s.Name,
+			ID:      s.ID,
+			Name:    s.Name,
+			Invalid: s.Err != nil,
 		}
 	}
 
@@ -152,11 +166,24 @@ func NewGame(
 		5,                                 // Máximo 5 tracks
 	)
 
+	// Línea de tiempo del escenario (mitad derecha del panel de estado)
+	game.timeline = NewTimeline(
+		float32(cfg.UI.Window.Width/2+20), // Alineada con la gráfica
+		float32(cfg.UI.Window.Height-125), // Arriba del paso del escenario
+		float32(cfg.UI.Window.Width/2-40),
+		16,
+	)
+
 	// Suscribirse a eventos
 	game.subscribeToEvents()
 
 	// Log inicial
 	game.eventLog.Add("Sistema iniciado", "success")
+	for _, s := range availableScenarios {
+		if s.Err != nil {
+			game.eventLog.Add("⚠️ "+s.Err.Error(), "error")
+		}
+	}
 
 	return game
 }
@@ -222,6 +249,19 @@ func (g *Game) subscribeToEvents() {
 		}
 	}()
 
+	// Avance del escenario
+	scenarioChannel := g.bus.Subscribe(eventbus.EventScenario)
+	go func() {
+		for event := range scenarioChannel {
+			if g.isRunning() {
+				select {
+				case g.scenarioEvents <- event:
+				default:
+				}
+			}
+		}
+	}()
+
 	// Camera
 	cameraChannel := g.bus.Subscribe(eventbus.EventCamera)
 	go func() {
@@ -268,12 +308,26 @@ func (g *Game) Update() error {
 	default:
 	}
 
+	select {
+	case event := <-g.scenarioEvents:
+		g.handleScenarioEvent(event)
+	default:
+	}
+
 	//Actualizar selector de escenarios
 	changed, newScenarioID := g.scenarioSelector.Update()
 	if changed {
 		g.changeScenario(newScenarioID)
 	}
 
+	// Actualizar línea de tiempo (click o arrastre = Seek)
+	if g.executor != nil {
+		g.timeline.SetProgress(g.executor.GetScenario(), g.executor.Elapsed())
+		if seconds, ok := g.timeline.Update(); ok {
+			g.seekScenario(seconds)
+		}
+	}
+
 	//Actualizar controles y procesar acciones
 	action := g.controls.Update()
 	if action != "" {
@@ -323,6 +377,9 @@ func (g *Game) Draw(screen *ebiten.Image) {
 
 	// Dibujar tracks de cámara
 	g.cameraTracks.Draw(screen)
+
+	// Dibujar línea de tiempo del escenario
+	g.timeline.Draw(screen)
 }
 
 // Layout define el tamaño de la ventana
@@ -382,6 +439,33 @@ func (g *Game) handlePassengerEvent(event eventbus.Event) {
 	}
 }
 
+// handleScenarioEvent muestra el avance del escenario (paso, vuelta, fin)
+func (g *Game) handleScenarioEvent(event eventbus.Event) {
+	data := event.Data.(eventbus.ScenarioEventData)
+
+	if data.EventType != eventbus.ScenarioStopped {
+		g.controls.SetScenarioProgress(data.Name, data.Step, data.TotalSteps, data.Loop)
+		g.controls.SetPlaylistProgress(data.Playlist, data.Entry, data.TotalEntries)
+	}
+
+	switch data.EventType {
+	case eventbus.ScenarioStarted:
+		if data.Playlist != "" && data.Loop == 1 {
+			g.eventLog.Add(fmt.Sprintf("📜 %s %d/%d: %s", data.Playlist, data.Entry, data.TotalEntries, data.Name), "info")
+		} else if data.Loop > 1 {
+			g.eventLog.Add(fmt.Sprintf("🔁 %s: vuelta %d", data.Name, data.Loop), "info")
+		} else {
+			g.eventLog.Add("🎬 Escenario: "+data.Name, "info")
+		}
+	case eventbus.ScenarioCompleted:
+		g.eventLog.Add(fmt.Sprintf("✅ %s completado (%s)", data.Name, data.OnEnd), "success")
+	case eventbus.ScenarioAborted:
+		g.eventLog.Add(fmt.Sprintf("❌ %s abortado en el paso %d", data.Name, data.Step), "error")
+	case eventbus.ScenarioSeek:
+		g.eventLog.Add(fmt.Sprintf("⏩ %s: salto a %.1fs", data.Name, data.ElapsedSec), "info")
+	}
+}
+
 // handleControlAction maneja las acciones de los controles
 func (g *Game) handleControlAction(action string) {
 	switch action {
@@ -423,9 +507,37 @@ func (g *Game) handleControlAction(action string) {
 		fmt.Println("🏃‍♀️💨 [UI] Velocidad: 3x")
 		g.applySpeedMultiplier(3.0)
 		// g.eventLog.Add("🏃‍♀️ Velocidad: 3x", "info")
+
+	case "step_next":
+		if g.executor != nil {
+			if err := g.executor.StepNext(); err != nil {
+				g.eventLog.Add("⚠️ "+err.Error(), "warning")
+			}
+		}
+
+	case "seek_back":
+		if g.executor != nil {
+			g.seekScenario(g.executor.Elapsed() - seekStepSec)
+		}
+
+	case "seek_forward":
+		if g.executor != nil {
+			g.seekScenario(g.executor.Elapsed() + seekStepSec)
+		}
 	}
 }
 
+// seekScenario salta a un segundo del escenario en curso
+func (g *Game) seekScenario(seconds float64) {
+	fmt.Printf("⏩ [UI] Seek a %.1fs\n", seconds)
+	if err := g.executor.Seek(seconds); err != nil {
+		g.eventLog.Add("⚠️ "+err.Error(), "warning")
+		return
+	}
+	g.speedGraph.Clear()
+	g.cameraTracks.Clear()
+}
+
 // drawWaitingMessage dibuja mensaje de espera
 func (g *Game) drawWaitingMessage(screen *ebiten.Image) {
 	// TODO: Dibujar texto "Esperando datos de sensores..."
@@ -457,8 +569,10 @@ func (g *Game) resetSimulation() {
 	g.vl53l0x.Pause()
 	g.camera.Pause()
 
-	// 3. Resetear GPS a posición inicial
+	// 3. Resetear GPS a posición inicial y cerrar la puerta
 	g.gps.Reset()
+	g.vl53l0x.Reset()
+	g.faults.Reset()
 
 	// 4. Resetear StateManager
 	g.stateMgr.Reset()
@@ -484,8 +598,22 @@ func (g *Game) resetSimulation() {
 
 	// 8. Reiniciar executor con escenario
 	scenarioName := g.controls.GetSelectedScenario()
-	newScenario := g.loadScenario(scenarioName)
+	newScenario, playlist, err := g.loadScenario(scenarioName)
+	if err != nil {
+		fmt.Printf("❌ [UI] Error cargando %s: %v (usando Parada Normal)\n", scenarioName, err)
+		g.eventLog.Add("❌ "+err.Error(), "error")
+		newScenario = scenario.GetParadaNormal()
+	}
 	g.executor = scenario.NewExecutor(newScenario, g.gps, g.bus)
+	g.executor.SetDoorController(g.door)
+	g.executor.SetFaultController(g.faults)
+	g.executor.SetPassengerController(g.camera)
+	g.executor.SetPositionController(g.gps)
+	g.executor.SetStateInspector(g.stateMgr)
+	g.executor.SetAutoLoop(g.config.Simulation.AutoLoop)
+	g.executor.SetScenarioLoader(g.repository)
+	g.executor.SetRate(float64(g.controls.GetSpeedMultiplier()))
+	g.executor.SetPlaylist(playlist)
 	g.executor.Start()
 
 	// 9. Cambiar estado a running
@@ -495,35 +623,27 @@ func (g *Game) resetSimulation() {
 	// g.eventLog.Add("✅ Simulación reiniciada", "success")
 }
 
-// loadScenario carga un escenario por nombre
-func (g *Game) loadScenario(id string) *scenario.Scenario {
-	switch id {
-	case "parada_normal":
-		return scenario.GetParadaNormal()
-	case "parada_con_salidas":
-		return scenario.GetParadaConSalidas()
-	case "circuito_completo":
-		return scenario.GetCircuitoCompleto()
-	default:
-		// Si el ID empieza con "yaml_", cargar desde archivo
-		if strings.HasPrefix(id, "yaml_") {
-			scenariosDir := "scenarios"
-			fileName := strings.TrimPrefix(id, "yaml_") + ".yaml"
-			filePath := filepath.Join(scenariosDir, fileName)
-
-			scn, err := scenario.LoadFromYAML(filePath)
-			if err != nil {
-				fmt.Printf("❌ Error cargando escenario YAML: %v\n", err)
-				return scenario.GetParadaNormal() // Fallback
-			}
-
-			fmt.Printf("✅ Escenario YAML cargado: %s\n", scn.Name)
-			return scn
+// loadScenario carga un escenario por ID desde el repositorio; si el ID es
+// una lista ("playlist_<archivo>") la retorna junto con su primer escenario
+func (g *Game) loadScenario(id string) (*scenario.Scenario, *scenario.Playlist, error) {
+	if strings.HasPrefix(id, "playlist_") {
+		playlist, err := g.repository.LoadPlaylist(id)
+		if err != nil {
+			return nil, nil, err
 		}
+		fmt.Printf("✅ Lista cargada: %s\n", playlist.Name)
+		return playlist.Scenario(0), playlist, nil
+	}
+
+	scn, err := g.repository.Load(id)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		// Fallback a parada normal
-		return scenario.GetParadaNormal()
+	if strings.HasPrefix(id, "yaml_") {
+		fmt.Printf("✅ Escenario YAML cargado: %s\n", scn.Name)
 	}
+	return scn, nil, nil
 }
 
 // applySpeedMultiplier aplica el multiplicador de velocidad a los sensores
@@ -551,12 +671,26 @@ func (g *Game) applySpeedMultiplier(multiplier float64) {
 	fmt.Printf("   MPU: %.1f Hz → %.1f Hz\n", baseMPUFreq, newMPUFreq)
 	fmt.Printf("   VL53L0X: %.1f Hz → %.1f Hz\n", baseVL53Freq, newVL53Freq)
 	fmt.Printf("   Camera: %.1f Hz → %.1f Hz\n", baseCameraFreq, newCameraFreq)
+
+	// El escenario corre al mismo ritmo que los sensores
+	if g.executor != nil {
+		g.executor.SetRate(multiplier)
+	}
 }
 
 // changeScenario cambia el escenario actual
 func (g *Game) changeScenario(scenarioID string) {
 	fmt.Printf("🔄 [UI] Cambiando escenario a: %s\n", scenarioID)
 
+	// Cargar nuevo escenario; si falla sigue el actual
+	newScenario, playlist, err := g.loadScenario(scenarioID)
+	if err != nil {
+		fmt.Printf("❌ [UI] Error cargando %s: %v\n", scenarioID, err)
+		g.eventLog.Add("❌ "+err.Error(), "error")
+		g.scenarioSelector.SetSelected(g.controls.GetSelectedScenario())
+		return
+	}
+
 	g.controls.SetSystemState(StateLoading)
 	g.controls.SetScenario(scenarioID)
 
@@ -565,11 +699,17 @@ func (g *Game) changeScenario(scenarioID string) {
 		g.executor.Stop()
 	}
 
-	// Cargar nuevo escenario
-	newScenario := g.loadScenario(scenarioID)
-
 	// Crear nuevo executor
 	g.executor = scenario.NewExecutor(newScenario, g.gps, g.bus)
+	g.executor.SetDoorController(g.door)
+	g.executor.SetFaultController(g.faults)
+	g.executor.SetPassengerController(g.camera)
+	g.executor.SetPositionController(g.gps)
+	g.executor.SetStateInspector(g.stateMgr)
+	g.executor.SetAutoLoop(g.config.Simulation.AutoLoop)
+	g.executor.SetScenarioLoader(g.repository)
+	g.executor.SetRate(float64(g.controls.GetSpeedMultiplier()))
+	g.executor.SetPlaylist(playlist)
 	g.executor.Start()
 
 	g.controls.SetSystemState(StateRunning)
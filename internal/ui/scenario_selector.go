@@ -11,8 +11,9 @@ import (
 
 // ScenarioOption representa una opción de escenario
 type ScenarioOption struct {
-	ID   string
-	Name string
+	ID      string
+	Name    string
+	Invalid bool // El archivo no carga (el detalle va al log de eventos)
 }
 
 // ScenarioSelector es un dropdown para seleccionar escenarios
@@ -33,6 +34,7 @@ type ScenarioSelector struct {
 	colorBorder     color.RGBA
 	colorText       color.RGBA
 	colorDropdownBg color.RGBA
+	colorInvalid    color.RGBA
 }
 
 // NewScenarioSelector crea un nuevo selector de escenarios
@@ -50,6 +52,7 @@ func NewScenarioSelector(x, y, width, height float32) *ScenarioSelector {
 		colorBorder:     color.RGBA{100, 100, 120, 255},
 		colorText:       color.RGBA{255, 255, 255, 255},
 		colorDropdownBg: color.RGBA{40, 40, 60, 255},
+		colorInvalid:    color.RGBA{80, 35, 40, 255},
 		options: []ScenarioOption{
 			{ID: "parada_normal", Name: "Parada Normal"},
 			{ID: "parada_con_salidas", Name: "Parada con Salidas"},
@@ -156,8 +159,11 @@ func (ss *ScenarioSelector) Draw(screen *ebiten.Image) {
 			reverseIndex := numOptions - 1 - i
 			optY := ss.y - float32(reverseIndex+1)*ss.height
 
-			// Color según hover
+			// Color según hover (rojizo si el escenario no carga)
 			optColor := ss.colorDropdownBg
+			if opt.Invalid {
+				optColor = ss.colorInvalid
+			}
 			if i == ss.hoveredIndex {
 				optColor = ss.colorBgHover
 			}
@@ -171,7 +177,11 @@ func (ss *ScenarioSelector) Draw(screen *ebiten.Image) {
 			if i == ss.selectedIndex {
 				prefix = "✓ "
 			}
-			ebitenutil.DebugPrintAt(screen, prefix+opt.Name, int(ss.x+10), int(optY+10))
+			label := prefix + opt.Name
+			if opt.Invalid {
+				label += " [error]"
+			}
+			ebitenutil.DebugPrintAt(screen, label, int(ss.x+10), int(optY+10))
 		}
 	}
 }
@@ -0,0 +1,157 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/MarcosBrindi/transporte-simulator/internal/scenario"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// seekStepSec salto de las flechas del teclado en la línea de tiempo
+const seekStepSec = 10.0
+
+// Timeline barra de tiempo del escenario: muestra el avance y los pasos, y
+// permite saltar haciendo click o arrastrando
+type Timeline struct {
+	x      float32
+	y      float32
+	width  float32
+	height float32
+
+	// Escenario mostrado
+	scenario *scenario.Scenario
+	markers  []float64 // Tiempo de cada paso
+	end      float64   // Último segundo de la línea de tiempo
+	elapsed  float64
+
+	// Arrastre en curso
+	dragging bool
+	dragTime float64
+
+	// Colores
+	colorBg       color.RGBA
+	colorBorder   color.RGBA
+	colorFill     color.RGBA
+	colorMarker   color.RGBA
+	colorHandle   color.RGBA
+	colorDragging color.RGBA
+}
+
+// NewTimeline crea una nueva línea de tiempo
+func NewTimeline(x, y, width, height float32) *Timeline {
+	return &Timeline{
+		x:             x,
+		y:             y,
+		width:         width,
+		height:        height,
+		colorBg:       color.RGBA{30, 30, 40, 255},
+		colorBorder:   color.RGBA{80, 80, 100, 255},
+		colorFill:     color.RGBA{60, 110, 160, 255},
+		colorMarker:   color.RGBA{200, 200, 220, 255},
+		colorHandle:   color.RGBA{100, 200, 255, 255},
+		colorDragging: color.RGBA{255, 200, 100, 255},
+	}
+}
+
+// SetProgress actualiza el escenario en curso y sus segundos transcurridos
+func (t *Timeline) SetProgress(scn *scenario.Scenario, elapsed float64) {
+	if scn != t.scenario {
+		t.scenario = scn
+		t.markers = t.markers[:0]
+		t.end = 0
+		if scn != nil {
+			for _, step := range scn.Steps {
+				t.markers = append(t.markers, step.Time)
+			}
+			t.end = scn.TimelineEnd()
+		}
+	}
+	t.elapsed = elapsed
+}
+
+// Update procesa click y arrastre; retorna el segundo elegido al soltar
+func (t *Timeline) Update() (seek float64, ok bool) {
+	if t.end <= 0 {
+		t.dragging = false
+		return 0, false
+	}
+
+	mouseX, mouseY := ebiten.CursorPosition()
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && t.contains(mouseX, mouseY) {
+		t.dragging = true
+	}
+	if !t.dragging {
+		return 0, false
+	}
+
+	t.dragTime = t.timeAt(mouseX)
+	if inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft) {
+		t.dragging = false
+		return t.dragTime, true
+	}
+	return 0, false
+}
+
+// contains verifica si el mouse está sobre la barra
+func (t *Timeline) contains(mouseX, mouseY int) bool {
+	mx := float32(mouseX)
+	my := float32(mouseY)
+	return mx >= t.x && mx <= t.x+t.width && my >= t.y && my <= t.y+t.height
+}
+
+// timeAt convierte una coordenada x en segundos del escenario
+func (t *Timeline) timeAt(mouseX int) float64 {
+	ratio := (float32(mouseX) - t.x) / t.width
+	if ratio < 0 {
+		ratio = 0
+	}
+	if ratio > 1 {
+		ratio = 1
+	}
+	return float64(ratio) * t.end
+}
+
+// xAt convierte segundos del escenario en una coordenada x
+func (t *Timeline) xAt(seconds float64) float32 {
+	ratio := seconds / t.end
+	if ratio > 1 {
+		ratio = 1
+	}
+	return t.x + t.width*float32(ratio)
+}
+
+// Draw dibuja la línea de tiempo
+func (t *Timeline) Draw(screen *ebiten.Image) {
+	if t.end <= 0 {
+		return
+	}
+
+	// Fondo y avance
+	vector.DrawFilledRect(screen, t.x, t.y, t.width, t.height, t.colorBg, false)
+	vector.DrawFilledRect(screen, t.x, t.y, t.xAt(t.elapsed)-t.x, t.height, t.colorFill, false)
+	vector.StrokeRect(screen, t.x, t.y, t.width, t.height, 2, t.colorBorder, false)
+
+	// Un marcador por paso
+	for _, seconds := range t.markers {
+		markerX := t.xAt(seconds)
+		vector.StrokeLine(screen, markerX, t.y+t.height-5, markerX, t.y+t.height, 1, t.colorMarker, false)
+	}
+
+	// Posición actual (o la elegida mientras se arrastra)
+	handleX := t.xAt(t.elapsed)
+	handleColor := t.colorHandle
+	label := fmt.Sprintf("%.1fs / %.0fs", t.elapsed, t.end)
+	if t.dragging {
+		handleX = t.xAt(t.dragTime)
+		handleColor = t.colorDragging
+		label = fmt.Sprintf("Ir a %.1fs / %.0fs", t.dragTime, t.end)
+	}
+	vector.DrawFilledRect(screen, handleX-2, t.y-3, 4, t.height+6, handleColor, false)
+
+	ebitenutil.DebugPrintAt(screen, label, int(t.x), int(t.y-18))
+}
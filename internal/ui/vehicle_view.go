@@ -235,6 +235,10 @@ func getStateEmoji(state string) string {
 		return "📡"
 	case eventbus.VehicleMPUMovimiento, eventbus.VehicleMPUMovimientoSinGPS:
 		return "⚡"
+	case eventbus.VehicleFrenando:
+		return "🔴"
+	case eventbus.VehicleAproximandoParada:
+		return "🚏"
 	default:
 		return "🚌"
 	}
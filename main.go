@@ -4,10 +4,15 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"time"
 
 	"github.com/MarcosBrindi/transporte-simulator/internal/config"
 	"github.com/MarcosBrindi/transporte-simulator/internal/eventbus"
+	"github.com/MarcosBrindi/transporte-simulator/internal/gtfs"
+	"github.com/MarcosBrindi/transporte-simulator/internal/gtfsrt"
 	"github.com/MarcosBrindi/transporte-simulator/internal/mqtt"
+	"github.com/MarcosBrindi/transporte-simulator/internal/runner"
 	"github.com/MarcosBrindi/transporte-simulator/internal/scenario"
 	"github.com/MarcosBrindi/transporte-simulator/internal/sensors"
 	"github.com/MarcosBrindi/transporte-simulator/internal/simulator"
@@ -17,9 +22,15 @@ import (
 )
 
 func main() {
+	// Subcomando run: ejecutar escenarios sin UI ni brokers
+	if len(os.Args) > 1 && os.Args[1] == "run" {
+		os.Exit(runScenarios(os.Args[2:]))
+	}
+
 	// Definir flags
 	headless := flag.Bool("headless", false, "Ejecutar en modo headless (sin UI)")
 	instances := flag.Int("instances", 1, "Número de instancias a ejecutar (1-1000)")
+	playlistPath := flag.String("playlist", "", "Lista de escenarios YAML (ID playlist_<archivo> o ruta)")
 	flag.Parse()
 
 	fmt.Println("=== SIMULADOR DE TRANSPORTE PÚBLICO ===")
@@ -37,11 +48,26 @@ func main() {
 	fmt.Printf("Device ID: %s\n", cfg.DeviceID)
 	fmt.Println()
 
+	// Escenarios y listas YAML (validados al cargar)
+	repository := scenario.NewRepository(scenario.DefaultScenariosDir, scenario.DefaultPlaylistsDir)
+
+	// Lista de escenarios (turno completo) en lugar del escenario por defecto
+	var playlist *scenario.Playlist
+	if *playlistPath != "" {
+		playlist, err = repository.LoadPlaylist(*playlistPath)
+		if err != nil {
+			fmt.Printf("❌ Error cargando lista: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("📜 %s\n", playlist)
+		fmt.Println()
+	}
+
 	// ========== Modo Headless ==========
 	if *headless {
 		fmt.Printf("🚀 Modo HEADLESS: Lanzando %d instancias\n", *instances)
 		fmt.Println()
-		simulator.RunHeadless(*instances, cfg)
+		simulator.RunHeadless(*instances, cfg, playlist)
 		fmt.Println("\n✅ Simulación finalizada")
 		return
 	}
@@ -59,9 +85,24 @@ func main() {
 		cfg.Sensors.GPS.InitialPosition.Latitude,
 		cfg.Sensors.GPS.InitialPosition.Longitude,
 	)
+
+	// Viaje programado desde un feed GTFS (reemplaza ruta y escenario)
+	var gtfsScenario *scenario.Scenario
+	if cfg.GTFS.Enabled {
+		gtfsRoute, generated, err := gtfs.LoadTrip(cfg.GTFS)
+		if err != nil {
+			fmt.Printf("⚠️  [GTFS] No se pudo importar el feed: %v\n", err)
+		} else {
+			route = gtfsRoute
+			gtfsScenario = generated
+		}
+	}
 	fmt.Printf("  %s\n", route)
 	fmt.Println()
 
+	// Controlador de viajes (ida/regreso entre terminales)
+	trips := scenario.NewTripController(bus, route, cfg.DeviceID)
+
 	// ========== Inicializar Publishers (MQTT y RabbitMQ) ==========
 	var mqttPublisher *mqtt.Publisher
 	var rabbitPublisher *mqtt.RabbitMQPublisher
@@ -69,6 +110,7 @@ func main() {
 	// MQTT Publisher
 	if cfg.MQTT.Enabled {
 		mqttPublisher = mqtt.NewPublisher(cfg.MQTT, cfg.DeviceID, bus)
+		mqttPublisher.SetTripProvider(trips)
 		err := mqttPublisher.Start()
 		if err != nil {
 			fmt.Printf("⚠️  [MQTT] No se pudo conectar: %v\n", err)
@@ -90,6 +132,7 @@ func main() {
 				fmt.Printf("⚠️  [RabbitMQ] Error creando canal: %v\n", err)
 			} else {
 				rabbitPublisher = mqtt.NewRabbitMQPublisher(ch, cfg.RabbitMQ, cfg.DeviceID, bus)
+				rabbitPublisher.SetTripProvider(trips)
 				err := rabbitPublisher.Start()
 				if err != nil {
 					fmt.Printf("⚠️  [RabbitMQ] Error iniciando publisher: %v\n", err)
@@ -99,18 +142,44 @@ func main() {
 	} else {
 		fmt.Println("ℹ️  [RabbitMQ] Deshabilitado en configuración")
 	}
+
+	// Feed GTFS-Realtime por HTTP
+	var realtime *gtfsrt.Server
+	if cfg.GTFSRT.Enabled {
+		realtime = gtfsrt.NewServer(cfg.GTFSRT)
+		realtime.Track(cfg.DeviceID, bus, route)
+		if err := realtime.Start(); err != nil {
+			fmt.Printf("⚠️  [GTFS-RT] No se pudo iniciar: %v\n", err)
+			realtime = nil
+		}
+	} else {
+		fmt.Println("ℹ️  [GTFS-RT] Deshabilitado en configuración")
+	}
 	// ===============================================================
 
 	// Crear sensores
 	gps := sensors.NewGPSSimulator(bus, cfg.Sensors.GPS, route)
 	mpu := sensors.NewMPU6050Simulator(bus, cfg.Sensors.MPU6050)
-	vl53l0x := sensors.NewVL53L0XSimulator(bus, cfg.Sensors.VL53L0X)
+	door := sensors.NewDoorActuator(cfg.Sensors.Door)
+	vl53l0x := sensors.NewVL53L0XSimulator(bus, cfg.Sensors.VL53L0X, door)
 	camera := sensors.NewCameraSimulator(bus, cfg.Sensors.Camera)
 
+	// Inyector de fallas compartido por los sensores
+	faults := sensors.NewFaultInjector(bus, *cfg)
+	gps.SetFaultInjector(faults)
+	mpu.SetFaultInjector(faults)
+	vl53l0x.SetFaultInjector(faults)
+	camera.SetFaultInjector(faults)
+	vl53l0x.SetDoorwayProbe(camera)
+	gps.SetTripController(trips)
+
 	// Crear State Manager
 	stateMgr := statemanager.NewStateManager(bus, *cfg)
+	stateMgr.SetRoute(route)
+	stateMgr.SetTripSource(trips)
 
-	// Iniciar sensores y state manager
+	// Iniciar primer viaje, sensores y state manager
+	trips.Start(time.Now())
 	gps.Start()
 	mpu.Start()
 	vl53l0x.Start()
@@ -147,9 +216,12 @@ func main() {
 
 	// Cargar y ejecutar escenario
 	scenarioToRun := scenario.GetParadaNormal()
+	if gtfsScenario != nil {
+		scenarioToRun = gtfsScenario
+	}
 
 	// Opción alternativa: Cargar desde YAML
-	// scenarioToRun, err := scenario.LoadScenario("scenarios/parada_normal.yaml")
+	// scenarioToRun, err := repository.Load("yaml_parada_normal")
 	// if err != nil {
 	//     fmt.Printf("⚠️  Error: %v\n", err)
 	//     scenarioToRun = scenario.GetParadaNormal()
@@ -157,10 +229,20 @@ func main() {
 
 	// Crear ejecutor de escenario
 	executor := scenario.NewExecutor(scenarioToRun, gps, bus)
+	executor.SetDoorController(door)
+	executor.SetFaultController(faults)
+	executor.SetPassengerController(camera)
+	executor.SetPositionController(gps)
+	executor.SetStateInspector(stateMgr)
+	executor.SetAutoLoop(cfg.Simulation.AutoLoop)
+	executor.SetScenarioLoader(repository)
+	if playlist != nil {
+		executor.SetPlaylist(playlist)
+	}
 	executor.Start()
 
 	// Crear juego Ebiten
-	game := ui.NewGame(bus, cfg, route, stateMgr, executor, gps, mpu, vl53l0x, camera)
+	game := ui.NewGame(bus, cfg, route, stateMgr, executor, gps, mpu, vl53l0x, door, camera, faults)
 
 	// Configurar ventana
 	ebiten.SetWindowSize(cfg.UI.Window.Width, cfg.UI.Window.Height)
@@ -180,6 +262,7 @@ func main() {
 	fmt.Println("\n🛑 Deteniendo sistema...")
 	executor.Stop()
 	game.Stop()
+	trips.Abort(time.Now())
 	gps.Stop()
 	mpu.Stop()
 	vl53l0x.Stop()
@@ -193,6 +276,94 @@ func main() {
 	if rabbitPublisher != nil {
 		rabbitPublisher.Stop()
 	}
+	if realtime != nil {
+		realtime.Stop()
+	}
 
 	fmt.Println("👋 ¡Hasta luego!")
 }
+
+// runScenarios ejecuta escenarios YAML con reloj virtual y genera los reportes
+// JUnit XML y JSON; retorna el código de salida (1 si alguno falló)
+func runScenarios(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	configPath := flags.String("config", "config.yaml", "Archivo de configuración")
+	speed := flags.Float64("speed", 0, "Segundos simulados por segundo real (0 = runner.speed)")
+	junitPath := flags.String("junit", "", "Reporte JUnit XML (vacío = runner.junit_report)")
+	jsonPath := flags.String("json", "", "Reporte JSON (vacío = runner.json_report)")
+	verbose := flags.Bool("v", false, "Mostrar los logs de la simulación")
+	seed := flags.Int64("seed", 0, "Semilla de los sensores (0 = runner.seed)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Uso: transporte-simulator run [opciones] escenario.yaml|lista.yaml|directorio ...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Printf("Error cargando config: %v\n", err)
+		fmt.Println("Usando configuración por defecto")
+		cfg = config.Default()
+	}
+
+	files, err := runner.ExpandPaths(flags.Args())
+	if err != nil {
+		fmt.Printf("❌ [Runner] %v\n", err)
+		return 2
+	}
+	if len(files) == 0 {
+		fmt.Println("❌ [Runner] No se encontraron escenarios")
+		return 2
+	}
+
+	opts := runner.OptionsFromConfig(cfg.Runner)
+	if *speed > 0 {
+		opts.Speed = *speed
+	}
+	opts.Verbose = *verbose
+	if *seed != 0 {
+		opts.Seed = *seed
+	}
+
+	fmt.Printf("🧪 [Runner] Ejecutando %d escenarios (reloj virtual %.0fx)\n", len(files), opts.Speed)
+	results := runner.Run(files, *cfg, opts)
+
+	if *junitPath == "" {
+		*junitPath = cfg.Runner.JUnitReport
+	}
+	if *jsonPath == "" {
+		*jsonPath = cfg.Runner.JSONReport
+	}
+	if *junitPath != "" {
+		if err := runner.WriteJUnit(*junitPath, results); err != nil {
+			fmt.Printf("⚠️  [Runner] No se pudo escribir %s: %v\n", *junitPath, err)
+		} else {
+			fmt.Printf("📄 [Runner] Reporte JUnit: %s\n", *junitPath)
+		}
+	}
+	if *jsonPath != "" {
+		if err := runner.WriteJSON(*jsonPath, results); err != nil {
+			fmt.Printf("⚠️  [Runner] No se pudo escribir %s: %v\n", *jsonPath, err)
+		} else {
+			fmt.Printf("📄 [Runner] Reporte JSON: %s\n", *jsonPath)
+		}
+	}
+
+	failed := 0
+	for _, result := range results {
+		if !result.Success() {
+			failed++
+		}
+	}
+	if failed > 0 {
+		fmt.Printf("❌ [Runner] %d de %d escenarios fallaron\n", failed, len(results))
+		return 1
+	}
+	fmt.Printf("✅ [Runner] %d escenarios OK\n", len(results))
+	return 0
+}